@@ -0,0 +1,225 @@
+// Package alert evaluates alert rules against consumed messages and runs the
+// commands they ask for. It is shared by the TUI and the headless commands.
+package alert
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/epalmerini/rabbithole/internal/archive"
+	"github.com/epalmerini/rabbithole/internal/config"
+	"github.com/epalmerini/rabbithole/internal/filter"
+	"github.com/epalmerini/rabbithole/internal/query"
+)
+
+// CommandTimeout bounds how long an alert command may run.
+const CommandTimeout = 30 * time.Second
+
+// rule is a compiled alert rule.
+type rule struct {
+	config.AlertRule
+	filter   *filter.Filter
+	cooldown time.Duration
+
+	last    time.Time // last bell, notification or command
+	running atomic.Bool
+}
+
+// Rules evaluates alert rules against consumed messages. It is not safe for
+// concurrent use, apart from the commands it starts.
+type Rules struct {
+	rules []*rule
+	fired int
+	last  string // name of the rule that matched last
+}
+
+// NewRules compiles alert rules, reporting the first invalid one. Highlight
+// colours are left to the TUI, which draws them.
+func NewRules(rules []config.AlertRule) (*Rules, error) {
+	a := &Rules{}
+	for i, r := range rules {
+		name := r.Name
+		if name == "" {
+			name = fmt.Sprintf("#%d", i+1)
+		}
+		if r.Filter == "" {
+			return nil, fmt.Errorf("alert %s: filter is required", name)
+		}
+		f, err := filter.New(r.Filter)
+		if err != nil {
+			return nil, fmt.Errorf("alert %s: %w", name, err)
+		}
+		compiled := &rule{AlertRule: r, filter: f}
+		compiled.Name = name
+		if r.Cooldown != "" {
+			d, err := time.ParseDuration(r.Cooldown)
+			if err != nil || d < 0 {
+				return nil, fmt.Errorf("alert %s: invalid cooldown %q (use e.g. 30s or 5m)", name, r.Cooldown)
+			}
+			compiled.cooldown = d
+		}
+		a.rules = append(a.rules, compiled)
+	}
+	return a, nil
+}
+
+// Fired returns the number of messages that matched a rule, and the name of
+// the rule that matched last.
+func (a *Rules) Fired() (int, string) {
+	if a == nil {
+		return 0, ""
+	}
+	return a.fired, a.last
+}
+
+// Match is what the rules matching one message ask for.
+type Match struct {
+	Rules     []string // names of the matching rules, in config order
+	Pause     bool
+	Bookmark  bool
+	Bell      bool
+	Notify    bool
+	Highlight string // colour of the first matching rule with one
+	Commands  []Command
+
+	subject string // exchange and routing key, for notifications
+}
+
+// Command is a command an alert rule runs.
+type Command struct {
+	Rule    string
+	Command string
+	done    func()
+}
+
+// Matched reports whether any rule matched.
+func (am Match) Matched() bool {
+	return len(am.Rules) > 0
+}
+
+// Fire evaluates the rules for msg, arriving at now. The bell, notifications
+// and commands of rules still cooling down are left out, as are the commands
+// of rules whose previous command is still running.
+func (a *Rules) Fire(msg query.Message, now time.Time) Match {
+	var am Match
+	if a == nil {
+		return am
+	}
+	for _, r := range a.rules {
+		if !r.filter.Match(msg) {
+			continue
+		}
+		am.Rules = append(am.Rules, r.Name)
+		am.Pause = am.Pause || r.Pause
+		am.Bookmark = am.Bookmark || r.Bookmark
+		if am.Highlight == "" {
+			am.Highlight = r.Highlight
+		}
+
+		if !r.last.IsZero() && now.Sub(r.last) < r.cooldown {
+			continue
+		}
+		signalled := r.Bell || r.Notify
+		am.Bell = am.Bell || r.Bell
+		am.Notify = am.Notify || r.Notify
+		if r.Command != "" && r.running.CompareAndSwap(false, true) {
+			am.Commands = append(am.Commands, Command{Rule: r.Name, Command: r.Command, done: func() { r.running.Store(false) }})
+			signalled = true
+		}
+		if signalled {
+			r.last = now
+		}
+	}
+	if am.Matched() {
+		a.fired++
+		a.last = am.Rules[len(am.Rules)-1]
+		am.subject = msg.Exchange + " " + msg.RoutingKey
+	}
+	return am
+}
+
+// FireRecord evaluates the rules for an archive record, as captured headless.
+func (a *Rules) FireRecord(r archive.Record, now time.Time) Match {
+	return a.Fire(filter.RecordMessage(r), now)
+}
+
+// Signal writes the terminal bell and desktop notification the match asks
+// for to w, the terminal.
+func (am Match) Signal(w io.Writer) error {
+	var b strings.Builder
+	if am.Bell {
+		b.WriteString("\a")
+	}
+	if am.Notify {
+		title := sanitizeOSC("rabbithole: " + strings.Join(am.Rules, ", "))
+		body := sanitizeOSC(am.subject)
+		// OSC 9 for iTerm2, WezTerm and others; OSC 777 for
+		// rxvt-derived terminals, foot and Ghostty
+		fmt.Fprintf(&b, "\x1b]9;%s: %s\x07", title, body)
+		fmt.Fprintf(&b, "\x1b]777;notify;%s;%s\x07", title, body)
+	}
+	if b.Len() == 0 {
+		return nil
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// sanitizeOSC drops the characters that would end or split an escape sequence.
+func sanitizeOSC(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r < 0x20 || r == 0x7f || r == ';' {
+			return ' '
+		}
+		return r
+	}, s)
+}
+
+// Run runs the command through the shell with rec as JSON on stdin and the
+// rule's name in RABBITHOLE_ALERT, waiting up to CommandTimeout for it.
+func (c Command) Run(ctx context.Context, rec archive.Record) error {
+	if c.done != nil {
+		defer c.done()
+	}
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(ctx, CommandTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "sh", "-c", c.Command)
+	cmd.Stdin = bytes.NewReader(data)
+	cmd.Env = append(os.Environ(), "RABBITHOLE_ALERT="+c.Rule)
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+	if err := cmd.Run(); err != nil {
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			err = fmt.Errorf("timed out after %s", CommandTimeout)
+		}
+		if msg := strings.TrimSpace(out.String()); msg != "" {
+			err = fmt.Errorf("%w: %s", err, truncate(msg, 200))
+		}
+		return fmt.Errorf("alert %s: command failed: %w", c.Rule, err)
+	}
+	return nil
+}
+
+// truncate shortens command output to max runes.
+func truncate(s string, max int) string {
+	r := []rune(s)
+	if len(r) <= max {
+		return s
+	}
+	return string(r[:max-3]) + "..."
+}
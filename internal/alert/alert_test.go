@@ -0,0 +1,129 @@
+package alert
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/epalmerini/rabbithole/internal/archive"
+	"github.com/epalmerini/rabbithole/internal/config"
+	"github.com/epalmerini/rabbithole/internal/query"
+)
+
+func TestNewRules_Invalid(t *testing.T) {
+	tests := []struct {
+		rule config.AlertRule
+		want string
+	}{
+		{config.AlertRule{Name: "empty"}, "alert empty: filter is required"},
+		{config.AlertRule{Filter: "re:("}, "alert #1:"},
+		{config.AlertRule{Name: "d", Filter: "rk:a", Cooldown: "soon"}, "invalid cooldown"},
+	}
+	for _, tt := range tests {
+		_, err := NewRules([]config.AlertRule{tt.rule})
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("NewRules(%+v) error = %v, want %q", tt.rule, err, tt.want)
+		}
+	}
+}
+
+func TestRules_Fire(t *testing.T) {
+	rules, err := NewRules([]config.AlertRule{
+		{Name: "failed", Filter: "rk:failed", Highlight: "red", Bell: true, Cooldown: "1m"},
+		{Name: "big refund", Filter: "body.amount > 100", Pause: true, Bookmark: true, Notify: true},
+		{Name: "script", Filter: "rk:failed", Command: "true"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+
+	am := rules.Fire(query.Message{RoutingKey: "order.created"}, now)
+	if am.Matched() {
+		t.Fatalf("unexpected match %v", am.Rules)
+	}
+
+	am = rules.Fire(query.Message{Exchange: "orders", RoutingKey: "payment.failed"}, now)
+	if strings.Join(am.Rules, ",") != "failed,script" || !am.Bell || am.Pause || am.Highlight != "red" || len(am.Commands) != 1 {
+		t.Fatalf("match = %+v", am)
+	}
+
+	// Within the cooldown the bell stays quiet, and the command does not
+	// start again while it runs
+	am2 := rules.Fire(query.Message{RoutingKey: "payment.failed"}, now.Add(time.Second))
+	if !am2.Matched() || am2.Bell || len(am2.Commands) != 0 {
+		t.Errorf("repeat match = %+v, want no bell or command", am2)
+	}
+	am.Commands[0].done()
+	am2 = rules.Fire(query.Message{RoutingKey: "payment.failed"}, now.Add(2*time.Minute))
+	if !am2.Bell || len(am2.Commands) != 1 {
+		t.Errorf("after the cooldown: %+v, want bell and command", am2)
+	}
+
+	am = rules.FireRecord(archive.Record{RoutingKey: "refund", RawBody: []byte(`{"amount": 250}`)}, now)
+	if !am.Pause || !am.Bookmark || !am.Notify || am.Bell || am.Highlight != "" {
+		t.Errorf("refund match = %+v", am)
+	}
+
+	if fired, last := rules.Fired(); fired != 4 || last != "big refund" {
+		t.Errorf("Fired() = %d, %q; want 4, big refund", fired, last)
+	}
+}
+
+func TestMatch_Signal(t *testing.T) {
+	var buf bytes.Buffer
+	am := Match{Rules: []string{"a;b"}, Bell: true, Notify: true, subject: "orders order\x1b.failed"}
+	if err := am.Signal(&buf); err != nil {
+		t.Fatal(err)
+	}
+	want := "\a\x1b]9;rabbithole: a b: orders order .failed\x07\x1b]777;notify;rabbithole: a b;orders order .failed\x07"
+	if buf.String() != want {
+		t.Errorf("Signal wrote %q, want %q", buf.String(), want)
+	}
+
+	buf.Reset()
+	_ = Match{Rules: []string{"quiet"}}.Signal(&buf)
+	if buf.Len() != 0 {
+		t.Errorf("a match without bell or notify wrote %q", buf.String())
+	}
+}
+
+func TestCommand_Run(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "alert.json")
+	c := Command{Rule: "failed", Command: `cat > "$OUT" && echo "$RABBITHOLE_ALERT" > "$OUT.rule"`}
+	t.Setenv("OUT", out)
+
+	rec := archive.Record{RoutingKey: "payment.failed"}
+	rec.SetBody([]byte(`{"id":7}`), nil)
+	if err := c.Run(context.Background(), rec); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rule, err := os.ReadFile(out + ".rule")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got struct {
+		RoutingKey string         `json:"routing_key"`
+		Body       map[string]any `json:"body"`
+	}
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("stdin was not the message JSON: %v", err)
+	}
+	if got.RoutingKey != "payment.failed" || got.Body["id"] != 7.0 || string(rule) != "failed\n" {
+		t.Errorf("command saw %+v and rule %q", got, rule)
+	}
+
+	c = Command{Rule: "broken", Command: "echo oops >&2; exit 3"}
+	if err := c.Run(context.Background(), rec); err == nil || !strings.Contains(err.Error(), "alert broken: command failed") || !strings.Contains(err.Error(), "oops") {
+		t.Errorf("failing command error = %v", err)
+	}
+}
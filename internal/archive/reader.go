@@ -0,0 +1,211 @@
+package archive
+
+import (
+	"bufio"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// traceTimeLayout is the timestamp format of rabbitmq_tracing JSON logs,
+// which append milliseconds after another colon ("2024-05-01 12:00:00:123").
+const traceTimeLayout = "2006-01-02 15:04:05"
+
+// Reader reads records from a JSON array or newline-delimited JSON stream.
+// Each element may be a Record (as written by export and tail, or the
+// consumer view's JSON export), a message from the management API's
+// "get messages" endpoint, or an entry of a rabbitmq_tracing JSON log.
+// Elements are decoded one at a time, so large files are never loaded whole.
+type Reader struct {
+	br      *bufio.Reader
+	dec     *json.Decoder
+	started bool
+	array   bool
+	index   int
+}
+
+// NewReader returns a Reader for r.
+func NewReader(r io.Reader) *Reader {
+	br := bufio.NewReader(r)
+	return &Reader{br: br, dec: json.NewDecoder(br)}
+}
+
+// Next returns the next record, skipping elements that do not describe a
+// published message. It returns io.EOF at the end of the input.
+func (r *Reader) Next() (Record, error) {
+	if !r.started {
+		r.started = true
+		if err := r.start(); err != nil {
+			return Record{}, err
+		}
+	}
+
+	for {
+		if r.array && !r.dec.More() {
+			if _, err := r.dec.Token(); err != nil {
+				return Record{}, fmt.Errorf("invalid JSON array: %w", err)
+			}
+			return Record{}, io.EOF
+		}
+
+		var raw json.RawMessage
+		if err := r.dec.Decode(&raw); err != nil {
+			if errors.Is(err, io.EOF) && !r.array {
+				return Record{}, io.EOF
+			}
+			return Record{}, fmt.Errorf("element %d: %w", r.index+1, err)
+		}
+		r.index++
+
+		rec, ok, err := parseElement(raw)
+		if err != nil {
+			return Record{}, fmt.Errorf("element %d: %w", r.index, err)
+		}
+		if ok {
+			return rec, nil
+		}
+	}
+}
+
+// start detects whether the input is a JSON array or a stream of objects.
+func (r *Reader) start() error {
+	for {
+		b, err := r.br.Peek(1)
+		if err != nil {
+			return err
+		}
+		switch b[0] {
+		case ' ', '\t', '\r', '\n':
+			_, _ = r.br.ReadByte()
+			continue
+		case '[':
+			r.array = true
+			_, err := r.dec.Token()
+			return err
+		case '{':
+			return nil
+		}
+		return errors.New("input must be a JSON array or newline-delimited JSON objects")
+	}
+}
+
+// amqpProperties are message properties as reported by the management API
+// and rabbitmq_tracing.
+type amqpProperties struct {
+	ContentType   string         `json:"content_type"`
+	Headers       map[string]any `json:"headers"`
+	CorrelationID string         `json:"correlation_id"`
+	ReplyTo       string         `json:"reply_to"`
+	MessageID     string         `json:"message_id"`
+	AppID         string         `json:"app_id"`
+	DeliveryMode  uint8          `json:"delivery_mode"`
+	Priority      uint8          `json:"priority"`
+	Expiration    string         `json:"expiration"`
+	Timestamp     int64          `json:"timestamp"` // unix seconds
+}
+
+// brokerMessage covers both management API messages and trace log entries.
+type brokerMessage struct {
+	Exchange        string         `json:"exchange"`
+	RoutingKey      string         `json:"routing_key"`
+	RoutingKeys     []string       `json:"routing_keys"` // tracing
+	Type            string         `json:"type"`         // tracing: published or received
+	Timestamp       string         `json:"timestamp"`    // tracing
+	Properties      amqpProperties `json:"properties"`
+	Payload         string         `json:"payload"`
+	PayloadEncoding string         `json:"payload_encoding"` // management API: string or base64
+}
+
+func parseElement(data json.RawMessage) (Record, bool, error) {
+	var probe struct {
+		Payload *string `json:"payload"`
+	}
+	if err := json.Unmarshal(data, &probe); err != nil {
+		return Record{}, false, err
+	}
+
+	if probe.Payload == nil {
+		var rec Record
+		if err := json.Unmarshal(data, &rec); err != nil {
+			return Record{}, false, err
+		}
+		if rec.RawBody == nil && rec.Body != nil {
+			// Hand-edited files may carry only the decoded body
+			rec.RawBody, _ = json.Marshal(rec.Body)
+		}
+		// Keep protobuf bodies the exporter decoded, as the raw body cannot
+		// be searched without the descriptors; others are re-derived from it
+		if _, ok := rec.Body.(map[string]any); !ok || rec.ProtoType == "" {
+			rec.Body = nil
+		}
+		rec.DecodeError = ""
+		return rec, true, nil
+	}
+
+	var m brokerMessage
+	if err := json.Unmarshal(data, &m); err != nil {
+		return Record{}, false, err
+	}
+
+	// Each publish is logged once as published and once per queue as received
+	if m.Type == "received" {
+		return Record{}, false, nil
+	}
+
+	rec := Record{
+		Exchange:      m.Exchange,
+		RoutingKey:    m.RoutingKey,
+		ContentType:   m.Properties.ContentType,
+		CorrelationID: m.Properties.CorrelationID,
+		ReplyTo:       m.Properties.ReplyTo,
+		MessageID:     m.Properties.MessageID,
+		AppID:         m.Properties.AppID,
+		DeliveryMode:  m.Properties.DeliveryMode,
+		Priority:      m.Properties.Priority,
+		Expiration:    m.Properties.Expiration,
+		Headers:       m.Properties.Headers,
+	}
+	if rec.RoutingKey == "" && len(m.RoutingKeys) > 0 {
+		rec.RoutingKey = m.RoutingKeys[0]
+	}
+
+	switch {
+	case m.Timestamp != "":
+		rec.Timestamp = parseTraceTime(m.Timestamp)
+	case m.Properties.Timestamp > 0:
+		rec.Timestamp = time.Unix(m.Properties.Timestamp, 0)
+	}
+
+	// Tracing logs always use base64; the management API says which it used
+	if m.PayloadEncoding == "string" {
+		rec.RawBody = []byte(m.Payload)
+	} else {
+		body, err := base64.StdEncoding.DecodeString(m.Payload)
+		if err != nil {
+			return Record{}, false, fmt.Errorf("invalid base64 payload: %w", err)
+		}
+		rec.RawBody = body
+	}
+	return rec, true, nil
+}
+
+// parseTraceTime parses a rabbitmq_tracing timestamp, returning the zero time
+// if it is malformed.
+func parseTraceTime(s string) time.Time {
+	if len(s) < len(traceTimeLayout) {
+		return time.Time{}
+	}
+	ts, err := time.Parse(traceTimeLayout, s[:len(traceTimeLayout)])
+	if err != nil {
+		return time.Time{}
+	}
+	if ms, err := strconv.Atoi(strings.TrimPrefix(s[len(traceTimeLayout):], ":")); err == nil {
+		ts = ts.Add(time.Duration(ms) * time.Millisecond)
+	}
+	return ts
+}
@@ -0,0 +1,139 @@
+package archive
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func readAll(t *testing.T, input string) []Record {
+	t.Helper()
+	r := NewReader(strings.NewReader(input))
+	var recs []Record
+	for {
+		rec, err := r.Next()
+		if errors.Is(err, io.EOF) {
+			return recs
+		}
+		if err != nil {
+			t.Fatalf("Next: %v", err)
+		}
+		recs = append(recs, rec)
+	}
+}
+
+func TestReader_Formats(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		wantRK   []string
+		wantBody []string
+	}{
+		{
+			name: "consumer view export",
+			input: `[
+  {"id": 1, "routing_key": "a.b", "exchange": "ex", "timestamp": "2024-05-01T12:00:00Z", "body": {"x": 1}, "raw_body": "aGVsbG8="}
+]`,
+			wantRK:   []string{"a.b"},
+			wantBody: []string{"hello"},
+		},
+		{
+			name:     "ndjson",
+			input:    "{\"routing_key\":\"a\",\"raw_body\":\"YQ==\"}\n\n{\"routing_key\":\"b\",\"raw_body\":\"Yg==\"}\n",
+			wantRK:   []string{"a", "b"},
+			wantBody: []string{"a", "b"},
+		},
+		{
+			name:     "body without raw_body",
+			input:    `{"routing_key":"a","body":{"x":1}}`,
+			wantRK:   []string{"a"},
+			wantBody: []string{`{"x":1}`},
+		},
+		{
+			name: "management get messages",
+			input: `[
+  {"payload_bytes": 5, "redelivered": false, "exchange": "ex", "routing_key": "k1", "message_count": 1,
+   "properties": {"content_type": "text/plain", "headers": {"h": "v"}, "timestamp": 1714564800},
+   "payload": "hello", "payload_encoding": "string"},
+  {"exchange": "ex", "routing_key": "k2", "properties": {}, "payload": "aGk=", "payload_encoding": "base64"}
+]`,
+			wantRK:   []string{"k1", "k2"},
+			wantBody: []string{"hello", "hi"},
+		},
+		{
+			name: "tracing log skips received events",
+			input: `{"timestamp":"2024-05-01 12:00:00:250","type":"published","exchange":"ex","queue":"none","routing_keys":["k"],"properties":{},"payload":"aGk="}
+{"timestamp":"2024-05-01 12:00:00:251","type":"received","exchange":"ex","queue":"q1","routing_keys":["k"],"properties":{},"payload":"aGk="}`,
+			wantRK:   []string{"k"},
+			wantBody: []string{"hi"},
+		},
+		{
+			name:  "empty array",
+			input: "[]",
+		},
+		{
+			name:  "empty input",
+			input: "  \n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recs := readAll(t, tt.input)
+			if len(recs) != len(tt.wantRK) {
+				t.Fatalf("got %d records, want %d", len(recs), len(tt.wantRK))
+			}
+			for i, rec := range recs {
+				if rec.RoutingKey != tt.wantRK[i] {
+					t.Errorf("record %d routing key = %q, want %q", i, rec.RoutingKey, tt.wantRK[i])
+				}
+				if string(rec.RawBody) != tt.wantBody[i] {
+					t.Errorf("record %d body = %q, want %q", i, rec.RawBody, tt.wantBody[i])
+				}
+			}
+		})
+	}
+}
+
+func TestReader_BrokerProperties(t *testing.T) {
+	recs := readAll(t, `[{"exchange":"ex","routing_key":"k","properties":{"content_type":"application/json","correlation_id":"c1","message_id":"m1","headers":{"h":"v"},"timestamp":1714564800},"payload":"{}","payload_encoding":"string"}]`)
+	if len(recs) != 1 {
+		t.Fatalf("got %d records", len(recs))
+	}
+	r := recs[0]
+	if r.ContentType != "application/json" || r.CorrelationID != "c1" || r.MessageID != "m1" || r.Headers["h"] != "v" {
+		t.Errorf("properties not mapped: %+v", r)
+	}
+	if !r.Timestamp.Equal(time.Unix(1714564800, 0)) {
+		t.Errorf("timestamp = %v", r.Timestamp)
+	}
+}
+
+func TestParseTraceTime(t *testing.T) {
+	want := time.Date(2024, 5, 1, 12, 0, 0, 250*int(time.Millisecond), time.UTC)
+	if got := parseTraceTime("2024-05-01 12:00:00:250"); !got.Equal(want) {
+		t.Errorf("parseTraceTime = %v, want %v", got, want)
+	}
+	if got := parseTraceTime("garbage"); !got.IsZero() {
+		t.Errorf("expected zero time for malformed input, got %v", got)
+	}
+}
+
+func TestReader_Errors(t *testing.T) {
+	for _, input := range []string{
+		"not json",
+		`[{"routing_key": "a"}`,
+		`{"payload": "!!!", "properties": {}}`,
+	} {
+		r := NewReader(strings.NewReader(input))
+		var err error
+		for err == nil {
+			_, err = r.Next()
+		}
+		if errors.Is(err, io.EOF) {
+			t.Errorf("input %q: expected a parse error, got EOF", input)
+		}
+	}
+}
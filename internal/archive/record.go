@@ -0,0 +1,57 @@
+// Package archive defines the file formats messages are exported to and imported from.
+package archive
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Record is a message as written to JSON, NDJSON and the other export formats.
+// Its fields are a superset of the consumer view's JSON export, so files written
+// by either can be read back.
+type Record struct {
+	ID            int64          `json:"id,omitempty"`
+	Timestamp     time.Time      `json:"timestamp,omitzero"`   // the publisher's, if it set one
+	ConsumedAt    time.Time      `json:"consumed_at,omitzero"` // arrival time
+	Exchange      string         `json:"exchange"`
+	RoutingKey    string         `json:"routing_key"`
+	ContentType   string         `json:"content_type,omitempty"`
+	CorrelationID string         `json:"correlation_id,omitempty"`
+	ReplyTo       string         `json:"reply_to,omitempty"`
+	MessageID     string         `json:"message_id,omitempty"`
+	AppID         string         `json:"app_id,omitempty"`
+	DeliveryMode  uint8          `json:"delivery_mode,omitempty"` // 2 is persistent
+	Priority      uint8          `json:"priority,omitempty"`
+	Expiration    string         `json:"expiration,omitempty"`
+	Headers       map[string]any `json:"headers,omitempty"`
+	ProtoType     string         `json:"proto_type,omitempty"`
+	Body          any            `json:"body,omitempty"`
+	DecodeError   string         `json:"decode_error,omitempty"`
+	Bookmarked    bool           `json:"bookmarked,omitempty"`
+	Note          string         `json:"note,omitempty"`
+	Tags          []string       `json:"tags,omitempty"`
+	RawBody       []byte         `json:"raw_body"` // base64 in JSON
+}
+
+// Arrival returns when the message arrived, or its timestamp for records
+// that do not say, such as those imported from older exports.
+func (r Record) Arrival() time.Time {
+	if r.ConsumedAt.IsZero() {
+		return r.Timestamp
+	}
+	return r.ConsumedAt
+}
+
+// SetBody fills Body from the decoded protobuf fields, or from raw when it is
+// itself valid JSON, so that tools like jq can reach into it.
+func (r *Record) SetBody(raw []byte, decoded map[string]any) {
+	r.RawBody = raw
+	switch {
+	case decoded != nil:
+		r.Body = decoded
+	case json.Valid(raw):
+		r.Body = json.RawMessage(raw)
+	default:
+		r.Body = nil
+	}
+}
@@ -0,0 +1,40 @@
+package archive
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestRecordSetBody(t *testing.T) {
+	tests := []struct {
+		name     string
+		raw      string
+		decoded  map[string]any
+		wantBody string
+	}{
+		{"decoded proto", "\x08\x01", map[string]any{"id": 1}, `"body":{"id":1}`},
+		{"json body", `{"id":2}`, nil, `"body":{"id":2}`},
+		{"binary body", "\x00\x01", nil, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var r Record
+			r.SetBody([]byte(tt.raw), tt.decoded)
+			data, err := json.Marshal(r)
+			if err != nil {
+				t.Fatalf("Marshal: %v", err)
+			}
+			if tt.wantBody != "" && !strings.Contains(string(data), tt.wantBody) {
+				t.Errorf("%s missing %s", data, tt.wantBody)
+			}
+			if tt.wantBody == "" && strings.Contains(string(data), `"body"`) {
+				t.Errorf("%s should omit body", data)
+			}
+			if !strings.Contains(string(data), `"raw_body":"`) {
+				t.Errorf("%s missing base64 raw_body", data)
+			}
+		})
+	}
+}
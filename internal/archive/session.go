@@ -0,0 +1,188 @@
+package archive
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/epalmerini/rabbithole/internal/db"
+	"github.com/epalmerini/rabbithole/internal/proto"
+)
+
+// exportPageSize is the number of messages read from the store per query.
+const exportPageSize = 500
+
+// FromDB converts a stored message to a record, decoding its body with dec
+// when one is given and otherwise using the decoded body saved with it.
+func FromDB(m db.Message, dec *proto.Decoder) Record {
+	r := Record{
+		ID:            m.ID,
+		Exchange:      m.Exchange,
+		RoutingKey:    m.RoutingKey,
+		ConsumedAt:    m.ConsumedAt,
+		ContentType:   m.ContentType.String,
+		ProtoType:     m.ProtoType.String,
+		CorrelationID: m.CorrelationID.String,
+		ReplyTo:       m.ReplyTo.String,
+		MessageID:     m.MessageID.String,
+		AppID:         m.AppID.String,
+		DeliveryMode:  uint8(m.DeliveryMode.Int64),
+		Priority:      uint8(m.Priority.Int64),
+		Expiration:    m.Expiration.String,
+	}
+	if m.Timestamp.Valid {
+		r.Timestamp = m.Timestamp.Time
+	}
+	if m.Headers.Valid {
+		var headers map[string]any
+		if err := json.Unmarshal([]byte(m.Headers.String), &headers); err == nil {
+			r.Headers = headers
+		}
+	}
+
+	// Without a decoder, fall back to the body decoded when it was stored
+	var decoded map[string]any
+	if dec == nil && m.DecodedJson.Valid {
+		_ = json.Unmarshal([]byte(m.DecodedJson.String), &decoded)
+	}
+	if dec != nil {
+		d, protoType, err := dec.DecodeWithHintAndType(m.Body, m.RoutingKey)
+		if err != nil {
+			r.DecodeError = err.Error()
+		} else {
+			decoded = d
+			r.ProtoType = protoType
+		}
+	}
+	r.SetBody(m.Body, decoded)
+	return r
+}
+
+// FromDBPage converts a page of stored messages to records like FromDB, with
+// the bookmarks, notes and tags recorded on them.
+func FromDBPage(ctx context.Context, store db.Store, msgs []db.Message, dec *proto.Decoder) ([]Record, error) {
+	ids := make([]int64, len(msgs))
+	for i, m := range msgs {
+		ids[i] = m.ID
+	}
+	annotations, err := store.GetAnnotations(ctx, ids)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load annotations: %w", err)
+	}
+	records := make([]Record, len(msgs))
+	for i, m := range msgs {
+		records[i] = FromDB(m, dec)
+		records[i].SetAnnotation(annotations[m.ID])
+	}
+	return records, nil
+}
+
+// SetAnnotation copies a stored message's annotation to the record.
+func (r *Record) SetAnnotation(a db.Annotation) {
+	r.Bookmarked, r.Note, r.Tags = a.Bookmarked, a.Note, a.Tags
+}
+
+// Annotated reports whether the record is bookmarked, noted or tagged.
+func (r Record) Annotated() bool {
+	return r.Bookmarked || r.Note != "" || len(r.Tags) > 0
+}
+
+// ExportSession writes every message of a session, oldest first, to w. Messages
+// are read a page at a time so large sessions are never held in memory. keep,
+// if not nil, selects which records are written. It returns the number written;
+// w is not closed.
+func ExportSession(ctx context.Context, store db.Store, sessionID int64, dec *proto.Decoder, keep func(Record) bool, w Writer) (int, error) {
+	written := 0
+	for offset := int64(0); ; offset += exportPageSize {
+		msgs, err := store.ListMessagesBySessionAsc(ctx, sessionID, exportPageSize, offset)
+		if err != nil {
+			return written, fmt.Errorf("failed to load messages: %w", err)
+		}
+		records, err := FromDBPage(ctx, store, msgs, dec)
+		if err != nil {
+			return written, err
+		}
+		for _, r := range records {
+			if keep != nil && !keep(r) {
+				continue
+			}
+			if err := w.Write(r); err != nil {
+				return written, fmt.Errorf("failed to write message %d: %w", r.ID, err)
+			}
+			written++
+		}
+		if len(msgs) < exportPageSize {
+			return written, nil
+		}
+	}
+}
+
+// ImportSession reads every record from r into a new session and returns its
+// ID and the number of messages imported. source describes where the records
+// came from and is stored as the session's URL. The session takes its
+// exchange from the first record. On error the partial session is removed.
+func ImportSession(ctx context.Context, store db.Store, r *Reader, source string) (int64, int, error) {
+	first, err := r.Next()
+	if errors.Is(err, io.EOF) {
+		return 0, 0, errors.New("no messages to import")
+	}
+	if err != nil {
+		return 0, 0, err
+	}
+
+	sid, err := store.CreateSession(ctx, first.Exchange, "#", "(imported)", source)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to create session: %w", err)
+	}
+
+	imported := 0
+	for rec := first; ; {
+		if _, err := store.InsertMessage(ctx, rec.toDB(sid)); err != nil {
+			return 0, 0, errors.Join(fmt.Errorf("failed to insert message: %w", err), store.DeleteSession(ctx, sid))
+		}
+		imported++
+
+		rec, err = r.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return 0, 0, errors.Join(err, store.DeleteSession(ctx, sid))
+		}
+	}
+
+	if err := store.EndSession(ctx, sid); err != nil {
+		return 0, 0, fmt.Errorf("failed to end session: %w", err)
+	}
+	return sid, imported, nil
+}
+
+func (r Record) toDB(sessionID int64) *db.MessageRecord {
+	// Keep protobuf bodies decoded by the exporter so they stay searchable
+	var decoded map[string]any
+	if body, ok := r.Body.(map[string]any); ok && r.ProtoType != "" {
+		decoded = body
+	}
+	return &db.MessageRecord{
+		SessionID:     sessionID,
+		Exchange:      r.Exchange,
+		RoutingKey:    r.RoutingKey,
+		Body:          r.RawBody,
+		ContentType:   r.ContentType,
+		Headers:       r.Headers,
+		Timestamp:     r.Timestamp,
+		ConsumedAt:    r.Arrival(),
+		ProtoType:     r.ProtoType,
+		Decoded:       decoded,
+		CorrelationID: r.CorrelationID,
+		ReplyTo:       r.ReplyTo,
+		MessageID:     r.MessageID,
+		AppID:         r.AppID,
+		DeliveryMode:  r.DeliveryMode,
+		Priority:      r.Priority,
+		Expiration:    r.Expiration,
+		Annotation:    db.Annotation{Bookmarked: r.Bookmarked, Note: r.Note, Tags: r.Tags},
+	}
+}
@@ -0,0 +1,264 @@
+package archive
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/epalmerini/rabbithole/internal/db"
+	"github.com/epalmerini/rabbithole/internal/query"
+)
+
+func TestExportSession_PagesThroughSession(t *testing.T) {
+	store, err := db.NewStore(t.TempDir() + "/test.db")
+	if err != nil {
+		t.Fatalf("NewStore: %v", err)
+	}
+	defer store.Close()
+
+	ctx := context.Background()
+	sid, err := store.CreateSession(ctx, "orders", "#", "q", "amqp://localhost")
+	if err != nil {
+		t.Fatalf("CreateSession: %v", err)
+	}
+
+	// More than one page, so paging is exercised
+	total := exportPageSize + 20
+	base := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	for i := 0; i < total; i++ {
+		rk := "order.created"
+		if i%2 == 1 {
+			rk = "order.paid"
+		}
+		if _, err := store.InsertMessage(ctx, &db.MessageRecord{
+			SessionID:  sid,
+			Exchange:   "orders",
+			RoutingKey: rk,
+			Body:       []byte(`{"n":1}`),
+			Headers:    map[string]any{"i": i},
+			Timestamp:  base.Add(time.Duration(i) * time.Second),
+		}); err != nil {
+			t.Fatalf("InsertMessage: %v", err)
+		}
+	}
+
+	var buf bytes.Buffer
+	w, _ := NewWriter(&buf, FormatNDJSON, nil)
+	keep := func(r Record) bool { return r.RoutingKey == "order.paid" }
+	n, err := ExportSession(ctx, store, sid, nil, keep, w)
+	if err != nil {
+		t.Fatalf("ExportSession: %v", err)
+	}
+	if n != total/2 {
+		t.Errorf("wrote %d, want %d", n, total/2)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != n {
+		t.Errorf("got %d lines, want %d", len(lines), n)
+	}
+	if !strings.Contains(lines[0], `"headers":{"i":1}`) {
+		t.Errorf("expected oldest matching message first, got %s", lines[0])
+	}
+}
+
+func TestImportSession_RoundTrip(t *testing.T) {
+	store, err := db.NewStore(t.TempDir() + "/test.db")
+	if err != nil {
+		t.Fatalf("NewStore: %v", err)
+	}
+	defer store.Close()
+	ctx := context.Background()
+
+	var buf bytes.Buffer
+	w, _ := NewWriter(&buf, FormatJSON, nil)
+	for _, r := range testRecords() {
+		if err := w.Write(r); err != nil {
+			t.Fatalf("Write: %v", err)
+		}
+	}
+	_ = w.Close()
+
+	sid, n, err := ImportSession(ctx, store, NewReader(&buf), "import:test.json")
+	if err != nil {
+		t.Fatalf("ImportSession: %v", err)
+	}
+	if n != 2 {
+		t.Errorf("imported %d, want 2", n)
+	}
+
+	msgs, err := store.ListMessagesBySessionAsc(ctx, sid, 10, 0)
+	if err != nil {
+		t.Fatalf("ListMessagesBySessionAsc: %v", err)
+	}
+	if len(msgs) != 2 {
+		t.Fatalf("stored %d messages, want 2", len(msgs))
+	}
+	got := FromDB(msgs[0], nil)
+	want := testRecords()[0]
+	if got.RoutingKey != want.RoutingKey || string(got.RawBody) != string(want.RawBody) || got.Headers["tenant"] != "acme" || !got.Timestamp.Equal(want.Timestamp) {
+		t.Errorf("round trip mismatch: got %+v", got)
+	}
+
+	sessions, _ := store.ListRecentSessions(ctx, 10)
+	if len(sessions) != 1 || sessions[0].Exchange != "orders" || !sessions[0].EndedAt.Valid {
+		t.Errorf("unexpected session: %+v", sessions)
+	}
+}
+
+func TestSession_AnnotationsRoundTrip(t *testing.T) {
+	store, err := db.NewStore(t.TempDir() + "/test.db")
+	if err != nil {
+		t.Fatalf("NewStore: %v", err)
+	}
+	defer store.Close()
+	ctx := context.Background()
+
+	sid, err := store.CreateSession(ctx, "orders", "#", "q", "amqp://localhost")
+	if err != nil {
+		t.Fatalf("CreateSession: %v", err)
+	}
+	for _, a := range []db.Annotation{{}, {Bookmarked: true, Note: "charged twice", Tags: []string{"incident-42"}}} {
+		if _, err := store.InsertMessage(ctx, &db.MessageRecord{SessionID: sid, Exchange: "orders", RoutingKey: "order.paid", Body: []byte("{}"), Annotation: a}); err != nil {
+			t.Fatalf("InsertMessage: %v", err)
+		}
+	}
+
+	var buf bytes.Buffer
+	w, _ := NewWriter(&buf, FormatNDJSON, nil)
+	if _, err := ExportSession(ctx, store, sid, nil, Record.Annotated, w); err != nil {
+		t.Fatalf("ExportSession: %v", err)
+	}
+	if got := buf.String(); strings.Count(got, "\n") != 1 || !strings.Contains(got, `"bookmarked":true,"note":"charged twice","tags":["incident-42"]`) {
+		t.Fatalf("export = %s", got)
+	}
+
+	imported, _, err := ImportSession(ctx, store, NewReader(&buf), "import:test")
+	if err != nil {
+		t.Fatalf("ImportSession: %v", err)
+	}
+	msgs, _ := store.ListMessagesBySessionAsc(ctx, imported, 10, 0)
+	records, err := FromDBPage(ctx, store, msgs, nil)
+	if err != nil {
+		t.Fatalf("FromDBPage: %v", err)
+	}
+	if len(records) != 1 || !records[0].Bookmarked || records[0].Note != "charged twice" || records[0].Tags[0] != "incident-42" {
+		t.Errorf("imported %+v", records)
+	}
+}
+
+func TestImportSession_RemovesPartialSession(t *testing.T) {
+	store, err := db.NewStore(t.TempDir() + "/test.db")
+	if err != nil {
+		t.Fatalf("NewStore: %v", err)
+	}
+	defer store.Close()
+	ctx := context.Background()
+
+	input := `{"routing_key":"a","raw_body":"YQ=="}` + "\n{broken"
+	if _, _, err := ImportSession(ctx, store, NewReader(strings.NewReader(input)), "import:bad"); err == nil {
+		t.Fatal("expected error")
+	}
+	if sessions, _ := store.ListRecentSessions(ctx, 10); len(sessions) != 0 {
+		t.Errorf("expected partial session to be removed, found %d", len(sessions))
+	}
+
+	if _, _, err := ImportSession(ctx, store, NewReader(strings.NewReader("[]")), "import:empty"); err == nil {
+		t.Error("expected error for empty input")
+	}
+}
+
+func TestFromDB_UsesStoredDecodedBody(t *testing.T) {
+	m := db.Message{
+		RoutingKey:  "order.created",
+		Body:        []byte{0x08, 0x2a},
+		ProtoType:   sql.NullString{String: "shop.OrderCreated", Valid: true},
+		DecodedJson: sql.NullString{String: `{"order_id":"ord-77"}`, Valid: true},
+	}
+	r := FromDB(m, nil)
+	body, ok := r.Body.(map[string]any)
+	if !ok || body["order_id"] != "ord-77" {
+		t.Fatalf("Body = %#v, want stored decoded JSON", r.Body)
+	}
+
+	// Imported back, the decoded body is kept for search
+	if rec := r.toDB(1); rec.Decoded["order_id"] != "ord-77" {
+		t.Errorf("toDB Decoded = %#v", rec.Decoded)
+	}
+}
+
+func TestFromDB_WithoutTimestamp(t *testing.T) {
+	arrived := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	r := FromDB(db.Message{RoutingKey: "order.created", ConsumedAt: arrived}, nil)
+	if !r.Timestamp.IsZero() || !r.Arrival().Equal(arrived) {
+		t.Errorf("Timestamp = %v, Arrival = %v; want none and the arrival time", r.Timestamp, r.Arrival())
+	}
+	data, err := json.Marshal(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), `"timestamp"`) {
+		t.Errorf("JSON has a timestamp: %s", data)
+	}
+	if rec := r.toDB(1); !rec.Timestamp.IsZero() {
+		t.Errorf("imported timestamp = %v, want none", rec.Timestamp)
+	}
+}
+
+func TestImportSession_KeepsDecodedProtobuf(t *testing.T) {
+	store, err := db.NewStore(t.TempDir() + "/test.db")
+	if err != nil {
+		t.Fatalf("NewStore: %v", err)
+	}
+	defer store.Close()
+	ctx := context.Background()
+
+	sid, _ := store.CreateSession(ctx, "orders", "#", "q", "amqp://localhost")
+	if _, err := store.InsertMessage(ctx, &db.MessageRecord{
+		SessionID:  sid,
+		Exchange:   "orders",
+		RoutingKey: "order.created",
+		Body:       []byte{0x0a, 0x06, 'o', 'r', 'd', '-', '7', '7'},
+		ProtoType:  "shop.OrderCreated",
+		Decoded:    map[string]any{"order_id": "ord-77"},
+	}); err != nil {
+		t.Fatalf("InsertMessage: %v", err)
+	}
+
+	// Exported without descriptors, the stored decoded body is written out
+	var buf bytes.Buffer
+	w, _ := NewWriter(&buf, FormatNDJSON, nil)
+	if _, err := ExportSession(ctx, store, sid, nil, nil, w); err != nil {
+		t.Fatalf("ExportSession: %v", err)
+	}
+	_ = w.Close()
+
+	imported, n, err := ImportSession(ctx, store, NewReader(&buf), "import:orders.ndjson")
+	if err != nil || n != 1 {
+		t.Fatalf("ImportSession = %d, %v", n, err)
+	}
+
+	found, err := store.SearchMessagesInSession(ctx, "ord", imported, 10, 0)
+	if err != nil {
+		t.Fatalf("SearchMessagesInSession: %v", err)
+	}
+	if len(found) != 1 || found[0].DecodedJson.String != `{"order_id":"ord-77"}` {
+		t.Fatalf("search found %+v, want the decoded protobuf body", found)
+	}
+
+	q, err := query.Parse(`body.order_id = "ord-77"`)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	where, args := q.SQL(time.Now())
+	rows, err := store.QueryMessages(ctx, where, args, imported, 10, 0)
+	if err != nil {
+		t.Fatalf("QueryMessages: %v", err)
+	}
+	if len(rows) != 1 {
+		t.Errorf("query matched %d messages, want 1", len(rows))
+	}
+}
@@ -0,0 +1,306 @@
+package archive
+
+import (
+	"encoding/base64"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+	"github.com/parquet-go/parquet-go/compress/snappy"
+)
+
+// Export formats.
+const (
+	FormatJSON    = "json"
+	FormatNDJSON  = "ndjson"
+	FormatCSV     = "csv"
+	FormatParquet = "parquet"
+)
+
+// Formats lists the supported export formats.
+var Formats = []string{FormatJSON, FormatNDJSON, FormatCSV, FormatParquet}
+
+// Columns lists the fields that can be selected for CSV export, in record order.
+var Columns = []string{
+	"id", "timestamp", "consumed_at", "exchange", "routing_key", "content_type", "correlation_id",
+	"reply_to", "message_id", "app_id", "delivery_mode", "priority", "expiration", "headers",
+	"proto_type", "body", "decode_error", "bookmarked", "note", "tags", "raw_body",
+}
+
+// DefaultColumns are the CSV columns used when none are given.
+var DefaultColumns = []string{"id", "timestamp", "exchange", "routing_key", "headers", "body"}
+
+// parquetRowGroup bounds how many rows are buffered before a row group is flushed.
+const parquetRowGroup = 10000
+
+// Writer writes records to a file in one of the export formats.
+// Close must be called to complete the file; it does not close the underlying writer.
+type Writer interface {
+	Write(r Record) error
+	Close() error
+}
+
+// FormatFromPath guesses the export format from a file extension, or returns "".
+func FormatFromPath(path string) string {
+	ext := strings.TrimPrefix(strings.ToLower(filepath.Ext(path)), ".")
+	switch ext {
+	case "jsonl":
+		return FormatNDJSON
+	case FormatJSON, FormatNDJSON, FormatCSV, FormatParquet:
+		return ext
+	}
+	return ""
+}
+
+// NewWriter returns a Writer for format. columns selects the CSV columns and
+// is ignored by other formats; nil means DefaultColumns.
+func NewWriter(w io.Writer, format string, columns []string) (Writer, error) {
+	switch format {
+	case FormatJSON:
+		return &jsonWriter{w: w}, nil
+	case FormatNDJSON:
+		enc := json.NewEncoder(w)
+		enc.SetEscapeHTML(false)
+		return &ndjsonWriter{enc: enc}, nil
+	case FormatCSV:
+		if len(columns) == 0 {
+			columns = DefaultColumns
+		}
+		for _, c := range columns {
+			if !validColumn(c) {
+				return nil, fmt.Errorf("unknown column %q (available: %s)", c, strings.Join(Columns, ", "))
+			}
+		}
+		return &csvWriter{w: csv.NewWriter(w), columns: columns}, nil
+	case FormatParquet:
+		return &parquetWriter{w: parquet.NewGenericWriter[parquetRow](w,
+			parquet.Compression(&snappy.Codec{}),
+			parquet.MaxRowsPerRowGroup(parquetRowGroup),
+		)}, nil
+	}
+	return nil, fmt.Errorf("unknown format %q (available: %s)", format, strings.Join(Formats, ", "))
+}
+
+func validColumn(name string) bool {
+	for _, c := range Columns {
+		if c == name {
+			return true
+		}
+	}
+	return false
+}
+
+// jsonWriter streams a JSON array, one indented record at a time.
+type jsonWriter struct {
+	w     io.Writer
+	count int
+}
+
+func (j *jsonWriter) Write(r Record) error {
+	data, err := json.MarshalIndent(r, "  ", "  ")
+	if err != nil {
+		return err
+	}
+	sep := ",\n  "
+	if j.count == 0 {
+		sep = "[\n  "
+	}
+	j.count++
+	if _, err := io.WriteString(j.w, sep); err != nil {
+		return err
+	}
+	_, err = j.w.Write(data)
+	return err
+}
+
+func (j *jsonWriter) Close() error {
+	end := "\n]\n"
+	if j.count == 0 {
+		end = "[]\n"
+	}
+	_, err := io.WriteString(j.w, end)
+	return err
+}
+
+type ndjsonWriter struct {
+	enc *json.Encoder
+}
+
+func (n *ndjsonWriter) Write(r Record) error { return n.enc.Encode(r) }
+func (n *ndjsonWriter) Close() error         { return nil }
+
+type csvWriter struct {
+	w           *csv.Writer
+	columns     []string
+	wroteHeader bool
+}
+
+func (c *csvWriter) Write(r Record) error {
+	if !c.wroteHeader {
+		c.wroteHeader = true
+		if err := c.w.Write(c.columns); err != nil {
+			return err
+		}
+	}
+	row := make([]string, len(c.columns))
+	for i, col := range c.columns {
+		row[i] = r.Field(col)
+	}
+	return c.w.Write(row)
+}
+
+func (c *csvWriter) Close() error {
+	if !c.wroteHeader {
+		if err := c.w.Write(c.columns); err != nil {
+			return err
+		}
+	}
+	c.w.Flush()
+	return c.w.Error()
+}
+
+// parquetRow is the flat Parquet schema. Headers and body are JSON strings,
+// which DuckDB can query with its JSON functions.
+type parquetRow struct {
+	ID            int64     `parquet:"id"`
+	Timestamp     time.Time `parquet:"timestamp,timestamp(millisecond),optional"`
+	ConsumedAt    time.Time `parquet:"consumed_at,timestamp(microsecond)"`
+	Exchange      string    `parquet:"exchange,dict"`
+	RoutingKey    string    `parquet:"routing_key,dict"`
+	ContentType   string    `parquet:"content_type,dict"`
+	CorrelationID string    `parquet:"correlation_id"`
+	ReplyTo       string    `parquet:"reply_to"`
+	MessageID     string    `parquet:"message_id"`
+	AppID         string    `parquet:"app_id,dict"`
+	DeliveryMode  int32     `parquet:"delivery_mode"`
+	Priority      int32     `parquet:"priority"`
+	Expiration    string    `parquet:"expiration"`
+	Headers       string    `parquet:"headers"`
+	ProtoType     string    `parquet:"proto_type,dict"`
+	Body          string    `parquet:"body"`
+	DecodeError   string    `parquet:"decode_error"`
+	Bookmarked    bool      `parquet:"bookmarked"`
+	Note          string    `parquet:"note"`
+	Tags          []string  `parquet:"tags,list"`
+	RawBody       []byte    `parquet:"raw_body"`
+}
+
+type parquetWriter struct {
+	w *parquet.GenericWriter[parquetRow]
+}
+
+func (p *parquetWriter) Write(r Record) error {
+	_, err := p.w.Write([]parquetRow{{
+		ID:            r.ID,
+		Timestamp:     r.Timestamp,
+		ConsumedAt:    r.ConsumedAt,
+		Exchange:      r.Exchange,
+		RoutingKey:    r.RoutingKey,
+		ContentType:   r.ContentType,
+		CorrelationID: r.CorrelationID,
+		ReplyTo:       r.ReplyTo,
+		MessageID:     r.MessageID,
+		AppID:         r.AppID,
+		DeliveryMode:  int32(r.DeliveryMode),
+		Priority:      int32(r.Priority),
+		Expiration:    r.Expiration,
+		Headers:       r.Field("headers"),
+		ProtoType:     r.ProtoType,
+		Body:          r.bodyJSON(),
+		DecodeError:   r.DecodeError,
+		Bookmarked:    r.Bookmarked,
+		Note:          r.Note,
+		Tags:          r.Tags,
+		RawBody:       r.RawBody,
+	}})
+	return err
+}
+
+func (p *parquetWriter) Close() error { return p.w.Close() }
+
+// Field returns the named column as text, as written to CSV. Headers and
+// decoded bodies are JSON; undecoded bodies are the raw bytes as a string.
+func (r Record) Field(name string) string {
+	switch name {
+	case "id":
+		return strconv.FormatInt(r.ID, 10)
+	case "timestamp":
+		if r.Timestamp.IsZero() {
+			return ""
+		}
+		return r.Timestamp.Format(time.RFC3339Nano)
+	case "consumed_at":
+		if r.ConsumedAt.IsZero() {
+			return ""
+		}
+		return r.ConsumedAt.Format(time.RFC3339Nano)
+	case "exchange":
+		return r.Exchange
+	case "routing_key":
+		return r.RoutingKey
+	case "content_type":
+		return r.ContentType
+	case "correlation_id":
+		return r.CorrelationID
+	case "reply_to":
+		return r.ReplyTo
+	case "message_id":
+		return r.MessageID
+	case "app_id":
+		return r.AppID
+	case "delivery_mode":
+		if r.DeliveryMode == 0 {
+			return ""
+		}
+		return strconv.Itoa(int(r.DeliveryMode))
+	case "priority":
+		if r.Priority == 0 {
+			return ""
+		}
+		return strconv.Itoa(int(r.Priority))
+	case "expiration":
+		return r.Expiration
+	case "headers":
+		if len(r.Headers) == 0 {
+			return ""
+		}
+		data, _ := json.Marshal(r.Headers)
+		return string(data)
+	case "proto_type":
+		return r.ProtoType
+	case "body":
+		if r.Body == nil {
+			return string(r.RawBody)
+		}
+		return r.bodyJSON()
+	case "decode_error":
+		return r.DecodeError
+	case "bookmarked":
+		return strconv.FormatBool(r.Bookmarked)
+	case "note":
+		return r.Note
+	case "tags":
+		return strings.Join(r.Tags, ",")
+	case "raw_body":
+		return base64.StdEncoding.EncodeToString(r.RawBody)
+	}
+	return ""
+}
+
+// bodyJSON returns the decoded body as compact JSON, or "" when there is none.
+func (r Record) bodyJSON() string {
+	if r.Body == nil {
+		return ""
+	}
+	data, err := json.Marshal(r.Body)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
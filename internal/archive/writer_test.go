@@ -0,0 +1,141 @@
+package archive
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+)
+
+func testRecords() []Record {
+	ts := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	a := Record{ID: 1, Timestamp: ts, Exchange: "orders", RoutingKey: "order.created", Headers: map[string]any{"tenant": "acme"}}
+	a.SetBody([]byte(`{"id":1}`), nil)
+	b := Record{ID: 2, Timestamp: ts.Add(time.Second), Exchange: "orders", RoutingKey: "order.paid"}
+	b.SetBody([]byte("plain, text"), nil)
+	return []Record{a, b}
+}
+
+func writeAll(t *testing.T, format string, columns []string, recs []Record) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, format, columns)
+	if err != nil {
+		t.Fatalf("NewWriter(%q): %v", format, err)
+	}
+	for _, r := range recs {
+		if err := w.Write(r); err != nil {
+			t.Fatalf("Write: %v", err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestWriter_JSON(t *testing.T) {
+	for _, n := range []int{0, 1, 2} {
+		out := writeAll(t, FormatJSON, nil, testRecords()[:n])
+		var got []Record
+		if err := json.Unmarshal(out, &got); err != nil {
+			t.Fatalf("%d records: invalid JSON array: %v\n%s", n, err, out)
+		}
+		if len(got) != n {
+			t.Errorf("%d records: decoded %d", n, len(got))
+		}
+	}
+}
+
+func TestWriter_NDJSON(t *testing.T) {
+	out := writeAll(t, FormatNDJSON, nil, testRecords())
+	lines := strings.Split(strings.TrimSpace(string(out)), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 lines, got %d", len(lines))
+	}
+	var got Record
+	if err := json.Unmarshal([]byte(lines[1]), &got); err != nil {
+		t.Fatalf("invalid line: %v", err)
+	}
+	if got.RoutingKey != "order.paid" || string(got.RawBody) != "plain, text" {
+		t.Errorf("unexpected record: %+v", got)
+	}
+}
+
+func TestWriter_CSVColumns(t *testing.T) {
+	out := writeAll(t, FormatCSV, []string{"routing_key", "body", "headers"}, testRecords())
+	rows, err := csv.NewReader(bytes.NewReader(out)).ReadAll()
+	if err != nil {
+		t.Fatalf("invalid CSV: %v", err)
+	}
+	want := [][]string{
+		{"routing_key", "body", "headers"},
+		{"order.created", `{"id":1}`, `{"tenant":"acme"}`},
+		{"order.paid", "plain, text", ""},
+	}
+	if len(rows) != len(want) {
+		t.Fatalf("got %d rows, want %d", len(rows), len(want))
+	}
+	for i := range want {
+		if strings.Join(rows[i], "|") != strings.Join(want[i], "|") {
+			t.Errorf("row %d = %q, want %q", i, rows[i], want[i])
+		}
+	}
+}
+
+func TestWriter_CSVHeaderOnlyWhenEmpty(t *testing.T) {
+	out := writeAll(t, FormatCSV, nil, nil)
+	if got := strings.TrimSpace(string(out)); got != strings.Join(DefaultColumns, ",") {
+		t.Errorf("got %q, want header row", got)
+	}
+}
+
+func TestWriter_Parquet(t *testing.T) {
+	recs := testRecords()
+	recs[0].Note, recs[0].Tags = "first", []string{"incident", "orders"}
+	out := writeAll(t, FormatParquet, nil, recs)
+	rows, err := parquet.Read[parquetRow](bytes.NewReader(out), int64(len(out)))
+	if err != nil {
+		t.Fatalf("reading parquet: %v", err)
+	}
+	if len(rows) != 2 {
+		t.Fatalf("got %d rows, want 2", len(rows))
+	}
+	if rows[0].RoutingKey != "order.created" || rows[0].Body != `{"id":1}` || rows[0].Headers != `{"tenant":"acme"}` ||
+		rows[0].Note != "first" || strings.Join(rows[0].Tags, ",") != "incident,orders" {
+		t.Errorf("unexpected row: %+v", rows[0])
+	}
+	if !rows[1].Timestamp.Equal(testRecords()[1].Timestamp) || string(rows[1].RawBody) != "plain, text" {
+		t.Errorf("unexpected row: %+v", rows[1])
+	}
+}
+
+func TestNewWriter_Errors(t *testing.T) {
+	if _, err := NewWriter(&bytes.Buffer{}, "xml", nil); err == nil {
+		t.Error("expected error for unknown format")
+	}
+	if _, err := NewWriter(&bytes.Buffer{}, FormatCSV, []string{"nope"}); err == nil {
+		t.Error("expected error for unknown column")
+	}
+}
+
+func TestFormatFromPath(t *testing.T) {
+	tests := map[string]string{
+		"out.json":   FormatJSON,
+		"out.NDJSON": FormatNDJSON,
+		"out.jsonl":  FormatNDJSON,
+		"out.csv":    FormatCSV,
+		"x.parquet":  FormatParquet,
+		"out.txt":    "",
+		"":           "",
+	}
+	for path, want := range tests {
+		if got := FormatFromPath(path); got != want {
+			t.Errorf("FormatFromPath(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
@@ -0,0 +1,284 @@
+package cli
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/epalmerini/rabbithole/internal/alert"
+	"github.com/epalmerini/rabbithole/internal/archive"
+	"github.com/epalmerini/rabbithole/internal/db"
+	"github.com/epalmerini/rabbithole/internal/proto"
+	"github.com/epalmerini/rabbithole/internal/rabbitmq"
+)
+
+// Reasons a capture stops.
+const (
+	stopCount       = "message limit reached"
+	stopDuration    = "duration elapsed"
+	stopIdle        = "idle timeout"
+	stopInterrupted = "interrupted"
+	stopClosed      = "connection closed"
+	stopSaveFailed  = "save failed"
+)
+
+type captureOptions struct {
+	count    int
+	duration time.Duration
+	idle     time.Duration
+}
+
+type captureSummary struct {
+	messages int
+	bytes    int64
+	alerts   int // messages that matched an alert rule
+	elapsed  time.Duration
+	reason   string
+	err      error // why saving failed, with stopSaveFailed
+}
+
+func runCapture(ctx context.Context, env Env, args []string) error {
+	fs := flag.NewFlagSet("capture", flag.ContinueOnError)
+	fs.SetOutput(env.Stderr)
+
+	var conn connOptions
+	conn.register(fs)
+	var target consumeOptions
+	target.register(fs)
+	var opts captureOptions
+	fs.IntVar(&opts.count, "count", 0, "Stop after N messages (0 = unlimited)")
+	fs.DurationVar(&opts.duration, "duration", 0, "Stop after this long (e.g. 10m)")
+	fs.DurationVar(&opts.idle, "idle", 0, "Stop after no message arrives for this long")
+	fs.Usage = func() {
+		_, _ = fmt.Fprintln(fs.Output(), "Usage: rabbithole capture -exchange NAME [flags]")
+		_, _ = fmt.Fprintln(fs.Output(), "\nRecord consumed messages to a new session in the SQLite store.")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := target.validate(); err != nil {
+		fs.Usage()
+		return err
+	}
+
+	cfg, err := conn.resolve(env)
+	if err != nil {
+		return err
+	}
+	dec, err := decoder(cfg)
+	if err != nil {
+		return err
+	}
+
+	rules, err := alert.NewRules(cfg.Alerts)
+	if err != nil {
+		return fmt.Errorf("invalid alert rules: %w", err)
+	}
+	alerts := &alerter{rules: rules, out: env.Stderr}
+
+	store, err := db.NewStore(cfg.DBPath)
+	if err != nil {
+		return fmt.Errorf("failed to initialize database: %w", err)
+	}
+	defer func() { _ = store.Close() }()
+
+	consumeCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	consumer, deliveries, err := target.start(consumeCtx, cfg.RabbitMQURL)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = consumer.Close() }()
+
+	queueName := target.queue
+	if queueName == "" {
+		queueName = "(auto-generated)"
+	}
+	sid, err := store.CreateSession(context.Background(), target.exchange, target.routingKey, queueName, cfg.RabbitMQURL)
+	if err != nil {
+		return fmt.Errorf("failed to create session: %w", err)
+	}
+	go db.KeepSessionAlive(consumeCtx, store, sid)
+
+	_, _ = fmt.Fprintf(env.Stderr, "Capturing to session %d (exchange %q, routing key %q). Press Ctrl+C to stop.\n", sid, target.exchange, target.routingKey)
+
+	// Messages are saved as they arrive rather than through a buffer that
+	// could overflow: a slow disk holds up consuming instead of losing data
+	summary := capture(ctx, deliveries, opts, func(d rabbitmq.Delivery) error {
+		rec := deliveryRecord(d, dec)
+		rec.SessionID = sid
+		alerts.fire(rec)
+		_, err := store.InsertMessage(context.Background(), rec)
+		return err
+	})
+
+	cancel()
+	alerts.wait()
+	summary.alerts, _ = rules.Fired()
+
+	bg := context.Background()
+	if summary.messages == 0 {
+		_ = store.DeleteSession(bg, sid)
+	} else if err := store.EndSession(bg, sid); err != nil {
+		return fmt.Errorf("failed to end session: %w", err)
+	}
+
+	printCaptureSummary(env.Stdout, sid, summary)
+	if summary.err != nil {
+		return fmt.Errorf("failed to save message: %w", summary.err)
+	}
+	return nil
+}
+
+// capture records deliveries until a stop condition is met, or until save
+// fails: the message that failed is not counted.
+func capture(ctx context.Context, deliveries <-chan rabbitmq.Delivery, opts captureOptions, save func(rabbitmq.Delivery) error) captureSummary {
+	start := time.Now()
+	var s captureSummary
+
+	var durationC <-chan time.Time
+	if opts.duration > 0 {
+		timer := time.NewTimer(opts.duration)
+		defer timer.Stop()
+		durationC = timer.C
+	}
+
+	var idleTimer *time.Timer
+	var idleC <-chan time.Time
+	if opts.idle > 0 {
+		idleTimer = time.NewTimer(opts.idle)
+		defer idleTimer.Stop()
+		idleC = idleTimer.C
+	}
+
+	for s.reason == "" {
+		select {
+		case <-ctx.Done():
+			s.reason = stopInterrupted
+		case <-durationC:
+			s.reason = stopDuration
+		case <-idleC:
+			s.reason = stopIdle
+		case d, ok := <-deliveries:
+			if !ok {
+				s.reason = stopClosed
+				break
+			}
+			if err := save(d); err != nil {
+				s.reason, s.err = stopSaveFailed, err
+				break
+			}
+			s.messages++
+			s.bytes += int64(len(d.Body))
+			if opts.count > 0 && s.messages >= opts.count {
+				s.reason = stopCount
+			}
+			if idleTimer != nil {
+				idleTimer.Reset(opts.idle)
+			}
+		}
+	}
+
+	s.elapsed = time.Since(start)
+	return s
+}
+
+// deliveryRecord converts a delivery to a store record, decoding the body if possible.
+func deliveryRecord(d rabbitmq.Delivery, dec *proto.Decoder) *db.MessageRecord {
+	rec := &db.MessageRecord{
+		Exchange:      d.Exchange,
+		RoutingKey:    d.RoutingKey,
+		Body:          d.Body,
+		ContentType:   d.ContentType,
+		Headers:       d.Headers,
+		Timestamp:     d.PublisherTimestamp(),
+		ConsumedAt:    d.ConsumedAt,
+		CorrelationID: d.CorrelationID,
+		ReplyTo:       d.ReplyTo,
+		MessageID:     d.MessageID,
+		AppID:         d.AppID,
+		DeliveryMode:  d.DeliveryMode,
+		Priority:      d.Priority,
+		Expiration:    d.Expiration,
+	}
+	if dec != nil {
+		if decoded, protoType, err := dec.DecodeWithHintAndType(d.Body, d.RoutingKey); err == nil {
+			rec.Decoded = decoded
+			rec.ProtoType = protoType
+		}
+	}
+	return rec
+}
+
+// alerter applies alert rules to captured messages. Matches are logged to
+// out, which also receives bells and notifications; commands run in the
+// background. Bookmarks are saved with the message.
+type alerter struct {
+	rules *alert.Rules
+	out   io.Writer
+	mu    sync.Mutex // guards out, written by commands failing
+	wg    sync.WaitGroup
+}
+
+func (a *alerter) fire(rec *db.MessageRecord) {
+	r := archive.Record{
+		Timestamp:     rec.Timestamp,
+		ConsumedAt:    rec.ConsumedAt,
+		Exchange:      rec.Exchange,
+		RoutingKey:    rec.RoutingKey,
+		ContentType:   rec.ContentType,
+		CorrelationID: rec.CorrelationID,
+		ReplyTo:       rec.ReplyTo,
+		MessageID:     rec.MessageID,
+		AppID:         rec.AppID,
+		Headers:       rec.Headers,
+		ProtoType:     rec.ProtoType,
+	}
+	r.SetBody(rec.Body, rec.Decoded)
+
+	am := a.rules.FireRecord(r, time.Now())
+	if !am.Matched() {
+		return
+	}
+	rec.Annotation.Bookmarked = am.Bookmark
+	a.mu.Lock()
+	_, _ = fmt.Fprintf(a.out, "Alert %s: %s %s\n", strings.Join(am.Rules, ", "), rec.Exchange, rec.RoutingKey)
+	_ = am.Signal(a.out)
+	a.mu.Unlock()
+
+	for _, c := range am.Commands {
+		a.wg.Add(1)
+		go func() {
+			defer a.wg.Done()
+			// Commands outlive an interrupted capture, up to their timeout
+			if err := c.Run(context.Background(), r); err != nil {
+				a.mu.Lock()
+				_, _ = fmt.Fprintln(a.out, err)
+				a.mu.Unlock()
+			}
+		}()
+	}
+}
+
+// wait waits for the commands still running.
+func (a *alerter) wait() {
+	a.wg.Wait()
+}
+
+func printCaptureSummary(w io.Writer, sid int64, s captureSummary) {
+	_, _ = fmt.Fprintf(w, "Captured %d messages (%d bytes) in %s, stopped: %s\n",
+		s.messages, s.bytes, s.elapsed.Round(time.Millisecond), s.reason)
+	if s.alerts > 0 {
+		_, _ = fmt.Fprintf(w, "Alert rules matched %d messages\n", s.alerts)
+	}
+	if s.messages > 0 {
+		_, _ = fmt.Fprintf(w, "Saved as session %d; open it from the session browser\n", sid)
+	} else {
+		_, _ = fmt.Fprintln(w, "No messages captured; session discarded")
+	}
+}
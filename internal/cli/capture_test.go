@@ -0,0 +1,164 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/epalmerini/rabbithole/internal/alert"
+	"github.com/epalmerini/rabbithole/internal/config"
+	"github.com/epalmerini/rabbithole/internal/db"
+	"github.com/epalmerini/rabbithole/internal/rabbitmq"
+)
+
+func TestCapture_StopConditions(t *testing.T) {
+	tests := []struct {
+		name       string
+		opts       captureOptions
+		send       int
+		closeAfter bool
+		cancel     bool
+		want       string
+		wantMsgs   int
+	}{
+		{"count", captureOptions{count: 3}, 5, false, false, stopCount, 3},
+		{"idle", captureOptions{idle: 20 * time.Millisecond}, 2, false, false, stopIdle, 2},
+		{"duration", captureOptions{duration: 20 * time.Millisecond}, 1, false, false, stopDuration, 1},
+		{"closed", captureOptions{}, 2, true, false, stopClosed, 2},
+		{"interrupted", captureOptions{}, 1, false, true, stopInterrupted, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			deliveries := make(chan rabbitmq.Delivery, 10)
+			for i := 0; i < tt.send; i++ {
+				deliveries <- rabbitmq.Delivery{Body: []byte("abc")}
+			}
+			if tt.closeAfter {
+				close(deliveries)
+			}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			var saved int
+			save := func(rabbitmq.Delivery) error {
+				saved++
+				if tt.cancel && saved == tt.send {
+					cancel()
+				}
+				return nil
+			}
+
+			s := capture(ctx, deliveries, tt.opts, save)
+			if s.reason != tt.want {
+				t.Errorf("reason = %q, want %q", s.reason, tt.want)
+			}
+			if s.messages != tt.wantMsgs || saved != tt.wantMsgs {
+				t.Errorf("messages = %d, saved = %d, want %d", s.messages, saved, tt.wantMsgs)
+			}
+			if s.bytes != int64(3*tt.wantMsgs) {
+				t.Errorf("bytes = %d", s.bytes)
+			}
+		})
+	}
+}
+
+func TestCapture_StopsWhenSaveFails(t *testing.T) {
+	deliveries := make(chan rabbitmq.Delivery, 3)
+	for range 3 {
+		deliveries <- rabbitmq.Delivery{Body: []byte("abc")}
+	}
+	close(deliveries)
+
+	diskFull := errors.New("database or disk is full")
+	saved := 0
+	s := capture(context.Background(), deliveries, captureOptions{}, func(rabbitmq.Delivery) error {
+		if saved == 1 {
+			return diskFull
+		}
+		saved++
+		return nil
+	})
+	if s.reason != stopSaveFailed || !errors.Is(s.err, diskFull) {
+		t.Errorf("reason = %q, err = %v", s.reason, s.err)
+	}
+	if s.messages != 1 || s.bytes != 3 {
+		t.Errorf("messages = %d, bytes = %d, want only the saved one", s.messages, s.bytes)
+	}
+}
+
+func TestAlerter_Fire(t *testing.T) {
+	rules, err := alert.NewRules([]config.AlertRule{
+		{Name: "failed", Filter: "rk:failed", Bell: true, Bookmark: true},
+		{Name: "broken", Filter: "rk:failed", Command: "echo oops >&2; exit 1"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	a := &alerter{rules: rules, out: &out}
+	a.fire(&db.MessageRecord{Exchange: "orders", RoutingKey: "order.created", Body: []byte("{}")})
+	failed := &db.MessageRecord{Exchange: "orders", RoutingKey: "payment.failed", Body: []byte("{}")}
+	a.fire(failed)
+	a.wait()
+
+	got := out.String()
+	for _, want := range []string{"Alert failed, broken: orders payment.failed\n\a", "alert broken: command failed", "oops"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("output %q missing %q", got, want)
+		}
+	}
+	if fired, _ := rules.Fired(); fired != 1 {
+		t.Errorf("fired = %d, want 1", fired)
+	}
+	if !failed.Annotation.Bookmarked {
+		t.Error("the match should be saved bookmarked")
+	}
+}
+
+func TestPrintCaptureSummary(t *testing.T) {
+	var buf bytes.Buffer
+	printCaptureSummary(&buf, 42, captureSummary{messages: 3, bytes: 9, alerts: 2, reason: stopIdle})
+	out := buf.String()
+	for _, want := range []string{"Captured 3 messages", "stopped: idle timeout", "matched 2 messages", "session 42"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("summary %q missing %q", out, want)
+		}
+	}
+}
+
+func TestConnOptionsResolve(t *testing.T) {
+	env := Env{FileConfig: &config.FileConfig{
+		Profiles: map[string]config.Profile{"prod": {URL: "amqp://prod:5672/"}},
+	}}
+
+	cfg, err := connOptions{profile: "prod"}.resolve(env)
+	if err != nil {
+		t.Fatalf("resolve: %v", err)
+	}
+	if cfg.RabbitMQURL != "amqp://prod:5672/" {
+		t.Errorf("RabbitMQURL = %q", cfg.RabbitMQURL)
+	}
+
+	cfg, _ = connOptions{profile: "prod", url: "amqp://override:5672/"}.resolve(env)
+	if cfg.RabbitMQURL != "amqp://override:5672/" {
+		t.Errorf("flag should override profile, got %q", cfg.RabbitMQURL)
+	}
+
+	if _, err := (connOptions{profile: "missing"}).resolve(env); err == nil {
+		t.Error("expected error for unknown profile")
+	}
+}
+
+func TestRun_UnknownCommand(t *testing.T) {
+	if IsCommand("nope") {
+		t.Error("IsCommand(nope) = true")
+	}
+	if err := Run("nope", nil, Env{}); err == nil {
+		t.Error("expected error for unknown command")
+	}
+}
@@ -0,0 +1,187 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/epalmerini/rabbithole/internal/config"
+	"github.com/epalmerini/rabbithole/internal/db"
+)
+
+// runDB dispatches the database maintenance subcommands.
+func runDB(ctx context.Context, env Env, args []string) error {
+	if len(args) == 0 || args[0] == "-h" || args[0] == "-help" {
+		_, _ = fmt.Fprintln(env.Stderr, "Usage: rabbithole db <prune|reindex> [flags]")
+		if len(args) == 0 {
+			return errors.New("missing db subcommand")
+		}
+		return flag.ErrHelp
+	}
+	switch args[0] {
+	case "prune":
+		return runPrune(ctx, env, args[1:])
+	case "reindex":
+		return runReindex(ctx, env, args[1:])
+	}
+	return fmt.Errorf("unknown db subcommand %q", args[0])
+}
+
+func runReindex(ctx context.Context, env Env, args []string) error {
+	fs := flag.NewFlagSet("db reindex", flag.ContinueOnError)
+	fs.SetOutput(env.Stderr)
+
+	var conn connOptions
+	conn.registerStore(fs)
+	session := fs.Int64("session", 0, "Only reindex this session (default: all)")
+	fs.Usage = func() {
+		_, _ = fmt.Fprintln(fs.Output(), "Usage: rabbithole db reindex -proto DIR [-session ID]")
+		_, _ = fmt.Fprintln(fs.Output(), "\nRe-decode stored messages with the current .proto files so search covers their decoded content.")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	cfg, err := conn.resolve(env)
+	if err != nil {
+		return err
+	}
+	dec, err := decoder(cfg)
+	if err != nil {
+		return err
+	}
+	if dec == nil {
+		fs.Usage()
+		return errors.New("-proto is required to decode messages")
+	}
+
+	store, err := db.NewStore(cfg.DBPath)
+	if err != nil {
+		return fmt.Errorf("failed to initialize database: %w", err)
+	}
+	defer func() { _ = store.Close() }()
+
+	res, err := store.ReindexDecoded(ctx, *session, dec.DecodeWithHintAndType, func(r db.ReindexResult) {
+		_, _ = fmt.Fprintf(env.Stderr, "\rReindexed %d messages", r.Scanned)
+	})
+	if res.Scanned > 0 {
+		_, _ = fmt.Fprintln(env.Stderr)
+	}
+	_, _ = fmt.Fprintf(env.Stdout, "Decoded %d of %d messages", res.Decoded, res.Scanned)
+	if res.Failed > 0 {
+		_, _ = fmt.Fprintf(env.Stdout, " (%d not matching any message type, left unchanged)", res.Failed)
+	}
+	_, _ = fmt.Fprintln(env.Stdout)
+	if errors.Is(err, context.Canceled) {
+		return nil
+	}
+	return err
+}
+
+func runPrune(ctx context.Context, env Env, args []string) error {
+	fs := flag.NewFlagSet("db prune", flag.ContinueOnError)
+	fs.SetOutput(env.Stderr)
+
+	var conn connOptions
+	conn.registerStore(fs)
+	dryRun := fs.Bool("dry-run", false, "Report what would be deleted without deleting")
+	var limits config.RetentionConfig
+	fs.StringVar(&limits.MaxAge, "max-age", "", "Override retention max_age (e.g. 30d)")
+	fs.StringVar(&limits.MaxSize, "max-size", "", "Override retention max_size (e.g. 2GB)")
+	fs.IntVar(&limits.MaxSessions, "max-sessions", 0, "Override retention max_sessions")
+	fs.Usage = func() {
+		_, _ = fmt.Fprintln(fs.Output(), "Usage: rabbithole db prune [-dry-run] [flags]")
+		_, _ = fmt.Fprintln(fs.Output(), "\nDelete sessions outside the [retention] limits in config.toml and reclaim space.")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	fileCfg := config.FileConfig{}
+	if env.FileConfig != nil {
+		fileCfg = *env.FileConfig
+	}
+	fileCfg.Retention = fileCfg.Retention.Override(limits)
+	retention, err := db.RetentionFromConfig(&fileCfg)
+	if err != nil {
+		return err
+	}
+	if !retention.Enabled() {
+		return errors.New("no retention limits set; configure [retention] in config.toml or pass -max-age, -max-size or -max-sessions")
+	}
+
+	cfg, err := conn.resolve(env)
+	if err != nil {
+		return err
+	}
+	store, err := db.NewStore(cfg.DBPath)
+	if err != nil {
+		return fmt.Errorf("failed to initialize database: %w", err)
+	}
+	defer func() { _ = store.Close() }()
+
+	plan, err := store.PlanPrune(ctx, retention, time.Now())
+	if err != nil {
+		return err
+	}
+	printPrunePlan(env.Stdout, plan, *dryRun)
+	if *dryRun || len(plan.Delete) == 0 {
+		return nil
+	}
+
+	// Older databases must be rewritten once before space can be reclaimed
+	incremental, err := store.IncrementalVacuum(ctx)
+	if err != nil {
+		return err
+	}
+
+	n, err := store.Prune(ctx, plan)
+	if err != nil {
+		return err
+	}
+	_, _ = fmt.Fprintf(env.Stdout, "Deleted %d sessions\n", n)
+
+	if !incremental {
+		_, _ = fmt.Fprintln(env.Stderr, "Enabling incremental vacuum (one-off full rewrite, may take a while)...")
+		if err := store.EnableIncrementalVacuum(ctx); err != nil {
+			return fmt.Errorf("failed to vacuum: %w", err)
+		}
+	}
+	size, err := store.DBSize(ctx)
+	if err == nil {
+		_, _ = fmt.Fprintf(env.Stdout, "Database now uses %s\n", db.FormatBytes(size))
+	}
+	return nil
+}
+
+func printPrunePlan(w io.Writer, plan db.PrunePlan, dryRun bool) {
+	if len(plan.Delete) == 0 {
+		_, _ = fmt.Fprintf(w, "Nothing to prune (%d sessions, %s)\n", plan.Sessions, db.FormatBytes(plan.DBSize))
+		return
+	}
+
+	verb := "Deleting"
+	if dryRun {
+		verb = "Would delete"
+	}
+	_, _ = fmt.Fprintf(w, "%s %d of %d sessions (~%s of %s):\n", verb, len(plan.Delete), plan.Sessions, db.FormatBytes(plan.EstFreed), db.FormatBytes(plan.DBSize))
+	for _, c := range plan.Delete {
+		_, _ = fmt.Fprintf(w, "  #%-5d %s  %-24s %8d msgs %10s  %s\n",
+			c.ID, c.StartedAt.Local().Format("2006-01-02 15:04"), truncateName(c.Exchange, 24), c.Messages, db.FormatBytes(c.Bytes), c.Reason)
+	}
+	if plan.OpenSessions > 0 {
+		_, _ = fmt.Fprintf(w, "%d sessions are not ended; those still recording are never pruned, abandoned ones only by age\n", plan.OpenSessions)
+	}
+}
+
+func truncateName(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n-1] + "…"
+}
@@ -0,0 +1,95 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/epalmerini/rabbithole/internal/config"
+	"github.com/epalmerini/rabbithole/internal/db"
+)
+
+func TestRunPrune(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "test.db")
+	endSession(t, dbPath, seedSession(t, dbPath))
+	endSession(t, dbPath, seedSession(t, dbPath))
+
+	var stdout bytes.Buffer
+	env := Env{
+		FileConfig: &config.FileConfig{Retention: config.RetentionConfig{MaxSessions: 1}},
+		Stdout:     &stdout,
+		Stderr:     &bytes.Buffer{},
+	}
+
+	if err := runDB(context.Background(), env, []string{"prune", "-db", dbPath, "-dry-run"}); err != nil {
+		t.Fatalf("dry run: %v", err)
+	}
+	if !strings.Contains(stdout.String(), "Would delete 1 of 2 sessions") || !strings.Contains(stdout.String(), "beyond the newest 1 sessions") {
+		t.Errorf("unexpected dry run output:\n%s", stdout.String())
+	}
+	if n := countSessions(t, dbPath); n != 2 {
+		t.Fatalf("dry run deleted sessions: %d left", n)
+	}
+
+	stdout.Reset()
+	if err := runDB(context.Background(), env, []string{"prune", "-db", dbPath}); err != nil {
+		t.Fatalf("prune: %v", err)
+	}
+	if !strings.Contains(stdout.String(), "Deleted 1 sessions") {
+		t.Errorf("unexpected output:\n%s", stdout.String())
+	}
+	if n := countSessions(t, dbPath); n != 1 {
+		t.Errorf("expected 1 session left, got %d", n)
+	}
+}
+
+func TestRunDB_Errors(t *testing.T) {
+	env := Env{Stdout: &bytes.Buffer{}, Stderr: &bytes.Buffer{}}
+	dbPath := filepath.Join(t.TempDir(), "test.db")
+
+	tests := []struct {
+		name string
+		args []string
+	}{
+		{"no subcommand", nil},
+		{"unknown subcommand", []string{"shrink"}},
+		{"no limits", []string{"prune", "-db", dbPath}},
+		{"bad age", []string{"prune", "-db", dbPath, "-max-age", "soon"}},
+		{"reindex without proto", []string{"reindex", "-db", dbPath}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := runDB(context.Background(), env, tt.args); err == nil {
+				t.Error("expected error")
+			}
+		})
+	}
+}
+
+func endSession(t *testing.T, path string, sid int64) {
+	t.Helper()
+	store, err := db.NewStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	if err := store.EndSession(context.Background(), sid); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func countSessions(t *testing.T, path string) int {
+	t.Helper()
+	store, err := db.NewStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	sessions, err := store.ListRecentSessions(context.Background(), 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return len(sessions)
+}
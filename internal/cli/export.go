@@ -0,0 +1,136 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/epalmerini/rabbithole/internal/archive"
+	"github.com/epalmerini/rabbithole/internal/db"
+	"github.com/epalmerini/rabbithole/internal/filter"
+	"github.com/epalmerini/rabbithole/internal/proto"
+)
+
+func runExport(ctx context.Context, env Env, args []string) error {
+	fs := flag.NewFlagSet("export", flag.ContinueOnError)
+	fs.SetOutput(env.Stderr)
+
+	var conn connOptions
+	conn.registerStore(fs)
+	session := fs.Int64("session", 0, "ID of the session to export (required)")
+	format := fs.String("format", "", "Output format: "+strings.Join(archive.Formats, ", ")+" (default: from -o extension, else ndjson)")
+	output := fs.String("o", "", "Output file (default: stdout)")
+	filterExpr := fs.String("filter", "", "Only export messages matching a query (body.total > 100) or search (rk:, body:, ex:, hdr:, type:, re:)")
+	annotated := fs.Bool("annotated", false, "Only export messages that are bookmarked, noted or tagged")
+	columns := fs.String("columns", strings.Join(archive.DefaultColumns, ","), "Comma-separated CSV columns: "+strings.Join(archive.Columns, ", "))
+	fs.Usage = func() {
+		_, _ = fmt.Fprintln(fs.Output(), "Usage: rabbithole export -session ID [-format FORMAT] [-o FILE] [flags]")
+		_, _ = fmt.Fprintln(fs.Output(), "\nWrite the messages of a stored session to a file.")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *session <= 0 {
+		fs.Usage()
+		return errors.New("-session is required")
+	}
+
+	if *format == "" {
+		*format = archive.FormatFromPath(*output)
+		if *format == "" {
+			*format = archive.FormatNDJSON
+		}
+	}
+
+	var keep func(archive.Record) bool
+	if *filterExpr != "" {
+		f, err := filter.New(*filterExpr)
+		if err != nil {
+			return fmt.Errorf("invalid filter: %w", err)
+		}
+		keep = f.MatchRecord
+	}
+	if *annotated {
+		match := keep
+		keep = func(r archive.Record) bool {
+			return r.Annotated() && (match == nil || match(r))
+		}
+	}
+
+	cfg, err := conn.resolve(env)
+	if err != nil {
+		return err
+	}
+	dec, err := decoder(cfg)
+	if err != nil {
+		return err
+	}
+
+	store, err := db.NewStore(cfg.DBPath)
+	if err != nil {
+		return fmt.Errorf("failed to initialize database: %w", err)
+	}
+	defer func() { _ = store.Close() }()
+
+	// Check the session exists before creating the output file
+	count, err := store.CountMessagesBySession(ctx, *session)
+	if err != nil {
+		return fmt.Errorf("failed to count messages: %w", err)
+	}
+	if count == 0 {
+		return fmt.Errorf("session %d has no messages", *session)
+	}
+
+	var out io.Writer = env.Stdout
+	var f *os.File
+	if *output != "" {
+		if f, err = os.Create(*output); err != nil {
+			return err
+		}
+		out = f
+	}
+
+	n, err := exportSession(ctx, store, *session, out, *format, splitColumns(*columns), keep, dec)
+	if f != nil {
+		// A failed close can leave the file truncated
+		err = errors.Join(err, f.Close())
+	}
+	if err != nil {
+		if *output != "" {
+			_ = os.Remove(*output)
+		}
+		return err
+	}
+
+	dest := *output
+	if dest == "" {
+		dest = "stdout"
+	}
+	_, _ = fmt.Fprintf(env.Stderr, "Exported %d of %d messages from session %d to %s\n", n, count, *session, dest)
+	return nil
+}
+
+// exportSession writes a session to out in format and completes the file.
+func exportSession(ctx context.Context, store db.Store, sessionID int64, out io.Writer, format string, columns []string, keep func(archive.Record) bool, dec *proto.Decoder) (int, error) {
+	w, err := archive.NewWriter(out, format, columns)
+	if err != nil {
+		return 0, err
+	}
+	n, err := archive.ExportSession(ctx, store, sessionID, dec, keep, w)
+	return n, errors.Join(err, w.Close())
+}
+
+func splitColumns(s string) []string {
+	var cols []string
+	for _, c := range strings.Split(s, ",") {
+		if c = strings.TrimSpace(c); c != "" {
+			cols = append(cols, c)
+		}
+	}
+	return cols
+}
@@ -0,0 +1,116 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/epalmerini/rabbithole/internal/db"
+)
+
+func seedSession(t *testing.T, path string) int64 {
+	t.Helper()
+	store, err := db.NewStore(path)
+	if err != nil {
+		t.Fatalf("NewStore: %v", err)
+	}
+	defer store.Close()
+
+	ctx := context.Background()
+	sid, err := store.CreateSession(ctx, "orders", "#", "q", "amqp://localhost")
+	if err != nil {
+		t.Fatalf("CreateSession: %v", err)
+	}
+	for _, rk := range []string{"order.created", "order.paid", "order.created"} {
+		if _, err := store.InsertMessage(ctx, &db.MessageRecord{SessionID: sid, Exchange: "orders", RoutingKey: rk, Body: []byte(`{}`)}); err != nil {
+			t.Fatalf("InsertMessage: %v", err)
+		}
+	}
+	return sid
+}
+
+func TestRunExport_CSVWithFilter(t *testing.T) {
+	dir := t.TempDir()
+	dbPath := filepath.Join(dir, "test.db")
+	sid := seedSession(t, dbPath)
+	out := filepath.Join(dir, "out.csv")
+
+	var stderr bytes.Buffer
+	env := Env{Stdout: &bytes.Buffer{}, Stderr: &stderr}
+	args := []string{"-db", dbPath, "-session", strconv.FormatInt(sid, 10), "-o", out, "-filter", "rk:created", "-columns", "id,routing_key"}
+	if err := runExport(context.Background(), env, args); err != nil {
+		t.Fatalf("runExport: %v", err)
+	}
+
+	f, err := os.Open(out)
+	if err != nil {
+		t.Fatalf("open output: %v", err)
+	}
+	defer f.Close()
+	rows, err := csv.NewReader(f).ReadAll()
+	if err != nil {
+		t.Fatalf("invalid CSV: %v", err)
+	}
+	if len(rows) != 3 || rows[0][1] != "routing_key" || rows[1][1] != "order.created" {
+		t.Errorf("unexpected rows: %q", rows)
+	}
+	if !bytes.Contains(stderr.Bytes(), []byte("Exported 2 of 3")) {
+		t.Errorf("unexpected summary: %s", stderr.String())
+	}
+}
+
+func TestRunExport_Annotated(t *testing.T) {
+	dir := t.TempDir()
+	dbPath := filepath.Join(dir, "test.db")
+	sid := seedSession(t, dbPath)
+
+	store, err := db.NewStore(dbPath)
+	if err != nil {
+		t.Fatalf("NewStore: %v", err)
+	}
+	ctx := context.Background()
+	msgs, _ := store.ListMessagesBySessionAsc(ctx, sid, 10, 0)
+	if err := store.SetAnnotation(ctx, msgs[1].ID, db.Annotation{Note: "late", Tags: []string{"sla", "payments"}}); err != nil {
+		t.Fatalf("SetAnnotation: %v", err)
+	}
+	_ = store.Close()
+
+	var stdout bytes.Buffer
+	env := Env{Stdout: &stdout, Stderr: &bytes.Buffer{}}
+	args := []string{"-db", dbPath, "-session", strconv.FormatInt(sid, 10), "-annotated", "-format", "csv", "-columns", "routing_key,note,tags"}
+	if err := runExport(ctx, env, args); err != nil {
+		t.Fatalf("runExport: %v", err)
+	}
+	want := "routing_key,note,tags\norder.paid,late,\"sla,payments\"\n"
+	if stdout.String() != want {
+		t.Errorf("export = %q, want %q", stdout.String(), want)
+	}
+}
+
+func TestRunExport_Errors(t *testing.T) {
+	dir := t.TempDir()
+	dbPath := filepath.Join(dir, "test.db")
+	sid := seedSession(t, dbPath)
+	env := Env{Stdout: &bytes.Buffer{}, Stderr: &bytes.Buffer{}}
+
+	tests := []struct {
+		name string
+		args []string
+	}{
+		{"missing session", []string{"-db", dbPath}},
+		{"unknown session", []string{"-db", dbPath, "-session", "999"}},
+		{"bad format", []string{"-db", dbPath, "-session", strconv.FormatInt(sid, 10), "-format", "xml"}},
+		{"bad filter", []string{"-db", dbPath, "-session", strconv.FormatInt(sid, 10), "-filter", "re:["}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := runExport(context.Background(), env, tt.args); err == nil {
+				t.Error("expected error")
+			}
+		})
+	}
+}
@@ -0,0 +1,70 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/epalmerini/rabbithole/internal/archive"
+	"github.com/epalmerini/rabbithole/internal/db"
+)
+
+func runImport(ctx context.Context, env Env, args []string) error {
+	fs := flag.NewFlagSet("import", flag.ContinueOnError)
+	fs.SetOutput(env.Stderr)
+
+	var conn connOptions
+	conn.registerStore(fs)
+	fs.Usage = func() {
+		_, _ = fmt.Fprintln(fs.Output(), "Usage: rabbithole import [flags] FILE...")
+		_, _ = fmt.Fprintln(fs.Output(), "\nLoad messages from JSON or NDJSON files (rabbithole exports, management API")
+		_, _ = fmt.Fprintln(fs.Output(), "\"get messages\" dumps or rabbitmq_tracing logs) into a new session per file.")
+		_, _ = fmt.Fprintln(fs.Output(), "Use - to read from stdin.")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return errors.New("no files to import")
+	}
+
+	cfg, err := conn.resolve(env)
+	if err != nil {
+		return err
+	}
+	store, err := db.NewStore(cfg.DBPath)
+	if err != nil {
+		return fmt.Errorf("failed to initialize database: %w", err)
+	}
+	defer func() { _ = store.Close() }()
+
+	for _, path := range fs.Args() {
+		sid, n, err := importFile(ctx, store, path)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		_, _ = fmt.Fprintf(env.Stdout, "Imported %d messages from %s into session %d\n", n, path, sid)
+	}
+	return nil
+}
+
+func importFile(ctx context.Context, store db.Store, path string) (int64, int, error) {
+	var in io.Reader = os.Stdin
+	source := "import:stdin"
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return 0, 0, err
+		}
+		defer func() { _ = f.Close() }()
+		in = f
+		source = "import:" + filepath.Base(path)
+	}
+	return archive.ImportSession(ctx, store, archive.NewReader(in), source)
+}
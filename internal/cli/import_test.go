@@ -0,0 +1,44 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRunImport(t *testing.T) {
+	dir := t.TempDir()
+	dbPath := filepath.Join(dir, "test.db")
+	file := filepath.Join(dir, "incident.ndjson")
+	input := `{"exchange":"orders","routing_key":"order.created","raw_body":"e30="}` + "\n" +
+		`{"exchange":"orders","routing_key":"order.paid","raw_body":"e30="}` + "\n"
+	if err := os.WriteFile(file, []byte(input), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var stdout bytes.Buffer
+	env := Env{Stdout: &stdout, Stderr: &bytes.Buffer{}}
+	if err := runImport(context.Background(), env, []string{"-db", dbPath, file}); err != nil {
+		t.Fatalf("runImport: %v", err)
+	}
+	if !strings.Contains(stdout.String(), "Imported 2 messages") {
+		t.Errorf("unexpected output: %s", stdout.String())
+	}
+
+	// The imported session exports back out
+	var out bytes.Buffer
+	env.Stdout = &out
+	if err := runExport(context.Background(), env, []string{"-db", dbPath, "-session", "1"}); err != nil {
+		t.Fatalf("runExport: %v", err)
+	}
+	if strings.Count(out.String(), "\n") != 2 || !strings.Contains(out.String(), "order.paid") {
+		t.Errorf("unexpected export: %s", out.String())
+	}
+
+	if err := runImport(context.Background(), env, []string{"-db", dbPath}); err == nil {
+		t.Error("expected error without files")
+	}
+}
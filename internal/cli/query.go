@@ -0,0 +1,102 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/epalmerini/rabbithole/internal/archive"
+	"github.com/epalmerini/rabbithole/internal/db"
+	"github.com/epalmerini/rabbithole/internal/proto"
+	"github.com/epalmerini/rabbithole/internal/query"
+)
+
+// queryPageSize is the number of matching messages read per store query.
+const queryPageSize = 500
+
+func runQuery(ctx context.Context, env Env, args []string) error {
+	fs := flag.NewFlagSet("query", flag.ContinueOnError)
+	fs.SetOutput(env.Stderr)
+
+	var conn connOptions
+	conn.registerStore(fs)
+	session := fs.Int64("session", 0, "Only search this session (default: all)")
+	format := fs.String("format", archive.FormatNDJSON, "Output format: "+strings.Join(archive.Formats, ", "))
+	columns := fs.String("columns", strings.Join(archive.DefaultColumns, ","), "Comma-separated CSV columns: "+strings.Join(archive.Columns, ", "))
+	limit := fs.Int("limit", 0, "Stop after this many messages (0 = no limit)")
+	fs.Usage = func() {
+		_, _ = fmt.Fprintln(fs.Output(), "Usage: rabbithole query [flags] EXPRESSION")
+		_, _ = fmt.Fprintln(fs.Output(), "\nPrint stored messages matching a query, oldest first, e.g.")
+		_, _ = fmt.Fprintln(fs.Output(), "  rabbithole query 'body.order.total > 100 and hdr.x-tenant = \"acme\" and ts > -1d'")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return errors.New("expected one query expression")
+	}
+
+	q, err := query.Parse(fs.Arg(0))
+	if err != nil {
+		return fmt.Errorf("invalid query: %w", err)
+	}
+
+	cfg, err := conn.resolve(env)
+	if err != nil {
+		return err
+	}
+	dec, err := decoder(cfg)
+	if err != nil {
+		return err
+	}
+
+	store, err := db.NewStore(cfg.DBPath)
+	if err != nil {
+		return fmt.Errorf("failed to initialize database: %w", err)
+	}
+	defer func() { _ = store.Close() }()
+
+	w, err := archive.NewWriter(env.Stdout, *format, splitColumns(*columns))
+	if err != nil {
+		return err
+	}
+	where, whereArgs := q.SQL(time.Now())
+	n, err := writeQuery(ctx, store, where, whereArgs, *session, *limit, dec, w)
+	if err = errors.Join(err, w.Close()); err != nil {
+		return err
+	}
+	_, _ = fmt.Fprintf(env.Stderr, "%d matching messages\n", n)
+	return nil
+}
+
+// writeQuery pages through the messages matching where and writes them to w.
+func writeQuery(ctx context.Context, store db.Store, where string, args []any, sessionID int64, limit int, dec *proto.Decoder, w archive.Writer) (int, error) {
+	written := 0
+	for offset := int64(0); ; offset += queryPageSize {
+		msgs, err := store.QueryMessages(ctx, where, args, sessionID, queryPageSize, offset)
+		if err != nil {
+			return written, fmt.Errorf("query failed: %w", err)
+		}
+		records, err := archive.FromDBPage(ctx, store, msgs, dec)
+		if err != nil {
+			return written, err
+		}
+		for _, r := range records {
+			if limit > 0 && written >= limit {
+				return written, nil
+			}
+			if err := w.Write(r); err != nil {
+				return written, err
+			}
+			written++
+		}
+		if len(msgs) < queryPageSize {
+			return written, nil
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRunQuery(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "test.db")
+	seedSession(t, dbPath)
+	seedSession(t, dbPath)
+
+	var stdout, stderr bytes.Buffer
+	env := Env{Stdout: &stdout, Stderr: &stderr}
+	args := []string{"-db", dbPath, "-format", "csv", "-columns", "routing_key", "rk = order.created"}
+	if err := runQuery(context.Background(), env, args); err != nil {
+		t.Fatalf("runQuery: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(stdout.String()), "\n")
+	if len(lines) != 5 { // header + 2 per session
+		t.Fatalf("expected header and 4 rows, got:\n%s", stdout.String())
+	}
+	for _, l := range lines[1:] {
+		if l != "order.created" {
+			t.Errorf("unexpected row %q", l)
+		}
+	}
+	if !strings.Contains(stderr.String(), "4 matching messages") {
+		t.Errorf("unexpected summary: %s", stderr.String())
+	}
+
+	stdout.Reset()
+	if err := runQuery(context.Background(), env, []string{"-db", dbPath, "-session", "1", "-limit", "1", "rk ~ order"}); err != nil {
+		t.Fatalf("runQuery with limit: %v", err)
+	}
+	if n := strings.Count(stdout.String(), "\n"); n != 1 {
+		t.Errorf("expected 1 NDJSON line, got %d", n)
+	}
+}
+
+func TestRunQuery_Errors(t *testing.T) {
+	env := Env{Stdout: &bytes.Buffer{}, Stderr: &bytes.Buffer{}}
+	dbPath := filepath.Join(t.TempDir(), "test.db")
+	for _, args := range [][]string{
+		{"-db", dbPath},
+		{"-db", dbPath, "body.total >"},
+		{"-db", dbPath, "-format", "xml", "rk = a"},
+	} {
+		if err := runQuery(context.Background(), env, args); err == nil {
+			t.Errorf("runQuery(%v): expected error", args)
+		}
+	}
+}
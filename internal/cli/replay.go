@@ -0,0 +1,260 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/epalmerini/rabbithole/internal/archive"
+	"github.com/epalmerini/rabbithole/internal/db"
+	"github.com/epalmerini/rabbithole/internal/filter"
+	"github.com/epalmerini/rabbithole/internal/rabbitmq"
+)
+
+// rewriteRule rewrites routing keys matching pattern to replacement,
+// which may reference capture groups ($1).
+type rewriteRule struct {
+	pattern     *regexp.Regexp
+	replacement string
+}
+
+// rewriteFlag collects repeated -rewrite PATTERN=REPLACEMENT flags.
+type rewriteFlag []rewriteRule
+
+func (f *rewriteFlag) String() string {
+	var parts []string
+	for _, r := range *f {
+		parts = append(parts, r.pattern.String()+"="+r.replacement)
+	}
+	return strings.Join(parts, ", ")
+}
+
+func (f *rewriteFlag) Set(s string) error {
+	pattern, replacement, ok := strings.Cut(s, "=")
+	if !ok || pattern == "" {
+		return errors.New("expected PATTERN=REPLACEMENT")
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return err
+	}
+	*f = append(*f, rewriteRule{pattern: re, replacement: replacement})
+	return nil
+}
+
+// rewrite applies the first rule whose pattern matches key.
+func (f rewriteFlag) rewrite(key string) string {
+	for _, r := range f {
+		if r.pattern.MatchString(key) {
+			return r.pattern.ReplaceAllString(key, r.replacement)
+		}
+	}
+	return key
+}
+
+// parseTiming parses -timing: "fast" (0, no delays), "original" (1) or a
+// speed multiplier such as "2x" or "0.5x".
+func parseTiming(s string) (float64, error) {
+	switch s {
+	case "fast":
+		return 0, nil
+	case "original":
+		return 1, nil
+	}
+	speed, err := strconv.ParseFloat(strings.TrimSuffix(s, "x"), 64)
+	if err != nil || speed <= 0 || !strings.HasSuffix(s, "x") {
+		return 0, fmt.Errorf("invalid timing %q (use fast, original or a multiplier like 2x)", s)
+	}
+	return speed, nil
+}
+
+// replayer publishes stored records in order, reproducing the gaps between
+// their arrival times divided by speed. Publisher timestamps are not used:
+// they have one-second resolution and come from the publishers' clocks. It
+// implements archive.Writer so it can consume a session page by page.
+type replayer struct {
+	ctx      context.Context
+	exchange string // overrides each record's exchange when set
+	speed    float64
+	rewrites rewriteFlag
+	publish  func(ctx context.Context, exchange string, msg rabbitmq.Delivery) error
+	sleep    func(ctx context.Context, d time.Duration) error
+
+	// dryRun, when set, receives a line per message instead of publishing
+	dryRun io.Writer
+
+	first, last time.Time
+	published   int
+}
+
+func (r *replayer) Write(rec archive.Record) error {
+	arrived := rec.Arrival()
+	if r.published == 0 {
+		r.first = arrived
+	} else if r.speed > 0 && r.dryRun == nil {
+		if gap := arrived.Sub(r.last); gap > 0 {
+			if err := r.sleep(r.ctx, time.Duration(float64(gap)/r.speed)); err != nil {
+				return err
+			}
+		}
+	}
+	r.last = arrived
+
+	exchange := rec.Exchange
+	if r.exchange != "" {
+		exchange = r.exchange
+	}
+	msg := rabbitmq.Delivery{
+		RoutingKey:    r.rewrites.rewrite(rec.RoutingKey),
+		Exchange:      exchange,
+		Timestamp:     rec.Timestamp,
+		Body:          rec.RawBody,
+		Headers:       rec.Headers,
+		ContentType:   rec.ContentType,
+		CorrelationID: rec.CorrelationID,
+		ReplyTo:       rec.ReplyTo,
+		MessageID:     rec.MessageID,
+		AppID:         rec.AppID,
+		DeliveryMode:  rec.DeliveryMode,
+		Priority:      rec.Priority,
+		Expiration:    rec.Expiration,
+	}
+
+	if r.dryRun != nil {
+		offset := time.Duration(0)
+		if r.speed > 0 {
+			offset = time.Duration(float64(arrived.Sub(r.first)) / r.speed)
+		}
+		_, err := fmt.Fprintf(r.dryRun, "+%-10s %-24s %-40s %d bytes\n", offset.Round(time.Millisecond), exchangeName(exchange), msg.RoutingKey, len(msg.Body))
+		r.published++
+		return err
+	}
+
+	if err := r.publish(r.ctx, exchange, msg); err != nil {
+		return err
+	}
+	r.published++
+	return nil
+}
+
+func (r *replayer) Close() error { return nil }
+
+func exchangeName(name string) string {
+	if name == "" {
+		return "(default)"
+	}
+	return name
+}
+
+// sleepContext waits for d or until ctx is done.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+func runReplay(ctx context.Context, env Env, args []string) error {
+	fs := flag.NewFlagSet("replay", flag.ContinueOnError)
+	fs.SetOutput(env.Stderr)
+
+	var conn connOptions
+	conn.register(fs)
+	session := fs.Int64("session", 0, "ID of the session to replay (required)")
+	exchange := fs.String("exchange", "", "Publish to this exchange instead of each message's original one")
+	timing := fs.String("timing", "fast", "fast, original, or a speed multiplier (e.g. 2x, 0.5x); gaps follow when messages arrived at capture")
+	filterExpr := fs.String("filter", "", "Only replay messages matching a query (body.total > 100) or search (rk:, body:, ex:, hdr:, type:, re:)")
+	dryRun := fs.Bool("dry-run", false, "Print what would be published without connecting")
+	force := fs.Bool("force", false, "Allow publishing to a profile marked production")
+	var rewrites rewriteFlag
+	fs.Var(&rewrites, "rewrite", "Rewrite routing keys, as REGEXP=REPLACEMENT (repeatable, first match wins)")
+	fs.Usage = func() {
+		_, _ = fmt.Fprintln(fs.Output(), "Usage: rabbithole replay -session ID [flags]")
+		_, _ = fmt.Fprintln(fs.Output(), "\nPublish the messages of a stored session to a broker, in order, with their")
+		_, _ = fmt.Fprintln(fs.Output(), "headers and properties, including delivery mode, priority and expiration.")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *session <= 0 {
+		fs.Usage()
+		return errors.New("-session is required")
+	}
+	speed, err := parseTiming(*timing)
+	if err != nil {
+		return err
+	}
+
+	var keep func(archive.Record) bool
+	if *filterExpr != "" {
+		f, err := filter.New(*filterExpr)
+		if err != nil {
+			return fmt.Errorf("invalid filter: %w", err)
+		}
+		keep = f.MatchRecord
+	}
+
+	cfg, err := conn.resolve(env)
+	if err != nil {
+		return err
+	}
+	if cfg.Production && !*dryRun && !*force {
+		return fmt.Errorf("profile %q is marked production; pass -force to publish to it", conn.profile)
+	}
+
+	store, err := db.NewStore(cfg.DBPath)
+	if err != nil {
+		return fmt.Errorf("failed to initialize database: %w", err)
+	}
+	defer func() { _ = store.Close() }()
+
+	count, err := store.CountMessagesBySession(ctx, *session)
+	if err != nil {
+		return fmt.Errorf("failed to count messages: %w", err)
+	}
+	if count == 0 {
+		return fmt.Errorf("session %d has no messages", *session)
+	}
+
+	r := &replayer{
+		ctx:      ctx,
+		exchange: *exchange,
+		speed:    speed,
+		rewrites: rewrites,
+		sleep:    sleepContext,
+	}
+	if *dryRun {
+		r.dryRun = env.Stdout
+	} else {
+		publisher, err := rabbitmq.NewPublisher(cfg.RabbitMQURL)
+		if err != nil {
+			return err
+		}
+		defer func() { _ = publisher.Close() }()
+		r.publish = publisher.Publish
+		_, _ = fmt.Fprintf(env.Stderr, "Replaying %d messages from session %d (timing: %s). Press Ctrl+C to stop.\n", count, *session, *timing)
+	}
+
+	start := time.Now()
+	n, err := archive.ExportSession(ctx, store, *session, nil, keep, r)
+	verb := "Published"
+	if *dryRun {
+		verb = "Would publish"
+	}
+	_, _ = fmt.Fprintf(env.Stderr, "%s %d of %d messages in %s\n", verb, n, count, time.Since(start).Round(time.Millisecond))
+	if errors.Is(err, context.Canceled) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,266 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/epalmerini/rabbithole/internal/archive"
+	"github.com/epalmerini/rabbithole/internal/config"
+	"github.com/epalmerini/rabbithole/internal/db"
+	"github.com/epalmerini/rabbithole/internal/rabbitmq"
+)
+
+func TestParseTiming(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    float64
+		wantErr bool
+	}{
+		{"fast", 0, false},
+		{"original", 1, false},
+		{"2x", 2, false},
+		{"0.5x", 0.5, false},
+		{"2", 0, true},
+		{"0x", 0, true},
+		{"slow", 0, true},
+	}
+	for _, tt := range tests {
+		got, err := parseTiming(tt.in)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("parseTiming(%q) = %v, %v; want %v, err=%v", tt.in, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestRewriteFlag(t *testing.T) {
+	var f rewriteFlag
+	for _, rule := range []string{`^orders\.(.*)$=replay.orders.$1`, `^order=never`} {
+		if err := f.Set(rule); err != nil {
+			t.Fatalf("Set(%q): %v", rule, err)
+		}
+	}
+	tests := map[string]string{
+		"orders.created": "replay.orders.created", // first match wins
+		"payments.done":  "payments.done",
+	}
+	for in, want := range tests {
+		if got := f.rewrite(in); got != want {
+			t.Errorf("rewrite(%q) = %q, want %q", in, got, want)
+		}
+	}
+	if err := f.Set("no-separator"); err == nil {
+		t.Error("expected error without =")
+	}
+	if err := f.Set("([=x"); err == nil {
+		t.Error("expected error for invalid regexp")
+	}
+}
+
+func replayRecords() []archive.Record {
+	base := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	return []archive.Record{
+		{Exchange: "orders", RoutingKey: "order.created", Timestamp: base, RawBody: []byte("a"), Headers: map[string]any{"h": "v"}},
+		{Exchange: "orders", RoutingKey: "order.paid", Timestamp: base.Add(2 * time.Second), RawBody: []byte("b")},
+		{Exchange: "orders", RoutingKey: "order.shipped", Timestamp: base.Add(3 * time.Second), RawBody: []byte("c")},
+	}
+}
+
+func TestReplayer_Timing(t *testing.T) {
+	tests := []struct {
+		name  string
+		speed float64
+		want  []time.Duration
+	}{
+		{"fast", 0, nil},
+		{"original", 1, []time.Duration{2 * time.Second, time.Second}},
+		{"double speed", 2, []time.Duration{time.Second, 500 * time.Millisecond}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var slept []time.Duration
+			var published []rabbitmq.Delivery
+			r := &replayer{
+				ctx:   context.Background(),
+				speed: tt.speed,
+				sleep: func(_ context.Context, d time.Duration) error {
+					slept = append(slept, d)
+					return nil
+				},
+				publish: func(_ context.Context, _ string, msg rabbitmq.Delivery) error {
+					published = append(published, msg)
+					return nil
+				},
+			}
+			for _, rec := range replayRecords() {
+				if err := r.Write(rec); err != nil {
+					t.Fatalf("Write: %v", err)
+				}
+			}
+			if len(published) != 3 {
+				t.Fatalf("published %d, want 3", len(published))
+			}
+			if len(slept) != len(tt.want) {
+				t.Fatalf("slept %v, want %v", slept, tt.want)
+			}
+			for i := range tt.want {
+				if slept[i] != tt.want[i] {
+					t.Errorf("sleep %d = %v, want %v", i, slept[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestReplayer_PreservesMessageAndRewrites(t *testing.T) {
+	var rewrites rewriteFlag
+	_ = rewrites.Set(`^order\.=replay.order.`)
+
+	var gotExchange string
+	var got rabbitmq.Delivery
+	r := &replayer{
+		ctx:      context.Background(),
+		exchange: "local-orders",
+		rewrites: rewrites,
+		publish: func(_ context.Context, exchange string, msg rabbitmq.Delivery) error {
+			gotExchange, got = exchange, msg
+			return nil
+		},
+	}
+	rec := replayRecords()[0]
+	rec.DeliveryMode, rec.Priority, rec.Expiration = 2, 5, "60000"
+	if err := r.Write(rec); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if gotExchange != "local-orders" || got.RoutingKey != "replay.order.created" || string(got.Body) != "a" || got.Headers["h"] != "v" {
+		t.Errorf("unexpected publish to %q: %+v", gotExchange, got)
+	}
+	if got.DeliveryMode != 2 || got.Priority != 5 || got.Expiration != "60000" {
+		t.Errorf("delivery mode, priority, expiration = %d, %d, %q", got.DeliveryMode, got.Priority, got.Expiration)
+	}
+}
+
+func TestReplayer_KeepsMissingTimestampUnset(t *testing.T) {
+	store, err := db.NewStore(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = store.Close() }()
+	ctx := context.Background()
+	sid, err := store.CreateSession(ctx, "orders", "#", "q", "amqp://localhost/")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The consumer fills in the arrival time when the publisher set none
+	arrived := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	published := arrived.Add(-time.Second)
+	for _, d := range []rabbitmq.Delivery{
+		{RoutingKey: "order.created", Timestamp: arrived, ConsumedAt: arrived, Body: []byte("a")},
+		{RoutingKey: "order.paid", Timestamp: published, Published: true, ConsumedAt: arrived, Body: []byte("b")},
+	} {
+		rec := deliveryRecord(d, nil)
+		rec.SessionID = sid
+		if _, err := store.InsertMessage(ctx, rec); err != nil {
+			t.Fatal(err)
+		}
+	}
+	msgs, err := store.ListMessagesBySessionAsc(ctx, sid, 10, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []rabbitmq.Delivery
+	r := &replayer{
+		ctx: context.Background(),
+		publish: func(_ context.Context, _ string, msg rabbitmq.Delivery) error {
+			got = append(got, msg)
+			return nil
+		},
+	}
+	for _, m := range msgs {
+		if err := r.Write(archive.FromDB(m, nil)); err != nil {
+			t.Fatalf("Write: %v", err)
+		}
+	}
+	if len(got) != 2 || !got[0].Timestamp.IsZero() || !got[1].Timestamp.Equal(published) {
+		t.Errorf("published timestamps = %v, want none and %v", got, published)
+	}
+}
+
+func TestReplayer_TimesArrivals(t *testing.T) {
+	// Publisher timestamps have one-second resolution and may go backwards;
+	// the gaps come from when the messages arrived
+	base := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	recs := []archive.Record{
+		{Timestamp: base, ConsumedAt: base.Add(100 * time.Millisecond)},
+		{Timestamp: base, ConsumedAt: base.Add(350 * time.Millisecond)},
+		{Timestamp: base.Add(-time.Second), ConsumedAt: base.Add(1350 * time.Millisecond)},
+	}
+	var slept []time.Duration
+	r := &replayer{
+		ctx:   context.Background(),
+		speed: 1,
+		sleep: func(_ context.Context, d time.Duration) error {
+			slept = append(slept, d)
+			return nil
+		},
+		publish: func(context.Context, string, rabbitmq.Delivery) error { return nil },
+	}
+	for _, rec := range recs {
+		if err := r.Write(rec); err != nil {
+			t.Fatalf("Write: %v", err)
+		}
+	}
+	if want := []time.Duration{250 * time.Millisecond, time.Second}; !slices.Equal(slept, want) {
+		t.Errorf("slept %v, want %v", slept, want)
+	}
+}
+
+func TestReplayer_DryRun(t *testing.T) {
+	var out bytes.Buffer
+	r := &replayer{
+		ctx:    context.Background(),
+		speed:  1,
+		dryRun: &out,
+		sleep: func(context.Context, time.Duration) error {
+			t.Fatal("dry run must not sleep")
+			return nil
+		},
+	}
+	for _, rec := range replayRecords() {
+		if err := r.Write(rec); err != nil {
+			t.Fatalf("Write: %v", err)
+		}
+	}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 3 || !strings.HasPrefix(lines[1], "+2s") || !strings.Contains(lines[2], "order.shipped") {
+		t.Errorf("unexpected dry run output:\n%s", out.String())
+	}
+}
+
+func TestRunReplay_DryRunAndProductionGuard(t *testing.T) {
+	dbPath := t.TempDir() + "/test.db"
+	sid := seedSession(t, dbPath)
+	session := strconv.FormatInt(sid, 10)
+
+	var stdout bytes.Buffer
+	env := Env{Stdout: &stdout, Stderr: &bytes.Buffer{}}
+	if err := runReplay(context.Background(), env, []string{"-db", dbPath, "-session", session, "-dry-run", "-filter", "rk:paid"}); err != nil {
+		t.Fatalf("runReplay: %v", err)
+	}
+	if !strings.Contains(stdout.String(), "order.paid") || strings.Contains(stdout.String(), "order.created") {
+		t.Errorf("unexpected dry run output: %s", stdout.String())
+	}
+
+	env.FileConfig = &config.FileConfig{Profiles: map[string]config.Profile{"prod": {Production: true}}}
+	err := runReplay(context.Background(), env, []string{"-profile", "prod", "-db", dbPath, "-session", session})
+	if err == nil || !strings.Contains(err.Error(), "production") {
+		t.Errorf("expected production guard error, got %v", err)
+	}
+}
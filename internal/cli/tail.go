@@ -0,0 +1,162 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"text/template"
+
+	"github.com/epalmerini/rabbithole/internal/archive"
+	"github.com/epalmerini/rabbithole/internal/filter"
+	"github.com/epalmerini/rabbithole/internal/proto"
+	"github.com/epalmerini/rabbithole/internal/rabbitmq"
+)
+
+// templateFuncs are available to -format templates.
+var templateFuncs = template.FuncMap{
+	"json": func(v any) (string, error) {
+		data, err := json.Marshal(v)
+		return string(data), err
+	},
+	"text": func(b []byte) string { return string(b) },
+}
+
+// tailer writes consumed messages to out, one per line.
+type tailer struct {
+	dec    *proto.Decoder
+	filter *filter.Filter
+	tmpl   *template.Template
+	out    io.Writer
+	enc    *json.Encoder
+}
+
+func newTailer(out io.Writer, dec *proto.Decoder, filterExpr, format string) (*tailer, error) {
+	t := &tailer{dec: dec, out: out, enc: json.NewEncoder(out)}
+	t.enc.SetEscapeHTML(false)
+
+	if filterExpr != "" {
+		f, err := filter.New(filterExpr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid filter: %w", err)
+		}
+		t.filter = f
+	}
+	if format != "" {
+		tmpl, err := template.New("format").Funcs(templateFuncs).Parse(format)
+		if err != nil {
+			return nil, fmt.Errorf("invalid format: %w", err)
+		}
+		t.tmpl = tmpl
+	}
+	return t, nil
+}
+
+// write decodes d and writes it if it passes the filter, reporting whether it did.
+func (t *tailer) write(d rabbitmq.Delivery) (bool, error) {
+	rec := archive.Record{
+		Timestamp:     d.PublisherTimestamp(),
+		ConsumedAt:    d.ConsumedAt,
+		Exchange:      d.Exchange,
+		RoutingKey:    d.RoutingKey,
+		ContentType:   d.ContentType,
+		CorrelationID: d.CorrelationID,
+		ReplyTo:       d.ReplyTo,
+		MessageID:     d.MessageID,
+		AppID:         d.AppID,
+		DeliveryMode:  d.DeliveryMode,
+		Priority:      d.Priority,
+		Expiration:    d.Expiration,
+		Headers:       d.Headers,
+	}
+
+	var decoded map[string]any
+	if t.dec != nil {
+		var err error
+		decoded, rec.ProtoType, err = t.dec.DecodeWithHintAndType(d.Body, d.RoutingKey)
+		if err != nil {
+			rec.DecodeError = err.Error()
+		}
+	}
+	rec.SetBody(d.Body, decoded)
+
+	if t.filter != nil && !t.filter.MatchRecord(rec) {
+		return false, nil
+	}
+
+	if t.tmpl != nil {
+		if err := t.tmpl.Execute(t.out, rec); err != nil {
+			return false, err
+		}
+		_, err := io.WriteString(t.out, "\n")
+		return true, err
+	}
+	return true, t.enc.Encode(rec)
+}
+
+func runTail(ctx context.Context, env Env, args []string) error {
+	fs := flag.NewFlagSet("tail", flag.ContinueOnError)
+	fs.SetOutput(env.Stderr)
+
+	var conn connOptions
+	conn.register(fs)
+	var target consumeOptions
+	target.register(fs)
+	filterExpr := fs.String("filter", "", "Only print messages matching a query (body.total > 100) or search (rk:, body:, ex:, hdr:, type:, re:)")
+	format := fs.String("format", "", "Go template for each line instead of JSON (e.g. '{{.RoutingKey}} {{json .Body}}')")
+	count := fs.Int("count", 0, "Exit after printing N messages (0 = unlimited)")
+	fs.Usage = func() {
+		_, _ = fmt.Fprintln(fs.Output(), "Usage: rabbithole tail -exchange NAME [flags]")
+		_, _ = fmt.Fprintln(fs.Output(), "\nStream consumed messages to stdout as newline-delimited JSON.")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := target.validate(); err != nil {
+		fs.Usage()
+		return err
+	}
+
+	cfg, err := conn.resolve(env)
+	if err != nil {
+		return err
+	}
+	dec, err := decoder(cfg)
+	if err != nil {
+		return err
+	}
+	t, err := newTailer(env.Stdout, dec, *filterExpr, *format)
+	if err != nil {
+		return err
+	}
+
+	consumer, deliveries, err := target.start(ctx, cfg.RabbitMQURL)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = consumer.Close() }()
+
+	printed := 0
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case d, ok := <-deliveries:
+			if !ok {
+				return fmt.Errorf("connection closed")
+			}
+			matched, err := t.write(d)
+			if err != nil {
+				return err
+			}
+			if matched {
+				printed++
+				if *count > 0 && printed >= *count {
+					return nil
+				}
+			}
+		}
+	}
+}
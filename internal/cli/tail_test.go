@@ -0,0 +1,107 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/epalmerini/rabbithole/internal/rabbitmq"
+)
+
+func TestTailer_WritesNDJSON(t *testing.T) {
+	var out bytes.Buffer
+	tl, err := newTailer(&out, nil, "", "")
+	if err != nil {
+		t.Fatalf("newTailer: %v", err)
+	}
+
+	d := rabbitmq.Delivery{
+		Exchange:   "orders",
+		RoutingKey: "order.created",
+		Headers:    map[string]any{"tenant": "acme"},
+		Body:       []byte(`{"id":1}`),
+	}
+	if ok, err := tl.write(d); err != nil || !ok {
+		t.Fatalf("write() = %v, %v", ok, err)
+	}
+
+	line := out.String()
+	if strings.Count(line, "\n") != 1 {
+		t.Fatalf("expected one line, got %q", line)
+	}
+	var got map[string]any
+	if err := json.Unmarshal([]byte(line), &got); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if got["routing_key"] != "order.created" || got["exchange"] != "orders" {
+		t.Errorf("unexpected record: %v", got)
+	}
+	if body, ok := got["body"].(map[string]any); !ok || body["id"] != float64(1) {
+		t.Errorf("body = %v, want JSON object", got["body"])
+	}
+	if got["raw_body"] != "eyJpZCI6MX0=" {
+		t.Errorf("raw_body = %v, want base64", got["raw_body"])
+	}
+}
+
+func TestTailer_Filter(t *testing.T) {
+	tests := []struct {
+		name   string
+		filter string
+		want   bool
+	}{
+		{"routing key match", "rk:order", true},
+		{"routing key miss", "rk:payment", false},
+		{"exchange", "ex:orders", true},
+		{"header", "hdr:acme", true},
+		{"body needs decoded proto", "body:id", false},
+	}
+
+	d := rabbitmq.Delivery{
+		Exchange:   "orders",
+		RoutingKey: "order.created",
+		Headers:    map[string]any{"tenant": "acme"},
+		Body:       []byte(`{"id":1}`),
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			tl, err := newTailer(&out, nil, tt.filter, "")
+			if err != nil {
+				t.Fatalf("newTailer: %v", err)
+			}
+			ok, err := tl.write(d)
+			if err != nil {
+				t.Fatalf("write: %v", err)
+			}
+			if ok != tt.want || (out.Len() > 0) != tt.want {
+				t.Errorf("matched = %v (output %q), want %v", ok, out.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestTailer_Format(t *testing.T) {
+	var out bytes.Buffer
+	tl, err := newTailer(&out, nil, "", `{{.RoutingKey}} {{text .RawBody}} {{json .Headers}}`)
+	if err != nil {
+		t.Fatalf("newTailer: %v", err)
+	}
+	d := rabbitmq.Delivery{RoutingKey: "a.b", Body: []byte("hello"), Headers: map[string]any{"k": "v"}}
+	if _, err := tl.write(d); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	if want := "a.b hello {\"k\":\"v\"}\n"; out.String() != want {
+		t.Errorf("output = %q, want %q", out.String(), want)
+	}
+}
+
+func TestNewTailer_InvalidOptions(t *testing.T) {
+	if _, err := newTailer(&bytes.Buffer{}, nil, "re:[", ""); err == nil {
+		t.Error("expected error for invalid filter regex")
+	}
+	if _, err := newTailer(&bytes.Buffer{}, nil, "", "{{.Nope"); err == nil {
+		t.Error("expected error for invalid template")
+	}
+}
@@ -0,0 +1,14 @@
+package config
+
+// AlertRule runs actions on consumed messages matching a filter.
+type AlertRule struct {
+	Name      string `toml:"name"`
+	Filter    string `toml:"filter"`              // query or search expression, as in the consumer's filter
+	Highlight string `toml:"highlight,omitempty"` // row colour: red, yellow, ..., "#rrggbb" or an ANSI number
+	Bell      bool   `toml:"bell,omitempty"`      // ring the terminal bell
+	Notify    bool   `toml:"notify,omitempty"`    // desktop notification through the terminal (OSC 9 and 777)
+	Pause     bool   `toml:"pause,omitempty"`     // pause the consumer view
+	Bookmark  bool   `toml:"bookmark,omitempty"`  // bookmark the message
+	Command   string `toml:"command,omitempty"`   // shell command, run with the message as JSON on stdin
+	Cooldown  string `toml:"cooldown,omitempty"`  // minimum time between bells, notifications and commands, e.g. "30s"
+}
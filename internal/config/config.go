@@ -19,17 +19,47 @@ const (
 
 // FileConfig is the TOML file structure.
 type FileConfig struct {
-	Proto       string             `toml:"proto"`
-	MaxMessages int                `toml:"max_messages"`
-	DBPath      string             `toml:"db"`
-	UI          UIConfig           `toml:"ui"`
-	Profiles    map[string]Profile `toml:"profiles"`
+	Proto         string                 `toml:"proto"`
+	MaxMessages   int                    `toml:"max_messages"`
+	Pause         PauseConfig            `toml:"pause,omitempty"`
+	DBPath        string                 `toml:"db"`
+	TraceHeader   string                 `toml:"trace_header,omitempty"`
+	DiffKey       string                 `toml:"diff_key,omitempty"`
+	LatencyHeader string                 `toml:"latency_header,omitempty"`
+	Alerts        []AlertRule            `toml:"alerts,omitempty"`
+	Filters       []SavedFilter          `toml:"filters,omitempty"`
+	Keys          Keys                   `toml:"keys,omitempty"`
+	UI            UIConfig               `toml:"ui"`
+	Themes        map[string]ThemeColors `toml:"themes,omitempty"`
+	Retention     RetentionConfig        `toml:"retention,omitempty"`
+	Profiles      map[string]Profile     `toml:"profiles"`
+}
+
+// PauseConfig bounds the messages buffered while the consumer is paused.
+type PauseConfig struct {
+	// Buffer caps the buffered messages; max_messages when unset.
+	Buffer int `toml:"buffer,omitempty"`
+	// Overflow is what a full buffer does: "drop_oldest" (default),
+	// "drop_newest" or "resume".
+	Overflow string `toml:"overflow,omitempty"`
 }
 
 // UIConfig holds UI-related settings.
 type UIConfig struct {
-	SplitRatio  float64 `toml:"split_ratio"`
-	CompactMode bool    `toml:"compact_mode"`
+	SplitRatio   float64 `toml:"split_ratio"`
+	CompactMode  bool    `toml:"compact_mode"`
+	ChartMinutes int     `toml:"chart_minutes,omitempty"` // span of the consumer charts
+
+	// Theme is a built-in theme (dark, light or high-contrast) or one of
+	// [themes]; dark when unset.
+	Theme string `toml:"theme,omitempty"`
+
+	// Columns are the message list's fields, in query syntax; time and
+	// routing key when unset.
+	Columns []string `toml:"columns,omitempty"`
+
+	// Split orients the consumer view: SplitVertical or SplitHorizontal.
+	Split string `toml:"split,omitempty"`
 }
 
 // Profile is a named connection profile.
@@ -37,6 +67,28 @@ type Profile struct {
 	URL           string `toml:"url"`
 	ManagementURL string `toml:"management_url"`
 	Proto         string `toml:"proto"`
+
+	// Production requires typed confirmation for destructive bulk operations.
+	Production bool `toml:"production"`
+
+	// TraceHeader overrides the global header that links traced messages.
+	TraceHeader string `toml:"trace_header,omitempty"`
+
+	// DiffKey overrides the global field identifying the entity a message is about.
+	DiffKey string `toml:"diff_key,omitempty"`
+
+	// LatencyHeader overrides the global header carrying the publish time.
+	LatencyHeader string `toml:"latency_header,omitempty"`
+
+	// Alerts apply to this profile on top of the global ones.
+	Alerts []AlertRule `toml:"alerts,omitempty"`
+
+	// Filters are offered on top of the global ones, replacing those of the
+	// same name.
+	Filters []SavedFilter `toml:"filters,omitempty"`
+
+	// Retention overrides the global limits for sessions recorded from this profile.
+	Retention RetentionConfig `toml:"retention,omitempty"`
 }
 
 // Config is the resolved runtime config after profile selection.
@@ -46,10 +98,22 @@ type Config struct {
 	ProtoPath     string
 	DBPath        string
 	MaxMessages   int
+	PauseBuffer   int
+	PauseOverflow string
+	Production    bool
+	TraceHeader   string
+	DiffKey       string
+	LatencyHeader string
+	Alerts        []AlertRule
+	Filters       []SavedFilter
+	Keys          Keys
 
 	// UI
 	DefaultSplitRatio float64
 	CompactMode       bool
+	ChartMinutes      int
+	Columns           []string
+	Split             string
 
 	// Runtime (set by browser on consume)
 	Exchange   string
@@ -92,9 +156,15 @@ func LoadFileConfig(configDir string) (*FileConfig, error) {
 // If profileName is empty or not found, only global/env settings are used.
 func (fc FileConfig) Resolve(profileName string, configDir string) Config {
 	cfg := Config{
-		ProtoPath: fc.Proto,
-		DBPath:    fc.DBPath,
-		ConfigDir: configDir,
+		ProtoPath:     fc.Proto,
+		DBPath:        fc.DBPath,
+		TraceHeader:   fc.TraceHeader,
+		DiffKey:       fc.DiffKey,
+		LatencyHeader: fc.LatencyHeader,
+		Alerts:        fc.Alerts,
+		Filters:       fc.Filters,
+		Keys:          fc.Keys,
+		ConfigDir:     configDir,
 	}
 
 	// Max messages
@@ -102,6 +172,8 @@ func (fc FileConfig) Resolve(profileName string, configDir string) Config {
 	if cfg.MaxMessages <= 0 {
 		cfg.MaxMessages = defaultMaxMessages
 	}
+	cfg.PauseBuffer = fc.Pause.Buffer
+	cfg.PauseOverflow = fc.Pause.Overflow
 
 	// UI defaults
 	cfg.DefaultSplitRatio = fc.UI.SplitRatio
@@ -109,14 +181,33 @@ func (fc FileConfig) Resolve(profileName string, configDir string) Config {
 		cfg.DefaultSplitRatio = defaultSplitRatio
 	}
 	cfg.CompactMode = fc.UI.CompactMode
+	cfg.ChartMinutes = fc.UI.ChartMinutes
+	cfg.Columns = fc.UI.Columns
+	cfg.Split = fc.UI.Split
 
 	// Apply profile overrides
 	if p, ok := fc.Profiles[profileName]; ok {
 		cfg.RabbitMQURL = p.URL
 		cfg.ManagementURL = p.ManagementURL
+		cfg.Production = p.Production
 		if p.Proto != "" {
 			cfg.ProtoPath = p.Proto
 		}
+		if p.TraceHeader != "" {
+			cfg.TraceHeader = p.TraceHeader
+		}
+		if p.DiffKey != "" {
+			cfg.DiffKey = p.DiffKey
+		}
+		if p.LatencyHeader != "" {
+			cfg.LatencyHeader = p.LatencyHeader
+		}
+		if len(p.Alerts) > 0 {
+			cfg.Alerts = append(append([]AlertRule{}, fc.Alerts...), p.Alerts...)
+		}
+		if len(p.Filters) > 0 {
+			cfg.Filters = mergeFilters(fc.Filters, p.Filters)
+		}
 	}
 
 	// Fall back to env vars for URL if not set by profile
@@ -3,7 +3,9 @@ package config
 import (
 	"os"
 	"path/filepath"
+	"slices"
 	"testing"
+	"time"
 )
 
 func TestLoadFileConfig_ValidTOML(t *testing.T) {
@@ -89,12 +91,22 @@ func TestResolve_WithProfile(t *testing.T) {
 	fc := FileConfig{
 		Proto:       "/global/protos",
 		MaxMessages: 500,
-		UI:          UIConfig{SplitRatio: 0.6},
+		TraceHeader: "x-trace-id",
+		DiffKey:     "body.id",
+		Alerts:      []AlertRule{{Name: "failures", Filter: "rk:failed", Bell: true}},
+		Filters:     []SavedFilter{{Name: "tenant x", Filter: "hdr:tenant-x"}, {Name: "failed", Filter: "type:OrderFailed"}},
+		UI:          UIConfig{SplitRatio: 0.6, ChartMinutes: 15},
 		Profiles: map[string]Profile{
 			"staging": {
 				URL:           "amqp://staging:5672/",
 				ManagementURL: "http://staging:15672/api",
 				Proto:         "/staging/protos",
+				Production:    true,
+				TraceHeader:   "x-request-id",
+				DiffKey:       "body.country.id",
+				LatencyHeader: "x-published-at",
+				Alerts:        []AlertRule{{Name: "refunds", Filter: "rk:refund", Pause: true}},
+				Filters:       []SavedFilter{{Name: "failed", Filter: "rk:failed"}, {Name: "eu", Filter: "hdr:eu"}},
 			},
 		},
 	}
@@ -112,6 +124,28 @@ func TestResolve_WithProfile(t *testing.T) {
 	if cfg.MaxMessages != 500 {
 		t.Errorf("MaxMessages = %d", cfg.MaxMessages)
 	}
+	if !cfg.Production {
+		t.Error("Production = false, want true from profile")
+	}
+	if cfg.TraceHeader != "x-request-id" {
+		t.Errorf("TraceHeader = %q, want x-request-id (profile override)", cfg.TraceHeader)
+	}
+	if cfg.DiffKey != "body.country.id" {
+		t.Errorf("DiffKey = %q, want body.country.id (profile override)", cfg.DiffKey)
+	}
+	if cfg.LatencyHeader != "x-published-at" {
+		t.Errorf("LatencyHeader = %q, want x-published-at (profile override)", cfg.LatencyHeader)
+	}
+	if cfg.ChartMinutes != 15 {
+		t.Errorf("ChartMinutes = %d, want 15", cfg.ChartMinutes)
+	}
+	if len(cfg.Alerts) != 2 || cfg.Alerts[0].Name != "failures" || cfg.Alerts[1].Name != "refunds" {
+		t.Errorf("Alerts = %+v, want the global rule then the profile's", cfg.Alerts)
+	}
+	wantFilters := []SavedFilter{{"tenant x", "hdr:tenant-x"}, {"failed", "rk:failed"}, {"eu", "hdr:eu"}}
+	if !slices.Equal(cfg.Filters, wantFilters) {
+		t.Errorf("Filters = %+v, want %+v (profile replaces by name)", cfg.Filters, wantFilters)
+	}
 }
 
 func TestResolve_ProfileProtoFallsBackToGlobal(t *testing.T) {
@@ -289,3 +323,183 @@ split_ratio = 0.8
 		t.Errorf("SplitRatio = %f, want 0.8 (existing should be preserved)", cfg.UI.SplitRatio)
 	}
 }
+
+func TestRetentionConfig_Parse(t *testing.T) {
+	r, err := RetentionConfig{MaxAge: "2w", MaxSize: "1.5GB", MaxSessions: 10}.Parse()
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if r.MaxAge != 14*24*time.Hour || r.MaxSize != 3<<29 || r.MaxSessions != 10 || !r.Enabled() {
+		t.Errorf("unexpected retention: %+v", r)
+	}
+
+	if r, _ := (RetentionConfig{}).Parse(); r.Enabled() {
+		t.Error("empty config should not enable retention")
+	}
+
+	for _, bad := range []RetentionConfig{{MaxAge: "forever"}, {MaxSize: "big"}, {MaxSessions: -1}, {MaxAge: "-3d"}} {
+		if _, err := bad.Parse(); err == nil {
+			t.Errorf("expected error for %+v", bad)
+		}
+	}
+}
+
+func TestParseAgeAndSize(t *testing.T) {
+	ages := map[string]time.Duration{"30d": 30 * 24 * time.Hour, "12h": 12 * time.Hour, "1w": 7 * 24 * time.Hour}
+	for in, want := range ages {
+		if got, err := ParseAge(in); err != nil || got != want {
+			t.Errorf("ParseAge(%q) = %v, %v; want %v", in, got, err, want)
+		}
+	}
+	sizes := map[string]int64{"500MB": 500 << 20, "2gb": 2 << 30, "10 KB": 10 << 10, "4096": 4096}
+	for in, want := range sizes {
+		if got, err := ParseSize(in); err != nil || got != want {
+			t.Errorf("ParseSize(%q) = %v, %v; want %v", in, got, err, want)
+		}
+	}
+}
+
+func TestLoadFileConfig_Retention(t *testing.T) {
+	dir := t.TempDir()
+	content := `
+[retention]
+max_age = "30d"
+max_size = "2GB"
+
+[profiles.prod]
+url = "amqp://prod:5672/"
+
+[profiles.prod.retention]
+max_age = "7d"
+`
+	if err := os.WriteFile(filepath.Join(dir, "config.toml"), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := LoadFileConfig(dir)
+	if err != nil {
+		t.Fatalf("LoadFileConfig: %v", err)
+	}
+	if cfg.Retention.MaxAge != "30d" || cfg.Retention.MaxSize != "2GB" || cfg.Profiles["prod"].Retention.MaxAge != "7d" {
+		t.Errorf("unexpected retention config: %+v / %+v", cfg.Retention, cfg.Profiles["prod"].Retention)
+	}
+}
+
+func TestLoadFileConfig_Pause(t *testing.T) {
+	dir := t.TempDir()
+	content := `
+[pause]
+buffer = 5000
+overflow = "resume"
+`
+	if err := os.WriteFile(filepath.Join(dir, "config.toml"), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	fc, err := LoadFileConfig(dir)
+	if err != nil {
+		t.Fatalf("LoadFileConfig: %v", err)
+	}
+	cfg := fc.Resolve("", dir)
+	if cfg.PauseBuffer != 5000 || cfg.PauseOverflow != "resume" {
+		t.Errorf("PauseBuffer = %d, PauseOverflow = %q", cfg.PauseBuffer, cfg.PauseOverflow)
+	}
+}
+
+func TestLoadFileConfig_Keys(t *testing.T) {
+	dir := t.TempDir()
+	toml := `
+[keys]
+yank_tab = "c"
+go_top = ["gg", "home"]
+yank = []
+
+[keys.browser]
+queues = "u"
+
+[keys.groups]
+close = "x"
+
+[keys.trace]
+jump = "o"
+`
+	if err := os.WriteFile(filepath.Join(dir, "config.toml"), []byte(toml), 0644); err != nil {
+		t.Fatal(err)
+	}
+	check := func(when string) {
+		t.Helper()
+		cfg, err := LoadFileConfig(dir)
+		if err != nil {
+			t.Fatalf("%s: %v", when, err)
+		}
+		keys := cfg.Keys
+		if !slices.Equal(keys.Consumer["yank_tab"], KeySequences{"c"}) || !slices.Equal(keys.Consumer["go_top"], KeySequences{"gg", "home"}) {
+			t.Errorf("%s: consumer keys = %v", when, keys.Consumer)
+		}
+		if seqs, ok := keys.Consumer["yank"]; !ok || len(seqs) != 0 {
+			t.Errorf("%s: yank = %v, %v; want unbound", when, seqs, ok)
+		}
+		if !slices.Equal(keys.Scope(KeysBrowser)["queues"], KeySequences{"u"}) || keys.Sessions != nil {
+			t.Errorf("%s: browser keys = %v, sessions = %v", when, keys.Browser, keys.Sessions)
+		}
+		if !slices.Equal(keys.Scope(KeysGroups)["close"], KeySequences{"x"}) || keys.Dashboard != nil {
+			t.Errorf("%s: groups keys = %v, dashboard = %v", when, keys.Groups, keys.Dashboard)
+		}
+		if !slices.Equal(keys.Scope(KeysTrace)["jump"], KeySequences{"o"}) || keys.Filters != nil {
+			t.Errorf("%s: trace keys = %v, filters = %v", when, keys.Trace, keys.Filters)
+		}
+	}
+	check("load")
+
+	// Saving the split ratio keeps the bindings
+	if err := SaveSplitRatio(dir, 0.6); err != nil {
+		t.Fatal(err)
+	}
+	check("after SaveSplitRatio")
+
+	for _, bad := range []string{"[keys.topology]\nqueues = \"u\"\n", "[keys]\nyank = 1\n"} {
+		if err := os.WriteFile(filepath.Join(dir, "config.toml"), []byte(bad), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := LoadFileConfig(dir); err == nil {
+			t.Errorf("LoadFileConfig accepted %q", bad)
+		}
+	}
+}
+
+func TestLoadFileConfig_Themes(t *testing.T) {
+	dir := t.TempDir()
+	toml := `
+[ui]
+theme = "paper"
+columns = ["ts", "ex", "rk", "hdr.x-tenant"]
+split = "horizontal"
+
+[themes.paper]
+base = "light"
+accent = "#b58900"
+json_key = "33"
+`
+	if err := os.WriteFile(filepath.Join(dir, "config.toml"), []byte(toml), 0644); err != nil {
+		t.Fatal(err)
+	}
+	check := func(when string) {
+		t.Helper()
+		fc, err := LoadFileConfig(dir)
+		if err != nil {
+			t.Fatalf("%s: %v", when, err)
+		}
+		if want := (ThemeColors{Base: "light", Accent: "#b58900", JSONKey: "33"}); fc.UI.Theme != "paper" || fc.Themes["paper"] != want {
+			t.Errorf("%s: theme %q, themes %+v", when, fc.UI.Theme, fc.Themes)
+		}
+		cfg := fc.Resolve("", dir)
+		if !slices.Equal(cfg.Columns, []string{"ts", "ex", "rk", "hdr.x-tenant"}) || cfg.Split != SplitHorizontal {
+			t.Errorf("%s: columns %q, split %q", when, cfg.Columns, cfg.Split)
+		}
+	}
+	check("load")
+
+	// Saving the split ratio keeps the theme and layout
+	if err := SaveSplitRatio(dir, 0.3); err != nil {
+		t.Fatal(err)
+	}
+	check("after SaveSplitRatio")
+}
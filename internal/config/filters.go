@@ -0,0 +1,25 @@
+package config
+
+// SavedFilter is a named filter expression, applied from the consumer view's
+// picker.
+type SavedFilter struct {
+	Name   string `toml:"name"`
+	Filter string `toml:"filter"` // query or search expression, as in the consumer's filter
+}
+
+// mergeFilters returns the global filters followed by the profile's, a
+// profile filter replacing a global one of the same name.
+func mergeFilters(global, profile []SavedFilter) []SavedFilter {
+	merged := append([]SavedFilter{}, global...)
+next:
+	for _, f := range profile {
+		for i := range merged {
+			if merged[i].Name == f.Name {
+				merged[i] = f
+				continue next
+			}
+		}
+		merged = append(merged, f)
+	}
+	return merged
+}
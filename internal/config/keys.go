@@ -0,0 +1,143 @@
+package config
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+)
+
+// Key binding scopes: the consumer view and its group, trace and saved
+// filter overlays, the topology browser, the session browser and the cluster
+// dashboard.
+const (
+	KeysConsumer  = "consumer"
+	KeysGroups    = "groups"
+	KeysTrace     = "trace"
+	KeysFilters   = "filters"
+	KeysBrowser   = "browser"
+	KeysSessions  = "sessions"
+	KeysDashboard = "dashboard"
+)
+
+// Keys binds action names to key sequences, replacing their default keys.
+// In config.toml the entries of [keys] bind consumer view actions, and the
+// [keys.groups], [keys.trace], [keys.filters], [keys.browser],
+// [keys.sessions] and [keys.dashboard] tables those of the other views:
+//
+//	[keys]
+//	clear = "ctrl+l"
+//	go_top = ["gg", "home"]
+//
+//	[keys.browser]
+//	queues = "u"
+type Keys struct {
+	Consumer  map[string]KeySequences `toml:"consumer,omitempty"`
+	Groups    map[string]KeySequences `toml:"groups,omitempty"`
+	Trace     map[string]KeySequences `toml:"trace,omitempty"`
+	Filters   map[string]KeySequences `toml:"filters,omitempty"`
+	Browser   map[string]KeySequences `toml:"browser,omitempty"`
+	Sessions  map[string]KeySequences `toml:"sessions,omitempty"`
+	Dashboard map[string]KeySequences `toml:"dashboard,omitempty"`
+}
+
+// KeySequences are the key sequences bound to one action. In config.toml it
+// is a string or a list of them; an empty list unbinds the action.
+type KeySequences []string
+
+// Scope returns the bindings of a scope, nil for an unknown one.
+func (k Keys) Scope(scope string) map[string]KeySequences {
+	switch scope {
+	case KeysConsumer:
+		return k.Consumer
+	case KeysGroups:
+		return k.Groups
+	case KeysTrace:
+		return k.Trace
+	case KeysFilters:
+		return k.Filters
+	case KeysBrowser:
+		return k.Browser
+	case KeysSessions:
+		return k.Sessions
+	case KeysDashboard:
+		return k.Dashboard
+	}
+	return nil
+}
+
+// UnmarshalTOML reads [keys]: top-level entries go to the consumer view,
+// tables to the view they name.
+func (k *Keys) UnmarshalTOML(data any) error {
+	table, ok := data.(map[string]any)
+	if !ok {
+		return fmt.Errorf("keys: want a table")
+	}
+	for _, name := range slices.Sorted(maps.Keys(table)) {
+		value := table[name]
+		if sub, ok := value.(map[string]any); ok {
+			scope := k.scopeMap(name)
+			if scope == nil {
+				return fmt.Errorf("keys: unknown view %q (use %s, %s, %s, %s, %s or %s)", name, KeysGroups, KeysTrace, KeysFilters, KeysBrowser, KeysSessions, KeysDashboard)
+			}
+			for _, action := range slices.Sorted(maps.Keys(sub)) {
+				seqs, err := parseKeySequences(sub[action])
+				if err != nil {
+					return fmt.Errorf("keys.%s.%s: %w", name, action, err)
+				}
+				scope[action] = seqs
+			}
+			continue
+		}
+		seqs, err := parseKeySequences(value)
+		if err != nil {
+			return fmt.Errorf("keys.%s: %w", name, err)
+		}
+		k.scopeMap(KeysConsumer)[name] = seqs
+	}
+	return nil
+}
+
+// scopeMap returns the bindings of a scope for writing, nil for an unknown one.
+func (k *Keys) scopeMap(scope string) map[string]KeySequences {
+	var m *map[string]KeySequences
+	switch scope {
+	case KeysConsumer:
+		m = &k.Consumer
+	case KeysGroups:
+		m = &k.Groups
+	case KeysTrace:
+		m = &k.Trace
+	case KeysFilters:
+		m = &k.Filters
+	case KeysBrowser:
+		m = &k.Browser
+	case KeysSessions:
+		m = &k.Sessions
+	case KeysDashboard:
+		m = &k.Dashboard
+	default:
+		return nil
+	}
+	if *m == nil {
+		*m = make(map[string]KeySequences)
+	}
+	return *m
+}
+
+func parseKeySequences(value any) (KeySequences, error) {
+	switch v := value.(type) {
+	case string:
+		return KeySequences{v}, nil
+	case []any:
+		seqs := make(KeySequences, 0, len(v))
+		for _, item := range v {
+			s, ok := item.(string)
+			if !ok {
+				return nil, fmt.Errorf("want a key sequence or a list of them, got %v", item)
+			}
+			seqs = append(seqs, s)
+		}
+		return seqs, nil
+	}
+	return nil, fmt.Errorf("want a key sequence or a list of them, got %v", value)
+}
@@ -0,0 +1,107 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RetentionConfig limits how much history the persistence database keeps.
+// Zero values mean no limit.
+type RetentionConfig struct {
+	MaxAge      string `toml:"max_age,omitempty"`      // e.g. "30d", "2w", "12h"
+	MaxSize     string `toml:"max_size,omitempty"`     // e.g. "2GB"; whole database, ignored in profiles
+	MaxSessions int    `toml:"max_sessions,omitempty"` // newest sessions to keep
+}
+
+// Retention is a parsed RetentionConfig.
+type Retention struct {
+	MaxAge      time.Duration
+	MaxSize     int64
+	MaxSessions int
+}
+
+// Enabled reports whether any limit is set.
+func (r Retention) Enabled() bool {
+	return r.MaxAge > 0 || r.MaxSize > 0 || r.MaxSessions > 0
+}
+
+// Parse validates and converts the configured limits.
+func (c RetentionConfig) Parse() (Retention, error) {
+	var r Retention
+	var err error
+	if c.MaxAge != "" {
+		if r.MaxAge, err = ParseAge(c.MaxAge); err != nil {
+			return Retention{}, fmt.Errorf("retention max_age: %w", err)
+		}
+	}
+	if c.MaxSize != "" {
+		if r.MaxSize, err = ParseSize(c.MaxSize); err != nil {
+			return Retention{}, fmt.Errorf("retention max_size: %w", err)
+		}
+	}
+	if c.MaxSessions < 0 {
+		return Retention{}, fmt.Errorf("retention max_sessions: must not be negative")
+	}
+	r.MaxSessions = c.MaxSessions
+	return r, nil
+}
+
+// Override returns c with the limits set in o replacing its own.
+func (c RetentionConfig) Override(o RetentionConfig) RetentionConfig {
+	if o.MaxAge != "" {
+		c.MaxAge = o.MaxAge
+	}
+	if o.MaxSize != "" {
+		c.MaxSize = o.MaxSize
+	}
+	if o.MaxSessions != 0 {
+		c.MaxSessions = o.MaxSessions
+	}
+	return c
+}
+
+// ParseAge parses a duration, additionally accepting days ("30d") and weeks ("2w").
+func ParseAge(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	for suffix, unit := range map[string]time.Duration{"d": 24 * time.Hour, "w": 7 * 24 * time.Hour} {
+		if n, ok := strings.CutSuffix(s, suffix); ok {
+			v, err := strconv.ParseFloat(n, 64)
+			if err != nil || v <= 0 {
+				return 0, fmt.Errorf("invalid age %q", s)
+			}
+			return time.Duration(v * float64(unit)), nil
+		}
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("invalid age %q (use e.g. 30d, 2w or 12h)", s)
+	}
+	return d, nil
+}
+
+// ParseSize parses a byte size such as "500MB" or "2GB" (binary units: 1KB = 1024 bytes).
+func ParseSize(s string) (int64, error) {
+	upper := strings.ToUpper(strings.TrimSpace(s))
+	units := []struct {
+		suffix string
+		mult   int64
+	}{
+		{"TB", 1 << 40}, {"GB", 1 << 30}, {"MB", 1 << 20}, {"KB", 1 << 10}, {"B", 1},
+	}
+	for _, u := range units {
+		if n, ok := strings.CutSuffix(upper, u.suffix); ok {
+			v, err := strconv.ParseFloat(strings.TrimSpace(n), 64)
+			if err != nil || v <= 0 {
+				return 0, fmt.Errorf("invalid size %q", s)
+			}
+			return int64(v * float64(u.mult)), nil
+		}
+	}
+	v, err := strconv.ParseInt(upper, 10, 64)
+	if err != nil || v <= 0 {
+		return 0, fmt.Errorf("invalid size %q (use e.g. 500MB or 2GB)", s)
+	}
+	return v, nil
+}
@@ -0,0 +1,26 @@
+package config
+
+// Orientations of the consumer view's split between the message list and
+// the detail panel.
+const (
+	SplitVertical   = "vertical"   // side by side, the default
+	SplitHorizontal = "horizontal" // the list above the details
+)
+
+// ThemeColors is a user-defined theme, from a [themes.<name>] table. Colours
+// are names, #rrggbb or 0-255; unset ones are taken from the base theme.
+type ThemeColors struct {
+	Base       string `toml:"base,omitempty"` // built-in theme to start from; dark when unset
+	Accent     string `toml:"accent,omitempty"`
+	Text       string `toml:"text,omitempty"`
+	Muted      string `toml:"muted,omitempty"`
+	Background string `toml:"background,omitempty"`
+	Selection  string `toml:"selection,omitempty"`
+	Success    string `toml:"success,omitempty"`
+	Error      string `toml:"error,omitempty"`
+	JSONKey    string `toml:"json_key,omitempty"`
+	JSONString string `toml:"json_string,omitempty"`
+	JSONNumber string `toml:"json_number,omitempty"`
+	JSONBool   string `toml:"json_bool,omitempty"`
+	JSONNull   string `toml:"json_null,omitempty"`
+}
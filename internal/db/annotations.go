@@ -0,0 +1,140 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+)
+
+// Annotation is what a user recorded about a stored message.
+type Annotation struct {
+	Bookmarked bool
+	Note       string
+	Tags       []string
+}
+
+// IsZero reports whether the annotation records nothing.
+func (a Annotation) IsZero() bool {
+	return !a.Bookmarked && a.Note == "" && len(a.Tags) == 0
+}
+
+// AnnotatedMessage is a stored message with its annotation.
+type AnnotatedMessage struct {
+	Message
+	Annotation Annotation
+	UpdatedAt  time.Time
+}
+
+// SetAnnotation replaces the annotation of a stored message. A zero
+// annotation removes it.
+func (s *SQLiteStore) SetAnnotation(ctx context.Context, messageID int64, a Annotation) error {
+	if a.IsZero() {
+		_, err := s.db.ExecContext(ctx, "DELETE FROM annotations WHERE message_id = ?", messageID)
+		return err
+	}
+	var tags sql.NullString
+	if len(a.Tags) > 0 {
+		data, err := json.Marshal(a.Tags)
+		if err != nil {
+			return err
+		}
+		tags = sql.NullString{String: string(data), Valid: true}
+	}
+	_, err := s.db.ExecContext(ctx, `
+INSERT INTO annotations (message_id, bookmarked, note, tags, updated_at)
+VALUES (?, ?, ?, ?, CURRENT_TIMESTAMP)
+ON CONFLICT (message_id) DO UPDATE SET
+    bookmarked = excluded.bookmarked,
+    note = excluded.note,
+    tags = excluded.tags,
+    updated_at = excluded.updated_at
+`, messageID, a.Bookmarked, a.Note, tags)
+	return err
+}
+
+// GetAnnotations returns the annotations of the given messages by ID.
+// Messages without one are left out.
+func (s *SQLiteStore) GetAnnotations(ctx context.Context, ids []int64) (_ map[int64]Annotation, err error) {
+	annotations := make(map[int64]Annotation)
+	if len(ids) == 0 {
+		return annotations, nil
+	}
+	args := make([]any, len(ids))
+	for i, id := range ids {
+		args[i] = id
+	}
+	q := `
+SELECT message_id, bookmarked, note, tags
+FROM annotations
+WHERE message_id IN (` + strings.TrimSuffix(strings.Repeat("?, ", len(ids)), ", ") + `)
+`
+	rows, err := s.db.QueryContext(ctx, q, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { err = errors.Join(err, rows.Close()) }()
+
+	for rows.Next() {
+		var id int64
+		var a Annotation
+		var tags sql.NullString
+		if err := rows.Scan(&id, &a.Bookmarked, &a.Note, &tags); err != nil {
+			return nil, err
+		}
+		a.Tags = parseTags(tags)
+		annotations[id] = a
+	}
+	return annotations, rows.Err()
+}
+
+// ListAnnotated returns the annotated messages of every session, most
+// recently annotated first.
+func (s *SQLiteStore) ListAnnotated(ctx context.Context, limit int64) (_ []AnnotatedMessage, err error) {
+	const q = `
+SELECT m.id, m.session_id, m.exchange, m.routing_key, m.body, m.content_type,
+       m.headers, m.timestamp, m.consumed_at, m.proto_type, m.correlation_id,
+       m.reply_to, m.message_id, m.app_id, m.decoded_json,
+       m.delivery_mode, m.priority, m.expiration,
+       a.bookmarked, a.note, a.tags, a.updated_at
+FROM annotations a
+JOIN messages m ON m.id = a.message_id
+ORDER BY a.updated_at DESC, a.message_id DESC
+LIMIT ?
+`
+	rows, err := s.db.QueryContext(ctx, q, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { err = errors.Join(err, rows.Close()) }()
+
+	var annotated []AnnotatedMessage
+	for rows.Next() {
+		var am AnnotatedMessage
+		m, a := &am.Message, &am.Annotation
+		var tags sql.NullString
+		if err := rows.Scan(
+			&m.ID, &m.SessionID, &m.Exchange, &m.RoutingKey, &m.Body, &m.ContentType,
+			&m.Headers, &m.Timestamp, &m.ConsumedAt, &m.ProtoType, &m.CorrelationID,
+			&m.ReplyTo, &m.MessageID, &m.AppID, &m.DecodedJson,
+			&m.DeliveryMode, &m.Priority, &m.Expiration,
+			&a.Bookmarked, &a.Note, &tags, &am.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		a.Tags = parseTags(tags)
+		annotated = append(annotated, am)
+	}
+	return annotated, rows.Err()
+}
+
+func parseTags(s sql.NullString) []string {
+	if !s.Valid {
+		return nil
+	}
+	var tags []string
+	_ = json.Unmarshal([]byte(s.String), &tags)
+	return tags
+}
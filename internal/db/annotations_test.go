@@ -0,0 +1,79 @@
+package db
+
+import (
+	"context"
+	"slices"
+	"testing"
+)
+
+func TestStore_Annotations(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+
+	sid, err := store.CreateSession(ctx, "orders", "#", "q", "amqp://localhost/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ids []int64
+	for _, rec := range []MessageRecord{
+		{SessionID: sid, Exchange: "orders", RoutingKey: "order.created", Body: []byte("{}")},
+		{SessionID: sid, Exchange: "orders", RoutingKey: "order.failed", Body: []byte("{}"),
+			Annotation: Annotation{Bookmarked: true}},
+		{SessionID: sid, Exchange: "orders", RoutingKey: "order.paid", Body: []byte("{}")},
+	} {
+		id, err := store.InsertMessage(ctx, &rec)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, id)
+	}
+
+	note := Annotation{Note: "double charge", Tags: []string{"incident-42", "payments"}}
+	if err := store.SetAnnotation(ctx, ids[0], note); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := store.GetAnnotations(ctx, ids)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || !got[ids[1]].Bookmarked || got[ids[0]].Note != "double charge" || !slices.Equal(got[ids[0]].Tags, note.Tags) {
+		t.Fatalf("GetAnnotations = %+v", got)
+	}
+
+	annotated, err := store.ListAnnotated(ctx, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(annotated) != 2 {
+		t.Fatalf("ListAnnotated returned %d messages, want 2", len(annotated))
+	}
+	for _, am := range annotated {
+		if am.ID == ids[0] && (am.RoutingKey != "order.created" || am.Annotation.Note != "double charge") {
+			t.Errorf("ListAnnotated = %+v", am)
+		}
+	}
+
+	// Updating replaces the annotation; clearing it removes the row
+	if err := store.SetAnnotation(ctx, ids[0], Annotation{Bookmarked: true}); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.SetAnnotation(ctx, ids[1], Annotation{}); err != nil {
+		t.Fatal(err)
+	}
+	got, err = store.GetAnnotations(ctx, ids)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || !got[ids[0]].Bookmarked || got[ids[0]].Note != "" || got[ids[0]].Tags != nil {
+		t.Errorf("after update: %+v", got)
+	}
+
+	// Annotations go with their session
+	if err := store.DeleteSession(ctx, sid); err != nil {
+		t.Fatal(err)
+	}
+	if annotated, err := store.ListAnnotated(ctx, 10); err != nil || len(annotated) != 0 {
+		t.Errorf("after deleting the session: %d annotations, %v", len(annotated), err)
+	}
+}
@@ -0,0 +1,101 @@
+package db
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"modernc.org/sqlite"
+)
+
+// SQL functions used by structured queries (see the query package):
+//
+//	X REGEXP PATTERN   Go regexp match, as SQLite has no built-in REGEXP
+//	unix_time(T)       seconds since the epoch of a stored DATETIME
+func init() {
+	sqlite.MustRegisterDeterministicScalarFunction("regexp", 2, sqlRegexp)
+	sqlite.MustRegisterDeterministicScalarFunction("unix_time", 1, sqlUnixTime)
+}
+
+// regexpCache holds compiled patterns; a query uses a handful of them for
+// every row it scans.
+var regexpCache sync.Map
+
+func sqlRegexp(_ *sqlite.FunctionContext, args []driver.Value) (driver.Value, error) {
+	pattern, ok := args[0].(string)
+	if !ok {
+		return nil, fmt.Errorf("regexp: pattern must be text")
+	}
+	var s string
+	switch v := args[1].(type) {
+	case nil:
+		return nil, nil
+	case string:
+		s = v
+	case []byte:
+		s = string(v)
+	default:
+		s = fmt.Sprint(v)
+	}
+
+	re, ok := regexpCache.Load(pattern)
+	if !ok {
+		compiled, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, err
+		}
+		re, _ = regexpCache.LoadOrStore(pattern, compiled)
+	}
+	if re.(*regexp.Regexp).MatchString(s) {
+		return int64(1), nil
+	}
+	return int64(0), nil
+}
+
+// storedTimeLayouts are the formats DATETIME columns hold: Go's time.String
+// for values written by the driver, and SQLite's own for CURRENT_TIMESTAMP.
+var storedTimeLayouts = []string{
+	"2006-01-02 15:04:05.999999999 -0700 MST",
+	"2006-01-02 15:04:05.999999999-07:00",
+	"2006-01-02T15:04:05.999999999Z07:00",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02 15:04:05",
+	"2006-01-02",
+}
+
+func sqlUnixTime(_ *sqlite.FunctionContext, args []driver.Value) (driver.Value, error) {
+	var t time.Time
+	switch v := args[0].(type) {
+	case time.Time:
+		t = v
+	case string:
+		parsed, ok := parseStoredTime(v)
+		if !ok {
+			return nil, nil
+		}
+		t = parsed
+	case int64:
+		return float64(v), nil
+	case float64:
+		return v, nil
+	default:
+		return nil, nil
+	}
+	return float64(t.UnixNano()) / 1e9, nil
+}
+
+func parseStoredTime(s string) (time.Time, bool) {
+	// Drop the monotonic clock reading time.String appends
+	if i := strings.Index(s, " m="); i >= 0 {
+		s = s[:i]
+	}
+	for _, layout := range storedTimeLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
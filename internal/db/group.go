@@ -0,0 +1,61 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// MessageGroup summarises the messages sharing one value of a grouping key.
+type MessageGroup struct {
+	Key       sql.NullString // NULL when the key is absent
+	Count     int64
+	FirstSeen time.Time
+	LastSeen  time.Time
+	AvgSize   float64
+	MaxSize   int64
+}
+
+// GroupMessages aggregates the messages matching where by the SQL expression
+// key over messages m, largest groups first. Groups are first and last seen
+// when their messages arrived, as publishers' clocks may disagree. sessionID limits it to one
+// session; 0 groups all of them.
+func (s *SQLiteStore) GroupMessages(ctx context.Context, where string, args []any, sessionID int64, key string, keyArgs []any, limit int64) (_ []MessageGroup, err error) {
+	q := `
+SELECT ` + key + ` AS k, COUNT(*),
+       MIN(unix_time(m.consumed_at)), MAX(unix_time(m.consumed_at)),
+       AVG(LENGTH(m.body)), MAX(LENGTH(m.body))
+FROM messages m
+WHERE (? = 0 OR m.session_id = ?) AND ` + where + `
+GROUP BY k
+ORDER BY COUNT(*) DESC, k
+LIMIT ?
+`
+	all := append(append([]any{}, keyArgs...), sessionID, sessionID)
+	all = append(append(all, args...), limit)
+	rows, err := s.db.QueryContext(ctx, q, all...)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { err = errors.Join(err, rows.Close()) }()
+
+	var groups []MessageGroup
+	for rows.Next() {
+		var g MessageGroup
+		var first, last sql.NullFloat64
+		if err := rows.Scan(&g.Key, &g.Count, &first, &last, &g.AvgSize, &g.MaxSize); err != nil {
+			return nil, err
+		}
+		g.FirstSeen, g.LastSeen = unixTime(first), unixTime(last)
+		groups = append(groups, g)
+	}
+	return groups, rows.Err()
+}
+
+func unixTime(f sql.NullFloat64) time.Time {
+	if !f.Valid {
+		return time.Time{}
+	}
+	return time.Unix(0, int64(f.Float64*1e9)).UTC()
+}
@@ -0,0 +1,73 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestStore_GroupMessages(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+
+	sid, err := store.CreateSession(ctx, "orders", "#", "q", "amqp://localhost/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := store.CreateSession(ctx, "orders", "#", "q", "amqp://localhost/")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	base := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	for i, rec := range []MessageRecord{
+		{SessionID: sid, RoutingKey: "order.created", Body: []byte("ab"), Decoded: map[string]any{"country": "IT"}},
+		{SessionID: sid, RoutingKey: "order.created", Body: []byte("abcdef"), Decoded: map[string]any{"country": "FR"}},
+		{SessionID: sid, RoutingKey: "order.created", Body: []byte("abcd"), Decoded: map[string]any{"country": "IT"}},
+		{SessionID: sid, RoutingKey: "order.paid", Body: []byte("a")},
+		{SessionID: other, RoutingKey: "order.paid", Body: []byte("a")},
+	} {
+		rec.Exchange = "orders"
+		// Groups are seen when their messages arrived, whatever the publishers' clocks say
+		rec.Timestamp = base.Add(-time.Duration(i) * time.Hour)
+		rec.ConsumedAt = base.Add(time.Duration(i) * time.Second)
+		if _, err := store.InsertMessage(ctx, &rec); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	groups, err := store.GroupMessages(ctx, "1", nil, sid, "m.routing_key", nil, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(groups) != 2 {
+		t.Fatalf("got %d groups, want 2", len(groups))
+	}
+	g := groups[0]
+	if g.Key.String != "order.created" || g.Count != 3 || g.MaxSize != 6 || g.AvgSize != 4 {
+		t.Errorf("largest group = %+v, want order.created: 3 messages, max 6, avg 4", g)
+	}
+	if !g.FirstSeen.Equal(base) || !g.LastSeen.Equal(base.Add(2*time.Second)) {
+		t.Errorf("first/last seen = %v/%v, want %v/%v", g.FirstSeen, g.LastSeen, base, base.Add(2*time.Second))
+	}
+	if groups[1].Key.String != "order.paid" || groups[1].Count != 1 {
+		t.Errorf("second group = %+v, want order.paid with 1 message of this session", groups[1])
+	}
+
+	// A JSON path key, with NULL for messages without it
+	groups, err = store.GroupMessages(ctx, "1", nil, sid, "m.decoded_json -> ?", []any{"$.country"}, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := map[string]int64{}
+	for _, g := range groups {
+		key := "NULL"
+		if g.Key.Valid {
+			key = g.Key.String
+		}
+		got[key] = g.Count
+	}
+	if got[`"IT"`] != 2 || got[`"FR"`] != 1 || got["NULL"] != 1 {
+		t.Errorf("groups by country = %v", got)
+	}
+}
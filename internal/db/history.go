@@ -0,0 +1,64 @@
+package db
+
+import (
+	"context"
+	"errors"
+)
+
+// Kinds of input history.
+const (
+	HistorySearch = "search"
+	HistoryFilter = "filter"
+)
+
+// AddHistory records entry as the latest of its kind, keeping the keep most
+// recent entries.
+func (s *SQLiteStore) AddHistory(ctx context.Context, kind, entry string, keep int) (err error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			err = errors.Join(err, tx.Rollback())
+		}
+	}()
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM input_history WHERE kind = ? AND entry = ?", kind, entry); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, "INSERT INTO input_history (kind, entry) VALUES (?, ?)", kind, entry); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `
+DELETE FROM input_history
+WHERE kind = ? AND id NOT IN (
+    SELECT id FROM input_history WHERE kind = ? ORDER BY id DESC LIMIT ?
+)`, kind, kind, keep); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// ListHistory returns the limit most recent entries of a kind, oldest first.
+func (s *SQLiteStore) ListHistory(ctx context.Context, kind string, limit int) (_ []string, err error) {
+	rows, err := s.db.QueryContext(ctx, `
+SELECT entry FROM (
+    SELECT id, entry FROM input_history WHERE kind = ? ORDER BY id DESC LIMIT ?
+) ORDER BY id
+`, kind, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { err = errors.Join(err, rows.Close()) }()
+
+	var entries []string
+	for rows.Next() {
+		var entry string
+		if err := rows.Scan(&entry); err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+	return entries, rows.Err()
+}
@@ -0,0 +1,42 @@
+package db
+
+import (
+	"context"
+	"slices"
+	"testing"
+)
+
+func TestStore_History(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+
+	for _, entry := range []string{"rk:order", "hdr:tenant-x", "type:OrderFailed", "rk:order", "re:^pay"} {
+		if err := store.AddHistory(ctx, HistoryFilter, entry, 3); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := store.AddHistory(ctx, HistorySearch, "refund", 3); err != nil {
+		t.Fatal(err)
+	}
+
+	// Re-entering moves an entry to the end; the oldest beyond keep go
+	got, err := store.ListHistory(ctx, HistoryFilter, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"type:OrderFailed", "rk:order", "re:^pay"}; !slices.Equal(got, want) {
+		t.Errorf("filter history = %q, want %q", got, want)
+	}
+
+	got, err = store.ListHistory(ctx, HistoryFilter, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"rk:order", "re:^pay"}; !slices.Equal(got, want) {
+		t.Errorf("limited history = %q, want %q", got, want)
+	}
+
+	if got, _ := store.ListHistory(ctx, HistorySearch, 10); !slices.Equal(got, []string{"refund"}) {
+		t.Errorf("search history = %q", got)
+	}
+}
@@ -0,0 +1,175 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// destructiveDirective marks a migration that drops or rewrites data. The
+// database file is backed up before it is applied.
+const destructiveDirective = "-- migrate:destructive"
+
+// migration is one step of the schema, read from migrations/NNNN_name.sql.
+// The applied version is stored in PRAGMA user_version.
+type migration struct {
+	version     int
+	name        string
+	sql         string
+	destructive bool
+}
+
+// loadMigrations returns the embedded migrations ordered by version.
+func loadMigrations(fsys fs.FS) ([]migration, error) {
+	paths, err := fs.Glob(fsys, "migrations/*.sql")
+	if err != nil {
+		return nil, err
+	}
+
+	var migrations []migration
+	for _, p := range paths {
+		base := strings.TrimSuffix(path.Base(p), ".sql")
+		prefix, name, _ := strings.Cut(base, "_")
+		version, err := strconv.Atoi(prefix)
+		if err != nil || version <= 0 {
+			return nil, fmt.Errorf("migration %s: name must start with a positive version number", p)
+		}
+		data, err := fs.ReadFile(fsys, p)
+		if err != nil {
+			return nil, err
+		}
+		if n := len(migrations); n > 0 && migrations[n-1].version+1 != version {
+			return nil, fmt.Errorf("migration %s: expected version %d", p, migrations[n-1].version+1)
+		}
+		if len(migrations) == 0 && version != 1 {
+			return nil, fmt.Errorf("migration %s: versions must start at 1", p)
+		}
+		migrations = append(migrations, migration{
+			version:     version,
+			name:        name,
+			sql:         string(data),
+			destructive: strings.Contains(string(data), destructiveDirective),
+		})
+	}
+	return migrations, nil
+}
+
+// SchemaVersion returns the migration version the database is at.
+func (s *SQLiteStore) SchemaVersion(ctx context.Context) (int, error) {
+	return userVersion(ctx, s.db)
+}
+
+type queryer interface {
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+func userVersion(ctx context.Context, q queryer) (int, error) {
+	var v int
+	err := q.QueryRowContext(ctx, "PRAGMA user_version").Scan(&v)
+	return v, err
+}
+
+// migrate brings the database at dbPath up to the latest migration. Each
+// migration runs in its own transaction together with the version bump, so a
+// failure leaves the database at the previous version. If any pending
+// migration is destructive, an existing database is first backed up next to
+// its file.
+func migrate(ctx context.Context, db *sql.DB, dbPath string, migrations []migration) error {
+	current, err := userVersion(ctx, db)
+	if err != nil {
+		return fmt.Errorf("failed to read schema version: %w", err)
+	}
+	if n := len(migrations); n > 0 && current > migrations[n-1].version {
+		return fmt.Errorf("database schema version %d is newer than this build supports (%d); upgrade rabbithole", current, migrations[n-1].version)
+	}
+
+	var pending []migration
+	destructive := false
+	for _, m := range migrations {
+		if m.version > current {
+			pending = append(pending, m)
+			destructive = destructive || m.destructive
+		}
+	}
+
+	// Keep a copy of the database as it was, unless it is new
+	if destructive {
+		var tables int
+		if err := db.QueryRowContext(ctx, "SELECT COUNT(*) FROM sqlite_master WHERE type = 'table'").Scan(&tables); err != nil {
+			return fmt.Errorf("failed to inspect schema: %w", err)
+		}
+		if tables > 0 {
+			if err := backupDatabase(ctx, db, dbPath, current); err != nil {
+				return fmt.Errorf("failed to back up database: %w", err)
+			}
+		}
+	}
+
+	for _, m := range pending {
+		if err := applyMigration(ctx, db, m); err != nil {
+			return fmt.Errorf("migration %d (%s): %w", m.version, m.name, err)
+		}
+	}
+	return nil
+}
+
+// applyMigration runs m on a single connection inside BEGIN IMMEDIATE, which
+// takes the write lock up front so two processes opening the same database
+// cannot apply the same migration twice.
+func applyMigration(ctx context.Context, db *sql.DB, m migration) (err error) {
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { err = errors.Join(err, conn.Close()) }()
+
+	if _, err := conn.ExecContext(ctx, "BEGIN IMMEDIATE"); err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_, rbErr := conn.ExecContext(context.Background(), "ROLLBACK")
+			err = errors.Join(err, rbErr)
+		}
+	}()
+
+	// Another process may have migrated while we waited for the lock
+	applied, err := userVersion(ctx, conn)
+	if err != nil {
+		return err
+	}
+	if applied >= m.version {
+		_, err = conn.ExecContext(ctx, "COMMIT")
+		return err
+	}
+
+	if _, err := conn.ExecContext(ctx, m.sql); err != nil {
+		return err
+	}
+	if _, err := conn.ExecContext(ctx, fmt.Sprintf("PRAGMA user_version = %d", m.version)); err != nil {
+		return err
+	}
+	_, err = conn.ExecContext(ctx, "COMMIT")
+	return err
+}
+
+// backupDatabase copies the database to a file next to it, named after the
+// schema version it holds. In-memory databases are not backed up.
+func backupDatabase(ctx context.Context, db *sql.DB, dbPath string, version int) error {
+	if dbPath == "" || dbPath == ":memory:" || strings.HasPrefix(dbPath, "file:") {
+		return nil
+	}
+	backup := fmt.Sprintf("%s.v%d-%s.bak", dbPath, version, time.Now().Format("20060102-150405"))
+	_, err := db.ExecContext(ctx, "VACUUM INTO ?", backup)
+	return err
+}
@@ -0,0 +1,233 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestLoadMigrations(t *testing.T) {
+	migrations, err := loadMigrations(migrationFiles)
+	if err != nil {
+		t.Fatalf("loadMigrations: %v", err)
+	}
+	if len(migrations) < 2 {
+		t.Fatalf("expected at least 2 migrations, got %d", len(migrations))
+	}
+	for i, m := range migrations {
+		if m.version != i+1 {
+			t.Errorf("migration %d has version %d", i, m.version)
+		}
+		if m.sql == "" {
+			t.Errorf("migration %d is empty", m.version)
+		}
+	}
+	if migrations[0].name != "baseline" {
+		t.Errorf("first migration = %q, want baseline", migrations[0].name)
+	}
+}
+
+func TestLoadMigrations_Invalid(t *testing.T) {
+	tests := []struct {
+		name  string
+		files fstest.MapFS
+	}{
+		{"gap", fstest.MapFS{
+			"migrations/0001_a.sql": {Data: []byte("SELECT 1;")},
+			"migrations/0003_c.sql": {Data: []byte("SELECT 1;")},
+		}},
+		{"not starting at 1", fstest.MapFS{
+			"migrations/0002_b.sql": {Data: []byte("SELECT 1;")},
+		}},
+		{"no version", fstest.MapFS{
+			"migrations/init.sql": {Data: []byte("SELECT 1;")},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := loadMigrations(tt.files); err == nil {
+				t.Error("expected error")
+			}
+		})
+	}
+}
+
+// openBaseline creates a database the way releases before versioned
+// migrations did: the baseline schema with user_version left at 0.
+func openBaseline(t *testing.T, path string) *sql.DB {
+	t.Helper()
+	baseline, err := migrationFiles.ReadFile("migrations/0001_baseline.sql")
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := conn.Exec(string(baseline)); err != nil {
+		t.Fatalf("baseline schema: %v", err)
+	}
+	return conn
+}
+
+func TestMigrate_UpgradeFromBaseline(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "old.db")
+
+	old := openBaseline(t, path)
+	if _, err := old.Exec(`INSERT INTO sessions (exchange, routing_key, queue_name, amqp_url) VALUES ('orders', '#', 'q', 'amqp://localhost/')`); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := old.Exec(`INSERT INTO messages (session_id, exchange, routing_key, body) VALUES (1, 'orders', 'order.created', 'legacy-payload')`); err != nil {
+		t.Fatal(err)
+	}
+	if err := old.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	store, err := NewStore(path)
+	if err != nil {
+		t.Fatalf("NewStore on baseline database: %v", err)
+	}
+	defer store.Close()
+
+	// Migration 3 rebuilds the search index, so the old file is kept
+	if backups, _ := filepath.Glob(path + ".v0-*.bak"); len(backups) != 1 {
+		t.Errorf("expected a backup of the baseline database, found %v", backups)
+	}
+
+	migrations, _ := loadMigrations(migrationFiles)
+	version, err := store.SchemaVersion(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if version != len(migrations) {
+		t.Errorf("schema version = %d, want %d", version, len(migrations))
+	}
+
+	// Existing rows and their search index survive the upgrade
+	msgs, err := store.SearchMessages(ctx, "legacy-payload", 10, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(msgs) != 1 {
+		t.Fatalf("expected legacy message to be searchable, got %d results", len(msgs))
+	}
+
+	// The update trigger added by migration 2 keeps FTS in sync
+	if _, err := store.db.Exec(`UPDATE messages SET body = 'rewritten-payload' WHERE id = ?`, msgs[0].ID); err != nil {
+		t.Fatal(err)
+	}
+	if msgs, _ := store.SearchMessages(ctx, "legacy-payload", 10, 0); len(msgs) != 0 {
+		t.Errorf("old body still indexed after update")
+	}
+	if msgs, _ := store.SearchMessages(ctx, "rewritten-payload", 10, 0); len(msgs) != 1 {
+		t.Errorf("new body not indexed after update")
+	}
+
+	// Migration 9 drops the reply_to index migration 4 added
+	var n int
+	if err := store.db.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE name = 'idx_messages_reply_to'`).Scan(&n); err != nil || n != 0 {
+		t.Errorf("reply_to index still present: %d, %v", n, err)
+	}
+}
+
+func TestMigrate_Reopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.db")
+	for i := 0; i < 2; i++ {
+		store, err := NewStore(path)
+		if err != nil {
+			t.Fatalf("open %d: %v", i, err)
+		}
+		if err := store.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestMigrate_NewerDatabase(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "future.db")
+	conn := openBaseline(t, path)
+	if _, err := conn.Exec("PRAGMA user_version = 999"); err != nil {
+		t.Fatal(err)
+	}
+	_ = conn.Close()
+
+	_, err := NewStore(path)
+	if err == nil || !strings.Contains(err.Error(), "newer than this build") {
+		t.Errorf("expected newer-schema error, got %v", err)
+	}
+}
+
+func TestMigrate_FailureRollsBack(t *testing.T) {
+	ctx := context.Background()
+	conn := openBaseline(t, filepath.Join(t.TempDir(), "test.db"))
+	defer conn.Close()
+
+	migrations := []migration{
+		{version: 1, name: "baseline", sql: "SELECT 1;"},
+		{version: 2, name: "broken", sql: "CREATE TABLE half_done (id INTEGER); INSERT INTO missing_table VALUES (1);"},
+	}
+	if err := migrate(ctx, conn, "", migrations); err == nil {
+		t.Fatal("expected migration error")
+	}
+
+	version, err := userVersion(ctx, conn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if version != 1 {
+		t.Errorf("version = %d, want 1 (failed migration must not be recorded)", version)
+	}
+	var n int
+	if err := conn.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE name = 'half_done'").Scan(&n); err != nil {
+		t.Fatal(err)
+	}
+	if n != 0 {
+		t.Error("partial migration was not rolled back")
+	}
+}
+
+func TestMigrate_DestructiveBackup(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	path := filepath.Join(dir, "test.db")
+
+	store, err := NewStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+
+	if backups, _ := filepath.Glob(path + ".v*.bak"); len(backups) != 0 {
+		t.Fatalf("new database should not be backed up, found %v", backups)
+	}
+
+	migrations, _ := loadMigrations(migrationFiles)
+	latest := len(migrations)
+	migrations = append(migrations, migration{
+		version:     latest + 1,
+		name:        "drop_index",
+		sql:         destructiveDirective + "\nDROP INDEX idx_messages_routing_key;",
+		destructive: true,
+	})
+	if err := migrate(ctx, store.db, path, migrations); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+
+	backups, _ := filepath.Glob(filepath.Join(dir, "test.db.v*.bak"))
+	if len(backups) != 1 {
+		t.Fatalf("expected one backup, found %v", backups)
+	}
+	backup, err := sql.Open("sqlite", backups[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer backup.Close()
+	if v, _ := userVersion(ctx, backup); v != latest {
+		t.Errorf("backup version = %d, want %d", v, latest)
+	}
+}
@@ -1,3 +1,6 @@
+-- Schema as shipped before versioned migrations. Every statement is
+-- idempotent so databases created by older versions adopt it unchanged.
+
 CREATE TABLE IF NOT EXISTS sessions (
     id INTEGER PRIMARY KEY AUTOINCREMENT,
     started_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
@@ -0,0 +1,7 @@
+-- Keep the search index in sync when a stored message is rewritten in place
+CREATE TRIGGER IF NOT EXISTS messages_au AFTER UPDATE OF body, routing_key ON messages BEGIN
+    INSERT INTO messages_fts(messages_fts, rowid, body_text, routing_key)
+    VALUES ('delete', OLD.id, CAST(OLD.body AS TEXT), OLD.routing_key);
+    INSERT INTO messages_fts(rowid, body_text, routing_key)
+    VALUES (NEW.id, CAST(NEW.body AS TEXT), NEW.routing_key);
+END;
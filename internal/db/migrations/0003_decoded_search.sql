@@ -0,0 +1,36 @@
+-- migrate:destructive
+-- Store decoded protobuf bodies as JSON and rebuild the search index over
+-- them, plus headers, proto type and correlation id. Raw bodies are still
+-- indexed when a message has no decoded form.
+ALTER TABLE messages ADD COLUMN decoded_json TEXT;
+
+DROP TRIGGER IF EXISTS messages_ai;
+DROP TRIGGER IF EXISTS messages_ad;
+DROP TRIGGER IF EXISTS messages_au;
+DROP TABLE IF EXISTS messages_fts;
+
+CREATE VIRTUAL TABLE messages_fts USING fts5(
+    body_text, routing_key, headers, proto_type, correlation_id,
+    content=messages, content_rowid=id
+);
+
+INSERT INTO messages_fts(rowid, body_text, routing_key, headers, proto_type, correlation_id)
+SELECT id, COALESCE(decoded_json, CAST(body AS TEXT)), routing_key, headers, proto_type, correlation_id
+FROM messages;
+
+CREATE TRIGGER messages_ai AFTER INSERT ON messages BEGIN
+    INSERT INTO messages_fts(rowid, body_text, routing_key, headers, proto_type, correlation_id)
+    VALUES (NEW.id, COALESCE(NEW.decoded_json, CAST(NEW.body AS TEXT)), NEW.routing_key, NEW.headers, NEW.proto_type, NEW.correlation_id);
+END;
+
+CREATE TRIGGER messages_ad AFTER DELETE ON messages BEGIN
+    INSERT INTO messages_fts(messages_fts, rowid, body_text, routing_key, headers, proto_type, correlation_id)
+    VALUES ('delete', OLD.id, COALESCE(OLD.decoded_json, CAST(OLD.body AS TEXT)), OLD.routing_key, OLD.headers, OLD.proto_type, OLD.correlation_id);
+END;
+
+CREATE TRIGGER messages_au AFTER UPDATE OF body, decoded_json, routing_key, headers, proto_type, correlation_id ON messages BEGIN
+    INSERT INTO messages_fts(messages_fts, rowid, body_text, routing_key, headers, proto_type, correlation_id)
+    VALUES ('delete', OLD.id, COALESCE(OLD.decoded_json, CAST(OLD.body AS TEXT)), OLD.routing_key, OLD.headers, OLD.proto_type, OLD.correlation_id);
+    INSERT INTO messages_fts(rowid, body_text, routing_key, headers, proto_type, correlation_id)
+    VALUES (NEW.id, COALESCE(NEW.decoded_json, CAST(NEW.body AS TEXT)), NEW.routing_key, NEW.headers, NEW.proto_type, NEW.correlation_id);
+END;
@@ -0,0 +1,5 @@
+-- Index the AMQP properties that link the messages of one conversation, so
+-- tracing a request across every stored session stays an index lookup
+CREATE INDEX IF NOT EXISTS idx_messages_correlation_id ON messages(correlation_id);
+CREATE INDEX IF NOT EXISTS idx_messages_message_id ON messages(message_id);
+CREATE INDEX IF NOT EXISTS idx_messages_reply_to ON messages(reply_to);
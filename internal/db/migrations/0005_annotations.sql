@@ -0,0 +1,12 @@
+-- Bookmarks, notes and tags on stored messages. Tags are a JSON array.
+-- Annotations go with their message when retention or a session delete
+-- removes it.
+CREATE TABLE IF NOT EXISTS annotations (
+    message_id INTEGER PRIMARY KEY REFERENCES messages(id) ON DELETE CASCADE,
+    bookmarked INTEGER NOT NULL DEFAULT 0,
+    note TEXT NOT NULL DEFAULT '',
+    tags TEXT,
+    updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_annotations_updated_at ON annotations(updated_at);
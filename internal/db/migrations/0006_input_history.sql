@@ -0,0 +1,8 @@
+-- Search and filter expressions entered in the consumer view, recalled with
+-- up and down. Re-entering an expression moves it to the end.
+CREATE TABLE IF NOT EXISTS input_history (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    kind TEXT NOT NULL,
+    entry TEXT NOT NULL,
+    UNIQUE (kind, entry)
+);
@@ -0,0 +1,5 @@
+-- AMQP properties that change how the broker treats a message, so replays
+-- publish it the way it was sent: 2 is persistent delivery
+ALTER TABLE messages ADD COLUMN delivery_mode INTEGER;
+ALTER TABLE messages ADD COLUMN priority INTEGER;
+ALTER TABLE messages ADD COLUMN expiration TEXT;
@@ -0,0 +1,7 @@
+-- When a process last wrote to, or was still recording, an open session.
+-- Retention leaves sessions with a recent heartbeat alone however quiet they
+-- are; open sessions without one were abandoned by a crash.
+CREATE TABLE IF NOT EXISTS session_heartbeats (
+    session_id INTEGER PRIMARY KEY REFERENCES sessions(id) ON DELETE CASCADE,
+    seen_at DATETIME NOT NULL
+);
@@ -0,0 +1,3 @@
+-- Tracing does not look messages up by reply_to: a reply queue is shared by
+-- unrelated requests, so replies are found by correlation id instead
+DROP INDEX IF EXISTS idx_messages_reply_to;
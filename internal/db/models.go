@@ -24,11 +24,18 @@ type Message struct {
 	ReplyTo       sql.NullString `json:"reply_to"`
 	MessageID     sql.NullString `json:"message_id"`
 	AppID         sql.NullString `json:"app_id"`
+	DecodedJson   sql.NullString `json:"decoded_json"`
+	DeliveryMode  sql.NullInt64  `json:"delivery_mode"`
+	Priority      sql.NullInt64  `json:"priority"`
+	Expiration    sql.NullString `json:"expiration"`
 }
 
 type MessagesFt struct {
-	BodyText   string `json:"body_text"`
-	RoutingKey string `json:"routing_key"`
+	BodyText      string `json:"body_text"`
+	RoutingKey    string `json:"routing_key"`
+	Headers       string `json:"headers"`
+	ProtoType     string `json:"proto_type"`
+	CorrelationID string `json:"correlation_id"`
 }
 
 type Session struct {
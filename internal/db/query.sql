@@ -15,25 +15,28 @@ LIMIT ?;
 -- name: InsertMessage :one
 INSERT INTO messages (
     session_id, exchange, routing_key, body, content_type,
-    headers, timestamp, proto_type, correlation_id, reply_to,
-    message_id, app_id
-) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+    headers, timestamp, consumed_at, proto_type, correlation_id,
+    reply_to, message_id, app_id, decoded_json, delivery_mode,
+    priority, expiration
+) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 RETURNING id;
 
 -- name: GetMessage :one
 SELECT id, session_id, exchange, routing_key, body, content_type,
        headers, timestamp, consumed_at, proto_type, correlation_id,
-       reply_to, message_id, app_id
+       reply_to, message_id, app_id, decoded_json,
+       delivery_mode, priority, expiration
 FROM messages
 WHERE id = ?;
 
 -- name: ListMessagesBySession :many
 SELECT id, session_id, exchange, routing_key, body, content_type,
        headers, timestamp, consumed_at, proto_type, correlation_id,
-       reply_to, message_id, app_id
+       reply_to, message_id, app_id, decoded_json,
+       delivery_mode, priority, expiration
 FROM messages
 WHERE session_id = ?
-ORDER BY consumed_at DESC
+ORDER BY consumed_at DESC, id DESC
 LIMIT ? OFFSET ?;
 
 -- name: GetLastSessionByExchange :one
@@ -46,10 +49,11 @@ LIMIT 1;
 -- name: ListMessagesBySessionAsc :many
 SELECT id, session_id, exchange, routing_key, body, content_type,
        headers, timestamp, consumed_at, proto_type, correlation_id,
-       reply_to, message_id, app_id
+       reply_to, message_id, app_id, decoded_json,
+       delivery_mode, priority, expiration
 FROM messages
 WHERE session_id = ?
-ORDER BY consumed_at ASC
+ORDER BY consumed_at ASC, id ASC
 LIMIT ? OFFSET ?;
 
 -- name: CountMessagesBySession :one
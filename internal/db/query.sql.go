@@ -8,6 +8,7 @@ package db
 import (
 	"context"
 	"database/sql"
+	"time"
 )
 
 const countMessagesBySession = `-- name: CountMessagesBySession :one
@@ -90,7 +91,8 @@ func (q *Queries) GetLastSessionByExchange(ctx context.Context, exchange string)
 const getMessage = `-- name: GetMessage :one
 SELECT id, session_id, exchange, routing_key, body, content_type,
        headers, timestamp, consumed_at, proto_type, correlation_id,
-       reply_to, message_id, app_id
+       reply_to, message_id, app_id, decoded_json,
+       delivery_mode, priority, expiration
 FROM messages
 WHERE id = ?
 `
@@ -113,6 +115,10 @@ func (q *Queries) GetMessage(ctx context.Context, id int64) (Message, error) {
 		&i.ReplyTo,
 		&i.MessageID,
 		&i.AppID,
+		&i.DecodedJson,
+		&i.DeliveryMode,
+		&i.Priority,
+		&i.Expiration,
 	)
 	return i, err
 }
@@ -120,9 +126,10 @@ func (q *Queries) GetMessage(ctx context.Context, id int64) (Message, error) {
 const insertMessage = `-- name: InsertMessage :one
 INSERT INTO messages (
     session_id, exchange, routing_key, body, content_type,
-    headers, timestamp, proto_type, correlation_id, reply_to,
-    message_id, app_id
-) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+    headers, timestamp, consumed_at, proto_type, correlation_id,
+    reply_to, message_id, app_id, decoded_json, delivery_mode,
+    priority, expiration
+) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 RETURNING id
 `
 
@@ -134,11 +141,16 @@ type InsertMessageParams struct {
 	ContentType   sql.NullString `json:"content_type"`
 	Headers       sql.NullString `json:"headers"`
 	Timestamp     sql.NullTime   `json:"timestamp"`
+	ConsumedAt    time.Time      `json:"consumed_at"`
 	ProtoType     sql.NullString `json:"proto_type"`
 	CorrelationID sql.NullString `json:"correlation_id"`
 	ReplyTo       sql.NullString `json:"reply_to"`
 	MessageID     sql.NullString `json:"message_id"`
 	AppID         sql.NullString `json:"app_id"`
+	DecodedJson   sql.NullString `json:"decoded_json"`
+	DeliveryMode  sql.NullInt64  `json:"delivery_mode"`
+	Priority      sql.NullInt64  `json:"priority"`
+	Expiration    sql.NullString `json:"expiration"`
 }
 
 func (q *Queries) InsertMessage(ctx context.Context, arg InsertMessageParams) (int64, error) {
@@ -150,11 +162,16 @@ func (q *Queries) InsertMessage(ctx context.Context, arg InsertMessageParams) (i
 		arg.ContentType,
 		arg.Headers,
 		arg.Timestamp,
+		arg.ConsumedAt,
 		arg.ProtoType,
 		arg.CorrelationID,
 		arg.ReplyTo,
 		arg.MessageID,
 		arg.AppID,
+		arg.DecodedJson,
+		arg.DeliveryMode,
+		arg.Priority,
+		arg.Expiration,
 	)
 	var id int64
 	err := row.Scan(&id)
@@ -164,10 +181,11 @@ func (q *Queries) InsertMessage(ctx context.Context, arg InsertMessageParams) (i
 const listMessagesBySession = `-- name: ListMessagesBySession :many
 SELECT id, session_id, exchange, routing_key, body, content_type,
        headers, timestamp, consumed_at, proto_type, correlation_id,
-       reply_to, message_id, app_id
+       reply_to, message_id, app_id, decoded_json,
+       delivery_mode, priority, expiration
 FROM messages
 WHERE session_id = ?
-ORDER BY consumed_at DESC
+ORDER BY consumed_at DESC, id DESC
 LIMIT ? OFFSET ?
 `
 
@@ -201,6 +219,10 @@ func (q *Queries) ListMessagesBySession(ctx context.Context, arg ListMessagesByS
 			&i.ReplyTo,
 			&i.MessageID,
 			&i.AppID,
+			&i.DecodedJson,
+			&i.DeliveryMode,
+			&i.Priority,
+			&i.Expiration,
 		); err != nil {
 			return nil, err
 		}
@@ -218,10 +240,11 @@ func (q *Queries) ListMessagesBySession(ctx context.Context, arg ListMessagesByS
 const listMessagesBySessionAsc = `-- name: ListMessagesBySessionAsc :many
 SELECT id, session_id, exchange, routing_key, body, content_type,
        headers, timestamp, consumed_at, proto_type, correlation_id,
-       reply_to, message_id, app_id
+       reply_to, message_id, app_id, decoded_json,
+       delivery_mode, priority, expiration
 FROM messages
 WHERE session_id = ?
-ORDER BY consumed_at ASC
+ORDER BY consumed_at ASC, id ASC
 LIMIT ? OFFSET ?
 `
 
@@ -255,6 +278,10 @@ func (q *Queries) ListMessagesBySessionAsc(ctx context.Context, arg ListMessages
 			&i.ReplyTo,
 			&i.MessageID,
 			&i.AppID,
+			&i.DecodedJson,
+			&i.DeliveryMode,
+			&i.Priority,
+			&i.Expiration,
 		); err != nil {
 			return nil, err
 		}
@@ -0,0 +1,108 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// reindexPageSize is the number of messages decoded and updated per transaction.
+const reindexPageSize = 500
+
+// DecodeFunc decodes a message body, using the routing key as a type hint.
+// It matches proto.Decoder.DecodeWithHintAndType.
+type DecodeFunc func(body []byte, routingKey string) (map[string]any, string, error)
+
+// ReindexResult counts what ReindexDecoded did.
+type ReindexResult struct {
+	Scanned int // messages read
+	Decoded int // messages whose decoded body was stored
+	Failed  int // messages the current schemas could not decode, left unchanged
+}
+
+// ReindexDecoded re-decodes stored messages with decode and saves the decoded
+// JSON and proto type, which refreshes the search index through the update
+// trigger. sessionID limits it to one session; 0 means every message.
+// progress, if not nil, is called after each page with the running totals.
+func (s *SQLiteStore) ReindexDecoded(ctx context.Context, sessionID int64, decode DecodeFunc, progress func(ReindexResult)) (ReindexResult, error) {
+	var res ReindexResult
+	for lastID := int64(0); ; {
+		page, err := s.reindexPage(ctx, sessionID, lastID)
+		if err != nil {
+			return res, fmt.Errorf("failed to load messages: %w", err)
+		}
+		if len(page) == 0 {
+			return res, nil
+		}
+
+		if err := s.updateDecoded(ctx, page, decode, &res); err != nil {
+			return res, fmt.Errorf("failed to update messages: %w", err)
+		}
+		lastID = page[len(page)-1].id
+		if progress != nil {
+			progress(res)
+		}
+	}
+}
+
+type reindexRow struct {
+	id         int64
+	body       []byte
+	routingKey string
+}
+
+func (s *SQLiteStore) reindexPage(ctx context.Context, sessionID, afterID int64) (_ []reindexRow, err error) {
+	rows, err := s.db.QueryContext(ctx, `
+SELECT id, body, routing_key FROM messages
+WHERE id > ? AND (? = 0 OR session_id = ?)
+ORDER BY id
+LIMIT ?
+`, afterID, sessionID, sessionID, reindexPageSize)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { err = errors.Join(err, rows.Close()) }()
+
+	var page []reindexRow
+	for rows.Next() {
+		var r reindexRow
+		if err := rows.Scan(&r.id, &r.body, &r.routingKey); err != nil {
+			return nil, err
+		}
+		page = append(page, r)
+	}
+	return page, rows.Err()
+}
+
+func (s *SQLiteStore) updateDecoded(ctx context.Context, page []reindexRow, decode DecodeFunc, res *ReindexResult) (err error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			err = errors.Join(err, tx.Rollback())
+		}
+	}()
+
+	for _, r := range page {
+		res.Scanned++
+		decoded, protoType, decodeErr := decode(r.body, r.routingKey)
+		if decodeErr != nil {
+			res.Failed++
+			continue
+		}
+		data, err := json.Marshal(decoded)
+		if err != nil {
+			res.Failed++
+			continue
+		}
+		if _, err := tx.ExecContext(ctx, "UPDATE messages SET decoded_json = ?, proto_type = ? WHERE id = ?",
+			string(data), toNullString(protoType), r.id); err != nil {
+			return err
+		}
+		res.Decoded++
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,99 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestStore_SearchDecodedAndMetadata(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+
+	sid, _ := store.CreateSession(ctx, "orders", "#", "q", "amqp://localhost/")
+	_, err := store.InsertMessage(ctx, &MessageRecord{
+		SessionID:     sid,
+		Exchange:      "orders",
+		RoutingKey:    "order.created",
+		Body:          []byte{0x0a, 0x05, 0x01, 0xff},
+		Headers:       map[string]any{"tenant": "acme-corp"},
+		ProtoType:     "shop.OrderCreated",
+		CorrelationID: "corr-4242",
+		Decoded:       map[string]any{"customer": "wile-coyote"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, query := range []string{"wile-coyote", "acme-corp", "shop.OrderCreated", "corr-4242"} {
+		msgs, err := store.SearchMessages(ctx, query, 10, 0)
+		if err != nil {
+			t.Fatalf("search %q: %v", query, err)
+		}
+		if len(msgs) != 1 {
+			t.Errorf("search %q: got %d results, want 1", query, len(msgs))
+			continue
+		}
+		if !msgs[0].DecodedJson.Valid {
+			t.Errorf("search %q: decoded_json not returned", query)
+		}
+	}
+}
+
+func TestStore_ReindexDecoded(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+
+	sid1, _ := store.CreateSession(ctx, "orders", "#", "q", "amqp://localhost/")
+	sid2, _ := store.CreateSession(ctx, "orders", "#", "q", "amqp://localhost/")
+	for _, m := range []struct {
+		sid int64
+		rk  string
+	}{
+		{sid1, "order.created"},
+		{sid1, "order.unknown"},
+		{sid2, "order.created"},
+	} {
+		if _, err := store.InsertMessage(ctx, &MessageRecord{SessionID: m.sid, Exchange: "orders", RoutingKey: m.rk, Body: []byte{0x08, 0x2a}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	decode := func(body []byte, routingKey string) (map[string]any, string, error) {
+		if routingKey != "order.created" {
+			return nil, "", errors.New("no matching type")
+		}
+		return map[string]any{"order_id": "ord-77"}, "shop.OrderCreated", nil
+	}
+
+	res, err := store.ReindexDecoded(ctx, sid1, decode, nil)
+	if err != nil {
+		t.Fatalf("ReindexDecoded: %v", err)
+	}
+	if want := (ReindexResult{Scanned: 2, Decoded: 1, Failed: 1}); res != want {
+		t.Errorf("result = %+v, want %+v", res, want)
+	}
+
+	msgs, err := store.SearchMessages(ctx, "ord-77", 10, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(msgs) != 1 || msgs[0].SessionID != sid1 {
+		t.Fatalf("expected only the reindexed message of session %d, got %+v", sid1, msgs)
+	}
+	if msgs[0].ProtoType.String != "shop.OrderCreated" {
+		t.Errorf("proto type = %q", msgs[0].ProtoType.String)
+	}
+
+	// All sessions
+	res, err = store.ReindexDecoded(ctx, 0, decode, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Scanned != 3 || res.Decoded != 2 {
+		t.Errorf("result = %+v, want 3 scanned, 2 decoded", res)
+	}
+	if msgs, _ := store.SearchMessages(ctx, "ord-77", 10, 0); len(msgs) != 2 {
+		t.Errorf("expected 2 results after full reindex, got %d", len(msgs))
+	}
+}
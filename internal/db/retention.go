@@ -0,0 +1,410 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"net/url"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/epalmerini/rabbithole/internal/config"
+)
+
+// Retention is the pruning policy for the database.
+type Retention struct {
+	// Default applies to every session; its MaxSize bounds the whole database.
+	Default config.Retention
+
+	// BySource replaces the default age and session limits for sessions
+	// recorded from a broker, keyed by sanitized AMQP URL. Sessions recorded
+	// from another vhost of the same broker fall back to its policy.
+	BySource map[string]config.Retention
+}
+
+// policyFor returns the policy for sessions recorded from amqpURL, and the
+// BySource key it came from, or "" for the default.
+func (r Retention) policyFor(amqpURL string) (string, config.Retention) {
+	if p, ok := r.BySource[amqpURL]; ok {
+		return amqpURL, p
+	}
+	broker := withoutVHost(amqpURL)
+	for _, key := range slices.Sorted(maps.Keys(r.BySource)) {
+		if withoutVHost(key) == broker {
+			return key, r.BySource[key]
+		}
+	}
+	return "", r.Default
+}
+
+// withoutVHost strips the vhost path from an AMQP URL.
+func withoutVHost(amqpURL string) string {
+	u, err := url.Parse(amqpURL)
+	if err != nil {
+		return amqpURL
+	}
+	u.Path, u.RawPath = "", ""
+	return u.String()
+}
+
+// SessionHeartbeat is how often a recording session's writer calls
+// TouchSession. Sessions seen within liveWindow are never pruned.
+const (
+	SessionHeartbeat = time.Minute
+	liveWindow       = 3 * SessionHeartbeat
+)
+
+// TouchSession records that a process is still recording the session.
+func (s *SQLiteStore) TouchSession(ctx context.Context, sessionID int64) error {
+	_, err := s.db.ExecContext(ctx, `
+INSERT INTO session_heartbeats (session_id, seen_at) VALUES (?, CURRENT_TIMESTAMP)
+ON CONFLICT (session_id) DO UPDATE SET seen_at = excluded.seen_at`, sessionID)
+	return err
+}
+
+// KeepSessionAlive touches the session now and then every SessionHeartbeat
+// until ctx is done, so retention knows it is still being recorded.
+func KeepSessionAlive(ctx context.Context, store Store, sessionID int64) {
+	ticker := time.NewTicker(SessionHeartbeat)
+	defer ticker.Stop()
+	for {
+		_ = store.TouchSession(ctx, sessionID)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Enabled reports whether any limit is set.
+func (r Retention) Enabled() bool {
+	if r.Default.Enabled() {
+		return true
+	}
+	for _, p := range r.BySource {
+		if p.Enabled() {
+			return true
+		}
+	}
+	return false
+}
+
+// RetentionFromConfig builds the policy from the [retention] table and the
+// [profiles.NAME.retention] overrides.
+func RetentionFromConfig(fc *config.FileConfig) (Retention, error) {
+	def, err := fc.Retention.Parse()
+	if err != nil {
+		return Retention{}, err
+	}
+	r := Retention{Default: def}
+
+	for _, name := range fc.ProfileNames() {
+		p := fc.Profiles[name]
+		if p.Retention == (config.RetentionConfig{}) || p.URL == "" {
+			continue
+		}
+		policy, err := fc.Retention.Override(p.Retention).Parse()
+		if err != nil {
+			return Retention{}, fmt.Errorf("profile %q: %w", name, err)
+		}
+		if r.BySource == nil {
+			r.BySource = make(map[string]config.Retention)
+		}
+		r.BySource[SanitizeAMQPURL(p.URL)] = policy
+	}
+	return r, nil
+}
+
+// SessionUsage is a session with the space its messages take.
+type SessionUsage struct {
+	ID           int64
+	Exchange     string
+	RoutingKey   string
+	AMQPURL      string
+	StartedAt    time.Time
+	LastActivity time.Time // end of the session, or its newest message while open
+	Open         bool
+	Heartbeat    time.Time // last TouchSession while open; zero if never
+	Messages     int64
+	Bytes        int64 // message bodies and headers
+}
+
+// PruneCandidate is a session selected for deletion and why.
+type PruneCandidate struct {
+	SessionUsage
+	Reason string
+}
+
+// PrunePlan lists the sessions a Retention policy would delete.
+type PrunePlan struct {
+	Delete       []PruneCandidate
+	Sessions     int   // sessions in the database
+	DBSize       int64 // bytes in use by the database file
+	EstFreed     int64 // estimated bytes freed by the deletions
+	OpenSessions int   // sessions not ended, never pruned while live
+}
+
+// Live reports whether the session is open and its writer was heard from
+// recently.
+func (u SessionUsage) Live(now time.Time) bool {
+	return u.Open && !u.Heartbeat.IsZero() && now.Sub(u.Heartbeat) < liveWindow
+}
+
+// SessionUsage returns every session with its message count and size, newest first.
+func (s *SQLiteStore) SessionUsage(ctx context.Context) (_ []SessionUsage, err error) {
+	const usageQuery = `
+SELECT s.id, s.exchange, s.routing_key, s.amqp_url, s.ended_at IS NULL,
+       COALESCE(CAST(strftime('%s', s.started_at) AS INTEGER), 0),
+       COALESCE(CAST(unix_time(COALESCE(s.ended_at, MAX(m.consumed_at), s.started_at)) AS INTEGER), 0),
+       COALESCE(CAST(unix_time(h.seen_at) AS INTEGER), 0),
+       COUNT(m.id),
+       COALESCE(SUM(LENGTH(m.body) + COALESCE(LENGTH(m.headers), 0)), 0)
+FROM sessions s
+LEFT JOIN session_heartbeats h ON h.session_id = s.id
+LEFT JOIN messages m ON m.session_id = s.id
+GROUP BY s.id
+ORDER BY s.started_at DESC, s.id DESC
+`
+	rows, err := s.db.QueryContext(ctx, usageQuery)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { err = errors.Join(err, rows.Close()) }()
+
+	var sessions []SessionUsage
+	for rows.Next() {
+		var u SessionUsage
+		var started, last, heartbeat int64
+		if err := rows.Scan(&u.ID, &u.Exchange, &u.RoutingKey, &u.AMQPURL, &u.Open, &started, &last, &heartbeat, &u.Messages, &u.Bytes); err != nil {
+			return nil, err
+		}
+		u.StartedAt = time.Unix(started, 0)
+		u.LastActivity = time.Unix(last, 0)
+		if heartbeat > 0 {
+			u.Heartbeat = time.Unix(heartbeat, 0)
+		}
+		sessions = append(sessions, u)
+	}
+	return sessions, rows.Err()
+}
+
+// DBSize returns the bytes in use by the database file, excluding free pages.
+func (s *SQLiteStore) DBSize(ctx context.Context) (int64, error) {
+	var pageCount, freePages, pageSize int64
+	if err := s.db.QueryRowContext(ctx, "PRAGMA page_count").Scan(&pageCount); err != nil {
+		return 0, err
+	}
+	if err := s.db.QueryRowContext(ctx, "PRAGMA freelist_count").Scan(&freePages); err != nil {
+		return 0, err
+	}
+	if err := s.db.QueryRowContext(ctx, "PRAGMA page_size").Scan(&pageSize); err != nil {
+		return 0, err
+	}
+	return (pageCount - freePages) * pageSize, nil
+}
+
+// PlanPrune works out which sessions r would delete at time now.
+func (s *SQLiteStore) PlanPrune(ctx context.Context, r Retention, now time.Time) (PrunePlan, error) {
+	sessions, err := s.SessionUsage(ctx)
+	if err != nil {
+		return PrunePlan{}, fmt.Errorf("failed to list sessions: %w", err)
+	}
+	dbSize, err := s.DBSize(ctx)
+	if err != nil {
+		return PrunePlan{}, fmt.Errorf("failed to read database size: %w", err)
+	}
+	return planPrune(sessions, dbSize, r, now), nil
+}
+
+// planPrune applies the policy to sessions, which must be ordered newest first.
+// Age and session-count limits apply per source; the size limit then removes
+// the oldest remaining sessions until the estimated size fits. Open sessions
+// are only pruned by age, and only once no writer is live.
+func planPrune(sessions []SessionUsage, dbSize int64, r Retention, now time.Time) PrunePlan {
+	plan := PrunePlan{Sessions: len(sessions), DBSize: dbSize}
+
+	var payload int64
+	for _, u := range sessions {
+		payload += u.Bytes
+	}
+	// Scale payload bytes to file bytes to account for indexes and FTS
+	ratio := 1.0
+	if payload > 0 && dbSize > payload {
+		ratio = float64(dbSize) / float64(payload)
+	}
+
+	deleted := make(map[int64]bool)
+	mark := func(u SessionUsage, reason string) {
+		deleted[u.ID] = true
+		plan.Delete = append(plan.Delete, PruneCandidate{SessionUsage: u, Reason: reason})
+	}
+
+	// Sessions from a broker with an override are counted separately; all
+	// others share the default limit
+	kept := make(map[string]int)
+	for _, u := range sessions {
+		scope, policy := r.policyFor(u.AMQPURL)
+		if u.Open {
+			plan.OpenSessions++
+		}
+
+		switch {
+		case u.Live(now):
+			// Still recording, however quiet the queue
+		case policy.MaxAge > 0 && now.Sub(u.LastActivity) > policy.MaxAge:
+			mark(u, fmt.Sprintf("older than %s", formatAge(policy.MaxAge)))
+		case u.Open:
+			// Never cut a recording short on count or size
+		case policy.MaxSessions > 0 && kept[scope] >= policy.MaxSessions:
+			mark(u, fmt.Sprintf("beyond the newest %d sessions", policy.MaxSessions))
+		default:
+			kept[scope]++
+		}
+	}
+
+	var freed float64
+	for _, c := range plan.Delete {
+		freed += float64(c.Bytes) * ratio
+	}
+
+	if limit := r.Default.MaxSize; limit > 0 {
+		remaining := float64(dbSize) - freed
+		for i := len(sessions) - 1; i >= 0 && remaining > float64(limit); i-- {
+			u := sessions[i]
+			if deleted[u.ID] || u.Open {
+				continue
+			}
+			mark(u, fmt.Sprintf("database over %s", FormatBytes(limit)))
+			size := float64(u.Bytes) * ratio
+			remaining -= size
+			freed += size
+		}
+	}
+
+	sort.SliceStable(plan.Delete, func(i, j int) bool {
+		return plan.Delete[i].StartedAt.Before(plan.Delete[j].StartedAt)
+	})
+	plan.EstFreed = int64(freed)
+	return plan
+}
+
+// Prune deletes the planned sessions, then compacts the full-text index and
+// returns free pages to the filesystem where the database allows it.
+func (s *SQLiteStore) Prune(ctx context.Context, plan PrunePlan) (int, error) {
+	deleted := 0
+	for _, c := range plan.Delete {
+		if err := s.deleteSessionMessages(ctx, c.ID); err != nil {
+			return deleted, fmt.Errorf("failed to delete session %d: %w", c.ID, err)
+		}
+		deleted++
+	}
+	if deleted == 0 {
+		return 0, nil
+	}
+
+	if _, err := s.db.ExecContext(ctx, "INSERT INTO messages_fts(messages_fts) VALUES('optimize')"); err != nil {
+		return deleted, fmt.Errorf("failed to optimize search index: %w", err)
+	}
+	if _, err := s.db.ExecContext(ctx, "PRAGMA incremental_vacuum"); err != nil {
+		return deleted, fmt.Errorf("failed to vacuum: %w", err)
+	}
+	return deleted, nil
+}
+
+// deleteSessionMessages removes a session and its messages in one transaction.
+// Messages are deleted explicitly so the FTS triggers fire even when foreign
+// key enforcement is off on the connection.
+func (s *SQLiteStore) deleteSessionMessages(ctx context.Context, sessionID int64) (err error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			err = errors.Join(err, tx.Rollback())
+		}
+	}()
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM messages WHERE session_id = ?", sessionID); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, "DELETE FROM sessions WHERE id = ?", sessionID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// IncrementalVacuum reports whether freed pages are returned to the
+// filesystem after deletes. Databases created before retention support need
+// a one-off EnableIncrementalVacuum.
+func (s *SQLiteStore) IncrementalVacuum(ctx context.Context) (bool, error) {
+	var mode int
+	if err := s.db.QueryRowContext(ctx, "PRAGMA auto_vacuum").Scan(&mode); err != nil {
+		return false, err
+	}
+	return mode == 2, nil
+}
+
+// EnableIncrementalVacuum switches the database to incremental auto-vacuum.
+// This rewrites the whole file and blocks other writers while it runs.
+func (s *SQLiteStore) EnableIncrementalVacuum(ctx context.Context) error {
+	conn, err := s.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = conn.Close() }()
+
+	if _, err := conn.ExecContext(ctx, "PRAGMA auto_vacuum = INCREMENTAL"); err != nil {
+		return err
+	}
+	_, err = conn.ExecContext(ctx, "VACUUM")
+	return err
+}
+
+func formatAge(d time.Duration) string {
+	if d >= 24*time.Hour && d%(24*time.Hour) == 0 {
+		return fmt.Sprintf("%dd", d/(24*time.Hour))
+	}
+	return d.String()
+}
+
+// FormatBytes formats a byte count with binary units.
+func FormatBytes(b int64) string {
+	const unit = 1024
+	if b < unit {
+		return fmt.Sprintf("%d B", b)
+	}
+	div, exp := int64(unit), 0
+	for n := b / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(b)/float64(div), "KMGT"[exp])
+}
+
+// RunPruner applies r immediately and then every interval until ctx is done.
+// It is meant to run in the background, so failures are passed to onError
+// (if not nil) rather than stopping it.
+func (s *SQLiteStore) RunPruner(ctx context.Context, r Retention, interval time.Duration, onError func(error)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		plan, err := s.PlanPrune(ctx, r, time.Now())
+		if err == nil {
+			_, err = s.Prune(ctx, plan)
+		}
+		if err != nil && ctx.Err() == nil && onError != nil {
+			onError(err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -3,7 +3,6 @@ package db
 import (
 	"context"
 	"database/sql"
-	_ "embed"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -17,13 +16,11 @@ import (
 	_ "modernc.org/sqlite"
 )
 
-//go:embed schema.sql
-var schemaSQL string
-
 // Store defines the interface for message persistence
 type Store interface {
 	CreateSession(ctx context.Context, exchange, routingKey, queueName, amqpURL string) (int64, error)
 	EndSession(ctx context.Context, sessionID int64) error
+	TouchSession(ctx context.Context, sessionID int64) error
 	ListRecentSessions(ctx context.Context, limit int64) ([]Session, error)
 	GetLastSessionByExchange(ctx context.Context, exchange string) (*Session, error)
 	InsertMessage(ctx context.Context, msg *MessageRecord) (int64, error)
@@ -35,6 +32,17 @@ type Store interface {
 	CountMessagesBySession(ctx context.Context, sessionID int64) (int64, error)
 	DeleteSession(ctx context.Context, sessionID int64) error
 	SearchSessionsByContent(ctx context.Context, query string, limit int64) ([]int64, error)
+	QueryMessages(ctx context.Context, where string, args []any, sessionID, limit, offset int64) ([]Message, error)
+	QuerySessions(ctx context.Context, where string, args []any, limit int64) ([]int64, error)
+	CountQueryMessages(ctx context.Context, where string, args []any, sessionID int64) (int64, error)
+	NextQueryMessage(ctx context.Context, where string, args []any, sessionID, id int64, forward bool) (int64, error)
+	TraceMessages(ctx context.Context, keys TraceKeys, limit int64) ([]Message, error)
+	GroupMessages(ctx context.Context, where string, args []any, sessionID int64, key string, keyArgs []any, limit int64) ([]MessageGroup, error)
+	SetAnnotation(ctx context.Context, messageID int64, a Annotation) error
+	GetAnnotations(ctx context.Context, ids []int64) (map[int64]Annotation, error)
+	ListAnnotated(ctx context.Context, limit int64) ([]AnnotatedMessage, error)
+	AddHistory(ctx context.Context, kind, entry string, keep int) error
+	ListHistory(ctx context.Context, kind string, limit int) ([]string, error)
 	Close() error
 }
 
@@ -47,11 +55,17 @@ type MessageRecord struct {
 	ContentType   string
 	Headers       map[string]any
 	Timestamp     time.Time
+	ConsumedAt    time.Time // arrival time; now when zero
 	ProtoType     string
 	CorrelationID string
 	ReplyTo       string
 	MessageID     string
 	AppID         string
+	DeliveryMode  uint8 // 0 when unset, 1 transient, 2 persistent
+	Priority      uint8
+	Expiration    string
+	Decoded       map[string]any // decoded protobuf body, indexed for search
+	Annotation    Annotation     // saved with the message unless zero
 }
 
 // SQLiteStore implements Store using SQLite
@@ -79,14 +93,18 @@ func NewStore(customPath string) (*SQLiteStore, error) {
 		return nil, fmt.Errorf("failed to open database: %w", err)
 	}
 
-	// Enable foreign keys and WAL mode for better performance
-	if _, err := db.Exec("PRAGMA foreign_keys = ON; PRAGMA journal_mode = WAL;"); err != nil {
+	// Enable foreign keys and WAL mode for better performance. Incremental
+	// auto-vacuum only takes effect on new databases; see EnableIncrementalVacuum.
+	if _, err := db.Exec("PRAGMA auto_vacuum = INCREMENTAL; PRAGMA foreign_keys = ON; PRAGMA journal_mode = WAL;"); err != nil {
 		return nil, errors.Join(fmt.Errorf("failed to set pragmas: %w", err), db.Close())
 	}
 
-	// Initialize schema
-	if err := initSchema(db); err != nil {
-		return nil, errors.Join(fmt.Errorf("failed to initialize schema: %w", err), db.Close())
+	migrations, err := loadMigrations(migrationFiles)
+	if err != nil {
+		return nil, errors.Join(fmt.Errorf("failed to load migrations: %w", err), db.Close())
+	}
+	if err := migrate(context.Background(), db, dbPath, migrations); err != nil {
+		return nil, errors.Join(fmt.Errorf("failed to migrate schema: %w", err), db.Close())
 	}
 
 	return &SQLiteStore{
@@ -100,13 +118,6 @@ func DefaultDataDir() (string, error) {
 	return xdg.Dir("XDG_DATA_HOME", ".local/share")
 }
 
-func initSchema(db *sql.DB) error {
-	if _, err := db.Exec(schemaSQL); err != nil {
-		return err
-	}
-	return nil
-}
-
 // SanitizeAMQPURL removes password from AMQP URL for storage
 func SanitizeAMQPURL(amqpURL string) string {
 	u, err := url.Parse(amqpURL)
@@ -145,7 +156,21 @@ func (s *SQLiteStore) InsertMessage(ctx context.Context, msg *MessageRecord) (in
 		}
 	}
 
-	return s.queries.InsertMessage(ctx, InsertMessageParams{
+	var decodedJSON sql.NullString
+	if msg.Decoded != nil {
+		data, err := json.Marshal(msg.Decoded)
+		if err == nil {
+			decodedJSON = sql.NullString{String: string(data), Valid: true}
+		}
+	}
+
+	// Stored in UTC so it sorts with the CURRENT_TIMESTAMP values of older rows
+	consumedAt := msg.ConsumedAt
+	if consumedAt.IsZero() {
+		consumedAt = time.Now()
+	}
+
+	id, err := s.queries.InsertMessage(ctx, InsertMessageParams{
 		SessionID:     msg.SessionID,
 		Exchange:      msg.Exchange,
 		RoutingKey:    msg.RoutingKey,
@@ -153,12 +178,21 @@ func (s *SQLiteStore) InsertMessage(ctx context.Context, msg *MessageRecord) (in
 		ContentType:   toNullString(msg.ContentType),
 		Headers:       headersJSON,
 		Timestamp:     toNullTime(msg.Timestamp),
+		ConsumedAt:    consumedAt.UTC(),
 		ProtoType:     toNullString(msg.ProtoType),
 		CorrelationID: toNullString(msg.CorrelationID),
 		ReplyTo:       toNullString(msg.ReplyTo),
 		MessageID:     toNullString(msg.MessageID),
 		AppID:         toNullString(msg.AppID),
+		DecodedJson:   decodedJSON,
+		DeliveryMode:  toNullInt(msg.DeliveryMode),
+		Priority:      toNullInt(msg.Priority),
+		Expiration:    toNullString(msg.Expiration),
 	})
+	if err != nil || msg.Annotation.IsZero() {
+		return id, err
+	}
+	return id, s.SetAnnotation(ctx, id, msg.Annotation)
 }
 
 func (s *SQLiteStore) GetMessage(ctx context.Context, id int64) (*Message, error) {
@@ -197,7 +231,8 @@ func (s *SQLiteStore) SearchMessages(ctx context.Context, query string, limit, o
 	const searchQuery = `
 SELECT m.id, m.session_id, m.exchange, m.routing_key, m.body, m.content_type,
        m.headers, m.timestamp, m.consumed_at, m.proto_type, m.correlation_id,
-       m.reply_to, m.message_id, m.app_id
+       m.reply_to, m.message_id, m.app_id, m.decoded_json,
+       m.delivery_mode, m.priority, m.expiration
 FROM messages m
 JOIN messages_fts fts ON m.id = fts.rowid
 WHERE messages_fts MATCH ?
@@ -211,7 +246,8 @@ func (s *SQLiteStore) SearchMessagesInSession(ctx context.Context, query string,
 	const searchQuery = `
 SELECT m.id, m.session_id, m.exchange, m.routing_key, m.body, m.content_type,
        m.headers, m.timestamp, m.consumed_at, m.proto_type, m.correlation_id,
-       m.reply_to, m.message_id, m.app_id
+       m.reply_to, m.message_id, m.app_id, m.decoded_json,
+       m.delivery_mode, m.priority, m.expiration
 FROM messages m
 JOIN messages_fts fts ON m.id = fts.rowid
 WHERE messages_fts MATCH ? AND m.session_id = ?
@@ -241,7 +277,8 @@ func (s *SQLiteStore) scanMessages(ctx context.Context, query string, args ...an
 		if err := rows.Scan(
 			&m.ID, &m.SessionID, &m.Exchange, &m.RoutingKey, &m.Body, &m.ContentType,
 			&m.Headers, &m.Timestamp, &m.ConsumedAt, &m.ProtoType, &m.CorrelationID,
-			&m.ReplyTo, &m.MessageID, &m.AppID,
+			&m.ReplyTo, &m.MessageID, &m.AppID, &m.DecodedJson,
+			&m.DeliveryMode, &m.Priority, &m.Expiration,
 		); err != nil {
 			return nil, err
 		}
@@ -283,6 +320,85 @@ LIMIT ?
 	return sessionIDs, rows.Err()
 }
 
+// QueryMessages returns messages matching where, a condition over the
+// messages table aliased m as rendered by the query package, oldest first.
+// sessionID limits the search to one session; 0 searches all of them.
+func (s *SQLiteStore) QueryMessages(ctx context.Context, where string, args []any, sessionID, limit, offset int64) ([]Message, error) {
+	q := `
+SELECT m.id, m.session_id, m.exchange, m.routing_key, m.body, m.content_type,
+       m.headers, m.timestamp, m.consumed_at, m.proto_type, m.correlation_id,
+       m.reply_to, m.message_id, m.app_id, m.decoded_json,
+       m.delivery_mode, m.priority, m.expiration
+FROM messages m
+WHERE (? = 0 OR m.session_id = ?) AND ` + where + `
+ORDER BY m.id
+LIMIT ? OFFSET ?
+`
+	all := append([]any{sessionID, sessionID}, args...)
+	return s.scanMessages(ctx, q, append(all, limit, offset)...)
+}
+
+// CountQueryMessages returns how many messages match where. sessionID limits
+// the count to one session; 0 counts all of them.
+func (s *SQLiteStore) CountQueryMessages(ctx context.Context, where string, args []any, sessionID int64) (int64, error) {
+	q := `
+SELECT COUNT(*)
+FROM messages m
+WHERE (? = 0 OR m.session_id = ?) AND ` + where
+	var n int64
+	err := s.db.QueryRowContext(ctx, q, append([]any{sessionID, sessionID}, args...)...).Scan(&n)
+	return n, err
+}
+
+// NextQueryMessage returns the ID of the first message matching where after
+// id, or with forward unset the last one before it; 0 if there is none.
+func (s *SQLiteStore) NextQueryMessage(ctx context.Context, where string, args []any, sessionID, id int64, forward bool) (int64, error) {
+	cmp, order := ">", "ASC"
+	if !forward {
+		cmp, order = "<", "DESC"
+	}
+	q := `
+SELECT m.id
+FROM messages m
+WHERE (? = 0 OR m.session_id = ?) AND m.id ` + cmp + ` ? AND ` + where + `
+ORDER BY m.id ` + order + `
+LIMIT 1
+`
+	var next int64
+	err := s.db.QueryRowContext(ctx, q, append([]any{sessionID, sessionID, id}, args...)...).Scan(&next)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, nil
+	}
+	return next, err
+}
+
+// QuerySessions returns the IDs of sessions with a message matching where,
+// newest first.
+func (s *SQLiteStore) QuerySessions(ctx context.Context, where string, args []any, limit int64) (_ []int64, err error) {
+	q := `
+SELECT DISTINCT m.session_id
+FROM messages m
+WHERE ` + where + `
+ORDER BY m.session_id DESC
+LIMIT ?
+`
+	rows, err := s.db.QueryContext(ctx, q, append(append([]any{}, args...), limit)...)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { err = errors.Join(err, rows.Close()) }()
+
+	var sessionIDs []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		sessionIDs = append(sessionIDs, id)
+	}
+	return sessionIDs, rows.Err()
+}
+
 func (s *SQLiteStore) Close() error {
 	return s.db.Close()
 }
@@ -294,6 +410,13 @@ func toNullString(s string) sql.NullString {
 	return sql.NullString{String: s, Valid: true}
 }
 
+func toNullInt(n uint8) sql.NullInt64 {
+	if n == 0 {
+		return sql.NullInt64{}
+	}
+	return sql.NullInt64{Int64: int64(n), Valid: true}
+}
+
 func toNullTime(t time.Time) sql.NullTime {
 	if t.IsZero() {
 		return sql.NullTime{}
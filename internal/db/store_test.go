@@ -3,6 +3,7 @@ package db
 import (
 	"context"
 	"database/sql"
+	"fmt"
 	"testing"
 	"time"
 )
@@ -198,6 +199,52 @@ func TestStore_InsertAndGetMessage(t *testing.T) {
 	}
 }
 
+func TestStore_ConsumedAtAndDeliveryProperties(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+
+	sessionID, err := store.CreateSession(ctx, "ex", "#", "q1", "amqp://localhost/")
+	if err != nil {
+		t.Fatalf("CreateSession: %v", err)
+	}
+
+	// Arrival times keep sub-second precision and order the session
+	arrived := time.Date(2025, 1, 15, 10, 30, 0, 250_000_000, time.FixedZone("CET", 3600))
+	for i, offset := range []time.Duration{300 * time.Millisecond, 0} {
+		rec := &MessageRecord{
+			SessionID:    sessionID,
+			Exchange:     "ex",
+			RoutingKey:   fmt.Sprintf("rk.%d", i),
+			Body:         []byte("{}"),
+			ConsumedAt:   arrived.Add(offset),
+			DeliveryMode: 2,
+			Priority:     uint8(i),
+			Expiration:   "60000",
+		}
+		if _, err := store.InsertMessage(ctx, rec); err != nil {
+			t.Fatalf("InsertMessage: %v", err)
+		}
+	}
+
+	msgs, err := store.ListMessagesBySessionAsc(ctx, sessionID, 10, 0)
+	if err != nil {
+		t.Fatalf("ListMessagesBySessionAsc: %v", err)
+	}
+	if len(msgs) != 2 || msgs[0].RoutingKey != "rk.1" {
+		t.Fatalf("messages = %+v, want rk.1 first", msgs)
+	}
+	m := msgs[0]
+	if !m.ConsumedAt.Equal(arrived) {
+		t.Errorf("ConsumedAt = %v, want %v", m.ConsumedAt, arrived)
+	}
+	if m.DeliveryMode.Int64 != 2 || m.Priority.Int64 != 1 || m.Expiration.String != "60000" {
+		t.Errorf("delivery mode, priority, expiration = %+v, %+v, %+v", m.DeliveryMode, m.Priority, m.Expiration)
+	}
+	if msgs[1].Priority.Valid {
+		t.Errorf("an unset priority should be NULL, got %+v", msgs[1].Priority)
+	}
+}
+
 func TestStore_ListMessagesBySession(t *testing.T) {
 	store := newTestStore(t)
 	ctx := context.Background()
@@ -464,3 +511,55 @@ func TestSanitizeFTS5Query(t *testing.T) {
 		}
 	}
 }
+
+func TestStore_CountAndNextQueryMessage(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+
+	sid, err := store.CreateSession(ctx, "orders", "#", "q", "amqp://localhost/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := store.CreateSession(ctx, "orders", "#", "q", "amqp://localhost/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ids []int64
+	for i, rk := range []string{"order.created", "order.paid", "user.created", "order.shipped"} {
+		session := sid
+		if i == 2 {
+			session = other
+		}
+		id, err := store.InsertMessage(ctx, &MessageRecord{SessionID: session, Exchange: "orders", RoutingKey: rk, Body: []byte(rk)})
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, id)
+	}
+
+	where, args := "m.routing_key LIKE ?", []any{"order.%"}
+	if n, err := store.CountQueryMessages(ctx, where, args, sid); err != nil || n != 3 {
+		t.Errorf("CountQueryMessages = %d, %v; want 3", n, err)
+	}
+	if n, err := store.CountQueryMessages(ctx, "1", nil, 0); err != nil || n != 4 {
+		t.Errorf("CountQueryMessages over all sessions = %d, %v; want 4", n, err)
+	}
+
+	tests := []struct {
+		from    int64
+		forward bool
+		want    int64
+	}{
+		{0, true, ids[0]},
+		{ids[1], true, ids[3]},
+		{ids[3], true, 0},
+		{ids[3], false, ids[1]},
+		{ids[0], false, 0},
+	}
+	for _, tt := range tests {
+		got, err := store.NextQueryMessage(ctx, where, args, sid, tt.from, tt.forward)
+		if err != nil || got != tt.want {
+			t.Errorf("NextQueryMessage(from %d, forward %v) = %d, %v; want %d", tt.from, tt.forward, got, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// TraceKeys identify the messages of one conversation, taken from the message
+// a trace starts at. Empty keys are ignored.
+type TraceKeys struct {
+	CorrelationID string
+	MessageID     string
+	ReplyTo       string // only marks replies to the message; not a key on its own
+	Header        string // configured trace header name, e.g. x-trace-id
+	HeaderValue   string
+}
+
+// IsZero reports whether there is nothing to trace by.
+func (k TraceKeys) IsZero() bool {
+	return k.CorrelationID == "" && k.MessageID == "" && (k.Header == "" || k.HeaderValue == "")
+}
+
+// ids returns the identifiers a related message may carry as either its
+// correlation or message id: replies usually correlate to the request's
+// message id, while saga steps share one correlation id.
+func (k TraceKeys) ids() []string {
+	var ids []string
+	for _, id := range []string{k.CorrelationID, k.MessageID} {
+		if id != "" && (len(ids) == 0 || ids[0] != id) {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// Match returns the property that links a message to the conversation, or ""
+// if it is unrelated. It is the in-memory form of the TraceMessages condition.
+// Messages correlated to a request that asked for replies are marked "reply".
+func (k TraceKeys) Match(correlationID, messageID string, headers map[string]any) string {
+	if k.ReplyTo != "" && k.MessageID != "" && correlationID == k.MessageID {
+		return "reply"
+	}
+	for _, id := range k.ids() {
+		if correlationID == id {
+			return "correlation_id"
+		}
+	}
+	for _, id := range k.ids() {
+		if messageID == id {
+			return "message_id"
+		}
+	}
+	if k.Header != "" && k.HeaderValue != "" {
+		if v, ok := headers[k.Header]; ok && v != nil && fmt.Sprint(v) == k.HeaderValue {
+			return k.Header
+		}
+	}
+	return ""
+}
+
+// TraceMessages returns the stored messages of every session that share one
+// of keys, ordered by arrival. Each key is looked up by its own query, so the
+// id indexes are used even when a trace header is configured.
+func (s *SQLiteStore) TraceMessages(ctx context.Context, keys TraceKeys, limit int64) ([]Message, error) {
+	var selects []string
+	var args []any
+
+	if ids := keys.ids(); len(ids) > 0 {
+		in := strings.TrimSuffix(strings.Repeat("?, ", len(ids)), ", ")
+		for _, col := range []string{"correlation_id", "message_id"} {
+			selects = append(selects, "SELECT id FROM messages WHERE "+col+" IN ("+in+")")
+			for _, id := range ids {
+				args = append(args, id)
+			}
+		}
+	}
+	if keys.Header != "" && keys.HeaderValue != "" {
+		selects = append(selects, "SELECT id FROM messages WHERE CAST(json_extract(headers, ?) AS TEXT) = ?")
+		args = append(args, fmt.Sprintf("$.%q", keys.Header), keys.HeaderValue)
+	}
+	if len(selects) == 0 {
+		return nil, nil
+	}
+
+	q := `
+SELECT m.id, m.session_id, m.exchange, m.routing_key, m.body, m.content_type,
+       m.headers, m.timestamp, m.consumed_at, m.proto_type, m.correlation_id,
+       m.reply_to, m.message_id, m.app_id, m.decoded_json,
+       m.delivery_mode, m.priority, m.expiration
+FROM messages m
+WHERE m.id IN (` + strings.Join(selects, " UNION ") + `)
+ORDER BY unix_time(m.consumed_at), m.id
+LIMIT ?
+`
+	return s.scanMessages(ctx, q, append(args, limit)...)
+}
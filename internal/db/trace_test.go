@@ -0,0 +1,104 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestStore_TraceMessages(t *testing.T) {
+	store := newTestStore(t)
+	ctx := context.Background()
+
+	base := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	insert := func(sessionID int64, rk string, offset time.Duration, rec MessageRecord) int64 {
+		t.Helper()
+		rec.SessionID = sessionID
+		rec.Exchange = "orders"
+		rec.RoutingKey = rk
+		rec.Body = []byte(rk)
+		// Publishers' clocks disagree; hops are ordered by arrival
+		rec.Timestamp = base.Add(-offset)
+		rec.ConsumedAt = base.Add(offset)
+		id, err := store.InsertMessage(ctx, &rec)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return id
+	}
+
+	s1, err := store.CreateSession(ctx, "orders", "#", "q1", "amqp://localhost/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s2, err := store.CreateSession(ctx, "orders", "#", "q2", "amqp://localhost/")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Inserted out of order across two sessions
+	reply := insert(s2, "order.reply", 250*time.Millisecond, MessageRecord{CorrelationID: "msg-1"})
+	request := insert(s1, "order.request", 0, MessageRecord{MessageID: "msg-1", ReplyTo: "replies"})
+	step := insert(s2, "order.step", 100*time.Millisecond, MessageRecord{Headers: map[string]any{"x-trace-id": "t-42"}})
+	insert(s1, "order.other", 50*time.Millisecond, MessageRecord{MessageID: "msg-2", Headers: map[string]any{"x-trace-id": "t-7"}})
+	insert(s1, "order.unrelated", 75*time.Millisecond, MessageRecord{MessageID: "msg-3", ReplyTo: "replies"})
+
+	keys := TraceKeys{MessageID: "msg-1", ReplyTo: "replies", Header: "x-trace-id", HeaderValue: "t-42"}
+	msgs, err := store.TraceMessages(ctx, keys, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []int64
+	for _, m := range msgs {
+		got = append(got, m.ID)
+	}
+	want := []int64{request, step, reply}
+	if len(got) != len(want) {
+		t.Fatalf("traced %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("traced %v, want %v (ordered by arrival)", got, want)
+		}
+	}
+
+	msgs, err = store.TraceMessages(ctx, TraceKeys{}, 100)
+	if err != nil || len(msgs) != 0 {
+		t.Errorf("empty keys traced %d messages (err %v), want none", len(msgs), err)
+	}
+}
+
+func TestTraceKeys_Match(t *testing.T) {
+	keys := TraceKeys{CorrelationID: "c-1", MessageID: "m-1", ReplyTo: "replies", Header: "x-trace-id", HeaderValue: "42"}
+
+	tests := []struct {
+		name                 string
+		correlationID, msgID string
+		headers              map[string]any
+		want                 string
+	}{
+		{"shared correlation id", "c-1", "", nil, "correlation_id"},
+		{"reply to the request", "m-1", "m-9", nil, "reply"},
+		{"request of a reply", "", "c-1", nil, "message_id"},
+		{"trace header", "", "", map[string]any{"x-trace-id": int32(42)}, "x-trace-id"},
+		{"unrelated", "c-2", "m-2", map[string]any{"x-trace-id": "7"}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := keys.Match(tt.correlationID, tt.msgID, tt.headers); got != tt.want {
+				t.Errorf("Match = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	// Without a reply queue, a correlated message is just another hop
+	if got := (TraceKeys{MessageID: "m-1"}).Match("m-1", "", nil); got != "correlation_id" {
+		t.Errorf("Match without reply_to = %q, want correlation_id", got)
+	}
+	if !(TraceKeys{Header: "x-trace-id"}).IsZero() {
+		t.Error("a header name without a value has nothing to trace")
+	}
+	if !(TraceKeys{ReplyTo: "replies"}).IsZero() {
+		t.Error("a reply queue alone has nothing to trace")
+	}
+}
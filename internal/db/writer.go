@@ -7,31 +7,52 @@ import (
 
 const defaultBufferSize = 1000
 
-// AsyncWriter provides non-blocking message persistence with a buffered channel
+// AsyncWriter provides non-blocking message persistence with a buffered channel.
+// It keeps the session alive for retention until closed.
 type AsyncWriter struct {
 	store     Store
 	sessionID int64
-	ch        chan *MessageRecord
+	ch        chan pendingRecord
 	wg        sync.WaitGroup
 	mu        sync.RWMutex
 	closed    bool
+	stop      context.CancelFunc // stops the heartbeat
+}
+
+// pendingRecord is a queued message and the callback reporting its insert.
+type pendingRecord struct {
+	msg   *MessageRecord
+	saved func(id int64, err error)
 }
 
 // NewAsyncWriter creates a new async writer with the given store and session
 func NewAsyncWriter(store Store, sessionID int64) *AsyncWriter {
+	ctx, stop := context.WithCancel(context.Background())
 	w := &AsyncWriter{
 		store:     store,
 		sessionID: sessionID,
-		ch:        make(chan *MessageRecord, defaultBufferSize),
+		ch:        make(chan pendingRecord, defaultBufferSize),
+		stop:      stop,
 	}
-	w.wg.Add(1)
+	w.wg.Add(2)
 	go w.run()
+	go func() {
+		defer w.wg.Done()
+		KeepSessionAlive(ctx, store, sessionID)
+	}()
 	return w
 }
 
 // Save queues a message for persistence. Non-blocking; drops message if buffer is full.
 // Returns false if the writer is closed or the buffer is full.
 func (w *AsyncWriter) Save(msg *MessageRecord) bool {
+	return w.SaveThen(msg, nil)
+}
+
+// SaveThen is Save, then calls saved with the ID of the inserted row, or the
+// insert error, once the message is written. saved runs on the writer's
+// goroutine, and is not called when SaveThen returns false.
+func (w *AsyncWriter) SaveThen(msg *MessageRecord, saved func(id int64, err error)) bool {
 	w.mu.RLock()
 	defer w.mu.RUnlock()
 	if w.closed {
@@ -39,7 +60,7 @@ func (w *AsyncWriter) Save(msg *MessageRecord) bool {
 	}
 	msg.SessionID = w.sessionID
 	select {
-	case w.ch <- msg:
+	case w.ch <- pendingRecord{msg: msg, saved: saved}:
 		return true
 	default:
 		return false
@@ -48,9 +69,12 @@ func (w *AsyncWriter) Save(msg *MessageRecord) bool {
 
 func (w *AsyncWriter) run() {
 	defer w.wg.Done()
-	for msg := range w.ch {
-		// Best effort insert, ignore errors
-		_, _ = w.store.InsertMessage(context.Background(), msg)
+	for p := range w.ch {
+		// Best effort insert; only SaveThen callers hear of errors
+		id, err := w.store.InsertMessage(context.Background(), p.msg)
+		if p.saved != nil {
+			p.saved(id, err)
+		}
 	}
 }
 
@@ -60,5 +84,6 @@ func (w *AsyncWriter) Close() {
 	w.closed = true
 	close(w.ch)
 	w.mu.Unlock()
+	w.stop()
 	w.wg.Wait()
 }
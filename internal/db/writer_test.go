@@ -2,6 +2,7 @@ package db
 
 import (
 	"context"
+	"slices"
 	"sync"
 	"sync/atomic"
 	"testing"
@@ -32,6 +33,7 @@ func (s *mockStore) CreateSession(context.Context, string, string, string, strin
 	return 0, nil
 }
 func (s *mockStore) EndSession(context.Context, int64) error                      { return nil }
+func (s *mockStore) TouchSession(context.Context, int64) error                    { return nil }
 func (s *mockStore) ListRecentSessions(context.Context, int64) ([]Session, error) { return nil, nil }
 func (s *mockStore) GetLastSessionByExchange(context.Context, string) (*Session, error) {
 	return nil, nil
@@ -54,6 +56,35 @@ func (s *mockStore) DeleteSession(context.Context, int64) error
 func (s *mockStore) SearchSessionsByContent(context.Context, string, int64) ([]int64, error) {
 	return nil, nil
 }
+func (s *mockStore) QueryMessages(context.Context, string, []any, int64, int64, int64) ([]Message, error) {
+	return nil, nil
+}
+func (s *mockStore) QuerySessions(context.Context, string, []any, int64) ([]int64, error) {
+	return nil, nil
+}
+func (s *mockStore) TraceMessages(context.Context, TraceKeys, int64) ([]Message, error) {
+	return nil, nil
+}
+func (s *mockStore) CountQueryMessages(context.Context, string, []any, int64) (int64, error) {
+	return 0, nil
+}
+func (s *mockStore) NextQueryMessage(context.Context, string, []any, int64, int64, bool) (int64, error) {
+	return 0, nil
+}
+func (s *mockStore) GroupMessages(context.Context, string, []any, int64, string, []any, int64) ([]MessageGroup, error) {
+	return nil, nil
+}
+func (s *mockStore) SetAnnotation(context.Context, int64, Annotation) error { return nil }
+func (s *mockStore) GetAnnotations(context.Context, []int64) (map[int64]Annotation, error) {
+	return nil, nil
+}
+func (s *mockStore) ListAnnotated(context.Context, int64) ([]AnnotatedMessage, error) {
+	return nil, nil
+}
+func (s *mockStore) AddHistory(context.Context, string, string, int) error { return nil }
+func (s *mockStore) ListHistory(context.Context, string, int) ([]string, error) {
+	return nil, nil
+}
 func (s *mockStore) Close() error { return nil }
 
 func TestAsyncWriter_SaveAndClose(t *testing.T) {
@@ -94,6 +125,28 @@ func TestAsyncWriter_SaveAfterClose(t *testing.T) {
 	}
 }
 
+func TestAsyncWriter_SaveThen(t *testing.T) {
+	store := &mockStore{}
+	w := NewAsyncWriter(store, 1)
+
+	var ids []int64
+	for range 3 {
+		if !w.SaveThen(&MessageRecord{RoutingKey: "test"}, func(id int64, err error) {
+			if err != nil {
+				t.Error(err)
+			}
+			ids = append(ids, id)
+		}) {
+			t.Fatal("SaveThen failed")
+		}
+	}
+	w.Close()
+
+	if !slices.Equal(ids, []int64{1, 2, 3}) {
+		t.Errorf("reported IDs %v, want [1 2 3]", ids)
+	}
+}
+
 func TestAsyncWriter_ConcurrentSaveAndClose(t *testing.T) {
 	store := &mockStore{}
 	w := NewAsyncWriter(store, 1)
@@ -0,0 +1,183 @@
+// Package filter compiles filter and search expressions and matches messages
+// against them, in memory or as SQL over stored sessions. It is shared by the
+// TUI, alert rules and the headless commands.
+package filter
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/epalmerini/rabbithole/internal/archive"
+	"github.com/epalmerini/rabbithole/internal/query"
+)
+
+// Filter is a compiled filter expression.
+type Filter struct {
+	field string
+	query string
+	re    *regexp.Regexp
+
+	// structured is set for query-language expressions (body.total > 100)
+	structured func(*query.Message) bool
+	parsed     *query.Query
+	now        time.Time
+}
+
+// New compiles a filter expression: either a structured query
+// (body.order.total > 100 and hdr.x-tenant = "acme") or text using the search
+// prefix syntax (rk:, body:, ex:, hdr:, type:, re:). Relative times in a
+// query are resolved against the current time.
+func New(expr string) (*Filter, error) {
+	field, text := ParseSearch(expr)
+	f := &Filter{field: field, now: time.Now()}
+	if field == "" && query.LooksLikeQuery(expr) {
+		parsed, err := query.Parse(expr)
+		if err != nil {
+			return nil, err
+		}
+		f.parsed = parsed
+		f.structured = parsed.Matcher(f.now)
+		return f, nil
+	}
+	if field == "re" {
+		re, err := regexp.Compile(text)
+		if err != nil {
+			return nil, err
+		}
+		f.re = re
+	} else {
+		f.query = strings.ToLower(text)
+	}
+	return f, nil
+}
+
+// ParseSearch extracts an optional field prefix from a search query.
+// Supported prefixes: rk:, body:, ex:, hdr:, type:, re:
+// Returns ("", query) for unprefixed queries.
+func ParseSearch(q string) (field, query string) {
+	for _, prefix := range []string{"rk:", "body:", "ex:", "hdr:", "type:", "re:"} {
+		if strings.HasPrefix(q, prefix) {
+			return prefix[:len(prefix)-1], q[len(prefix):]
+		}
+	}
+	return "", q
+}
+
+// Match reports whether msg matches the filter. Text searches only see
+// msg.Body, the body decoded from protobuf; query body paths also read
+// RawBody as JSON.
+func (f *Filter) Match(msg query.Message) bool {
+	if f.structured != nil {
+		return f.structured(&msg)
+	}
+	return MatchText(msg, f.field, f.query, f.re)
+}
+
+// MatchRecord reports whether an archive record matches the filter.
+func (f *Filter) MatchRecord(r archive.Record) bool {
+	return f.Match(RecordMessage(r))
+}
+
+// RecordMessage converts an archive record to the message filters match.
+func RecordMessage(r archive.Record) query.Message {
+	decoded, _ := r.Body.(map[string]any)
+	// Like the consumer view, messages without a timestamp use their arrival
+	ts := r.Timestamp
+	if ts.IsZero() {
+		ts = r.ConsumedAt
+	}
+	return query.Message{
+		Exchange:      r.Exchange,
+		RoutingKey:    r.RoutingKey,
+		ProtoType:     r.ProtoType,
+		ContentType:   r.ContentType,
+		CorrelationID: r.CorrelationID,
+		ReplyTo:       r.ReplyTo,
+		MessageID:     r.MessageID,
+		AppID:         r.AppID,
+		Timestamp:     ts,
+		Headers:       r.Headers,
+		Body:          decoded,
+		RawBody:       r.RawBody,
+	}
+}
+
+// MatchText reports whether msg matches a text search on field, as split off
+// by ParseSearch. query must already be lower case; re is used for "re".
+func MatchText(msg query.Message, field, query string, re *regexp.Regexp) bool {
+	switch field {
+	case "re":
+		if re == nil {
+			return false
+		}
+		if re.MatchString(msg.RoutingKey) {
+			return true
+		}
+		if msg.Body != nil {
+			bodyJSON, _ := json.Marshal(msg.Body)
+			return re.Match(bodyJSON)
+		}
+		return false
+	case "rk":
+		return strings.Contains(strings.ToLower(msg.RoutingKey), query)
+	case "body":
+		if msg.Body != nil {
+			bodyJSON, _ := json.Marshal(msg.Body)
+			return strings.Contains(strings.ToLower(string(bodyJSON)), query)
+		}
+		return false
+	case "ex":
+		return strings.Contains(strings.ToLower(msg.Exchange), query)
+	case "hdr":
+		if len(msg.Headers) > 0 {
+			hdrJSON, _ := json.Marshal(msg.Headers)
+			return strings.Contains(strings.ToLower(string(hdrJSON)), query)
+		}
+		return false
+	case "type":
+		return strings.Contains(strings.ToLower(msg.ProtoType), query)
+	default:
+		// Unprefixed: search routing key + body (original behavior)
+		if strings.Contains(strings.ToLower(msg.RoutingKey), query) {
+			return true
+		}
+		if msg.Body != nil {
+			bodyJSON, _ := json.Marshal(msg.Body)
+			return strings.Contains(strings.ToLower(string(bodyJSON)), query)
+		}
+		return false
+	}
+}
+
+// SQL renders the filter as a condition over the messages table aliased m, so
+// stored sessions can be searched without loading them. As in memory, text
+// searches only see bodies decoded from protobuf, here the stored decoded_json.
+func (f *Filter) SQL() (string, []any) {
+	if f.parsed != nil {
+		return f.parsed.SQL(f.now)
+	}
+
+	const (
+		decoded = "(m.decoded_json IS NOT NULL AND instr(lower(m.decoded_json), ?) > 0)"
+		rk      = "instr(lower(m.routing_key), ?) > 0"
+	)
+	q := f.query
+	switch f.field {
+	case "re":
+		p := f.re.String()
+		return "(m.routing_key REGEXP ? OR (m.decoded_json IS NOT NULL AND m.decoded_json REGEXP ?))", []any{p, p}
+	case "rk":
+		return rk, []any{q}
+	case "body":
+		return decoded, []any{q}
+	case "ex":
+		return "instr(lower(m.exchange), ?) > 0", []any{q}
+	case "hdr":
+		return "(m.headers IS NOT NULL AND instr(lower(m.headers), ?) > 0)", []any{q}
+	case "type":
+		return "instr(lower(COALESCE(m.proto_type, '')), ?) > 0", []any{q}
+	}
+	return "(" + rk + " OR " + decoded + ")", []any{q, q}
+}
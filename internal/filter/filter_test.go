@@ -0,0 +1,189 @@
+package filter
+
+import (
+	"context"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"testing"
+
+	"github.com/epalmerini/rabbithole/internal/archive"
+	"github.com/epalmerini/rabbithole/internal/db"
+	"github.com/epalmerini/rabbithole/internal/query"
+)
+
+func TestParseSearch_Prefixes(t *testing.T) {
+	tests := []struct {
+		input     string
+		wantField string
+		wantQuery string
+	}{
+		{"hello", "", "hello"},
+		{"rk:country", "rk", "country"},
+		{"body:alice", "body", "alice"},
+		{"ex:events", "ex", "events"},
+		{"hdr:trace", "hdr", "trace"},
+		{"type:User", "type", "User"},
+		{"re:foo.*bar", "re", "foo.*bar"},
+	}
+	for _, tt := range tests {
+		field, query := ParseSearch(tt.input)
+		if field != tt.wantField || query != tt.wantQuery {
+			t.Errorf("ParseSearch(%q) = (%q, %q), want (%q, %q)",
+				tt.input, field, query, tt.wantField, tt.wantQuery)
+		}
+	}
+}
+
+func TestMatchText_Substring(t *testing.T) {
+	msg := query.Message{
+		RoutingKey: "events.user.created",
+		Exchange:   "main",
+		Body:       map[string]any{"name": "Alice"},
+	}
+
+	if !MatchText(msg, "", "user", nil) {
+		t.Error("expected unprefixed 'user' to match routing key")
+	}
+	if !MatchText(msg, "rk", "user", nil) {
+		t.Error("expected rk:user to match")
+	}
+	if MatchText(msg, "rk", "zzz", nil) {
+		t.Error("expected rk:zzz to not match")
+	}
+	if !MatchText(msg, "body", "alice", nil) {
+		t.Error("expected body:alice to match (case insensitive)")
+	}
+}
+
+func TestMatchText_Regex(t *testing.T) {
+	msg := query.Message{
+		RoutingKey: "events.user.created",
+		Exchange:   "main",
+		Body:       map[string]any{"name": "Alice", "email": "alice@example.com"},
+	}
+
+	tests := []struct {
+		pattern string
+		want    bool
+	}{
+		{`user\.created`, true},
+		{`^events\.`, true},
+		{`\.deleted$`, false},
+		{`alice@.*\.com`, true}, // matches body
+		{`ALICE`, false},        // regex is case-sensitive by default
+		{`(?i)ALICE`, true},     // case-insensitive flag
+	}
+
+	for _, tt := range tests {
+		got := MatchText(msg, "re", "", regexp.MustCompile(tt.pattern))
+		if got != tt.want {
+			t.Errorf("MatchText(re:%q) = %v, want %v", tt.pattern, got, tt.want)
+		}
+	}
+}
+
+func TestNew(t *testing.T) {
+	f, err := New("rk:ORDER")
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if !f.Match(query.Message{RoutingKey: "events.order.placed"}) {
+		t.Error("expected case-insensitive routing key match")
+	}
+	if f.Match(query.Message{RoutingKey: "events.user.created"}) {
+		t.Error("unexpected match")
+	}
+
+	if _, err := New("re:[invalid"); err == nil {
+		t.Error("expected error for invalid regex")
+	}
+	// A malformed query is an error, not a substring search
+	if _, err := New("body.total >"); err == nil {
+		t.Error("expected parse error")
+	}
+}
+
+func TestFilter_MatchRecord(t *testing.T) {
+	f, err := New("body.total > 100")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var r archive.Record
+	r.SetBody([]byte(`{"total": 150}`), nil)
+	if !f.MatchRecord(r) {
+		t.Error("query body paths should read JSON bodies")
+	}
+
+	// Text searches only see decoded protobuf bodies
+	text, _ := New("body:150")
+	if text.MatchRecord(r) {
+		t.Error("body: matched a raw JSON body")
+	}
+	r.SetBody([]byte{0x08}, map[string]any{"total": 150.0})
+	if !text.MatchRecord(r) {
+		t.Error("body: should match the decoded body")
+	}
+}
+
+// TestFilter_SQLMatchesInMemory checks that text filters pushed down to the
+// store select the same stored messages as Match does in memory.
+func TestFilter_SQLMatchesInMemory(t *testing.T) {
+	store, err := db.NewStore(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	ctx := context.Background()
+
+	sid, err := store.CreateSession(ctx, "orders", "#", "q", "amqp://localhost/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	records := []db.MessageRecord{
+		{Exchange: "orders", RoutingKey: "Order.Created", ProtoType: "shop.OrderCreated", Body: []byte{0x08}, Decoded: map[string]any{"customer": "Alice"}},
+		{Exchange: "billing", RoutingKey: "invoice.sent", Body: []byte("alice"), Headers: map[string]any{"x-tenant": "acme"}},
+		{Exchange: "orders", RoutingKey: "order.paid", Body: []byte{0x10}, Decoded: map[string]any{"customer": "bob"}},
+	}
+	var msgs []query.Message
+	var ids []int64
+	for i := range records {
+		records[i].SessionID = sid
+		id, err := store.InsertMessage(ctx, &records[i])
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, id)
+		r := records[i]
+		msgs = append(msgs, query.Message{
+			Exchange: r.Exchange, RoutingKey: r.RoutingKey, ProtoType: r.ProtoType,
+			RawBody: r.Body, Body: r.Decoded, Headers: r.Headers,
+		})
+	}
+
+	for _, expr := range []string{"alice", "order", "rk:ORDER.", "body:alice", "ex:bill", "hdr:acme", "type:ordercreated", "re:^order", "re:Ali", "rk = order.paid"} {
+		f, err := New(expr)
+		if err != nil {
+			t.Fatalf("New(%q): %v", expr, err)
+		}
+		var want []int64
+		for i, msg := range msgs {
+			if f.Match(msg) {
+				want = append(want, ids[i])
+			}
+		}
+
+		where, args := f.SQL()
+		rows, err := store.QueryMessages(ctx, where, args, sid, 100, 0)
+		if err != nil {
+			t.Fatalf("%q: %v\n%s", expr, err, where)
+		}
+		var got []int64
+		for _, r := range rows {
+			got = append(got, r.ID)
+		}
+		if !slices.Equal(got, want) {
+			t.Errorf("%q: SQL selected %v, in memory %v", expr, got, want)
+		}
+	}
+}
@@ -0,0 +1,240 @@
+package query
+
+import (
+	"encoding/json"
+	"strconv"
+	"time"
+)
+
+// Message is the view of a message a query is evaluated against.
+type Message struct {
+	Exchange      string
+	RoutingKey    string
+	ProtoType     string
+	ContentType   string
+	CorrelationID string
+	ReplyTo       string
+	MessageID     string
+	AppID         string
+	Timestamp     time.Time
+	Headers       map[string]any
+
+	// Body is the decoded protobuf body. When nil, RawBody is read as JSON
+	// the first time a body path is compared.
+	Body    map[string]any
+	RawBody []byte
+
+	rawParsed bool
+}
+
+func (m *Message) body() map[string]any {
+	if m.Body == nil && !m.rawParsed {
+		m.rawParsed = true
+		_ = json.Unmarshal(m.RawBody, &m.Body)
+	}
+	return m.Body
+}
+
+// Matcher returns a predicate for the query, with relative times such as
+// ts > -15m resolved against now.
+func (q *Query) Matcher(now time.Time) func(*Message) bool {
+	return func(m *Message) bool { return eval(q.root, m, now) }
+}
+
+func eval(n node, m *Message, now time.Time) bool {
+	switch n := n.(type) {
+	case andNode:
+		return eval(n.left, m, now) && eval(n.right, m, now)
+	case orNode:
+		return eval(n.left, m, now) || eval(n.right, m, now)
+	case notNode:
+		return !eval(n.expr, m, now)
+	case existsNode:
+		v, ok := resolve(n.field, m)
+		if s, isText := v.(string); ok && isText && n.field.kind == kindText {
+			return s != ""
+		}
+		return ok
+	case compareNode:
+		return compare(n, m, now)
+	}
+	return false
+}
+
+// Lookup returns the value of a field, such as rk or body.country.id, in m
+// and whether it is present.
+func Lookup(name string, m *Message) (any, bool) {
+	f, ok := lookupField(name)
+	if !ok {
+		return nil, false
+	}
+	return resolve(f, m)
+}
+
+// FieldName returns the name Lookup knows a field or path by, such as rk for
+// routing_key, and whether it is one.
+func FieldName(name string) (string, bool) {
+	f, ok := lookupField(name)
+	return f.name, ok
+}
+
+// resolve returns the value of f in m and whether it is present.
+func resolve(f field, m *Message) (any, bool) {
+	switch f.kind {
+	case kindTime:
+		return m.Timestamp, true
+	case kindNumber:
+		return float64(len(m.RawBody)), true
+	case kindDynamic:
+		var doc any = m.Headers
+		if f.doc == "body" {
+			doc = m.body()
+		}
+		return walk(doc, f.path)
+	}
+
+	switch f.name {
+	case "rk":
+		return m.RoutingKey, true
+	case "ex":
+		return m.Exchange, true
+	case "type":
+		return m.ProtoType, true
+	case "content_type":
+		return m.ContentType, true
+	case "correlation_id":
+		return m.CorrelationID, true
+	case "reply_to":
+		return m.ReplyTo, true
+	case "message_id":
+		return m.MessageID, true
+	case "app_id":
+		return m.AppID, true
+	}
+	return nil, false
+}
+
+// walk follows path through nested objects and arrays; numeric segments
+// index arrays.
+func walk(doc any, path []string) (any, bool) {
+	cur := doc
+	for _, key := range path {
+		switch c := cur.(type) {
+		case map[string]any:
+			v, ok := c[key]
+			if !ok {
+				return nil, false
+			}
+			cur = v
+		case []any:
+			i, err := strconv.Atoi(key)
+			if err != nil || i < 0 || i >= len(c) {
+				return nil, false
+			}
+			cur = c[i]
+		default:
+			return nil, false
+		}
+	}
+	return cur, true
+}
+
+func compare(n compareNode, m *Message, now time.Time) bool {
+	v, ok := resolve(n.field, m)
+	if !ok {
+		return false
+	}
+
+	switch n.value.kind {
+	case valTime:
+		t, ok := v.(time.Time)
+		return ok && ordered(t.Compare(n.value.time(now)), n.op)
+	case valNumber:
+		f, ok := toFloat(v)
+		if !ok {
+			return false
+		}
+		switch {
+		case f < n.value.num:
+			return ordered(-1, n.op)
+		case f > n.value.num:
+			return ordered(1, n.op)
+		}
+		return ordered(0, n.op)
+	case valBool:
+		b, ok := v.(bool)
+		if !ok {
+			return false
+		}
+		return (b == n.value.boolean) == (n.op == "=")
+	}
+
+	s, ok := v.(string)
+	if !ok {
+		return false
+	}
+	switch n.op {
+	case "~":
+		return n.value.re.MatchString(s)
+	case "!~":
+		return !n.value.re.MatchString(s)
+	}
+	switch {
+	case s < n.value.str:
+		return ordered(-1, n.op)
+	case s > n.value.str:
+		return ordered(1, n.op)
+	}
+	return ordered(0, n.op)
+}
+
+// ordered reports whether a comparison result c (-1, 0, 1) satisfies op.
+func ordered(c int, op string) bool {
+	switch op {
+	case "=":
+		return c == 0
+	case "!=":
+		return c != 0
+	case ">":
+		return c > 0
+	case ">=":
+		return c >= 0
+	case "<":
+		return c < 0
+	case "<=":
+		return c <= 0
+	}
+	return false
+}
+
+// toFloat converts JSON and AMQP numeric values.
+func toFloat(v any) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case float32:
+		return float64(n), true
+	case int:
+		return float64(n), true
+	case int8:
+		return float64(n), true
+	case int16:
+		return float64(n), true
+	case int32:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case uint8:
+		return float64(n), true
+	case uint16:
+		return float64(n), true
+	case uint32:
+		return float64(n), true
+	case uint64:
+		return float64(n), true
+	case json.Number:
+		f, err := n.Float64()
+		return f, err == nil
+	}
+	return 0, false
+}
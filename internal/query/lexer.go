@@ -0,0 +1,133 @@
+package query
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokIdent
+	tokString
+	tokLiteral // number, duration or date
+	tokOp
+	tokLParen
+	tokRParen
+	tokAnd
+	tokOr
+	tokNot
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+func (t token) String() string {
+	if t.kind == tokEOF {
+		return "end of query"
+	}
+	return fmt.Sprintf("%q", t.text)
+}
+
+// lex splits src into tokens. Identifiers may contain dots and dashes so that
+// field paths like hdr.x-tenant are a single token.
+func lex(src string) ([]token, error) {
+	var tokens []token
+	for i := 0; i < len(src); {
+		c := rune(src[i])
+		switch {
+		case unicode.IsSpace(c):
+			i++
+		case c == '(':
+			tokens = append(tokens, token{tokLParen, "(", i})
+			i++
+		case c == ')':
+			tokens = append(tokens, token{tokRParen, ")", i})
+			i++
+		case c == '"' || c == '\'':
+			s, n, err := lexString(src[i:])
+			if err != nil {
+				return nil, fmt.Errorf("at %d: %w", i, err)
+			}
+			tokens = append(tokens, token{tokString, s, i})
+			i += n
+		case strings.ContainsRune("=!<>~", c):
+			op := lexOp(src[i:])
+			if op == "" {
+				return nil, fmt.Errorf("at %d: unexpected %q", i, c)
+			}
+			tokens = append(tokens, token{tokOp, op, i})
+			i += len(op)
+		case c == '-' || c == '+' || unicode.IsDigit(c):
+			j := i + 1
+			for j < len(src) && isLiteralChar(rune(src[j])) {
+				j++
+			}
+			tokens = append(tokens, token{tokLiteral, src[i:j], i})
+			i = j
+		case c == '_' || unicode.IsLetter(c):
+			j := i + 1
+			for j < len(src) && isIdentChar(rune(src[j])) {
+				j++
+			}
+			word := src[i:j]
+			kind := tokIdent
+			switch strings.ToLower(word) {
+			case "and":
+				kind = tokAnd
+			case "or":
+				kind = tokOr
+			case "not":
+				kind = tokNot
+			}
+			tokens = append(tokens, token{kind, word, i})
+			i = j
+		default:
+			return nil, fmt.Errorf("at %d: unexpected %q", i, c)
+		}
+	}
+	return append(tokens, token{tokEOF, "", len(src)}), nil
+}
+
+func lexOp(s string) string {
+	for _, op := range []string{"==", "!=", ">=", "<=", "!~", "=", ">", "<", "~"} {
+		if strings.HasPrefix(s, op) {
+			return op
+		}
+	}
+	return ""
+}
+
+// lexString reads a quoted string starting at s[0] and returns its value and
+// the number of bytes consumed. Backslash escapes the next character.
+func lexString(s string) (string, int, error) {
+	quote := s[0]
+	var b strings.Builder
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			if i+1 < len(s) {
+				i++
+				b.WriteByte(s[i])
+			}
+		case quote:
+			return b.String(), i + 1, nil
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+	return "", 0, fmt.Errorf("unterminated string")
+}
+
+func isIdentChar(c rune) bool {
+	return c == '_' || c == '-' || c == '.' || unicode.IsLetter(c) || unicode.IsDigit(c)
+}
+
+func isLiteralChar(c rune) bool {
+	return c == '.' || c == ':' || c == '-' || c == '+' || unicode.IsLetter(c) || unicode.IsDigit(c)
+}
@@ -0,0 +1,371 @@
+// Package query implements the structured query language used to filter
+// messages, both in memory and in the session store:
+//
+//	body.order.total > 100 and hdr.x-tenant = "acme"
+//	rk ~ "^order\." and not type = shop.OrderCancelled
+//	ts > -15m or (size >= 1024 and content_type != "application/json")
+//
+// A comparison is FIELD OP VALUE, with OP one of = != > >= < <= ~ (regexp)
+// and !~. A field on its own tests that it is present. Comparisons combine
+// with and, or, not and parentheses.
+package query
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// fieldKind is the type of values a field holds.
+type fieldKind int
+
+const (
+	kindText fieldKind = iota
+	kindNumber
+	kindTime
+	kindDynamic // body and header paths, typed by their JSON value
+)
+
+// field is a message attribute a query can compare.
+type field struct {
+	name   string
+	kind   fieldKind
+	column string   // SQL expression over the messages table aliased m
+	path   []string // for body and header paths
+	doc    string   // body or hdr, for dynamic fields
+}
+
+// columns maps field names and their aliases to message attributes.
+var columns = map[string]field{
+	"rk":             {name: "rk", kind: kindText, column: "m.routing_key"},
+	"routing_key":    {name: "rk", kind: kindText, column: "m.routing_key"},
+	"ex":             {name: "ex", kind: kindText, column: "m.exchange"},
+	"exchange":       {name: "ex", kind: kindText, column: "m.exchange"},
+	"type":           {name: "type", kind: kindText, column: "m.proto_type"},
+	"proto_type":     {name: "type", kind: kindText, column: "m.proto_type"},
+	"content_type":   {name: "content_type", kind: kindText, column: "m.content_type"},
+	"correlation_id": {name: "correlation_id", kind: kindText, column: "m.correlation_id"},
+	"reply_to":       {name: "reply_to", kind: kindText, column: "m.reply_to"},
+	"message_id":     {name: "message_id", kind: kindText, column: "m.message_id"},
+	"app_id":         {name: "app_id", kind: kindText, column: "m.app_id"},
+	"ts":             {name: "ts", kind: kindTime, column: "m.timestamp"},
+	"timestamp":      {name: "ts", kind: kindTime, column: "m.timestamp"},
+	"size":           {name: "size", kind: kindNumber, column: "m.body"},
+}
+
+// lookupField resolves a field name or a body./hdr. path.
+func lookupField(name string) (field, bool) {
+	if f, ok := columns[strings.ToLower(name)]; ok {
+		return f, true
+	}
+	for prefix, doc := range map[string]string{"body.": "body", "hdr.": "hdr", "headers.": "hdr"} {
+		if rest, ok := strings.CutPrefix(name, prefix); ok && rest != "" {
+			path := strings.Split(rest, ".")
+			for _, p := range path {
+				if p == "" {
+					return field{}, false
+				}
+			}
+			return field{name: name, kind: kindDynamic, doc: doc, path: path}, true
+		}
+	}
+	return field{}, false
+}
+
+// valueKind is the type of a literal in a comparison.
+type valueKind int
+
+const (
+	valString valueKind = iota
+	valNumber
+	valBool
+	valTime
+)
+
+// value is a comparison literal. Relative times are kept as an offset and
+// resolved when the query is evaluated.
+type value struct {
+	kind     valueKind
+	str      string
+	num      float64
+	boolean  bool
+	at       time.Time
+	relative *time.Duration
+	re       *regexp.Regexp
+}
+
+func (v value) time(now time.Time) time.Time {
+	if v.relative != nil {
+		return now.Add(*v.relative)
+	}
+	return v.at
+}
+
+// node is a parsed expression.
+type node interface{}
+
+type andNode struct{ left, right node }
+type orNode struct{ left, right node }
+type notNode struct{ expr node }
+type existsNode struct{ field field }
+type compareNode struct {
+	field field
+	op    string
+	value value
+}
+
+// Query is a parsed query.
+type Query struct {
+	root node
+	src  string
+}
+
+func (q *Query) String() string { return q.src }
+
+// Parse parses a query.
+func Parse(src string) (*Query, error) {
+	tokens, err := lex(src)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens}
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != tokEOF {
+		return nil, fmt.Errorf("at %d: unexpected %s, expected and/or", t.pos, t)
+	}
+	return &Query{root: root, src: src}, nil
+}
+
+// Quote renders s as a string literal for a query.
+func Quote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
+
+// LooksLikeQuery reports whether src is meant as a structured query rather
+// than plain search text: it starts with a known field, "not" or "(" and
+// contains a comparison operator, or is "not" and a known field.
+func LooksLikeQuery(src string) bool {
+	tokens, err := lex(src)
+	if err != nil || len(tokens) < 2 {
+		return false
+	}
+	first := tokens[0]
+	switch first.kind {
+	case tokIdent:
+		if _, ok := lookupField(first.text); !ok {
+			return false
+		}
+	case tokNot:
+		if len(tokens) == 3 && tokens[1].kind == tokIdent { // not hdr.x-tenant
+			_, ok := lookupField(tokens[1].text)
+			return ok
+		}
+	case tokLParen:
+	default:
+		return false
+	}
+	for _, t := range tokens {
+		if t.kind == tokOp {
+			return true
+		}
+	}
+	return false
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *parser) peek() token { return p.tokens[p.pos] }
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *parser) parseOr() (node, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().kind == tokOr {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = orNode{left, right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseAnd() (node, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().kind == tokAnd {
+		p.next()
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = andNode{left, right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseUnary() (node, error) {
+	switch t := p.peek(); t.kind {
+	case tokNot:
+		p.next()
+		expr, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return notNode{expr}, nil
+	case tokLParen:
+		p.next()
+		expr, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if t := p.next(); t.kind != tokRParen {
+			return nil, fmt.Errorf("at %d: expected ), got %s", t.pos, t)
+		}
+		return expr, nil
+	}
+	return p.parseComparison()
+}
+
+func (p *parser) parseComparison() (node, error) {
+	t := p.next()
+	if t.kind != tokIdent {
+		return nil, fmt.Errorf("at %d: expected a field, got %s", t.pos, t)
+	}
+	f, ok := lookupField(t.text)
+	if !ok {
+		return nil, fmt.Errorf("at %d: unknown field %q (use rk, ex, type, ts, size, body.PATH, hdr.NAME, ...)", t.pos, t.text)
+	}
+	if p.peek().kind != tokOp {
+		return existsNode{f}, nil
+	}
+	op := p.next().text
+	if op == "==" {
+		op = "="
+	}
+
+	vt := p.next()
+	v, err := parseValue(vt, f)
+	if err != nil {
+		return nil, fmt.Errorf("at %d: %w", vt.pos, err)
+	}
+	if err := checkComparison(f, op, &v); err != nil {
+		return nil, fmt.Errorf("at %d: %w", vt.pos, err)
+	}
+	return compareNode{field: f, op: op, value: v}, nil
+}
+
+// parseValue reads a literal. Bare words are strings, except true and false;
+// comparisons with ts read strings and literals as times.
+func parseValue(t token, f field) (value, error) {
+	if f.kind == kindText && (t.kind == tokString || t.kind == tokIdent || t.kind == tokLiteral) {
+		return value{kind: valString, str: t.text}, nil
+	}
+	switch t.kind {
+	case tokString:
+		if f.kind == kindTime {
+			return parseTime(t.text)
+		}
+		return value{kind: valString, str: t.text}, nil
+	case tokIdent:
+		switch {
+		case f.kind == kindTime && strings.EqualFold(t.text, "now"):
+			zero := time.Duration(0)
+			return value{kind: valTime, relative: &zero}, nil
+		case f.kind == kindTime:
+			return parseTime(t.text)
+		case t.text == "true" || t.text == "false":
+			return value{kind: valBool, boolean: t.text == "true"}, nil
+		}
+		return value{kind: valString, str: t.text}, nil
+	case tokLiteral:
+		if f.kind == kindTime {
+			return parseTime(t.text)
+		}
+		if n, err := strconv.ParseFloat(t.text, 64); err == nil && !math.IsInf(n, 0) && !math.IsNaN(n) {
+			return value{kind: valNumber, num: n}, nil
+		}
+		return value{kind: valString, str: t.text}, nil
+	}
+	return value{}, fmt.Errorf("expected a value, got %s", t)
+}
+
+// parseTime accepts an offset from now (-15m, -2h, -7d, -1w) or an absolute
+// date or time in local time unless a zone is given.
+func parseTime(s string) (value, error) {
+	if d, ok := parseOffset(s); ok {
+		return value{kind: valTime, relative: &d}, nil
+	}
+	for _, layout := range []string{time.RFC3339Nano, "2006-01-02T15:04:05", "2006-01-02T15:04", "2006-01-02 15:04:05", "2006-01-02 15:04", "2006-01-02"} {
+		if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			return value{kind: valTime, at: t}, nil
+		}
+	}
+	return value{}, fmt.Errorf("invalid time %q (use an offset like -15m or a date like 2024-05-01T12:00)", s)
+}
+
+// parseOffset parses a signed duration, adding d (days) and w (weeks) units.
+func parseOffset(s string) (time.Duration, bool) {
+	if len(s) < 2 || (s[0] != '-' && s[0] != '+') {
+		return 0, false
+	}
+	unit := map[byte]time.Duration{'d': 24 * time.Hour, 'w': 7 * 24 * time.Hour}[s[len(s)-1]]
+	if unit != 0 {
+		n, err := strconv.ParseFloat(s[:len(s)-1], 64)
+		if err != nil {
+			return 0, false
+		}
+		return time.Duration(n * float64(unit)), true
+	}
+	d, err := time.ParseDuration(s)
+	return d, err == nil
+}
+
+// checkComparison rejects comparisons that can never match and compiles
+// regular expressions.
+func checkComparison(f field, op string, v *value) error {
+	if op == "~" || op == "!~" {
+		if v.kind != valString || f.kind == kindTime || f.kind == kindNumber {
+			return fmt.Errorf("%s needs a text field and a pattern", op)
+		}
+		re, err := regexp.Compile(v.str)
+		if err != nil {
+			return err
+		}
+		v.re = re
+		return nil
+	}
+
+	switch f.kind {
+	case kindNumber:
+		if v.kind != valNumber {
+			return fmt.Errorf("%s compares with numbers", f.name)
+		}
+	case kindDynamic:
+		if v.kind == valBool && op != "=" && op != "!=" {
+			return fmt.Errorf("true and false only compare with = and !=")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,190 @@
+package query
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParse_Errors(t *testing.T) {
+	tests := []string{
+		"",
+		"body.total >",
+		"nosuchfield = 1",
+		"rk = a b",
+		"(rk = a",
+		"size > big",
+		"ts > yesterday",
+		"body.flag > true",
+		"rk ~ \"[\"",
+		"size ~ \"1\"",
+		"rk = \"unterminated",
+		"rk = a and",
+		"body. = 1",
+	}
+	for _, src := range tests {
+		if _, err := Parse(src); err == nil {
+			t.Errorf("Parse(%q): expected error", src)
+		}
+	}
+}
+
+func TestLooksLikeQuery(t *testing.T) {
+	tests := []struct {
+		src  string
+		want bool
+	}{
+		{"body.total > 100", true},
+		{`hdr.x-tenant = "acme"`, true},
+		{"not rk = a", true},
+		{"(rk = a or rk = b)", true},
+		{"rk=order.created", true},
+		{"ts > -15m", true},
+		{"order created", false},
+		{"not found", false},
+		{"not hdr.x-tenant", true},
+		{"type and color", false},
+		{"user", false},
+		{"rk:order", false},
+		{"body.total >", true}, // still a query, so the parse error is shown
+	}
+	for _, tt := range tests {
+		if got := LooksLikeQuery(tt.src); got != tt.want {
+			t.Errorf("LooksLikeQuery(%q) = %v, want %v", tt.src, got, tt.want)
+		}
+	}
+}
+
+func TestMatcher(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	msg := Message{
+		Exchange:      "orders",
+		RoutingKey:    "order.created",
+		ProtoType:     "shop.OrderCreated",
+		CorrelationID: "corr-1",
+		Timestamp:     now.Add(-10 * time.Minute),
+		Headers:       map[string]any{"x-tenant": "acme", "x-retry": int32(2)},
+		Body: map[string]any{
+			"order": map[string]any{
+				"total": 150.5,
+				"paid":  true,
+				"items": []any{map[string]any{"sku": "A-1"}},
+			},
+			"note": nil,
+		},
+		RawBody: make([]byte, 64),
+	}
+
+	tests := []struct {
+		src  string
+		want bool
+	}{
+		{"body.order.total > 100", true},
+		{"body.order.total <= 100", false},
+		{"body.order.total = 150.5", true},
+		{`body.order.total = "150.5"`, false},
+		{"body.order.paid = true", true},
+		{"body.order.paid != true", false},
+		{"body.order.items.0.sku = A-1", true},
+		{"body.order.items.1.sku = A-1", false},
+		{"body.order.missing", false},
+		{"not body.order.missing", true},
+		{"body.note", true},
+		{"body.order.missing != 1", false},
+		{`hdr.x-tenant = "acme"`, true},
+		{"hdr.x-retry >= 2", true},
+		{"headers.x-retry < 2", false},
+		{"rk = order.created", true},
+		{`rk ~ "^order\."`, true},
+		{`rk !~ "^order\."`, false},
+		{`rk ~ "(?i)ORDER"`, true},
+		{"ex = orders and type = shop.OrderCreated", true},
+		{"ex = payments or correlation_id = corr-1", true},
+		{"not (ex = orders)", false},
+		{"ex = orders and not rk = order.created", false},
+		{"reply_to", false},
+		{"correlation_id", true},
+		{"ts > -15m", true},
+		{"ts > -5m", false},
+		{"ts < now", true},
+		{"ts >= 2024-05-01", true},
+		{`ts < "2024-05-01T11:00:00Z"`, false},
+		{"size = 64", true},
+		{"size > 64", false},
+	}
+	for _, tt := range tests {
+		q, err := Parse(tt.src)
+		if err != nil {
+			t.Errorf("Parse(%q): %v", tt.src, err)
+			continue
+		}
+		m := msg
+		if got := q.Matcher(now)(&m); got != tt.want {
+			t.Errorf("%q = %v, want %v", tt.src, got, tt.want)
+		}
+	}
+}
+
+func TestMatcher_JSONBody(t *testing.T) {
+	q, err := Parse("body.user.name = alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	match := q.Matcher(time.Now())
+	if !match(&Message{RawBody: []byte(`{"user":{"name":"alice"}}`)}) {
+		t.Error("expected a JSON body to be queried when there is no decoded body")
+	}
+	if match(&Message{RawBody: []byte{0x08, 0x01}}) {
+		t.Error("binary body must not match")
+	}
+}
+
+func TestLookup(t *testing.T) {
+	m := &Message{
+		RoutingKey: "country.updated",
+		Headers:    map[string]any{"x-tenant": "acme"},
+		RawBody:    []byte(`{"country":{"id":7,"tags":["a","b"]}}`),
+	}
+	tests := []struct {
+		name string
+		want any
+		ok   bool
+	}{
+		{"rk", "country.updated", true},
+		{"body.country.id", float64(7), true},
+		{"body.country.tags.1", "b", true},
+		{"hdr.x-tenant", "acme", true},
+		{"body.country.name", nil, false},
+		{"nope", nil, false},
+	}
+	for _, tt := range tests {
+		got, ok := Lookup(tt.name, m)
+		if ok != tt.ok || got != tt.want {
+			t.Errorf("Lookup(%q) = %v, %v; want %v, %v", tt.name, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestFieldName(t *testing.T) {
+	for name, want := range map[string]string{"routing_key": "rk", "TIMESTAMP": "ts", "size": "size", "hdr.x-tenant": "hdr.x-tenant"} {
+		if got, ok := FieldName(name); !ok || got != want {
+			t.Errorf("FieldName(%q) = %q, %v; want %q", name, got, ok, want)
+		}
+	}
+	for _, name := range []string{"nope", "body.", "hdr.a..b"} {
+		if _, ok := FieldName(name); ok {
+			t.Errorf("FieldName(%q) accepted", name)
+		}
+	}
+}
+
+func TestQuote(t *testing.T) {
+	for _, v := range []string{"plain", `say "hi"`, `back\slash`, ""} {
+		q, err := Parse("rk = " + Quote(v))
+		if err != nil {
+			t.Fatalf("Parse(rk = %s): %v", Quote(v), err)
+		}
+		if !q.Matcher(time.Now())(&Message{RoutingKey: v}) {
+			t.Errorf("rk = %s does not match %q", Quote(v), v)
+		}
+	}
+}
@@ -0,0 +1,144 @@
+package query
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SQL renders the query as a condition over the messages table aliased m,
+// with relative times resolved against now. It relies on the regexp and
+// unix_time functions registered by the db package. Every comparison is
+// two-valued, so a missing field never turns not into a match for NULL.
+func (q *Query) SQL(now time.Time) (string, []any) {
+	var args []any
+	return render(q.root, now, &args), args
+}
+
+// GroupSQL renders the value of a field as an expression over the messages
+// table aliased m, to group messages by: text fields as text, body and header
+// paths as JSON text, NULL when absent. Times and sizes cannot be grouped by.
+func GroupSQL(name string) (expr string, args []any, isJSON bool, err error) {
+	f, ok := lookupField(name)
+	if !ok {
+		return "", nil, false, fmt.Errorf("unknown field %q (use rk, ex, type, app_id, body.PATH, hdr.NAME, ...)", name)
+	}
+	switch f.kind {
+	case kindText:
+		return "COALESCE(" + f.column + ", '')", nil, false, nil
+	case kindDynamic:
+		doc, path := jsonDoc(f)
+		return doc + " -> ?", []any{path}, true, nil
+	}
+	return "", nil, false, fmt.Errorf("cannot group by %s", f.name)
+}
+
+// jsonBody is the body document: the decoded protobuf JSON, or the raw body
+// when it is itself a JSON object.
+const jsonBody = `COALESCE(m.decoded_json, CASE WHEN json_valid(CAST(m.body AS TEXT)) AND json_type(CAST(m.body AS TEXT)) = 'object' THEN CAST(m.body AS TEXT) END)`
+
+func render(n node, now time.Time, args *[]any) string {
+	switch n := n.(type) {
+	case andNode:
+		return "(" + render(n.left, now, args) + " AND " + render(n.right, now, args) + ")"
+	case orNode:
+		return "(" + render(n.left, now, args) + " OR " + render(n.right, now, args) + ")"
+	case notNode:
+		return "NOT " + render(n.expr, now, args)
+	case existsNode:
+		return "COALESCE(" + renderExists(n.field, args) + ", 0)"
+	case compareNode:
+		return "COALESCE(" + renderCompare(n, now, args) + ", 0)"
+	}
+	return "0"
+}
+
+func renderExists(f field, args *[]any) string {
+	switch f.kind {
+	case kindDynamic:
+		doc, path := jsonDoc(f)
+		*args = append(*args, path)
+		return "json_type(" + doc + ", ?) IS NOT NULL"
+	case kindText:
+		return "COALESCE(" + f.column + ", '') != ''"
+	}
+	return "1"
+}
+
+func renderCompare(n compareNode, now time.Time, args *[]any) string {
+	f, v := n.field, n.value
+	op := sqlOp(n.op)
+
+	switch f.kind {
+	case kindTime:
+		t := v.time(now)
+		*args = append(*args, float64(t.UnixNano())/1e9)
+		return "unix_time(COALESCE(m.timestamp, m.consumed_at)) " + op + " ?"
+	case kindNumber:
+		*args = append(*args, v.num)
+		return "LENGTH(" + f.column + ") " + op + " ?"
+	case kindText:
+		return renderText("COALESCE("+f.column+", '')", n.op, v, args)
+	}
+
+	doc, path := jsonDoc(f)
+	typ := "json_type(" + doc + ", ?)"
+	extract := "json_extract(" + doc + ", ?)"
+	switch v.kind {
+	case valNumber:
+		*args = append(*args, path, path, v.num)
+		return typ + " IN ('integer', 'real') AND " + extract + " " + op + " ?"
+	case valBool:
+		*args = append(*args, path)
+		if n.op == "=" {
+			return typ + " = '" + strconv.FormatBool(v.boolean) + "'"
+		}
+		return typ + " = '" + strconv.FormatBool(!v.boolean) + "'"
+	}
+	*args = append(*args, path, path)
+	return typ + " = 'text' AND " + renderText(extract, n.op, v, args)
+}
+
+func renderText(expr, op string, v value, args *[]any) string {
+	*args = append(*args, v.str)
+	switch op {
+	case "~":
+		return expr + " REGEXP ?"
+	case "!~":
+		return "NOT (" + expr + " REGEXP ?)"
+	}
+	return expr + " " + sqlOp(op) + " ?"
+}
+
+func sqlOp(op string) string {
+	if op == "!=" {
+		return "<>"
+	}
+	return op
+}
+
+// jsonDoc returns the document a dynamic field reads and its JSON path, with
+// every key quoted so names like x-tenant need no escaping. Numeric segments
+// of body paths index arrays.
+func jsonDoc(f field) (string, string) {
+	doc := "m.headers"
+	if f.doc == "body" {
+		doc = jsonBody
+	}
+	var b strings.Builder
+	b.WriteString("$")
+	for i, key := range f.path {
+		if _, err := strconv.Atoi(key); err == nil && i > 0 {
+			b.WriteString("[" + key + "]")
+			continue
+		}
+		// SQLite reads quoted keys as JSON strings, whose escapes differ
+		// from Go's
+		quoted, _ := json.Marshal(key)
+		b.WriteString(".")
+		b.Write(quoted)
+	}
+	return doc, b.String()
+}
@@ -0,0 +1,158 @@
+package query
+
+import (
+	"context"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/epalmerini/rabbithole/internal/db"
+)
+
+// TestSQL_MatchesInMemory checks that each query selects the same messages
+// from the store as the in-memory matcher does.
+func TestSQL_MatchesInMemory(t *testing.T) {
+	store, err := db.NewStore(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	ctx := context.Background()
+
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	records := []db.MessageRecord{
+		{
+			Exchange: "orders", RoutingKey: "order.created", ProtoType: "shop.OrderCreated",
+			Body:      []byte{0x0a, 0x02, 0x08, 0x01},
+			Decoded:   map[string]any{"order": map[string]any{"total": 150.5, "paid": true, "items": []any{map[string]any{"sku": "A-1"}}}},
+			Headers:   map[string]any{"x-tenant": "acme", "x-retry": 2},
+			Timestamp: now.Add(-10 * time.Minute),
+		},
+		{
+			Exchange: "orders", RoutingKey: "order.paid", ContentType: "application/json",
+			Body:      []byte(`{"order":{"total":80,"paid":false},"note":null}`),
+			Headers:   map[string]any{"x-tenant": "globex"},
+			Timestamp: now.Add(-2 * time.Hour),
+		},
+		{
+			Exchange: "audit", RoutingKey: "audit.log", CorrelationID: "corr-9",
+			Body:      []byte("plain text"),
+			Timestamp: time.Date(2024, 5, 1, 13, 0, 0, 0, time.FixedZone("CEST", 2*3600)),
+		},
+	}
+
+	sid, err := store.CreateSession(ctx, "orders", "#", "q", "amqp://localhost/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ids []int64
+	for i := range records {
+		records[i].SessionID = sid
+		id, err := store.InsertMessage(ctx, &records[i])
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, id)
+	}
+
+	queries := []string{
+		"body.order.total > 100",
+		"body.order.total = 80",
+		"body.order.total != 80",
+		"body.order.paid = true",
+		"body.order.paid != true",
+		"body.order.items.0.sku = A-1",
+		"body.note",
+		"not body.order.total > 100",
+		`hdr.x-tenant = "acme"`,
+		"hdr.x-retry >= 2",
+		"hdr.x-tenant",
+		"not hdr.x-tenant",
+		"hdr.x-tenant != acme",
+		"rk = order.created or ex = audit",
+		`rk ~ "^order\."`,
+		`rk !~ "paid$"`,
+		"type = shop.OrderCreated",
+		"content_type",
+		"not correlation_id",
+		"correlation_id >= corr-1",
+		"ts > -15m",
+		"ts < -1h",
+		"ts >= 2024-05-01T11:30:00Z",
+		"size > 10",
+		"(ex = orders and not body.order.paid = true) or size < 11",
+	}
+
+	for _, src := range queries {
+		q, err := Parse(src)
+		if err != nil {
+			t.Fatalf("Parse(%q): %v", src, err)
+		}
+
+		match := q.Matcher(now)
+		var want []int64
+		for i, r := range records {
+			m := Message{
+				Exchange: r.Exchange, RoutingKey: r.RoutingKey, ProtoType: r.ProtoType,
+				ContentType: r.ContentType, CorrelationID: r.CorrelationID,
+				Timestamp: r.Timestamp, Headers: r.Headers, Body: r.Decoded, RawBody: r.Body,
+			}
+			if match(&m) {
+				want = append(want, ids[i])
+			}
+		}
+
+		where, args := q.SQL(now)
+		msgs, err := store.QueryMessages(ctx, where, args, sid, 100, 0)
+		if err != nil {
+			t.Fatalf("%q: QueryMessages: %v\n%s", src, err, where)
+		}
+		var got []int64
+		for _, m := range msgs {
+			got = append(got, m.ID)
+		}
+		if !slices.Equal(got, want) {
+			t.Errorf("%q: SQL selected %v, in memory %v", src, got, want)
+		}
+	}
+}
+
+func TestGroupSQL_PathKeys(t *testing.T) {
+	store, err := db.NewStore(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	ctx := context.Background()
+
+	sid, err := store.CreateSession(ctx, "orders", "#", "q", "amqp://localhost/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Keys that Go and JSON quote differently
+	keys := []string{`dir\name`, `say"hi"`, "bell\a", "größe", "<tag>"}
+	decoded := map[string]any{}
+	for i, key := range keys {
+		decoded[key] = i
+	}
+	rec := db.MessageRecord{SessionID: sid, Exchange: "orders", RoutingKey: "order.created", Body: []byte("x"), Decoded: decoded}
+	if _, err := store.InsertMessage(ctx, &rec); err != nil {
+		t.Fatal(err)
+	}
+
+	for i, key := range keys {
+		expr, args, _, err := GroupSQL("body." + key)
+		if err != nil {
+			t.Fatalf("GroupSQL(%q): %v", key, err)
+		}
+		groups, err := store.GroupMessages(ctx, "1", nil, sid, expr, args, 10)
+		if err != nil {
+			t.Fatalf("%q: GroupMessages: %v", key, err)
+		}
+		if want := strconv.Itoa(i); len(groups) != 1 || groups[0].Key.String != want {
+			t.Errorf("%q: groups = %+v, want key %s", key, groups, want)
+		}
+	}
+}
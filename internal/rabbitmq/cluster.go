@@ -0,0 +1,142 @@
+package rabbitmq
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// HealthChecks lists the /api/health/checks endpoints queried for the dashboard.
+var HealthChecks = []string{"alarms", "local-alarms", "virtual-hosts", "node-is-quorum-critical"}
+
+// MessageStats holds cluster-wide message rates from /api/overview.
+type MessageStats struct {
+	PublishDetails    Rate `json:"publish_details"`
+	DeliverGetDetails Rate `json:"deliver_get_details"`
+	AckDetails        Rate `json:"ack_details"`
+}
+
+// QueueTotals holds cluster-wide message counts from /api/overview.
+type QueueTotals struct {
+	Messages               int `json:"messages"`
+	MessagesReady          int `json:"messages_ready"`
+	MessagesUnacknowledged int `json:"messages_unacknowledged"`
+}
+
+// ObjectTotals holds cluster-wide object counts from /api/overview.
+type ObjectTotals struct {
+	Connections int `json:"connections"`
+	Channels    int `json:"channels"`
+	Exchanges   int `json:"exchanges"`
+	Queues      int `json:"queues"`
+	Consumers   int `json:"consumers"`
+}
+
+// Overview is the cluster summary returned by /api/overview.
+type Overview struct {
+	ClusterName     string       `json:"cluster_name"`
+	RabbitMQVersion string       `json:"rabbitmq_version"`
+	ErlangVersion   string       `json:"erlang_version"`
+	Node            string       `json:"node"`
+	MessageStats    MessageStats `json:"message_stats"`
+	QueueTotals     QueueTotals  `json:"queue_totals"`
+	ObjectTotals    ObjectTotals `json:"object_totals"`
+}
+
+// Node is a cluster member as returned by /api/nodes.
+type Node struct {
+	Name          string   `json:"name"`
+	Type          string   `json:"type"`
+	Running       bool     `json:"running"`
+	Uptime        int64    `json:"uptime"` // milliseconds
+	MemUsed       int64    `json:"mem_used"`
+	MemLimit      int64    `json:"mem_limit"`
+	MemAlarm      bool     `json:"mem_alarm"`
+	DiskFree      int64    `json:"disk_free"`
+	DiskFreeLimit int64    `json:"disk_free_limit"`
+	DiskFreeAlarm bool     `json:"disk_free_alarm"`
+	FDUsed        int      `json:"fd_used"`
+	FDTotal       int      `json:"fd_total"`
+	SocketsUsed   int      `json:"sockets_used"`
+	SocketsTotal  int      `json:"sockets_total"`
+	ProcUsed      int      `json:"proc_used"`
+	ProcTotal     int      `json:"proc_total"`
+	Partitions    []string `json:"partitions"`
+}
+
+// Alarms describes the resource alarms firing on the node.
+func (n Node) Alarms() []string {
+	var alarms []string
+	if n.MemAlarm {
+		alarms = append(alarms, fmt.Sprintf("memory alarm on %s", n.Name))
+	}
+	if n.DiskFreeAlarm {
+		alarms = append(alarms, fmt.Sprintf("disk alarm on %s", n.Name))
+	}
+	return alarms
+}
+
+// ClusterAlarms collects the resource alarms firing across nodes.
+func ClusterAlarms(nodes []Node) []string {
+	var alarms []string
+	for _, n := range nodes {
+		alarms = append(alarms, n.Alarms()...)
+	}
+	return alarms
+}
+
+// HealthCheck is the outcome of one /api/health/checks endpoint.
+type HealthCheck struct {
+	Name   string
+	OK     bool
+	Reason string
+}
+
+// GetOverview returns the cluster overview.
+func (c *ManagementClient) GetOverview(ctx context.Context) (*Overview, error) {
+	var overview Overview
+	if err := c.getJSON(ctx, "/overview", &overview); err != nil {
+		return nil, err
+	}
+	return &overview, nil
+}
+
+// GetNodes returns the cluster nodes.
+func (c *ManagementClient) GetNodes(ctx context.Context) ([]Node, error) {
+	var nodes []Node
+	if err := c.getJSON(ctx, "/nodes", &nodes); err != nil {
+		return nil, err
+	}
+	return nodes, nil
+}
+
+// CheckHealth runs a health check such as "alarms". A failing check is reported
+// in the result, not as an error; errors mean the check could not be run.
+func (c *ManagementClient) CheckHealth(ctx context.Context, check string) (_ HealthCheck, err error) {
+	result := HealthCheck{Name: check}
+
+	resp, err := c.doRequest(ctx, "GET", "/health/checks/"+check, nil)
+	if err != nil {
+		return result, err
+	}
+	defer func() { err = errors.Join(err, resp.Body.Close()) }()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		result.OK = true
+		return result, nil
+	case http.StatusServiceUnavailable:
+		var body struct {
+			Reason string `json:"reason"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+			body.Reason = "check failed"
+		}
+		result.Reason = body.Reason
+		return result, nil
+	}
+
+	return result, fmt.Errorf("API returned status %d", resp.StatusCode)
+}
@@ -0,0 +1,79 @@
+package rabbitmq
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestGetOverview(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/overview" {
+			t.Errorf("path = %q", r.URL.Path)
+		}
+		_, _ = io.WriteString(w, `{"cluster_name":"rabbit@prod","rabbitmq_version":"3.13.1",
+			"message_stats":{"publish_details":{"rate":12.5}},
+			"queue_totals":{"messages":42},"object_totals":{"connections":3}}`)
+	})
+
+	o, err := client.GetOverview(context.Background())
+	if err != nil {
+		t.Fatalf("GetOverview: %v", err)
+	}
+	if o.ClusterName != "rabbit@prod" || o.MessageStats.PublishDetails.Rate != 12.5 || o.QueueTotals.Messages != 42 || o.ObjectTotals.Connections != 3 {
+		t.Errorf("unexpected overview: %+v", o)
+	}
+}
+
+func TestClusterAlarms(t *testing.T) {
+	nodes := []Node{
+		{Name: "rabbit@a", MemAlarm: true},
+		{Name: "rabbit@b"},
+		{Name: "rabbit@c", DiskFreeAlarm: true},
+	}
+
+	want := []string{"memory alarm on rabbit@a", "disk alarm on rabbit@c"}
+	if got := ClusterAlarms(nodes); !reflect.DeepEqual(got, want) {
+		t.Errorf("ClusterAlarms() = %v, want %v", got, want)
+	}
+	if got := ClusterAlarms([]Node{{Name: "rabbit@b"}}); got != nil {
+		t.Errorf("expected no alarms, got %v", got)
+	}
+}
+
+func TestCheckHealth(t *testing.T) {
+	tests := []struct {
+		name       string
+		status     int
+		body       string
+		wantOK     bool
+		wantReason string
+		wantErr    bool
+	}{
+		{"passing", http.StatusOK, `{"status":"ok"}`, true, "", false},
+		{"failing", http.StatusServiceUnavailable, `{"status":"failed","reason":"resource alarm(s) in effect"}`, false, "resource alarm(s) in effect", false},
+		{"unauthorized", http.StatusUnauthorized, ``, false, "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/api/health/checks/alarms" {
+					t.Errorf("path = %q", r.URL.Path)
+				}
+				w.WriteHeader(tt.status)
+				_, _ = io.WriteString(w, tt.body)
+			})
+
+			got, err := client.CheckHealth(context.Background(), "alarms")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got.OK != tt.wantOK || got.Reason != tt.wantReason {
+				t.Errorf("CheckHealth() = %+v", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,162 @@
+package rabbitmq
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// Rate is a message or byte rate sample from the management API.
+type Rate struct {
+	Rate float64 `json:"rate"`
+}
+
+// Connection is a client connection as returned by /api/connections.
+type Connection struct {
+	Name             string         `json:"name"`
+	VHost            string         `json:"vhost"`
+	User             string         `json:"user"`
+	PeerHost         string         `json:"peer_host"`
+	PeerPort         int            `json:"peer_port"`
+	Protocol         string         `json:"protocol"`
+	State            string         `json:"state"`
+	Channels         int            `json:"channels"`
+	ClientProperties map[string]any `json:"client_properties"`
+	RecvOctDetails   Rate           `json:"recv_oct_details"`
+	SendOctDetails   Rate           `json:"send_oct_details"`
+}
+
+// Blocked reports whether the broker is throttling the connection's publishers.
+func (c Connection) Blocked() bool {
+	return c.State == "blocked" || c.State == "blocking"
+}
+
+// ClientName returns the most descriptive client identifier available:
+// the connection_name client property, falling back to product and version.
+func (c Connection) ClientName() string {
+	if name, ok := c.ClientProperties["connection_name"].(string); ok && name != "" {
+		return name
+	}
+	product, _ := c.ClientProperties["product"].(string)
+	version, _ := c.ClientProperties["version"].(string)
+	switch {
+	case product != "" && version != "":
+		return product + " " + version
+	case product != "":
+		return product
+	}
+	return ""
+}
+
+// ChannelConnection identifies the connection owning a channel or consumer.
+type ChannelConnection struct {
+	Name     string `json:"name"`
+	PeerHost string `json:"peer_host"`
+	PeerPort int    `json:"peer_port"`
+}
+
+// Channel is an AMQP channel as returned by /api/channels.
+type Channel struct {
+	Name                   string            `json:"name"`
+	Number                 int               `json:"number"`
+	VHost                  string            `json:"vhost"`
+	User                   string            `json:"user"`
+	State                  string            `json:"state"`
+	PrefetchCount          int               `json:"prefetch_count"`
+	GlobalPrefetchCount    int               `json:"global_prefetch_count"`
+	MessagesUnacknowledged int               `json:"messages_unacknowledged"`
+	MessagesUnconfirmed    int               `json:"messages_unconfirmed"`
+	Confirm                bool              `json:"confirm"`
+	Transactional          bool              `json:"transactional"`
+	ConsumerCount          int               `json:"consumer_count"`
+	ConnectionDetails      ChannelConnection `json:"connection_details"`
+}
+
+// ConsumerChannel identifies the channel a consumer is attached to.
+type ConsumerChannel struct {
+	Name           string `json:"name"`
+	Number         int    `json:"number"`
+	ConnectionName string `json:"connection_name"`
+	PeerHost       string `json:"peer_host"`
+	PeerPort       int    `json:"peer_port"`
+	User           string `json:"user"`
+}
+
+// ConsumerQueue identifies the queue a consumer is subscribed to.
+type ConsumerQueue struct {
+	Name  string `json:"name"`
+	VHost string `json:"vhost"`
+}
+
+// ConsumerInfo is a queue subscription as returned by /api/consumers.
+type ConsumerInfo struct {
+	ConsumerTag    string          `json:"consumer_tag"`
+	AckRequired    bool            `json:"ack_required"`
+	Exclusive      bool            `json:"exclusive"`
+	PrefetchCount  int             `json:"prefetch_count"`
+	Active         bool            `json:"active"`
+	ActivityStatus string          `json:"activity_status"`
+	ChannelDetails ConsumerChannel `json:"channel_details"`
+	Queue          ConsumerQueue   `json:"queue"`
+}
+
+// GetConnections returns the client connections to vhost.
+func (c *ManagementClient) GetConnections(ctx context.Context, vhost string) ([]Connection, error) {
+	var conns []Connection
+	if err := c.getJSON(ctx, fmt.Sprintf("/vhosts/%s/connections", url.PathEscape(vhost)), &conns); err != nil {
+		return nil, err
+	}
+	return conns, nil
+}
+
+// GetConnectionChannels returns the channels opened on a connection.
+func (c *ManagementClient) GetConnectionChannels(ctx context.Context, connection string) ([]Channel, error) {
+	var channels []Channel
+	if err := c.getJSON(ctx, fmt.Sprintf("/connections/%s/channels", url.PathEscape(connection)), &channels); err != nil {
+		return nil, err
+	}
+	return channels, nil
+}
+
+// GetChannels returns all channels in vhost.
+func (c *ManagementClient) GetChannels(ctx context.Context, vhost string) ([]Channel, error) {
+	var channels []Channel
+	if err := c.getJSON(ctx, fmt.Sprintf("/vhosts/%s/channels", url.PathEscape(vhost)), &channels); err != nil {
+		return nil, err
+	}
+	return channels, nil
+}
+
+// GetConsumers returns all consumers in vhost.
+func (c *ManagementClient) GetConsumers(ctx context.Context, vhost string) ([]ConsumerInfo, error) {
+	var consumers []ConsumerInfo
+	if err := c.getJSON(ctx, fmt.Sprintf("/consumers/%s", url.PathEscape(vhost)), &consumers); err != nil {
+		return nil, err
+	}
+	return consumers, nil
+}
+
+// CloseConnection force-closes a client connection. The reason is sent to the client.
+func (c *ManagementClient) CloseConnection(ctx context.Context, name, reason string) (err error) {
+	req, err := c.newRequest(ctx, "DELETE", fmt.Sprintf("/connections/%s", url.PathEscape(name)), nil)
+	if err != nil {
+		return err
+	}
+	if reason != "" {
+		req.Header.Set("X-Reason", reason)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { err = errors.Join(err, resp.Body.Close()) }()
+
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to close connection: status %d", resp.StatusCode)
+	}
+
+	return nil
+}
@@ -0,0 +1,81 @@
+package rabbitmq
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"testing"
+)
+
+func TestGetConnections(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.EscapedPath() != "/api/vhosts/%2F/connections" {
+			t.Errorf("path = %q", r.URL.EscapedPath())
+		}
+		_, _ = io.WriteString(w, `[{"name":"10.0.0.1:5000 -> 10.0.0.2:5672","user":"svc","peer_host":"10.0.0.1","channels":2,"state":"blocked",
+			"client_properties":{"product":"amqp091-go","version":"1.10.0"},"recv_oct_details":{"rate":512.5}}]`)
+	})
+
+	conns, err := client.GetConnections(context.Background(), "/")
+	if err != nil {
+		t.Fatalf("GetConnections: %v", err)
+	}
+	if len(conns) != 1 {
+		t.Fatalf("expected 1 connection, got %d", len(conns))
+	}
+	c := conns[0]
+	if !c.Blocked() {
+		t.Error("expected connection to be blocked")
+	}
+	if c.ClientName() != "amqp091-go 1.10.0" {
+		t.Errorf("ClientName() = %q", c.ClientName())
+	}
+	if c.RecvOctDetails.Rate != 512.5 {
+		t.Errorf("recv rate = %v", c.RecvOctDetails.Rate)
+	}
+}
+
+func TestConnectionClientName_PrefersConnectionName(t *testing.T) {
+	c := Connection{ClientProperties: map[string]any{"connection_name": "orders-worker", "product": "x"}}
+	if c.ClientName() != "orders-worker" {
+		t.Errorf("ClientName() = %q, want orders-worker", c.ClientName())
+	}
+}
+
+func TestGetConsumers(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, `[{"consumer_tag":"ctag-1","prefetch_count":10,"ack_required":true,
+			"channel_details":{"name":"conn (1)","connection_name":"conn","peer_host":"10.0.0.1"},"queue":{"name":"orders","vhost":"/"}}]`)
+	})
+
+	consumers, err := client.GetConsumers(context.Background(), "/")
+	if err != nil {
+		t.Fatalf("GetConsumers: %v", err)
+	}
+	if len(consumers) != 1 || consumers[0].Queue.Name != "orders" || consumers[0].ChannelDetails.ConnectionName != "conn" {
+		t.Errorf("unexpected consumers: %+v", consumers)
+	}
+}
+
+func TestCloseConnection_SendsReason(t *testing.T) {
+	var gotMethod, gotPath, gotReason string
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		gotMethod = r.Method
+		gotPath = r.URL.EscapedPath()
+		gotReason = r.Header.Get("X-Reason")
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	if err := client.CloseConnection(context.Background(), "a -> b", "stuck consumer"); err != nil {
+		t.Fatalf("CloseConnection: %v", err)
+	}
+	if gotMethod != "DELETE" {
+		t.Errorf("method = %q", gotMethod)
+	}
+	if gotPath != "/api/connections/a%20-%3E%20b" {
+		t.Errorf("path = %q", gotPath)
+	}
+	if gotReason != "stuck consumer" {
+		t.Errorf("X-Reason = %q", gotReason)
+	}
+}
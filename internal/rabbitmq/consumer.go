@@ -21,7 +21,9 @@ type Config struct {
 type Delivery struct {
 	RoutingKey    string
 	Exchange      string
-	Timestamp     time.Time
+	Timestamp     time.Time // the publisher's timestamp, or the arrival time without one
+	Published     bool      // the publisher set Timestamp
+	ConsumedAt    time.Time // when the message arrived
 	Body          []byte
 	Headers       map[string]any
 	ContentType   string
@@ -29,6 +31,18 @@ type Delivery struct {
 	ReplyTo       string
 	MessageID     string
 	AppID         string
+	DeliveryMode  uint8 // 0 when unset, 1 transient, 2 persistent
+	Priority      uint8
+	Expiration    string
+}
+
+// PublisherTimestamp returns the timestamp the publisher set, or the zero
+// time if it set none.
+func (d Delivery) PublisherTimestamp() time.Time {
+	if !d.Published {
+		return time.Time{}
+	}
+	return d.Timestamp
 }
 
 type Consumer struct {
@@ -144,9 +158,10 @@ func (c *Consumer) Consume(ctx context.Context) (<-chan Delivery, error) {
 					return
 				}
 
+				arrived := time.Now()
 				ts := msg.Timestamp
 				if ts.IsZero() {
-					ts = time.Now()
+					ts = arrived
 				}
 
 				headers := make(map[string]any)
@@ -158,6 +173,8 @@ func (c *Consumer) Consume(ctx context.Context) (<-chan Delivery, error) {
 					RoutingKey:    msg.RoutingKey,
 					Exchange:      msg.Exchange,
 					Timestamp:     ts,
+					Published:     !msg.Timestamp.IsZero(),
+					ConsumedAt:    arrived,
 					Body:          msg.Body,
 					Headers:       headers,
 					ContentType:   msg.ContentType,
@@ -165,6 +182,9 @@ func (c *Consumer) Consume(ctx context.Context) (<-chan Delivery, error) {
 					ReplyTo:       msg.ReplyTo,
 					MessageID:     msg.MessageId,
 					AppID:         msg.AppId,
+					DeliveryMode:  msg.DeliveryMode,
+					Priority:      msg.Priority,
+					Expiration:    msg.Expiration,
 				}
 			}
 		}
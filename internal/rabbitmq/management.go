@@ -36,12 +36,18 @@ type Exchange struct {
 
 type Queue struct {
 	Name       string `json:"name"`
+	Type       string `json:"type"`
 	Durable    bool   `json:"durable"`
 	AutoDelete bool   `json:"auto_delete"`
 	Exclusive  bool   `json:"exclusive"`
 	Messages   int    `json:"messages"`
 	Consumers  int    `json:"consumers"`
 	VHost      string `json:"vhost"`
+
+	// Policies applied by the broker
+	Policy                    string         `json:"policy"`
+	OperatorPolicy            string         `json:"operator_policy"`
+	EffectivePolicyDefinition map[string]any `json:"effective_policy_definition"`
 }
 
 type Binding struct {
@@ -86,16 +92,11 @@ func NewManagementClient(amqpURL string, managementURL string) (*ManagementClien
 		baseURL = fmt.Sprintf("%s://%s:15672/api", scheme, host)
 	}
 
-	vhost := "/"
-	if p := strings.TrimPrefix(parsed.Path, "/"); p != "" {
-		vhost = p
-	}
-
 	return &ManagementClient{
 		baseURL:  baseURL,
 		username: username,
 		password: password,
-		vhost:    vhost,
+		vhost:    VHostFromURL(amqpURL),
 		client: &http.Client{
 			Timeout: 10 * time.Second,
 		},
@@ -103,6 +104,15 @@ func NewManagementClient(amqpURL string, managementURL string) (*ManagementClien
 }
 
 func (c *ManagementClient) doRequest(ctx context.Context, method, path string, body []byte) (*http.Response, error) {
+	req, err := c.newRequest(ctx, method, path, body)
+	if err != nil {
+		return nil, err
+	}
+	return c.client.Do(req)
+}
+
+// newRequest builds an authenticated API request for callers that need extra headers.
+func (c *ManagementClient) newRequest(ctx context.Context, method, path string, body []byte) (*http.Request, error) {
 	reqURL := c.baseURL + path
 
 	var req *http.Request
@@ -119,7 +129,22 @@ func (c *ManagementClient) doRequest(ctx context.Context, method, path string, b
 	req.SetBasicAuth(c.username, c.password)
 	req.Header.Set("Content-Type", "application/json")
 
-	return c.client.Do(req)
+	return req, nil
+}
+
+// getJSON performs a GET request and decodes the JSON response into v.
+func (c *ManagementClient) getJSON(ctx context.Context, path string, v any) (err error) {
+	resp, err := c.doRequest(ctx, "GET", path, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { err = errors.Join(err, resp.Body.Close()) }()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("API returned status %d", resp.StatusCode)
+	}
+
+	return json.NewDecoder(resp.Body).Decode(v)
 }
 
 func (c *ManagementClient) GetExchanges(ctx context.Context, vhost string) (_ []Exchange, err error) {
@@ -241,3 +266,20 @@ func (c *ManagementClient) DeleteQueue(ctx context.Context, vhost, name string)
 
 	return nil
 }
+
+// PurgeQueue removes all ready messages from a queue, keeping the queue itself.
+func (c *ManagementClient) PurgeQueue(ctx context.Context, vhost, name string) (err error) {
+	path := fmt.Sprintf("/queues/%s/%s/contents", url.PathEscape(vhost), url.PathEscape(name))
+
+	resp, err := c.doRequest(ctx, "DELETE", path, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { err = errors.Join(err, resp.Body.Close()) }()
+
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to purge queue: status %d", resp.StatusCode)
+	}
+
+	return nil
+}
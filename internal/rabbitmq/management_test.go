@@ -1,6 +1,10 @@
 package rabbitmq
 
-import "testing"
+import (
+	"context"
+	"net/http"
+	"testing"
+)
 
 func TestNewManagementClient(t *testing.T) {
 	tests := []struct {
@@ -79,3 +83,19 @@ func TestNewManagementClient(t *testing.T) {
 		})
 	}
 }
+
+func TestPurgeQueue(t *testing.T) {
+	var gotMethod, gotPath string
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		gotMethod = r.Method
+		gotPath = r.URL.EscapedPath()
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	if err := client.PurgeQueue(context.Background(), "/", "rabbithole-abc"); err != nil {
+		t.Fatalf("PurgeQueue: %v", err)
+	}
+	if gotMethod != "DELETE" || gotPath != "/api/queues/%2F/rabbithole-abc/contents" {
+		t.Errorf("request = %s %s", gotMethod, gotPath)
+	}
+}
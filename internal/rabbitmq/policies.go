@@ -0,0 +1,151 @@
+package rabbitmq
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+)
+
+// Policy kinds, distinguishing user policies from operator policies.
+const (
+	PolicyKindUser     = "policy"
+	PolicyKindOperator = "operator"
+)
+
+// Policy apply-to values accepted by the management API.
+var PolicyApplyTo = []string{"all", "queues", "classic_queues", "quorum_queues", "streams", "exchanges"}
+
+// Policy is a user or operator policy as returned by /api/policies and /api/operator-policies.
+type Policy struct {
+	Name       string         `json:"name"`
+	VHost      string         `json:"vhost"`
+	Pattern    string         `json:"pattern"`
+	ApplyTo    string         `json:"apply-to"`
+	Priority   int            `json:"priority"`
+	Definition map[string]any `json:"definition"`
+
+	// Kind is PolicyKindUser or PolicyKindOperator; it is not part of the API payload.
+	Kind string `json:"-"`
+}
+
+// GetPolicies returns the user policies defined in vhost.
+func (c *ManagementClient) GetPolicies(ctx context.Context, vhost string) ([]Policy, error) {
+	return c.getPolicies(ctx, "/policies", vhost, PolicyKindUser)
+}
+
+// GetOperatorPolicies returns the operator policies defined in vhost.
+func (c *ManagementClient) GetOperatorPolicies(ctx context.Context, vhost string) ([]Policy, error) {
+	return c.getPolicies(ctx, "/operator-policies", vhost, PolicyKindOperator)
+}
+
+func (c *ManagementClient) getPolicies(ctx context.Context, base, vhost, kind string) ([]Policy, error) {
+	var policies []Policy
+	if err := c.getJSON(ctx, fmt.Sprintf("%s/%s", base, url.PathEscape(vhost)), &policies); err != nil {
+		return nil, err
+	}
+	for i := range policies {
+		policies[i].Kind = kind
+	}
+	return policies, nil
+}
+
+// PutPolicy creates or replaces a policy. Operator policies are written when p.Kind is PolicyKindOperator.
+func (c *ManagementClient) PutPolicy(ctx context.Context, vhost string, p Policy) (err error) {
+	body, err := json.Marshal(struct {
+		Pattern    string         `json:"pattern"`
+		ApplyTo    string         `json:"apply-to"`
+		Priority   int            `json:"priority"`
+		Definition map[string]any `json:"definition"`
+	}{p.Pattern, p.ApplyTo, p.Priority, p.Definition})
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.doRequest(ctx, "PUT", policyPath(vhost, p.Name, p.Kind), body)
+	if err != nil {
+		return err
+	}
+	defer func() { err = errors.Join(err, resp.Body.Close()) }()
+
+	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusNoContent {
+		return fmt.Errorf("failed to save policy: status %d", resp.StatusCode)
+	}
+
+	return nil
+}
+
+// DeletePolicy removes a user or operator policy.
+func (c *ManagementClient) DeletePolicy(ctx context.Context, vhost, name, kind string) (err error) {
+	resp, err := c.doRequest(ctx, "DELETE", policyPath(vhost, name, kind), nil)
+	if err != nil {
+		return err
+	}
+	defer func() { err = errors.Join(err, resp.Body.Close()) }()
+
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to delete policy: status %d", resp.StatusCode)
+	}
+
+	return nil
+}
+
+func policyPath(vhost, name, kind string) string {
+	base := "/policies"
+	if kind == PolicyKindOperator {
+		base = "/operator-policies"
+	}
+	return fmt.Sprintf("%s/%s/%s", base, url.PathEscape(vhost), url.PathEscape(name))
+}
+
+// AppliesTo reports whether the policy's apply-to setting covers a resource.
+// isExchange selects exchanges; otherwise queueType ("classic", "quorum", "stream")
+// is matched against the queue-specific apply-to values.
+func (p Policy) AppliesTo(isExchange bool, queueType string) bool {
+	switch p.ApplyTo {
+	case "", "all":
+		return true
+	case "exchanges":
+		return isExchange
+	case "queues":
+		return !isExchange
+	case "classic_queues":
+		return !isExchange && (queueType == "" || queueType == "classic")
+	case "quorum_queues":
+		return !isExchange && queueType == "quorum"
+	case "streams":
+		return !isExchange && queueType == "stream"
+	}
+	return false
+}
+
+// Matches reports whether the policy pattern matches name and applies to the resource kind.
+// An invalid pattern never matches.
+func (p Policy) Matches(name string, isExchange bool, queueType string) bool {
+	if !p.AppliesTo(isExchange, queueType) {
+		return false
+	}
+	re, err := regexp.Compile(p.Pattern)
+	if err != nil {
+		return false
+	}
+	return re.MatchString(name)
+}
+
+// SortPolicies orders policies by kind (user first), descending priority, then name.
+func SortPolicies(policies []Policy) {
+	sort.SliceStable(policies, func(i, j int) bool {
+		a, b := policies[i], policies[j]
+		if a.Kind != b.Kind {
+			return a.Kind == PolicyKindUser
+		}
+		if a.Priority != b.Priority {
+			return a.Priority > b.Priority
+		}
+		return a.Name < b.Name
+	})
+}
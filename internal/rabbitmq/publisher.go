@@ -0,0 +1,106 @@
+package rabbitmq
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// Publisher publishes messages with publisher confirms, so each Publish
+// returns only once the broker has taken responsibility for the message.
+type Publisher struct {
+	conn    *amqp.Connection
+	channel *amqp.Channel
+}
+
+func NewPublisher(url string) (*Publisher, error) {
+	conn, err := amqp.Dial(url)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to RabbitMQ: %w", err)
+	}
+
+	ch, err := conn.Channel()
+	if err != nil {
+		return nil, errors.Join(fmt.Errorf("failed to open channel: %w", err), conn.Close())
+	}
+	if err := ch.Confirm(false); err != nil {
+		return nil, errors.Join(fmt.Errorf("failed to enable publisher confirms: %w", err), conn.Close())
+	}
+
+	return &Publisher{conn: conn, channel: ch}, nil
+}
+
+// Publish sends msg to exchange with msg's routing key, headers and properties,
+// and waits for the broker to confirm it.
+func (p *Publisher) Publish(ctx context.Context, exchange string, msg Delivery) error {
+	confirm, err := p.channel.PublishWithDeferredConfirmWithContext(ctx, exchange, msg.RoutingKey, false, false, amqp.Publishing{
+		Headers:       toTable(msg.Headers),
+		ContentType:   msg.ContentType,
+		CorrelationId: msg.CorrelationID,
+		ReplyTo:       msg.ReplyTo,
+		MessageId:     msg.MessageID,
+		AppId:         msg.AppID,
+		Timestamp:     msg.Timestamp,
+		DeliveryMode:  msg.DeliveryMode,
+		Priority:      msg.Priority,
+		Expiration:    msg.Expiration,
+		Body:          msg.Body,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to publish: %w", err)
+	}
+
+	acked, err := confirm.WaitContext(ctx)
+	if err != nil {
+		return err
+	}
+	if !acked {
+		return errors.New("message was nacked by the broker")
+	}
+	return nil
+}
+
+func (p *Publisher) Close() error {
+	var chanErr error
+	if p.channel != nil {
+		chanErr = p.channel.Close()
+	}
+	if p.conn != nil {
+		return errors.Join(chanErr, p.conn.Close())
+	}
+	return chanErr
+}
+
+// toTable converts headers to AMQP field values. Headers read back from JSON
+// hold map[string]any and float64; whole numbers are restored to integers.
+func toTable(headers map[string]any) amqp.Table {
+	if len(headers) == 0 {
+		return nil
+	}
+	t := make(amqp.Table, len(headers))
+	for k, v := range headers {
+		t[k] = toFieldValue(v)
+	}
+	return t
+}
+
+func toFieldValue(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		return toTable(v)
+	case []any:
+		out := make([]any, len(v))
+		for i, e := range v {
+			out[i] = toFieldValue(e)
+		}
+		return out
+	case float64:
+		if v == math.Trunc(v) && math.Abs(v) < 1<<53 {
+			return int64(v)
+		}
+	}
+	return v
+}
@@ -0,0 +1,38 @@
+package rabbitmq
+
+import (
+	"testing"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+func TestToTable(t *testing.T) {
+	headers := map[string]any{
+		"count":  float64(3),
+		"ratio":  0.5,
+		"name":   "x",
+		"nested": map[string]any{"n": float64(1)},
+		"list":   []any{float64(2), "y"},
+	}
+
+	table := toTable(headers)
+	if err := table.Validate(); err != nil {
+		t.Fatalf("table does not validate: %v", err)
+	}
+	if table["count"] != int64(3) {
+		t.Errorf("count = %#v, want int64(3)", table["count"])
+	}
+	if table["ratio"] != 0.5 {
+		t.Errorf("ratio = %#v, want 0.5", table["ratio"])
+	}
+	if nested, ok := table["nested"].(amqp.Table); !ok || nested["n"] != int64(1) {
+		t.Errorf("nested = %#v, want amqp.Table with int64", table["nested"])
+	}
+	if list := table["list"].([]any); list[0] != int64(2) || list[1] != "y" {
+		t.Errorf("list = %#v", list)
+	}
+
+	if toTable(nil) != nil {
+		t.Error("expected nil table for no headers")
+	}
+}
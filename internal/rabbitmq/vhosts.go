@@ -0,0 +1,71 @@
+package rabbitmq
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// VHost is a virtual host as returned by /api/vhosts.
+type VHost struct {
+	Name                   string `json:"name"`
+	Description            string `json:"description"`
+	Messages               int    `json:"messages"`
+	MessagesReady          int    `json:"messages_ready"`
+	MessagesUnacknowledged int    `json:"messages_unacknowledged"`
+}
+
+// GetVHosts returns all virtual hosts visible to the user.
+func (c *ManagementClient) GetVHosts(ctx context.Context) ([]VHost, error) {
+	var vhosts []VHost
+	if err := c.getJSON(ctx, "/vhosts", &vhosts); err != nil {
+		return nil, err
+	}
+	return vhosts, nil
+}
+
+// GetAllQueues returns the queues of every virtual host visible to the user.
+func (c *ManagementClient) GetAllQueues(ctx context.Context) ([]Queue, error) {
+	var queues []Queue
+	if err := c.getJSON(ctx, "/queues", &queues); err != nil {
+		return nil, err
+	}
+	return queues, nil
+}
+
+// WithVHost returns a copy of the client scoped to another virtual host.
+func (c *ManagementClient) WithVHost(vhost string) *ManagementClient {
+	clone := *c
+	clone.vhost = vhost
+	return &clone
+}
+
+// URLWithVHost rewrites the path of an AMQP URL so it connects to vhost.
+// The default vhost "/" is written as an empty path.
+func URLWithVHost(amqpURL, vhost string) (string, error) {
+	u, err := url.Parse(amqpURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid AMQP URL: %w", err)
+	}
+	if vhost == "" || vhost == "/" {
+		u.Path = "/"
+		u.RawPath = ""
+	} else {
+		u.Path = "/" + vhost
+		u.RawPath = "/" + url.PathEscape(vhost)
+	}
+	return u.String(), nil
+}
+
+// VHostFromURL returns the virtual host encoded in an AMQP URL path.
+func VHostFromURL(amqpURL string) string {
+	u, err := url.Parse(amqpURL)
+	if err != nil {
+		return "/"
+	}
+	if p := strings.TrimPrefix(u.Path, "/"); p != "" {
+		return p
+	}
+	return "/"
+}
@@ -0,0 +1,105 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/epalmerini/rabbithole/internal/alert"
+	"github.com/epalmerini/rabbithole/internal/archive"
+	"github.com/epalmerini/rabbithole/internal/config"
+)
+
+// checkAlertRules reports the first invalid alert rule, including highlight
+// colours, which only the TUI draws.
+func checkAlertRules(rules []config.AlertRule) error {
+	if _, err := alert.NewRules(rules); err != nil {
+		return err
+	}
+	for i, r := range rules {
+		if r.Highlight == "" {
+			continue
+		}
+		if _, err := parseHighlight(r.Highlight); err != nil {
+			name := r.Name
+			if name == "" {
+				name = fmt.Sprintf("#%d", i+1)
+			}
+			return fmt.Errorf("alert %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+func parseHighlight(s string) (lipgloss.Color, error) {
+	if c, ok := parseColor(s); ok {
+		return c, nil
+	}
+	return "", fmt.Errorf("invalid highlight %q (use a colour name, #rrggbb or 0-255)", s)
+}
+
+// record converts msg to the record alert commands read on stdin.
+func (msg Message) record() archive.Record {
+	rec := archive.Record{
+		Timestamp:     msg.Timestamp,
+		ConsumedAt:    msg.ConsumedAt,
+		Exchange:      msg.Exchange,
+		RoutingKey:    msg.RoutingKey,
+		ContentType:   msg.ContentType,
+		CorrelationID: msg.CorrelationID,
+		ReplyTo:       msg.ReplyTo,
+		MessageID:     msg.MessageID,
+		AppID:         msg.AppID,
+		Headers:       msg.Headers,
+		ProtoType:     msg.ProtoType,
+		Bookmarked:    msg.Bookmarked,
+		Note:          msg.Note,
+		Tags:          msg.Tags,
+	}
+	if msg.DecodeErr != nil {
+		rec.DecodeError = msg.DecodeErr.Error()
+	}
+	rec.SetBody(msg.RawBody, msg.Decoded)
+	return rec
+}
+
+type alertCommandMsg struct {
+	err error
+}
+
+// fireAlerts applies the alert rules to a message just received, before it
+// is listed: marking it for highlighting and bookmarking, and signalling the
+// terminal and running commands in the background. It reports whether a rule
+// asks to pause the view.
+func (m *model) fireAlerts(msg *Message) (bool, tea.Cmd) {
+	am := m.alerts.Fire(*msg.queryMessage(), time.Now())
+	if !am.Matched() {
+		return false, nil
+	}
+	if c, err := parseHighlight(am.Highlight); err == nil {
+		style := highlightStyle(c)
+		msg.highlight = &style
+	}
+
+	var cmds []tea.Cmd
+	if am.Bookmark && !msg.Bookmarked {
+		msg.Bookmarked = true
+		cmds = append(cmds, m.saveAnnotation(*msg))
+	}
+	if (am.Bell || am.Notify) && m.alertOut != nil {
+		w := m.alertOut
+		cmds = append(cmds, func() tea.Msg {
+			_ = am.Signal(w)
+			return nil
+		})
+	}
+	rec := msg.record()
+	for _, c := range am.Commands {
+		cmds = append(cmds, func() tea.Msg {
+			return alertCommandMsg{err: c.Run(context.Background(), rec)}
+		})
+	}
+	return am.Pause, tea.Batch(cmds...)
+}
@@ -0,0 +1,88 @@
+package tui
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/bubbles/viewport"
+	"github.com/epalmerini/rabbithole/internal/alert"
+	"github.com/epalmerini/rabbithole/internal/config"
+)
+
+func TestCheckAlertRules(t *testing.T) {
+	tests := []struct {
+		rule config.AlertRule
+		want string
+	}{
+		{config.AlertRule{Name: "empty"}, "alert empty: filter is required"},
+		{config.AlertRule{Name: "c", Filter: "rk:a", Highlight: "mauve"}, "alert c: invalid highlight"},
+		{config.AlertRule{Filter: "rk:a", Highlight: "300"}, "alert #1: invalid highlight"},
+	}
+	for _, tt := range tests {
+		err := checkAlertRules([]config.AlertRule{tt.rule})
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("checkAlertRules(%+v) error = %v, want %q", tt.rule, err, tt.want)
+		}
+	}
+
+	if err := checkAlertRules([]config.AlertRule{{Filter: "rk:a", Highlight: "#ff0000"}, {Filter: "rk:b", Highlight: "196"}}); err != nil {
+		t.Errorf("valid rules rejected: %v", err)
+	}
+}
+
+func TestAlerts_ConsumerView(t *testing.T) {
+	alerts, err := alert.NewRules([]config.AlertRule{
+		{Name: "failed", Filter: "rk:failed", Highlight: "red", Bookmark: true, Pause: true, Bell: true},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var term bytes.Buffer
+	m := model{
+		messages:       newMessageRing(0),
+		detailViewport: viewport.New(80, 20),
+		vimKeys:        NewVimKeyState(),
+		alerts:         alerts,
+		alertOut:       &term,
+		width:          120,
+		height:         30,
+	}
+
+	updated, _ := m.Update(msgReceived{msg: Message{RoutingKey: "order.created"}})
+	m = updated.(model)
+	updated, _ = m.Update(msgReceived{msg: Message{RoutingKey: "payment.failed"}})
+	m = updated.(model)
+
+	if !m.paused {
+		t.Error("the rule should pause the view")
+	}
+	failed := m.messages.At(1)
+	if failed.highlight == nil || !failed.Bookmarked {
+		t.Errorf("matched message: highlight %v, bookmarked %v", failed.highlight != nil, failed.Bookmarked)
+	}
+	if !strings.Contains(m.View(), "⚑ 1 alerts (failed)") {
+		t.Error("status bar should count the alerts")
+	}
+
+	// While paused, matches are buffered but still counted
+	updated, _ = m.Update(msgReceived{msg: Message{RoutingKey: "refund.failed"}})
+	m = updated.(model)
+	if fired, _ := m.alerts.Fired(); fired != 2 || len(m.pauseBuffer) != 1 {
+		t.Errorf("fired = %d, buffered = %d; want 2, 1", fired, len(m.pauseBuffer))
+	}
+	m.resume()
+	if !m.messages.At(2).Bookmarked {
+		t.Error("a buffered match should be bookmarked once listed")
+	}
+
+	// The bell is rung by a command, off the update loop
+	_, cmd := m.fireAlerts(&Message{RoutingKey: "stock.failed"})
+	if term.Len() != 0 {
+		t.Fatal("the terminal was written to during the update")
+	}
+	cmd()
+	if term.String() != "\a" {
+		t.Errorf("terminal got %q, want a bell", term.String())
+	}
+}
@@ -0,0 +1,309 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/epalmerini/rabbithole/internal/config"
+	"github.com/epalmerini/rabbithole/internal/db"
+)
+
+// annotatedLimit bounds the session browser's list of annotated messages.
+const annotatedLimit = 500
+
+// annotateField is the annotation being edited in the consumer view.
+type annotateField int
+
+const (
+	annotateNone annotateField = iota
+	annotateNote
+	annotateTags
+)
+
+type annotationSavedMsg struct {
+	err error
+}
+
+// annotation returns what the user recorded about msg.
+func (msg Message) annotation() db.Annotation {
+	return db.Annotation{Bookmarked: msg.Bookmarked, Note: msg.Note, Tags: msg.Tags}
+}
+
+// annotate copies stored annotations, keyed by stored message ID, to msgs.
+func annotate(msgs []Message, notes map[int64]db.Annotation) {
+	for i := range msgs {
+		if a, ok := notes[msgs[i].storeID]; ok {
+			msgs[i].Bookmarked, msgs[i].Note, msgs[i].Tags = a.Bookmarked, a.Note, a.Tags
+		}
+	}
+}
+
+func storeIDs(rows []db.Message) []int64 {
+	ids := make([]int64, len(rows))
+	for i, r := range rows {
+		ids[i] = r.ID
+	}
+	return ids
+}
+
+// parseTags splits tag input on spaces and commas, dropping a leading # and
+// duplicates.
+func parseTags(s string) []string {
+	var tags []string
+	seen := make(map[string]bool)
+	for _, t := range strings.FieldsFunc(s, func(r rune) bool { return r == ',' || r == ' ' || r == '\t' }) {
+		t = strings.TrimLeft(t, "#")
+		if t != "" && !seen[t] {
+			seen[t] = true
+			tags = append(tags, t)
+		}
+	}
+	return tags
+}
+
+// startAnnotate opens the input for the selected message's note or tags.
+func (m *model) startAnnotate(field annotateField) tea.Cmd {
+	if m.messages.Len() == 0 || m.selectedIdx >= m.messages.Len() {
+		return nil
+	}
+	msg := m.messages.At(m.selectedIdx)
+
+	in := textinput.New()
+	in.Width = 50
+	switch field {
+	case annotateNote:
+		in.Placeholder = "Note..."
+		in.CharLimit = 500
+		in.SetValue(msg.Note)
+	case annotateTags:
+		in.Placeholder = "Tags, separated by spaces..."
+		in.CharLimit = 200
+		in.SetValue(strings.Join(msg.Tags, " "))
+	}
+	in.CursorEnd()
+	in.Focus()
+
+	m.annotating = field
+	m.annotateInput = in
+	// New deliveries may move the selection while the user types
+	m.annotateID = msg.ID
+	return textinput.Blink
+}
+
+func (m model) updateAnnotate(key tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch key.String() {
+	case "esc":
+		m.annotating = annotateNone
+		return m, nil
+	case "enter":
+		field := m.annotating
+		m.annotating = annotateNone
+		var msg *Message
+		for i := range m.messages.Len() {
+			if m.messages.At(i).ID == m.annotateID {
+				msg = m.messages.At(i)
+				break
+			}
+		}
+		if msg == nil {
+			return m, m.setStatusMsg("The message is no longer listed")
+		}
+		if field == annotateNote {
+			msg.Note = strings.TrimSpace(m.annotateInput.Value())
+		} else {
+			msg.Tags = parseTags(m.annotateInput.Value())
+		}
+		return m, m.saveAnnotation(*msg)
+	}
+	var cmd tea.Cmd
+	m.annotateInput, cmd = m.annotateInput.Update(key)
+	return m, cmd
+}
+
+func (m model) renderAnnotateBar() string {
+	label := "Note: "
+	if m.annotating == annotateTags {
+		label = "Tags: "
+	}
+	return helpStyle.Render(label) + m.annotateInput.View() + helpStyle.Render("  (Enter to save, Esc to cancel)")
+}
+
+// renderAnnotation shows the note and tags of msg above its details, or ""
+// when it has none.
+func renderAnnotation(msg Message, width int) string {
+	var parts []string
+	if msg.Note != "" {
+		parts = append(parts, "✎ "+msg.Note)
+	}
+	for _, t := range msg.Tags {
+		parts = append(parts, "#"+t)
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return bookmarkStyle.Render(truncate(strings.Join(parts, "  "), width))
+}
+
+// saveAnnotation saves the bookmark, note and tags of msg with the stored
+// message in the background. Without persistence they last as long as the
+// message is listed.
+func (m *model) saveAnnotation(msg Message) tea.Cmd {
+	store := m.store
+	if store == nil || (msg.storeID == 0 && msg.saved == nil) {
+		return nil
+	}
+	a := msg.annotation()
+	return func() tea.Msg {
+		ctx := context.Background()
+		id, err := msg.storedID(ctx)
+		if err != nil {
+			return annotationSavedMsg{err: err}
+		}
+		return annotationSavedMsg{err: store.SetAnnotation(ctx, id, a)}
+	}
+}
+
+// Annotated messages across sessions, listed by the session browser (B key).
+
+type annotatedLoadedMsg struct {
+	entries []db.AnnotatedMessage
+	err     error
+}
+
+func (m sessionBrowserModel) loadAnnotated() tea.Cmd {
+	store := m.store
+	return func() tea.Msg {
+		entries, err := store.ListAnnotated(context.Background(), annotatedLimit)
+		return annotatedLoadedMsg{entries: entries, err: err}
+	}
+}
+
+// openAnnotated replays the session of the selected annotated message,
+// starting at it.
+func (m sessionBrowserModel) openAnnotated() tea.Cmd {
+	if m.annotatedIdx >= len(m.annotated) {
+		return nil
+	}
+	am := m.annotated[m.annotatedIdx]
+	session := db.Session{ID: am.SessionID, Exchange: am.Exchange, RoutingKey: "#"}
+	for _, entry := range m.sessions {
+		if entry.session.ID == am.SessionID {
+			session = entry.session
+			break
+		}
+	}
+	return func() tea.Msg {
+		return replaySessionMsg{session: session, messageID: am.ID}
+	}
+}
+
+func (m sessionBrowserModel) updateAnnotated(key tea.KeyMsg) (tea.Model, tea.Cmd) {
+	visible := max(m.height-12, 1)
+	switch m.config.Keys.translate(config.KeysSessions, key.String()) {
+	case "q", "ctrl+c":
+		return m, tea.Quit
+	case "esc", "B":
+		m.showAnnotated = false
+	case "up", "k":
+		if m.annotatedIdx > 0 {
+			m.annotatedIdx--
+		}
+	case "down", "j":
+		if m.annotatedIdx < len(m.annotated)-1 {
+			m.annotatedIdx++
+		}
+	case "g":
+		m.annotatedIdx = 0
+	case "G":
+		m.annotatedIdx = max(len(m.annotated)-1, 0)
+	case "r":
+		m.loading = true
+		return m, m.loadAnnotated()
+	case "enter":
+		if m.annotatedIdx < len(m.annotated) {
+			m.loading = true
+			return m, m.openAnnotated()
+		}
+	}
+	m.annotatedOff = min(m.annotatedOff, m.annotatedIdx)
+	if m.annotatedIdx >= m.annotatedOff+visible {
+		m.annotatedOff = m.annotatedIdx - visible + 1
+	}
+	return m, nil
+}
+
+func (m sessionBrowserModel) renderAnnotated() string {
+	var sb strings.Builder
+	sb.WriteString(fieldNameStyle.Render("Bookmarks and notes (all sessions):"))
+	sb.WriteString("\n\n")
+
+	box := func() string {
+		return messageListStyle.Width(m.width - 4).Height(m.height - 8).Render(sb.String())
+	}
+	if m.loading {
+		sb.WriteString("  " + m.spinner.View() + " Loading...")
+		return box()
+	}
+	if m.err != nil {
+		sb.WriteString(errorStyle.Render(fmt.Sprintf("  Error: %v", m.err)))
+		return box()
+	}
+	if len(m.annotated) == 0 {
+		sb.WriteString(mutedStyle.Render("  No bookmarked or annotated messages. Press m, i or # on a message in the consumer view."))
+		return box()
+	}
+
+	innerWidth := m.width - 8
+	end := min(m.annotatedOff+max(m.height-12, 1), len(m.annotated))
+	for i := m.annotatedOff; i < end; i++ {
+		am := m.annotated[i]
+		ts := am.ConsumedAt
+		if am.Timestamp.Valid {
+			ts = am.Timestamp.Time
+		}
+		mark := " "
+		if am.Annotation.Bookmarked {
+			mark = "*"
+		}
+		var note []string
+		if am.Annotation.Note != "" {
+			note = append(note, am.Annotation.Note)
+		}
+		for _, t := range am.Annotation.Tags {
+			note = append(note, "#"+t)
+		}
+		line := fmt.Sprintf("%s %s  %s  %-25s  %s",
+			mark,
+			mutedStyle.Render(fmt.Sprintf("#%-4d", am.SessionID)),
+			ts.Local().Format("Jan 02 15:04:05"),
+			routingKeyStyle.Render(truncate(am.RoutingKey, 25)),
+			bookmarkStyle.Render(truncate(strings.Join(note, "  "), max(innerWidth-60, 10))),
+		)
+		if i == m.annotatedIdx {
+			sb.WriteString(selectedMessageStyle.Width(innerWidth).Render("▶" + line))
+		} else {
+			sb.WriteString(normalMessageStyle.Width(innerWidth).Render(" " + line))
+		}
+		sb.WriteString("\n")
+	}
+	return box()
+}
+
+func (m sessionBrowserModel) renderAnnotatedHelp() string {
+	h := func(actions ...string) string { return m.config.Keys.hint(config.KeysSessions, actions...) }
+	keys := []struct{ key, desc string }{
+		{h("down", "up"), "navigate"},
+		{h("open"), "open in session"},
+		{h("refresh"), "refresh"},
+		{h("clear_filter", "bookmarks"), "sessions"},
+		{h("quit"), "quit"},
+	}
+	var parts []string
+	for _, k := range keys {
+		parts = append(parts, fmt.Sprintf("%s %s", helpKeyStyle.Render(k.key), k.desc))
+	}
+	return helpStyle.Render(strings.Join(parts, "  "))
+}
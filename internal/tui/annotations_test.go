@@ -0,0 +1,98 @@
+package tui
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/epalmerini/rabbithole/internal/db"
+)
+
+func TestParseTags(t *testing.T) {
+	got := parseTags(" #incident-42, payments  payments,,#")
+	if want := []string{"incident-42", "payments"}; !slices.Equal(got, want) {
+		t.Errorf("parseTags = %q, want %q", got, want)
+	}
+	if got := parseTags("  "); got != nil {
+		t.Errorf("parseTags of blanks = %q, want none", got)
+	}
+}
+
+func TestAnnotations_Replay(t *testing.T) {
+	m := newReplayTestModel(t, 20)
+	ctx := context.Background()
+
+	m = press(t, m, "3", "j", "m")
+	m = press(t, m, "i")
+	m.annotateInput.SetValue("  late delivery ")
+	m = press(t, m, "enter")
+	m = press(t, m, "#")
+	m.annotateInput.SetValue("#sla, payments")
+	m = press(t, m, "enter")
+
+	msg := m.messages.At(m.selectedIdx)
+	if !msg.Bookmarked || msg.Note != "late delivery" || !slices.Equal(msg.Tags, []string{"sla", "payments"}) {
+		t.Fatalf("selected message = bookmarked %v, note %q, tags %q", msg.Bookmarked, msg.Note, msg.Tags)
+	}
+
+	annotated, err := m.store.ListAnnotated(ctx, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(annotated) != 1 || annotated[0].RoutingKey != "order.3" || annotated[0].Annotation.Note != "late delivery" {
+		t.Fatalf("ListAnnotated = %+v", annotated)
+	}
+
+	// Replaying the session again shows the annotation
+	again := run(t, m, m.fetchReplay(replayTarget{id: annotated[0].ID}))
+	if msg := again.messages.At(again.selectedIdx); msg.RoutingKey != "order.3" || !msg.Bookmarked || len(msg.Tags) != 2 {
+		t.Errorf("reopened at %s: bookmarked %v, tags %q", msg.RoutingKey, msg.Bookmarked, msg.Tags)
+	}
+
+	// The session browser lists it and opens its session at the message
+	b := newSessionBrowserModel(Config{}, m.store)
+	b.width, b.height = 120, 40
+	updated, cmd := b.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("B")})
+	b = updated.(sessionBrowserModel)
+	updated, _ = b.Update(cmd())
+	b = updated.(sessionBrowserModel)
+	if !b.showAnnotated || len(b.annotated) != 1 {
+		t.Fatalf("annotated list = %v, %d entries", b.showAnnotated, len(b.annotated))
+	}
+	_, cmd = b.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	open, ok := cmd().(replaySessionMsg)
+	if !ok || open.messageID != annotated[0].ID || open.session.ID != annotated[0].SessionID {
+		t.Errorf("enter = %+v, want to replay the annotated message", open)
+	}
+}
+
+func TestSaveAnnotation_LiveMessage(t *testing.T) {
+	m := newReplayTestModel(t, 5)
+	ctx := context.Background()
+
+	// A live delivery is annotated by the ID the writer reports back
+	w := db.NewAsyncWriter(m.store, m.pager.sessionID)
+	live := Message{RoutingKey: "order.9", RawBody: []byte{0x08, 9}, Note: "retried", saved: newSavedRow()}
+	if !w.SaveThen(&db.MessageRecord{RoutingKey: live.RoutingKey, Body: live.RawBody}, live.saved.set) {
+		t.Fatal("SaveThen failed")
+	}
+	w.Close()
+	if saved := m.saveAnnotation(live)().(annotationSavedMsg); saved.err != nil {
+		t.Fatal(saved.err)
+	}
+	annotated, err := m.store.ListAnnotated(ctx, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(annotated) != 1 || annotated[0].RoutingKey != "order.9" || annotated[0].ID != live.saved.id {
+		t.Fatalf("ListAnnotated = %+v", annotated)
+	}
+
+	// One the writer dropped is not mistaken for another
+	dropped := Message{RoutingKey: "order.10", Bookmarked: true, saved: newSavedRow()}
+	dropped.saved.set(0, errNotSaved)
+	if saved := m.saveAnnotation(dropped)().(annotationSavedMsg); saved.err == nil {
+		t.Error("a message that was not stored should not be annotated")
+	}
+}
@@ -1,6 +1,8 @@
 package tui
 
 import (
+	"fmt"
+
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/epalmerini/rabbithole/internal/config"
 	"github.com/epalmerini/rabbithole/internal/db"
@@ -15,6 +17,7 @@ const (
 	appViewSessionBrowser
 	appViewProfilePicker
 	appViewURLPrompt
+	appViewDashboard
 )
 
 type appModel struct {
@@ -29,12 +32,13 @@ type appModel struct {
 	sessionBrowser sessionBrowserModel
 	profilePicker  profilePickerModel
 	urlPrompt      urlPromptModel
+	dashboard      dashboardModel
 
 	// Last known window size
 	width, height int
 
 	// Track queues created across views for deletion
-	createdQueues map[string]bool
+	createdQueues map[queueRef]bool
 }
 
 func newAppModel(cfg Config, store db.Store) appModel {
@@ -43,7 +47,7 @@ func newAppModel(cfg Config, store db.Store) appModel {
 		store:         store,
 		view:          appViewBrowser,
 		browser:       newBrowserModel(cfg),
-		createdQueues: make(map[string]bool),
+		createdQueues: make(map[queueRef]bool),
 	}
 }
 
@@ -54,7 +58,7 @@ func newAppModelWithProfilePicker(fileCfg *config.FileConfig, configDir string,
 		store:         store,
 		view:          appViewProfilePicker,
 		profilePicker: newProfilePickerModel(fileCfg.Profiles),
-		createdQueues: make(map[string]bool),
+		createdQueues: make(map[queueRef]bool),
 	}
 }
 
@@ -65,7 +69,7 @@ func newAppModelWithURLPrompt(fileCfg *config.FileConfig, configDir string, stor
 		store:         store,
 		view:          appViewURLPrompt,
 		urlPrompt:     newURLPromptModel(),
-		createdQueues: make(map[string]bool),
+		createdQueues: make(map[queueRef]bool),
 	}
 }
 
@@ -81,10 +85,23 @@ func resolveConfig(fileCfg *config.FileConfig, configDir, profileName, url strin
 			ProtoPath:         resolved.ProtoPath,
 			DBPath:            resolved.DBPath,
 			MaxMessages:       resolved.MaxMessages,
+			PauseBuffer:       resolved.PauseBuffer,
+			PauseOverflow:     resolved.PauseOverflow,
+			Production:        resolved.Production,
+			TraceHeader:       resolved.TraceHeader,
+			DiffKey:           resolved.DiffKey,
+			LatencyHeader:     resolved.LatencyHeader,
+			Alerts:            resolved.Alerts,
+			Filters:           resolved.Filters,
 			DefaultSplitRatio: resolved.DefaultSplitRatio,
 			CompactMode:       resolved.CompactMode,
+			ChartMinutes:      resolved.ChartMinutes,
+			Columns:           resolved.Columns,
+			Split:             resolved.Split,
 			ConfigDir:         resolved.ConfigDir,
 		}
+		// Run has reported bad bindings already
+		cfg.Keys, _ = NewKeymap(resolved.Keys)
 	}
 
 	// Override URL if provided directly (from URL prompt)
@@ -139,10 +156,22 @@ func (m appModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.browser.height = m.height
 		return m, m.browser.Init()
 
+	case pruneFailedMsg:
+		text := fmt.Sprintf("Pruning old sessions failed: %v", msg.err)
+		if m.view == appViewConsumer {
+			return m, m.consumer.setStatusMsg(text)
+		}
+		m.browser.notice = text
+		return m, nil
+
+	case vhostSwitchedMsg:
+		// Consumers started from now on connect to the new vhost
+		m.config.RabbitMQURL = msg.url
+
 	case startConsumingMsg:
 		// Track newly created queue
 		if msg.queue != "" {
-			m.createdQueues[msg.queue] = true
+			m.createdQueues[m.browser.queueRef(msg.queue)] = true
 		}
 		// Copy created queues to browser for tracking
 		for q := range m.createdQueues {
@@ -172,20 +201,23 @@ func (m appModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		replayCfg := m.config
 		replayCfg.Exchange = msg.session.Exchange
 		replayCfg.RoutingKey = msg.session.RoutingKey
-		m.consumer = initialReplayModel(replayCfg, msg.session, msg.messages)
+		m.consumer = initialReplayModel(replayCfg, msg.session, m.store)
 		m.consumer.width = m.sessionBrowser.width
 		m.consumer.height = m.sessionBrowser.height
-		return m, nil
+		return m, m.consumer.fetchReplay(replayTarget{id: msg.messageID})
 
 	case tea.KeyMsg:
 		// Global escape to go back to browser from consumer
-		if m.view == appViewConsumer && msg.String() == "b" && !m.consumer.searchMode && !m.consumer.filterMode {
+		if m.view == appViewConsumer && m.config.Keys.is(config.KeysConsumer, msg.String(), "back") && !m.consumer.searchMode && !m.consumer.filterMode && m.consumer.annotating == annotateNone && m.consumer.filterPicker == nil && m.consumer.trace == nil && m.consumer.groups == nil {
 			// Clean up consumer resources on navigate-away
 			m.consumer.cleanup()
 
 			// If we came from session browser (replay mode), go back there
 			if m.consumer.replayMode {
 				m.view = appViewSessionBrowser
+				if m.sessionBrowser.showAnnotated {
+					return m, tea.Batch(m.sessionBrowser.loadSessions(), m.sessionBrowser.loadAnnotated())
+				}
 				return m, m.sessionBrowser.loadSessions()
 			}
 
@@ -198,7 +230,7 @@ func (m appModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 
 		// Switch to session browser from topology browser
-		if m.view == appViewBrowser && msg.String() == "s" && !m.browser.searchMode && m.store != nil {
+		if m.view == appViewBrowser && m.config.Keys.is(config.KeysBrowser, msg.String(), "sessions") && !m.browser.capturingInput() && m.store != nil {
 			m.view = appViewSessionBrowser
 			m.sessionBrowser = newSessionBrowserModel(m.config, m.store)
 			m.sessionBrowser.width = m.browser.width
@@ -206,8 +238,23 @@ func (m appModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m, m.sessionBrowser.Init()
 		}
 
+		// Open the cluster dashboard from the exchange list
+		if m.view == appViewBrowser && m.config.Keys.is(config.KeysBrowser, msg.String(), "dashboard") && m.browser.view == viewExchanges && !m.browser.capturingInput() {
+			m.view = appViewDashboard
+			m.dashboard = newDashboardModel(m.config)
+			m.dashboard.width = m.browser.width
+			m.dashboard.height = m.browser.height
+			return m, m.dashboard.Init()
+		}
+
+		// Back to topology browser from the dashboard
+		if m.view == appViewDashboard && m.config.Keys.is(config.KeysDashboard, msg.String(), "back") {
+			m.view = appViewBrowser
+			return m, m.browser.loadTopology()
+		}
+
 		// Back to topology browser from session browser
-		if m.view == appViewSessionBrowser && msg.String() == "b" && !m.sessionBrowser.searchMode && !m.sessionBrowser.ftsMode && !m.sessionBrowser.confirmDelete {
+		if m.view == appViewSessionBrowser && m.config.Keys.is(config.KeysSessions, msg.String(), "back") && !m.sessionBrowser.searchMode && !m.sessionBrowser.ftsMode && !m.sessionBrowser.confirmDelete && !m.sessionBrowser.exportMode {
 			m.view = appViewBrowser
 			return m, m.browser.loadTopology()
 		}
@@ -242,6 +289,11 @@ func (m appModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		newSB, cmd := m.sessionBrowser.Update(msg)
 		m.sessionBrowser = newSB.(sessionBrowserModel)
 		return m, cmd
+
+	case appViewDashboard:
+		newDashboard, cmd := m.dashboard.Update(msg)
+		m.dashboard = newDashboard.(dashboardModel)
+		return m, cmd
 	}
 
 	return m, nil
@@ -259,6 +311,8 @@ func (m appModel) View() string {
 		return m.consumer.View()
 	case appViewSessionBrowser:
 		return m.sessionBrowser.View()
+	case appViewDashboard:
+		return m.dashboard.View()
 	}
 	return ""
 }
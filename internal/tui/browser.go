@@ -9,6 +9,7 @@ import (
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/epalmerini/rabbithole/internal/config"
 	"github.com/epalmerini/rabbithole/internal/rabbitmq"
 	"github.com/epalmerini/rabbithole/internal/randutil"
 )
@@ -19,6 +20,14 @@ const (
 	viewExchanges browserView = iota
 	viewBindings
 	viewCreateQueue
+	viewPolicies
+	viewPolicyEdit
+	viewVHosts
+	viewConnections
+	viewChannels
+	viewConsumers
+	viewQueues
+	viewBulkConfirm
 )
 
 type browserModel struct {
@@ -46,7 +55,32 @@ type browserModel struct {
 	filteredList []int // indices of filtered items
 
 	// Created queues tracking (for deletion)
-	createdQueues map[string]bool
+	createdQueues map[queueRef]bool
+
+	// Policies
+	policies            []rabbitmq.Policy
+	policyForm          policyForm
+	confirmPolicyDelete bool
+
+	// Virtual hosts
+	vhosts []vhostEntry
+
+	// Connections, channels and consumers
+	connections        []rabbitmq.Connection
+	channels           []rabbitmq.Channel
+	consumers          []rabbitmq.ConsumerInfo
+	selectedConnection string
+	consumerScope      consumerScope
+	consumersBack      browserView // view to return to from consumers
+	confirmClose       bool
+	closeReasonInput   textinput.Model
+
+	// Queue multi-selection and bulk operations
+	selectedQueues map[string]bool
+	patternMode    bool
+	patternInput   textinput.Model
+	bulk           bulkOp
+	notice         string // outcome of the last bulk operation
 
 	// Spinner
 	spinner spinner.Model
@@ -76,6 +110,13 @@ type startConsumingMsg struct {
 	durable    bool
 }
 
+// queueRef names a queue in a virtual host: queues of the same name in
+// other vhosts are different queues.
+type queueRef struct {
+	vhost string
+	name  string
+}
+
 type queueDeletedMsg struct {
 	queue string
 }
@@ -104,15 +145,18 @@ func newBrowserModel(cfg Config) browserModel {
 	mgmt, _ := rabbitmq.NewManagementClient(cfg.RabbitMQURL, cfg.ManagementURL)
 
 	return browserModel{
-		config:          cfg,
-		mgmt:            mgmt,
-		view:            viewExchanges,
-		routingKeyInput: routingInput,
-		queueNameInput:  queueInput,
-		searchInput:     searchInput,
-		createdQueues:   make(map[string]bool),
-		spinner:         sp,
-		loading:         true,
+		config:           cfg,
+		mgmt:             mgmt,
+		view:             viewExchanges,
+		routingKeyInput:  routingInput,
+		queueNameInput:   queueInput,
+		searchInput:      searchInput,
+		createdQueues:    make(map[queueRef]bool),
+		closeReasonInput: newCloseReasonInput(),
+		selectedQueues:   make(map[string]bool),
+		patternInput:     newPatternInput(),
+		spinner:          sp,
+		loading:          true,
 	}
 }
 
@@ -146,6 +190,26 @@ func (m browserModel) loadTopology() tea.Cmd {
 	}
 }
 
+// capturingInput reports whether keys are going to a text input, so global
+// shortcuts must not fire.
+func (m browserModel) capturingInput() bool {
+	return m.searchMode || m.confirmClose || m.patternMode ||
+		m.view == viewCreateQueue || m.view == viewPolicyEdit || m.view == viewBulkConfirm
+}
+
+// queueRef refers to the queue name in the current vhost.
+func (m browserModel) queueRef(name string) queueRef {
+	return queueRef{vhost: m.vhost(), name: name}
+}
+
+// vhost returns the virtual host the browser is scoped to.
+func (m browserModel) vhost() string {
+	if m.mgmt == nil {
+		return "/"
+	}
+	return m.mgmt.VHost()
+}
+
 func (m browserModel) loadBindings(exchange string) tea.Cmd {
 	mgmt := m.mgmt
 	return func() tea.Msg {
@@ -178,6 +242,11 @@ func (m browserModel) deleteQueue(queueName string) tea.Cmd {
 	}
 }
 
+// key returns the key a list switch matches, after [keys.browser] bindings.
+func (m browserModel) key(msg tea.KeyMsg) string {
+	return m.config.Keys.translate(config.KeysBrowser, msg.String())
+}
+
 func (m browserModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	var cmds []tea.Cmd
 
@@ -252,7 +321,7 @@ func (m browserModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				}
 				durable := m.durableQueue
 				// Track created queue
-				m.createdQueues[queueName] = true
+				m.createdQueues[m.queueRef(queueName)] = true
 				return m, func() tea.Msg {
 					return startConsumingMsg{
 						exchange:   m.selectedExchange,
@@ -274,7 +343,52 @@ func (m browserModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 		}
 
-		switch msg.String() {
+		m.notice = ""
+
+		switch m.view {
+		case viewPolicyEdit:
+			return m.updatePolicyForm(msg)
+		case viewBulkConfirm:
+			return m.updateBulkConfirm(msg)
+		case viewQueues:
+			var cmd tea.Cmd
+			var handled bool
+			if m, cmd, handled = m.updateQueues(msg); handled {
+				return m, cmd
+			}
+		case viewPolicies:
+			var cmd tea.Cmd
+			var handled bool
+			if m, cmd, handled = m.updatePolicies(msg); handled {
+				return m, cmd
+			}
+		case viewVHosts:
+			var cmd tea.Cmd
+			var handled bool
+			if m, cmd, handled = m.updateVHosts(msg); handled {
+				return m, cmd
+			}
+		case viewConnections:
+			var cmd tea.Cmd
+			var handled bool
+			if m, cmd, handled = m.updateConnections(msg); handled {
+				return m, cmd
+			}
+		case viewChannels:
+			var cmd tea.Cmd
+			var handled bool
+			if m, cmd, handled = m.updateChannels(msg); handled {
+				return m, cmd
+			}
+		case viewConsumers:
+			var cmd tea.Cmd
+			var handled bool
+			if m, cmd, handled = m.updateConsumers(msg); handled {
+				return m, cmd
+			}
+		}
+
+		switch m.key(msg) {
 		case "q", "ctrl+c":
 			return m, tea.Quit
 		case "/":
@@ -306,14 +420,31 @@ func (m browserModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			if maxIdx >= 0 {
 				m.selectedIdx = maxIdx
 			}
+		case " ":
+			// Toggle the binding's destination queue for bulk operations
+			if m.view == viewBindings && m.selectedIdx > 0 && m.selectedIdx-1 < len(m.bindings) {
+				m.toggleQueueSelection(m.bindings[m.selectedIdx-1].Destination)
+			}
+		case "p":
+			if m.view == viewBindings {
+				current := ""
+				if m.selectedIdx > 0 && m.selectedIdx-1 < len(m.bindings) {
+					current = m.bindings[m.selectedIdx-1].Destination
+				}
+				return m.startBulk(bulkPurge, m.selection(current))
+			}
 		case "d":
+			// Bulk delete the selection, if any
+			if selected := m.selection(""); m.view == viewBindings && len(selected) > 0 {
+				return m.startBulk(bulkDelete, selected)
+			}
 			// Delete queue (only if created this session)
 			if m.view == viewBindings && m.selectedIdx > 0 {
 				bindingIdx := m.selectedIdx - 1
 				if bindingIdx < len(m.bindings) {
 					queueName := m.bindings[bindingIdx].Destination
-					if m.createdQueues[queueName] {
-						delete(m.createdQueues, queueName)
+					if m.createdQueues[m.queueRef(queueName)] {
+						delete(m.createdQueues, m.queueRef(queueName))
 						return m, m.deleteQueue(queueName)
 					}
 				}
@@ -352,6 +483,11 @@ func (m browserModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					}
 				}
 			}
+		case "c":
+			// Consumers of the selected binding's queue
+			if m.view == viewBindings && m.selectedIdx > 0 && m.selectedIdx-1 < len(m.bindings) {
+				return m.showConsumers(consumerScope{queue: m.bindings[m.selectedIdx-1].Destination}, viewBindings)
+			}
 		case "esc", "backspace":
 			if m.view == viewBindings {
 				m.view = viewExchanges
@@ -361,6 +497,46 @@ func (m browserModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.filterQuery = ""
 				m.filteredList = nil
 			}
+		case "P":
+			if m.view == viewExchanges {
+				m.view = viewPolicies
+				m.selectedIdx = 0
+				m.scrollOff = 0
+				m.loading = true
+				m.filterQuery = ""
+				m.filteredList = nil
+				return m, m.loadPolicies()
+			}
+		case "V":
+			if m.view == viewExchanges {
+				m.view = viewVHosts
+				m.selectedIdx = 0
+				m.scrollOff = 0
+				m.loading = true
+				m.filterQuery = ""
+				m.filteredList = nil
+				return m, m.loadVHosts()
+			}
+		case "Q":
+			if m.view == viewExchanges {
+				m.view = viewQueues
+				m.selectedIdx = 0
+				m.scrollOff = 0
+				m.loading = true
+				m.filterQuery = ""
+				m.filteredList = nil
+				return m, m.loadTopology()
+			}
+		case "C":
+			if m.view == viewExchanges {
+				m.view = viewConnections
+				m.selectedIdx = 0
+				m.scrollOff = 0
+				m.loading = true
+				m.filterQuery = ""
+				m.filteredList = nil
+				return m, m.loadConnections()
+			}
 		case "r":
 			m.loading = true
 			return m, m.loadTopology()
@@ -371,15 +547,86 @@ func (m browserModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.height = msg.Height
 
 	case exchangesLoadedMsg:
-		m.loading = false
+		if m.view == viewExchanges || m.view == viewBindings || m.view == viewQueues {
+			m.loading = false
+		}
 		m.exchanges = msg.exchanges
 		m.queues = msg.queues
 		m.applyFilter()
+		if m.view == viewQueues && m.selectedIdx >= len(m.queues) {
+			m.selectedIdx = 0
+			m.scrollOff = 0
+		}
 
 	case bindingsLoadedMsg:
 		m.loading = false
 		m.bindings = msg.bindings
 
+	case vhostsLoadedMsg:
+		m.loading = false
+		m.err = nil
+		m.vhosts = msg.entries
+		for i, e := range m.vhosts {
+			if e.vhost.Name == m.vhost() {
+				m.selectedIdx = i
+			}
+		}
+
+	case bulkDoneMsg:
+		m.loading = false
+		m.err = msg.err
+		verb := "Purged"
+		if msg.action == bulkDelete {
+			verb = "Deleted"
+		}
+		m.notice = fmt.Sprintf("%s %d queue(s)", verb, len(msg.done))
+		for _, q := range msg.done {
+			delete(m.selectedQueues, q)
+			if msg.action == bulkDelete {
+				delete(m.createdQueues, m.queueRef(q))
+			}
+		}
+		cmds := []tea.Cmd{m.loadTopology()}
+		if m.view == viewBindings {
+			cmds = append(cmds, m.loadBindings(m.selectedExchange))
+		}
+		return m, tea.Batch(cmds...)
+
+	case connectionsLoadedMsg:
+		m.loading = false
+		m.err = nil
+		m.connections = msg.connections
+		if m.selectedIdx >= len(m.connections) {
+			m.selectedIdx = 0
+		}
+
+	case channelsLoadedMsg:
+		m.loading = false
+		m.err = nil
+		m.channels = msg.channels
+
+	case consumersLoadedMsg:
+		m.loading = false
+		m.err = nil
+		m.consumers = msg.consumers
+
+	case connectionClosedMsg:
+		m.loading = true
+		return m, m.loadConnections()
+
+	case policiesLoadedMsg:
+		m.loading = false
+		m.err = nil
+		m.policies = msg.policies
+		if m.selectedIdx >= len(m.policies) {
+			m.selectedIdx = 0
+		}
+
+	case policySavedMsg, policyDeletedMsg:
+		// Reload policies and queues so effective policies reflect the change
+		m.loading = true
+		return m, tea.Batch(m.loadPolicies(), m.loadTopology())
+
 	case queueDeletedMsg:
 		// Reload bindings after deletion
 		m.loading = true
@@ -436,6 +683,18 @@ func (m browserModel) maxIndex() int {
 		return len(m.exchanges) - 1
 	case viewBindings:
 		return len(m.bindings) // +1 for "new binding" option, -1 for 0-index
+	case viewPolicies:
+		return len(m.policies) - 1
+	case viewVHosts:
+		return len(m.vhosts) - 1
+	case viewConnections:
+		return len(m.connections) - 1
+	case viewChannels:
+		return len(m.channels) - 1
+	case viewConsumers:
+		return len(m.consumers) - 1
+	case viewQueues:
+		return len(m.queues) - 1
 	default:
 		return 0
 	}
@@ -447,7 +706,7 @@ func (m browserModel) View() string {
 	}
 
 	header := headerStyle.Width(m.width - 2).Render(
-		"rabbithole - Topology Browser",
+		"rabbithole - Topology Browser" + mutedStyle.Render(fmt.Sprintf("  vhost %s", m.vhost())),
 	)
 
 	var content string
@@ -458,12 +717,33 @@ func (m browserModel) View() string {
 		content = m.renderBindings()
 	case viewCreateQueue:
 		content = m.renderCreateQueue()
+	case viewPolicies:
+		content = m.renderPolicies()
+	case viewPolicyEdit:
+		content = m.renderPolicyForm()
+	case viewVHosts:
+		content = m.renderVHosts()
+	case viewConnections:
+		content = m.renderConnections()
+	case viewChannels:
+		content = m.renderChannels()
+	case viewConsumers:
+		content = m.renderConsumers()
+	case viewQueues:
+		content = m.renderQueues()
+	case viewBulkConfirm:
+		content = m.renderBulkConfirm()
 	}
 
 	var bottomBar string
-	if m.searchMode {
+	switch {
+	case m.searchMode:
 		bottomBar = helpStyle.Render("Filter: ") + m.searchInput.View() + helpStyle.Render("  (Enter to apply, Esc to cancel)")
-	} else {
+	case m.patternMode:
+		bottomBar = helpStyle.Render("Select queues matching: ") + m.patternInput.View() + helpStyle.Render("  (glob, Enter to select, Esc to cancel)")
+	case m.notice != "":
+		bottomBar = confirmationStyle.Render(m.notice) + "  " + m.renderHelp()
+	default:
 		bottomBar = m.renderHelp()
 	}
 
@@ -599,11 +879,21 @@ func (m browserModel) renderBindings() string {
 
 			// Show indicator if queue was created this session (can be deleted)
 			deleteHint := ""
-			if m.createdQueues[b.Destination] {
+			if m.createdQueues[m.queueRef(b.Destination)] {
 				deleteHint = mutedStyle.Render(" [d to delete]")
 			}
 
-			line := fmt.Sprintf("%s → %s%s", routingKeyStyle.Render(routingKey), b.Destination, deleteHint)
+			policyHint := ""
+			if q := m.queueByName(b.Destination); q != nil && (q.Policy != "" || q.OperatorPolicy != "") {
+				policyHint = mutedStyle.Render(" [" + effectivePolicyLine(q) + "]")
+			}
+
+			selectMark := ""
+			if m.selectedQueues[b.Destination] {
+				selectMark = "[x] "
+			}
+
+			line := fmt.Sprintf("%s%s → %s%s%s", selectMark, routingKeyStyle.Render(routingKey), b.Destination, policyHint, deleteHint)
 
 			actualIdx := i + 1 // +1 because of "new binding" at index 0
 			if actualIdx == m.selectedIdx {
@@ -613,6 +903,15 @@ func (m browserModel) renderBindings() string {
 			}
 			sb.WriteString("\n")
 		}
+
+		// Effective policy of the selected queue
+		if m.selectedIdx > 0 && m.selectedIdx-1 < len(m.bindings) {
+			if q := m.queueByName(m.bindings[m.selectedIdx-1].Destination); q != nil {
+				sb.WriteString("\n")
+				sb.WriteString(fieldNameStyle.Render("  Effective policy: "))
+				sb.WriteString(mutedStyle.Render(effectivePolicyLine(q)))
+			}
+		}
 	}
 
 	return messageListStyle.Width(m.width - 4).Height(m.height - 8).Render(sb.String())
@@ -675,24 +974,33 @@ func (m browserModel) renderCreateQueue() string {
 
 func (m browserModel) renderHelp() string {
 	var keys []struct{ key, desc string }
+	h := func(actions ...string) string { return m.config.Keys.hint(config.KeysBrowser, actions...) }
 
 	switch m.view {
 	case viewExchanges:
 		keys = []struct{ key, desc string }{
-			{"j/k", "navigate"},
-			{"/", "filter"},
-			{"enter", "select"},
-			{"s", "sessions"},
-			{"r", "refresh"},
-			{"q", "quit"},
+			{h("down", "up"), "navigate"},
+			{h("filter"), "filter"},
+			{h("open"), "select"},
+			{h("policies"), "policies"},
+			{h("vhosts"), "vhosts"},
+			{h("queues"), "queues"},
+			{h("connections"), "connections"},
+			{h("dashboard"), "dashboard"},
+			{h("sessions"), "sessions"},
+			{h("refresh"), "refresh"},
+			{h("quit"), "quit"},
 		}
 	case viewBindings:
 		keys = []struct{ key, desc string }{
-			{"j/k", "navigate"},
-			{"enter", "select"},
-			{"d", "delete"},
-			{"esc", "back"},
-			{"q", "quit"},
+			{h("down", "up"), "navigate"},
+			{h("open"), "select"},
+			{h("select"), "select"},
+			{h("consumers"), "consumers"},
+			{h("purge"), "purge"},
+			{h("delete"), "delete"},
+			{h("back"), "back"},
+			{h("quit"), "quit"},
 		}
 	case viewCreateQueue:
 		keys = []struct{ key, desc string }{
@@ -700,6 +1008,65 @@ func (m browserModel) renderHelp() string {
 			{"enter", "create"},
 			{"esc", "cancel"},
 		}
+	case viewPolicies:
+		keys = []struct{ key, desc string }{
+			{h("down", "up"), "navigate"},
+			{h("new"), "new"},
+			{h("edit"), "edit"},
+			{h("delete"), "delete"},
+			{h("refresh"), "refresh"},
+			{h("back"), "back"},
+		}
+	case viewVHosts:
+		keys = []struct{ key, desc string }{
+			{h("down", "up"), "navigate"},
+			{h("open"), "switch"},
+			{h("refresh"), "refresh"},
+			{h("back"), "back"},
+		}
+	case viewQueues:
+		keys = []struct{ key, desc string }{
+			{h("down", "up"), "navigate"},
+			{h("select"), "select"},
+			{h("select_pattern"), "select pattern"},
+			{h("select_all", "select_none"), "all/none"},
+			{h("purge"), "purge"},
+			{h("delete"), "delete"},
+			{h("consumers"), "consumers"},
+			{h("back"), "back"},
+		}
+	case viewBulkConfirm:
+		keys = []struct{ key, desc string }{
+			{"enter", "confirm"},
+			{"esc", "cancel"},
+		}
+	case viewConnections:
+		keys = []struct{ key, desc string }{
+			{h("down", "up"), "navigate"},
+			{h("open"), "channels"},
+			{h("close_connection"), "close"},
+			{h("refresh"), "refresh"},
+			{h("back"), "back"},
+		}
+	case viewChannels:
+		keys = []struct{ key, desc string }{
+			{h("down", "up"), "navigate"},
+			{h("open"), "consumers"},
+			{h("refresh"), "refresh"},
+			{h("back"), "back"},
+		}
+	case viewConsumers:
+		keys = []struct{ key, desc string }{
+			{h("down", "up"), "navigate"},
+			{h("refresh"), "refresh"},
+			{h("back"), "back"},
+		}
+	case viewPolicyEdit:
+		keys = []struct{ key, desc string }{
+			{"tab", "next field"},
+			{"enter", "save"},
+			{"esc", "cancel"},
+		}
 	}
 
 	var parts []string
@@ -0,0 +1,441 @@
+package tui
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+const (
+	// chartBuckets is the number of buckets a chart's span is divided into.
+	chartBuckets = 120
+	// chartKeys caps the routing keys tracked separately; messages for
+	// others only count in the totals.
+	chartKeys = 64
+	// chartTopKeys is the number of routing keys broken down in the panel.
+	chartTopKeys = 5
+)
+
+// sizeBounds are the upper bounds of the body size histogram bins; the last
+// bin holds anything larger.
+var sizeBounds = [...]int64{64, 256, 1 << 10, 4 << 10, 16 << 10, 64 << 10, 256 << 10, 1 << 20}
+
+const sizeBins = len(sizeBounds) + 1
+
+// chartBucket aggregates the messages that arrived in one slice of the span.
+type chartBucket struct {
+	slot       int64 // bucket number since the epoch
+	count      int64
+	latencySum time.Duration
+	latencyN   int64 // messages with a known publish time
+	latencyMax time.Duration
+	sizes      [sizeBins]int64
+}
+
+func (b *chartBucket) merge(o chartBucket) {
+	b.count += o.count
+	b.latencySum += o.latencySum
+	b.latencyN += o.latencyN
+	b.latencyMax = max(b.latencyMax, o.latencyMax)
+	for i, n := range o.sizes {
+		b.sizes[i] += n
+	}
+}
+
+// series is a ring of buckets covering the chart span.
+type series struct {
+	buckets [chartBuckets]chartBucket
+}
+
+// bucket returns the bucket for slot, recycling the one it replaces.
+func (s *series) bucket(slot int64) *chartBucket {
+	b := &s.buckets[slot%chartBuckets]
+	if b.slot != slot {
+		*b = chartBucket{slot: slot}
+	}
+	return b
+}
+
+// window returns the buckets of the span ending at slot now, oldest first;
+// those not reached since they were last recycled are empty.
+func (s *series) window(now int64) []chartBucket {
+	out := make([]chartBucket, chartBuckets)
+	for i := range out {
+		slot := now - chartBuckets + 1 + int64(i)
+		out[i] = chartBucket{slot: slot}
+		if slot >= 0 && s.buckets[slot%chartBuckets].slot == slot {
+			out[i] = s.buckets[slot%chartBuckets]
+		}
+	}
+	return out
+}
+
+// charts tracks message rate, body sizes and publish-to-consume latency over
+// the last span, in total and for the busiest routing keys.
+type charts struct {
+	span  time.Duration
+	total series
+	keys  map[string]*series
+}
+
+func newCharts(span time.Duration) *charts {
+	return &charts{span: span, keys: make(map[string]*series)}
+}
+
+// bucketWidth is the time each bucket covers.
+func (c *charts) bucketWidth() time.Duration {
+	return c.span / chartBuckets
+}
+
+func (c *charts) slot(t time.Time) int64 {
+	return t.UnixNano() / int64(c.bucketWidth())
+}
+
+// record logs a message consumed at now; latency is its publish-to-consume
+// time, when known.
+func (c *charts) record(now time.Time, rk string, size int, latency time.Duration, hasLatency bool) {
+	slot := c.slot(now)
+	add := func(b *chartBucket) {
+		b.count++
+		b.sizes[sizeBin(int64(size))]++
+		if hasLatency {
+			b.latencySum += latency
+			b.latencyN++
+			b.latencyMax = max(b.latencyMax, latency)
+		}
+	}
+	add(c.total.bucket(slot))
+
+	s, ok := c.keys[rk]
+	if !ok {
+		if len(c.keys) >= chartKeys {
+			c.prune(slot)
+		}
+		if len(c.keys) >= chartKeys {
+			return
+		}
+		s = &series{}
+		c.keys[rk] = s
+	}
+	add(s.bucket(slot))
+}
+
+// prune forgets the routing keys with no messages in the span ending at slot.
+func (c *charts) prune(slot int64) {
+	for rk, s := range c.keys {
+		if windowCount(s.window(slot)) == 0 {
+			delete(c.keys, rk)
+		}
+	}
+}
+
+// topKeys returns the routing keys with the most messages in the span ending
+// at slot, busiest first.
+func (c *charts) topKeys(slot int64, n int) []string {
+	type keyCount struct {
+		key   string
+		count int64
+	}
+	var counts []keyCount
+	for rk, s := range c.keys {
+		if count := windowCount(s.window(slot)); count > 0 {
+			counts = append(counts, keyCount{rk, count})
+		}
+	}
+	sort.Slice(counts, func(i, j int) bool {
+		if counts[i].count != counts[j].count {
+			return counts[i].count > counts[j].count
+		}
+		return counts[i].key < counts[j].key
+	})
+	var keys []string
+	for _, kc := range counts[:min(n, len(counts))] {
+		keys = append(keys, kc.key)
+	}
+	return keys
+}
+
+func windowCount(buckets []chartBucket) int64 {
+	var n int64
+	for _, b := range buckets {
+		n += b.count
+	}
+	return n
+}
+
+// columns merges buckets into at most width columns, newest last. The oldest
+// column may cover fewer buckets than the others.
+func columns(buckets []chartBucket, width int) (cols []chartBucket, perCol int) {
+	if width <= 0 {
+		return nil, 1
+	}
+	perCol = (len(buckets) + width - 1) / width
+	for end := len(buckets); end > 0; end -= perCol {
+		var col chartBucket
+		for _, b := range buckets[max(end-perCol, 0):end] {
+			col.merge(b)
+		}
+		cols = append(cols, col)
+	}
+	for i, j := 0, len(cols)-1; i < j; i, j = i+1, j-1 {
+		cols[i], cols[j] = cols[j], cols[i]
+	}
+	return cols, perCol
+}
+
+func sizeBin(size int64) int {
+	for i, bound := range sizeBounds {
+		if size <= bound {
+			return i
+		}
+	}
+	return len(sizeBounds)
+}
+
+// messageLatency returns the time between a message's publish and now: the
+// time in the configured header, or else the AMQP timestamp the publisher set.
+func messageLatency(msg Message, header string, now time.Time) (time.Duration, bool) {
+	var published time.Time
+	if v, ok := msg.Headers[header]; ok && header != "" {
+		published, ok = headerTime(v)
+		if !ok {
+			return 0, false
+		}
+	} else if msg.published {
+		published = msg.Timestamp
+	} else {
+		return 0, false
+	}
+	// Clocks of publisher and consumer may disagree
+	return max(now.Sub(published), 0), true
+}
+
+// headerTime reads a timestamp header: an AMQP timestamp, an RFC 3339
+// string, or a Unix time in seconds, milliseconds, microseconds or
+// nanoseconds, told apart by magnitude.
+func headerTime(v any) (time.Time, bool) {
+	var f float64
+	switch v := v.(type) {
+	case time.Time:
+		return v, true
+	case string:
+		if t, err := time.Parse(time.RFC3339Nano, v); err == nil {
+			return t, true
+		}
+		n, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return time.Time{}, false
+		}
+		f = n
+	case int:
+		f = float64(v)
+	case int8:
+		f = float64(v)
+	case int16:
+		f = float64(v)
+	case int32:
+		f = float64(v)
+	case int64:
+		f = float64(v)
+	case uint8:
+		f = float64(v)
+	case uint16:
+		f = float64(v)
+	case uint32:
+		f = float64(v)
+	case uint64:
+		f = float64(v)
+	case float32:
+		f = float64(v)
+	case float64:
+		f = v
+	default:
+		return time.Time{}, false
+	}
+	if f <= 0 || math.IsInf(f, 0) || math.IsNaN(f) {
+		return time.Time{}, false
+	}
+	unit := int64(time.Second)
+	switch {
+	case f >= 1e17:
+		unit = int64(time.Nanosecond)
+	case f >= 1e14:
+		unit = int64(time.Microsecond)
+	case f >= 1e11:
+		unit = int64(time.Millisecond)
+	}
+	if f == math.Trunc(f) && f < 1<<62 {
+		// Exact for whole numbers, which most are
+		return time.Unix(0, int64(f)*unit), true
+	}
+	return time.Unix(0, int64(f*float64(unit))), true
+}
+
+// recordCharts logs a consumed message in the charts.
+func (m *model) recordCharts(msg Message, now time.Time) {
+	if m.charts == nil {
+		m.charts = newCharts(m.config.ChartSpan())
+	}
+	latency, ok := messageLatency(msg, m.config.LatencyHeader, now)
+	m.charts.record(now, msg.RoutingKey, len(msg.RawBody), latency, ok)
+}
+
+type chartTickMsg struct {
+	id int64
+}
+
+// toggleCharts shows or hides the charts panel in place of the detail panel.
+func (m *model) toggleCharts() tea.Cmd {
+	m.showCharts = !m.showCharts
+	if !m.showCharts {
+		return nil
+	}
+	m.chartTickID = nextPollID()
+	return m.scheduleChartTick()
+}
+
+// scheduleChartTick redraws the charts once a bucket has passed, so they
+// scroll while no messages arrive.
+func (m model) scheduleChartTick() tea.Cmd {
+	id := m.chartTickID
+	width := m.config.ChartSpan() / chartBuckets
+	return tea.Tick(max(width, time.Second), func(time.Time) tea.Msg {
+		return chartTickMsg{id: id}
+	})
+}
+
+// renderCharts renders the charts panel.
+func (m model) renderCharts(width, height int) string {
+	innerWidth := width - 4
+	innerHeight := height - 2
+
+	span := m.config.ChartSpan()
+	title := fieldNameStyle.Render("Charts") + mutedStyle.Render(" · last "+formatSpan(span))
+	lines := []string{title, ""}
+	switch {
+	case m.replayMode:
+		lines = append(lines, mutedStyle.Render("Charts follow live consumption"))
+	case m.charts == nil:
+		lines = append(lines, mutedStyle.Render("No messages yet"))
+	default:
+		lines = append(lines, m.chartLines(innerWidth)...)
+	}
+
+	if len(lines) > innerHeight {
+		lines = lines[:max(innerHeight, 1)]
+	}
+	for i, l := range lines {
+		lines[i] = truncate(l, innerWidth)
+	}
+	return detailPanelStyle.Width(width).Height(height).Render(strings.Join(lines, "\n"))
+}
+
+func (m model) chartLines(width int) []string {
+	c := m.charts
+	now := time.Now()
+	slot := c.slot(now)
+	window := c.total.window(slot)
+	cols, perCol := columns(window, width)
+	colSecs := (time.Duration(perCol) * c.bucketWidth()).Seconds()
+
+	var lines []string
+
+	// Rate
+	rates := make([]float64, len(cols))
+	peak := 0.0
+	for i, col := range cols {
+		rates[i] = float64(col.count) / colSecs
+		peak = max(peak, rates[i])
+	}
+	lines = append(lines,
+		fieldNameStyle.Render("Rate ")+fmt.Sprintf("%s  ", formatRate(m.stats.msgPerSec(now)))+
+			mutedStyle.Render("peak "+formatRate(peak)),
+		sparklineStyle.Render(sparkline(rates, width)),
+		"")
+
+	// Latency: the largest of each column, as spikes are what matter
+	var total chartBucket
+	for _, b := range window {
+		total.merge(b)
+	}
+	source := "AMQP timestamp"
+	if m.config.LatencyHeader != "" {
+		source = m.config.LatencyHeader + " header"
+	}
+	if total.latencyN == 0 {
+		lines = append(lines,
+			fieldNameStyle.Render("Latency ")+mutedStyle.Render("no publish time in "+source),
+			"")
+	} else {
+		maxes := make([]float64, len(cols))
+		for i, col := range cols {
+			maxes[i] = col.latencyMax.Seconds()
+		}
+		avg := total.latencySum / time.Duration(total.latencyN)
+		lines = append(lines,
+			fieldNameStyle.Render("Latency ")+fmt.Sprintf("avg %s  max %s  ", formatLatency(avg), formatLatency(total.latencyMax))+
+				mutedStyle.Render("from "+source),
+			sparklineStyle.Render(sparkline(maxes, width)),
+			"")
+	}
+
+	// Body size distribution
+	lines = append(lines, fieldNameStyle.Render("Body size"))
+	var most int64
+	for _, n := range total.sizes {
+		most = max(most, n)
+	}
+	barWidth := max(width-20, 1)
+	for i, n := range total.sizes {
+		if n == 0 {
+			continue
+		}
+		label := "> " + formatBytes(sizeBounds[len(sizeBounds)-1])
+		if i < len(sizeBounds) {
+			label = "≤ " + formatBytes(sizeBounds[i])
+		}
+		bar := strings.Repeat("█", max(int(float64(n)/float64(most)*float64(barWidth)), 1))
+		lines = append(lines, fmt.Sprintf("  %-9s %s %d", label, sparklineStyle.Render(bar), n))
+	}
+	lines = append(lines, "")
+
+	// Busiest routing keys
+	lines = append(lines, fieldNameStyle.Render("Top routing keys"))
+	const statCols = " %9s %8s %8s"
+	keyWidth := 20
+	sparkWidth := max(width-keyWidth-len(fmt.Sprintf(statCols, "", "", ""))-3, 0)
+	lines = append(lines, mutedStyle.Render(fmt.Sprintf("  %-*s %-*s"+statCols, keyWidth, "KEY", sparkWidth, "", "RATE", "AVG LAT", "MAX LAT")))
+	for _, rk := range c.topKeys(slot, chartTopKeys) {
+		window := c.keys[rk].window(slot)
+		cols, _ := columns(window, sparkWidth)
+		rates := make([]float64, len(cols))
+		var sum chartBucket
+		for i, col := range cols {
+			rates[i] = float64(col.count)
+			sum.merge(col)
+		}
+		avg, peak := "—", "—"
+		if sum.latencyN > 0 {
+			avg = formatLatency(sum.latencySum / time.Duration(sum.latencyN))
+			peak = formatLatency(sum.latencyMax)
+		}
+		rate := float64(sum.count) / c.span.Seconds()
+		lines = append(lines, fmt.Sprintf("  %-*s %s"+statCols, keyWidth, truncate(rk, keyWidth),
+			sparklineStyle.Render(fmt.Sprintf("%-*s", sparkWidth, sparkline(rates, sparkWidth))),
+			fmt.Sprintf("%.2f/s", rate), avg, peak))
+	}
+	return lines
+}
+
+// formatSpan renders a chart span in minutes or hours.
+func formatSpan(d time.Duration) string {
+	if d >= time.Hour && d%time.Hour == 0 {
+		return fmt.Sprintf("%dh", d/time.Hour)
+	}
+	return fmt.Sprintf("%dm", d/time.Minute)
+}
@@ -0,0 +1,175 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestCharts_Record(t *testing.T) {
+	c := newCharts(2 * time.Minute) // one second buckets
+	base := time.Unix(1_700_000_000, 0)
+
+	c.record(base, "order.created", 10, 100*time.Millisecond, true)
+	c.record(base.Add(500*time.Millisecond), "order.created", 300, 300*time.Millisecond, true)
+	c.record(base.Add(time.Second), "order.paid", 2000, 0, false)
+
+	slot := c.slot(base.Add(time.Second))
+	window := c.total.window(slot)
+	last, prev := window[len(window)-1], window[len(window)-2]
+	if prev.count != 2 || last.count != 1 {
+		t.Fatalf("bucket counts = %d, %d; want 2, 1", prev.count, last.count)
+	}
+	if prev.latencyN != 2 || prev.latencyMax != 300*time.Millisecond || prev.latencySum != 400*time.Millisecond {
+		t.Errorf("latency = %d samples, max %v, sum %v", prev.latencyN, prev.latencyMax, prev.latencySum)
+	}
+	if prev.sizes[0] != 1 || prev.sizes[2] != 1 || last.sizes[3] != 1 {
+		t.Errorf("size bins = %v, %v", prev.sizes, last.sizes)
+	}
+	if last.latencyN != 0 {
+		t.Error("a message without publish time should not count in latency")
+	}
+
+	if got := c.topKeys(slot, 5); strings.Join(got, " ") != "order.created order.paid" {
+		t.Errorf("topKeys = %v", got)
+	}
+
+	// Once the span has passed, the buckets are empty and the keys pruned
+	later := slot + chartBuckets
+	if n := windowCount(c.total.window(later)); n != 0 {
+		t.Errorf("%d messages still in the window after the span", n)
+	}
+	c.prune(later)
+	if len(c.keys) != 0 {
+		t.Errorf("idle keys not pruned: %d left", len(c.keys))
+	}
+}
+
+func TestCharts_KeyCap(t *testing.T) {
+	c := newCharts(2 * time.Minute)
+	now := time.Unix(1_700_000_000, 0)
+	for i := range chartKeys + 10 {
+		c.record(now, strings.Repeat("k", i+1), 1, 0, false)
+	}
+	if len(c.keys) != chartKeys {
+		t.Errorf("tracked %d keys, want the cap of %d", len(c.keys), chartKeys)
+	}
+	if n := windowCount(c.total.window(c.slot(now))); n != chartKeys+10 {
+		t.Errorf("total = %d, want every message counted", n)
+	}
+}
+
+func TestColumns(t *testing.T) {
+	buckets := make([]chartBucket, chartBuckets)
+	for i := range buckets {
+		buckets[i].count = 1
+	}
+	cols, perCol := columns(buckets, 50)
+	if perCol != 3 || len(cols) != 40 {
+		t.Fatalf("got %d columns of %d buckets, want 40 of 3", len(cols), perCol)
+	}
+	cols, perCol = columns(buckets, 500)
+	if perCol != 1 || len(cols) != chartBuckets {
+		t.Errorf("wide: got %d columns of %d buckets", len(cols), perCol)
+	}
+	cols, _ = columns(buckets, 7)
+	if cols[0].count != 18-(7*18-chartBuckets) || cols[len(cols)-1].count != 18 {
+		t.Errorf("the oldest column should be partial: %d, newest %d", cols[0].count, cols[len(cols)-1].count)
+	}
+}
+
+func TestHeaderTime(t *testing.T) {
+	want := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	for _, v := range []any{
+		want,
+		"2024-05-01T12:00:00Z",
+		want.Unix(),
+		int32(want.Unix()),
+		want.UnixMilli(),
+		want.UnixMicro(),
+		want.UnixNano(),
+		float64(want.UnixMilli()),
+		"1714564800000",
+	} {
+		got, ok := headerTime(v)
+		if !ok || !got.Equal(want) {
+			t.Errorf("headerTime(%T %v) = %v, %v; want %v", v, v, got, ok, want)
+		}
+	}
+	for _, v := range []any{"soon", -1, nil, []byte("1")} {
+		if _, ok := headerTime(v); ok {
+			t.Errorf("headerTime(%v) should fail", v)
+		}
+	}
+}
+
+func TestMessageLatency(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 1, 0, time.UTC)
+	sent := now.Add(-250 * time.Millisecond)
+
+	tests := []struct {
+		name   string
+		msg    Message
+		header string
+		want   time.Duration
+		ok     bool
+	}{
+		{"AMQP timestamp", Message{Timestamp: now.Add(-time.Second), published: true}, "", time.Second, true},
+		{"arrival time only", Message{Timestamp: now}, "", 0, false},
+		{"header", Message{Timestamp: now.Add(-time.Second), published: true, Headers: map[string]any{"x-sent": sent.UnixMilli()}}, "x-sent", 250 * time.Millisecond, true},
+		{"missing header", Message{Timestamp: now.Add(-time.Second), published: true}, "x-sent", time.Second, true},
+		{"unreadable header", Message{Headers: map[string]any{"x-sent": "soon"}}, "x-sent", 0, false},
+		{"clock skew", Message{Timestamp: now.Add(time.Second), published: true}, "", 0, true},
+	}
+	for _, tt := range tests {
+		got, ok := messageLatency(tt.msg, tt.header, now)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("%s: latency = %v, %v; want %v, %v", tt.name, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestCharts_Panel(t *testing.T) {
+	m := model{
+		messages:       newMessageRing(0),
+		detailViewport: viewport.New(80, 20),
+		vimKeys:        NewVimKeyState(),
+		splitRatio:     0.4,
+		width:          160,
+		height:         40,
+	}
+
+	s := tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("s")}
+	updated, _ := m.Update(s)
+	m = updated.(model)
+	if !m.showCharts {
+		t.Fatal("s should show the charts")
+	}
+	if view := m.View(); !strings.Contains(view, "No messages yet") {
+		t.Errorf("empty charts view:\n%s", view)
+	}
+
+	for _, rk := range []string{"order.created", "order.created", "order.paid"} {
+		updated, _ = m.Update(msgReceived{msg: Message{RoutingKey: rk, RawBody: []byte("{}"), Timestamp: time.Now().Add(-2 * time.Second), published: true}})
+		m = updated.(model)
+	}
+	view := m.View()
+	for _, want := range []string{"Charts", "last 5m", "Latency", "avg 2", "Top routing keys", "order.created", "order.paid", "≤ 64 B"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("charts view missing %q", want)
+		}
+	}
+
+	// Ticks keep the panel moving only while it is shown
+	if _, cmd := m.Update(chartTickMsg{id: m.chartTickID}); cmd == nil {
+		t.Error("a tick should schedule the next one")
+	}
+	updated, _ = m.Update(s)
+	m = updated.(model)
+	if _, cmd := m.Update(chartTickMsg{id: m.chartTickID}); cmd != nil {
+		t.Error("hidden charts should stop ticking")
+	}
+}
@@ -2,6 +2,8 @@ package tui
 
 import (
 	"context"
+	"errors"
+	"strings"
 	"testing"
 
 	"github.com/epalmerini/rabbithole/internal/db"
@@ -27,6 +29,7 @@ func (s *cleanupStore) DeleteSession(_ context.Context, sessionID int64) error {
 }
 
 // Unused Store interface methods
+func (s *cleanupStore) TouchSession(context.Context, int64) error { return nil }
 func (s *cleanupStore) CreateSession(context.Context, string, string, string, string) (int64, error) {
 	return 0, nil
 }
@@ -55,6 +58,35 @@ func (s *cleanupStore) SearchMessagesInSession(context.Context, string, int64, i
 func (s *cleanupStore) SearchSessionsByContent(context.Context, string, int64) ([]int64, error) {
 	return nil, nil
 }
+func (s *cleanupStore) QueryMessages(context.Context, string, []any, int64, int64, int64) ([]db.Message, error) {
+	return nil, nil
+}
+func (s *cleanupStore) QuerySessions(context.Context, string, []any, int64) ([]int64, error) {
+	return nil, nil
+}
+func (s *cleanupStore) TraceMessages(context.Context, db.TraceKeys, int64) ([]db.Message, error) {
+	return nil, nil
+}
+func (s *cleanupStore) CountQueryMessages(context.Context, string, []any, int64) (int64, error) {
+	return 0, nil
+}
+func (s *cleanupStore) NextQueryMessage(context.Context, string, []any, int64, int64, bool) (int64, error) {
+	return 0, nil
+}
+func (s *cleanupStore) GroupMessages(context.Context, string, []any, int64, string, []any, int64) ([]db.MessageGroup, error) {
+	return nil, nil
+}
+func (s *cleanupStore) SetAnnotation(context.Context, int64, db.Annotation) error { return nil }
+func (s *cleanupStore) GetAnnotations(context.Context, []int64) (map[int64]db.Annotation, error) {
+	return nil, nil
+}
+func (s *cleanupStore) ListAnnotated(context.Context, int64) ([]db.AnnotatedMessage, error) {
+	return nil, nil
+}
+func (s *cleanupStore) AddHistory(context.Context, string, string, int) error { return nil }
+func (s *cleanupStore) ListHistory(context.Context, string, int) ([]string, error) {
+	return nil, nil
+}
 func (s *cleanupStore) Close() error { return nil }
 
 func TestCleanup_DeletesEmptySession(t *testing.T) {
@@ -96,3 +128,20 @@ func TestCleanup_EndsNonEmptySession(t *testing.T) {
 		t.Error("expected sessionID to be reset to 0")
 	}
 }
+
+func TestApp_ShowsPruneFailure(t *testing.T) {
+	m := newAppModel(Config{}, &cleanupStore{})
+
+	result, _ := m.Update(pruneFailedMsg{err: errors.New("database is locked")})
+	m = result.(appModel)
+	if !strings.Contains(m.browser.notice, "database is locked") {
+		t.Errorf("browser notice = %q, want the prune error", m.browser.notice)
+	}
+
+	m.view = appViewConsumer
+	result, _ = m.Update(pruneFailedMsg{err: errors.New("disk I/O error")})
+	m = result.(appModel)
+	if !strings.Contains(m.consumer.statusMsg, "disk I/O error") {
+		t.Errorf("consumer status = %q, want the prune error", m.consumer.statusMsg)
+	}
+}
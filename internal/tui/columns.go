@@ -0,0 +1,134 @@
+package tui
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/epalmerini/rabbithole/internal/query"
+)
+
+// defaultColumns are the message list's columns unless [ui] columns is set.
+var defaultColumns = []string{"ts", "rk"}
+
+// columnWidths fixes the width of the columns whose values are short; the
+// others share the rest of the row.
+var columnWidths = map[string]int{"ts": 8, "size": 8}
+
+// listColumn is a column of the message list.
+type listColumn struct {
+	field string // as query.FieldName names it
+	width int    // 0 for a share of the space left
+}
+
+// newListColumns checks the configured columns; none are the defaults.
+func newListColumns(fields []string) ([]listColumn, error) {
+	if len(fields) == 0 {
+		fields = defaultColumns
+	}
+	cols := make([]listColumn, 0, len(fields))
+	for _, f := range fields {
+		name, ok := query.FieldName(f)
+		if !ok {
+			return nil, fmt.Errorf("unknown column %q (use ts, ex, rk, type, size, correlation_id, hdr.NAME, body.PATH, ...)", f)
+		}
+		cols = append(cols, listColumn{field: name, width: columnWidths[name]})
+	}
+	return cols, nil
+}
+
+// listColumns returns the configured columns, or the defaults if they are
+// invalid: Run has reported that already.
+func listColumns(fields []string) []listColumn {
+	cols, err := newListColumns(fields)
+	if err != nil {
+		cols, _ = newListColumns(nil)
+	}
+	return cols
+}
+
+// columnValue formats the value of a column for msg. Body columns decode a
+// paged replay message in place, as viewing it would.
+func (m model) columnValue(msg *Message, field string) string {
+	switch field {
+	case "ts":
+		if m.timestampRel {
+			return formatRelativeTime(msg.Timestamp)
+		}
+		return msg.Timestamp.Format("15:04:05")
+	case "size":
+		return formatBytes(int64(len(msg.RawBody)))
+	}
+	if strings.HasPrefix(field, "body") {
+		decodeLazy(msg, m.config.Decoder)
+	}
+	v, ok := query.Lookup(field, msg.queryMessage())
+	if !ok {
+		return ""
+	}
+	s, isString := v.(string)
+	if !isString {
+		data, _ := json.Marshal(v)
+		s = string(data)
+	}
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// renderColumns lays the columns of msg out in a row width wide: fixed-width
+// columns keep their width, the others share what is left, and the last one
+// takes the rest.
+func (m model) renderColumns(msg *Message, width int) string {
+	cols := m.columns
+	if cols == nil {
+		cols = listColumns(nil)
+	}
+	fixed, flex := len(cols)-1, 0 // separators
+	for _, c := range cols {
+		if c.width == 0 {
+			flex++
+		}
+		fixed += c.width
+	}
+	share := 0
+	if flex > 0 {
+		share = max((width-fixed)/flex, 1)
+	}
+
+	var b strings.Builder
+	used := 0
+	for i, c := range cols {
+		if i > 0 {
+			b.WriteByte(' ')
+			used++
+		}
+		value := m.columnValue(msg, c.field)
+		if i == len(cols)-1 {
+			b.WriteString(fitCell(value, width-used, false))
+			break
+		}
+		w := c.width
+		if w == 0 {
+			w = share
+		}
+		b.WriteString(fitCell(value, w, true))
+		used += w
+	}
+	return b.String()
+}
+
+// fitCell truncates s to w characters, padding it to w when pad is set.
+func fitCell(s string, w int, pad bool) string {
+	if w <= 0 {
+		return ""
+	}
+	runes := []rune(s)
+	switch {
+	case len(runes) > w && w > 3:
+		s = truncate(s, w)
+	case len(runes) > w:
+		s = string(runes[:w])
+	case pad:
+		s += strings.Repeat(" ", w-len(runes))
+	}
+	return s
+}
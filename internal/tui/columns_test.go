@@ -0,0 +1,108 @@
+package tui
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/epalmerini/rabbithole/internal/config"
+)
+
+func TestNewListColumns(t *testing.T) {
+	cols, err := newListColumns([]string{"timestamp", "routing_key", "size", "hdr.x-tenant"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []listColumn{{"ts", 8}, {"rk", 0}, {"size", 8}, {"hdr.x-tenant", 0}}
+	for i, c := range cols {
+		if c != want[i] {
+			t.Errorf("column %d = %+v, want %+v", i, c, want[i])
+		}
+	}
+	if _, err := newListColumns([]string{"rk", "tenant"}); err == nil || !strings.Contains(err.Error(), `unknown column "tenant"`) {
+		t.Errorf("unknown column error = %v", err)
+	}
+}
+
+func TestRenderColumns(t *testing.T) {
+	msg := Message{
+		Timestamp:     time.Date(2024, 5, 1, 12, 30, 5, 0, time.UTC),
+		Exchange:      "orders",
+		RoutingKey:    "order.created.eu",
+		CorrelationID: "c-1",
+		Headers:       map[string]any{"x-tenant": "acme", "x-retry": int32(2)},
+		RawBody:       make([]byte, 2048),
+	}
+	m := model{columns: listColumns(nil)}
+	if got := m.renderColumns(&msg, 30); got != "12:30:05 order.created.eu" {
+		t.Errorf("default columns = %q", got)
+	}
+
+	m.columns = listColumns([]string{"ts", "ex", "size", "rk", "correlation_id", "hdr.x-retry"})
+	got := m.renderColumns(&msg, 48)
+	if want := "12:30:05 orders 2.0 KB   ord... c-1    2"; got != want {
+		t.Errorf("row = %q, want %q", got, want)
+	}
+
+	// A missing field leaves its cell blank
+	m.columns = listColumns([]string{"hdr.x-missing", "rk"})
+	if got := m.renderColumns(&msg, 30); !strings.HasPrefix(got, "               order") {
+		t.Errorf("row = %q, want a blank first cell", got)
+	}
+}
+
+func TestHorizontalSplit(t *testing.T) {
+	cfg := Config{Split: config.SplitHorizontal, DefaultSplitRatio: 0.4}
+	m := initialModel(cfg, nil)
+	m.detailViewport = viewport.New(80, 20)
+	for i := range 40 {
+		m.messages.Push(Message{RoutingKey: "order." + string(rune('a'+i%26)), Decoded: map[string]any{"n": i}})
+	}
+	updated, _ := m.Update(tea.WindowSizeMsg{Width: 100, Height: 45})
+	m = updated.(model)
+
+	listWidth, listHeight, detailWidth, detailHeight := m.panes()
+	if listWidth != 98 || detailWidth != 98 || listHeight != 15 || detailHeight != 23 {
+		t.Errorf("panes = %dx%d over %dx%d", listWidth, listHeight, detailWidth, detailHeight)
+	}
+	if m.visibleItems() != 14 || m.detailViewport.Width != 94 {
+		t.Errorf("visible items = %d, viewport width = %d", m.visibleItems(), m.detailViewport.Width)
+	}
+
+	// The list is drawn above the details
+	lines := strings.Split(m.View(), "\n")
+	list := lineIndex(lines, "order.a")
+	detail := lineIndex(lines, "Body")
+	if list < 0 || detail <= list || !strings.Contains(lines[list], "order.a") {
+		t.Errorf("list at line %d, details at line %d", list, detail)
+	}
+}
+
+// lineIndex returns the first line containing s, or -1.
+func lineIndex(lines []string, s string) int {
+	for i, l := range lines {
+		if strings.Contains(l, s) {
+			return i
+		}
+	}
+	return -1
+}
+
+func TestRenderColumns_ReplayPage(t *testing.T) {
+	m := newReplayTestModel(t, 20)
+	m.columns = listColumns([]string{"rk", "body.seq"})
+
+	// Paged rows are decoded lazily; a body column has to decode them
+	var row string
+	for line := range strings.SplitSeq(m.View(), "\n") {
+		if strings.Contains(line, "order.10 ") {
+			row = line
+		}
+	}
+	if fields := strings.Fields(row); !slices.Contains(fields, "10") {
+		t.Errorf("order.10 row = %q, want its body.seq", row)
+	}
+}
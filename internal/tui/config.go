@@ -1,8 +1,23 @@
 package tui
 
-import "github.com/epalmerini/rabbithole/internal/proto"
+import (
+	"time"
 
-const defaultMaxMessages = 1000
+	"github.com/epalmerini/rabbithole/internal/config"
+	"github.com/epalmerini/rabbithole/internal/proto"
+)
+
+const (
+	defaultMaxMessages  = 1000
+	defaultChartMinutes = 5
+)
+
+// Pause buffer overflow policies.
+const (
+	PauseDropOldest = "drop_oldest" // discard the oldest buffered message (default)
+	PauseDropNewest = "drop_newest" // discard the message that just arrived
+	PauseResume     = "resume"      // unpause, merging the buffer into the list
+)
 
 // Config is the runtime configuration for TUI views.
 type Config struct {
@@ -12,11 +27,23 @@ type Config struct {
 	DBPath        string
 	Decoder       *proto.Decoder
 	MaxMessages   int
+	PauseBuffer   int    // messages held while paused; MaxMessages when unset
+	PauseOverflow string // what a full pause buffer does: PauseDropOldest, PauseDropNewest or PauseResume
+	Production    bool   // destructive bulk operations need typed confirmation
+	TraceHeader   string // header linking traced messages, besides the AMQP ids
+	DiffKey       string // field identifying a message's entity, e.g. body.country.id
+	LatencyHeader string // header carrying the publish time; the AMQP timestamp when unset
+	Alerts        []config.AlertRule
+	Filters       []config.SavedFilter // named filters offered by the picker
+	Keys          *Keymap              // key bindings; nil for the defaults
 
 	// UI
 	AutoPauseOnSelect bool
 	DefaultSplitRatio float64
 	CompactMode       bool
+	ChartMinutes      int      // span of the charts panel
+	Columns           []string // message list fields; time and routing key when unset
+	Split             string   // config.SplitVertical (default) or config.SplitHorizontal
 
 	// Runtime (set by browser on consume)
 	Exchange   string
@@ -35,3 +62,19 @@ func (c Config) MessageLimit() int {
 	}
 	return c.MaxMessages
 }
+
+// PauseLimit returns PauseBuffer, falling back to the message limit if unset.
+func (c Config) PauseLimit() int {
+	if c.PauseBuffer <= 0 {
+		return c.MessageLimit()
+	}
+	return c.PauseBuffer
+}
+
+// ChartSpan returns the span of the charts panel, 5 minutes if unset.
+func (c Config) ChartSpan() time.Duration {
+	if c.ChartMinutes <= 0 {
+		return defaultChartMinutes * time.Minute
+	}
+	return time.Duration(c.ChartMinutes) * time.Minute
+}
@@ -0,0 +1,457 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/epalmerini/rabbithole/internal/rabbitmq"
+)
+
+// defaultCloseReason is sent to clients when a connection is closed from the browser.
+const defaultCloseReason = "Closed via rabbithole"
+
+type connectionsLoadedMsg struct {
+	connections []rabbitmq.Connection
+}
+
+type channelsLoadedMsg struct {
+	channels []rabbitmq.Channel
+}
+
+type consumersLoadedMsg struct {
+	consumers []rabbitmq.ConsumerInfo
+}
+
+type connectionClosedMsg struct {
+	name string
+}
+
+// consumerScope restricts the consumers view to one queue or one channel.
+type consumerScope struct {
+	queue   string
+	channel string
+}
+
+func (s consumerScope) matches(c rabbitmq.ConsumerInfo) bool {
+	if s.queue != "" && c.Queue.Name != s.queue {
+		return false
+	}
+	if s.channel != "" && c.ChannelDetails.Name != s.channel {
+		return false
+	}
+	return true
+}
+
+func (s consumerScope) title() string {
+	switch {
+	case s.queue != "":
+		return fmt.Sprintf("Consumers on queue %s", s.queue)
+	case s.channel != "":
+		return fmt.Sprintf("Consumers on channel %s", s.channel)
+	}
+	return "Consumers"
+}
+
+func newCloseReasonInput() textinput.Model {
+	ti := textinput.New()
+	ti.Placeholder = defaultCloseReason
+	ti.CharLimit = 200
+	ti.Width = 50
+	return ti
+}
+
+func (m browserModel) loadConnections() tea.Cmd {
+	mgmt := m.mgmt
+	return func() tea.Msg {
+		if mgmt == nil {
+			return errorMsg{err: fmt.Errorf("management client not initialized")}
+		}
+
+		conns, err := mgmt.GetConnections(context.Background(), mgmt.VHost())
+		if err != nil {
+			return errorMsg{err: fmt.Errorf("failed to load connections: %w", err)}
+		}
+
+		sort.Slice(conns, func(i, j int) bool { return conns[i].Name < conns[j].Name })
+		return connectionsLoadedMsg{connections: conns}
+	}
+}
+
+func (m browserModel) loadChannels(connection string) tea.Cmd {
+	mgmt := m.mgmt
+	return func() tea.Msg {
+		if mgmt == nil {
+			return errorMsg{err: fmt.Errorf("management client not initialized")}
+		}
+
+		channels, err := mgmt.GetConnectionChannels(context.Background(), connection)
+		if err != nil {
+			return errorMsg{err: fmt.Errorf("failed to load channels: %w", err)}
+		}
+
+		sort.Slice(channels, func(i, j int) bool { return channels[i].Number < channels[j].Number })
+		return channelsLoadedMsg{channels: channels}
+	}
+}
+
+func (m browserModel) loadConsumers(scope consumerScope) tea.Cmd {
+	mgmt := m.mgmt
+	return func() tea.Msg {
+		if mgmt == nil {
+			return errorMsg{err: fmt.Errorf("management client not initialized")}
+		}
+
+		all, err := mgmt.GetConsumers(context.Background(), mgmt.VHost())
+		if err != nil {
+			return errorMsg{err: fmt.Errorf("failed to load consumers: %w", err)}
+		}
+
+		var consumers []rabbitmq.ConsumerInfo
+		for _, c := range all {
+			if scope.matches(c) {
+				consumers = append(consumers, c)
+			}
+		}
+		sort.Slice(consumers, func(i, j int) bool { return consumers[i].ConsumerTag < consumers[j].ConsumerTag })
+		return consumersLoadedMsg{consumers: consumers}
+	}
+}
+
+func (m browserModel) closeConnection(name, reason string) tea.Cmd {
+	mgmt := m.mgmt
+	return func() tea.Msg {
+		if mgmt == nil {
+			return errorMsg{err: fmt.Errorf("management client not initialized")}
+		}
+		if err := mgmt.CloseConnection(context.Background(), name, reason); err != nil {
+			return errorMsg{err: fmt.Errorf("failed to close connection: %w", err)}
+		}
+		return connectionClosedMsg{name: name}
+	}
+}
+
+// showConsumers opens the consumers view for scope, returning to back on Esc.
+func (m browserModel) showConsumers(scope consumerScope, back browserView) (browserModel, tea.Cmd) {
+	m.consumerScope = scope
+	m.consumersBack = back
+	m.consumers = nil
+	m.view = viewConsumers
+	m.selectedIdx = 0
+	m.scrollOff = 0
+	m.err = nil
+	m.loading = true
+	return m, m.loadConsumers(scope)
+}
+
+// updateConnections handles keys in the connections list. handled is false for
+// keys that fall through to the shared browser navigation.
+func (m browserModel) updateConnections(msg tea.KeyMsg) (_ browserModel, _ tea.Cmd, handled bool) {
+	if m.confirmClose {
+		switch msg.String() {
+		case "enter":
+			m.confirmClose = false
+			m.closeReasonInput.Blur()
+			if m.selectedIdx < len(m.connections) {
+				reason := strings.TrimSpace(m.closeReasonInput.Value())
+				if reason == "" {
+					reason = defaultCloseReason
+				}
+				m.loading = true
+				return m, m.closeConnection(m.connections[m.selectedIdx].Name, reason), true
+			}
+		case "esc":
+			m.confirmClose = false
+			m.closeReasonInput.Blur()
+		default:
+			var cmd tea.Cmd
+			m.closeReasonInput, cmd = m.closeReasonInput.Update(msg)
+			return m, cmd, true
+		}
+		return m, nil, true
+	}
+
+	switch m.key(msg) {
+	case "enter":
+		if m.selectedIdx < len(m.connections) {
+			m.selectedConnection = m.connections[m.selectedIdx].Name
+			m.channels = nil
+			m.view = viewChannels
+			m.selectedIdx = 0
+			m.scrollOff = 0
+			m.loading = true
+			return m, m.loadChannels(m.selectedConnection), true
+		}
+		return m, nil, true
+	case "X":
+		if m.selectedIdx < len(m.connections) {
+			m.confirmClose = true
+			m.closeReasonInput.SetValue("")
+			m.closeReasonInput.Focus()
+			return m, textinput.Blink, true
+		}
+		return m, nil, true
+	case "r":
+		m.loading = true
+		return m, m.loadConnections(), true
+	case "esc", "backspace":
+		m.view = viewExchanges
+		m.selectedIdx = 0
+		m.scrollOff = 0
+		m.err = nil
+		return m, nil, true
+	}
+	return m, nil, false
+}
+
+// updateChannels handles keys in a connection's channel list.
+func (m browserModel) updateChannels(msg tea.KeyMsg) (_ browserModel, _ tea.Cmd, handled bool) {
+	switch m.key(msg) {
+	case "enter":
+		if m.selectedIdx < len(m.channels) {
+			m, cmd := m.showConsumers(consumerScope{channel: m.channels[m.selectedIdx].Name}, viewChannels)
+			return m, cmd, true
+		}
+		return m, nil, true
+	case "r":
+		m.loading = true
+		return m, m.loadChannels(m.selectedConnection), true
+	case "esc", "backspace":
+		m.view = viewConnections
+		m.selectedIdx = 0
+		m.scrollOff = 0
+		m.err = nil
+		for i, c := range m.connections {
+			if c.Name == m.selectedConnection {
+				m.selectedIdx = i
+			}
+		}
+		return m, nil, true
+	}
+	return m, nil, false
+}
+
+// updateConsumers handles keys in the consumers list.
+func (m browserModel) updateConsumers(msg tea.KeyMsg) (_ browserModel, _ tea.Cmd, handled bool) {
+	switch m.key(msg) {
+	case "r":
+		m.loading = true
+		return m, m.loadConsumers(m.consumerScope), true
+	case "esc", "backspace":
+		m.view = m.consumersBack
+		m.selectedIdx = 0
+		m.scrollOff = 0
+		m.err = nil
+		return m, nil, true
+	}
+	return m, nil, false
+}
+
+// listWindow returns the [start, end) range of a list of total items that
+// keeps selected visible within height rows.
+func listWindow(selected, total, height int) (int, int) {
+	if height < 1 {
+		height = 1
+	}
+	start := 0
+	if selected >= height {
+		start = selected - height + 1
+	}
+	end := start + height
+	if end > total {
+		end = total
+	}
+	return start, end
+}
+
+func (m browserModel) renderListLine(sb *strings.Builder, i int, line string) {
+	if i == m.selectedIdx {
+		sb.WriteString(selectedMessageStyle.Width(m.width - 8).Render("▶ " + line))
+	} else {
+		sb.WriteString(normalMessageStyle.Width(m.width - 8).Render("  " + line))
+	}
+	sb.WriteString("\n")
+}
+
+// renderListState renders the loading or error state, reporting whether it did.
+func (m browserModel) renderListState(sb *strings.Builder) bool {
+	if m.loading {
+		sb.WriteString("  " + m.spinner.View() + " Loading...")
+		return true
+	}
+	if m.err != nil {
+		sb.WriteString(errorStyle.Render(fmt.Sprintf("  Error: %v", m.err)))
+		return true
+	}
+	return false
+}
+
+func (m browserModel) renderConnections() string {
+	var sb strings.Builder
+
+	sb.WriteString(fieldNameStyle.Render(fmt.Sprintf("Connections to %s:", m.vhost())))
+	sb.WriteString("\n\n")
+
+	if m.renderListState(&sb) {
+		return messageListStyle.Width(m.width - 4).Height(m.height - 8).Render(sb.String())
+	}
+
+	if len(m.connections) == 0 {
+		sb.WriteString(mutedStyle.Render("  No open connections"))
+		return messageListStyle.Width(m.width - 4).Height(m.height - 8).Render(sb.String())
+	}
+
+	sb.WriteString(mutedStyle.Render(fmt.Sprintf("    %-24s %-12s %-21s %4s  %-10s %-12s %-12s", "CLIENT", "USER", "PEER", "CHAN", "STATE", "RECV", "SEND")))
+	sb.WriteString("\n")
+
+	startIdx, endIdx := listWindow(m.selectedIdx, len(m.connections), m.height-14)
+	for i := startIdx; i < endIdx; i++ {
+		c := m.connections[i]
+		client := c.ClientName()
+		if client == "" {
+			client = c.Name
+		}
+		state := fmt.Sprintf("%-10s", c.State)
+		if c.Blocked() {
+			state = errorStyle.Render(state)
+		}
+		line := fmt.Sprintf("%-24s %-12s %-21s %4d  %s %-12s %-12s",
+			truncate(client, 24),
+			truncate(c.User, 12),
+			truncate(fmt.Sprintf("%s:%d", c.PeerHost, c.PeerPort), 21),
+			c.Channels,
+			state,
+			formatBytes(int64(c.RecvOctDetails.Rate))+"/s",
+			formatBytes(int64(c.SendOctDetails.Rate))+"/s",
+		)
+		m.renderListLine(&sb, i, line)
+	}
+
+	if m.selectedIdx < len(m.connections) {
+		c := m.connections[m.selectedIdx]
+		sb.WriteString("\n")
+		sb.WriteString(fieldNameStyle.Render("  Name: ") + mutedStyle.Render(c.Name) + "\n")
+		if props := clientPropertiesLine(c.ClientProperties); props != "" {
+			sb.WriteString(fieldNameStyle.Render("  Client: ") + mutedStyle.Render(props) + "\n")
+		}
+		if m.confirmClose {
+			sb.WriteString("\n")
+			sb.WriteString(errorStyle.Render("  Close this connection? Reason: "))
+			sb.WriteString(m.closeReasonInput.View())
+			sb.WriteString("\n")
+			sb.WriteString(mutedStyle.Render("  Enter to close, Esc to cancel"))
+		}
+	}
+
+	return messageListStyle.Width(m.width - 4).Height(m.height - 8).Render(sb.String())
+}
+
+// clientPropertiesLine summarises the string-valued client properties.
+func clientPropertiesLine(props map[string]any) string {
+	var parts []string
+	for _, key := range []string{"connection_name", "product", "version", "platform", "information"} {
+		if v, ok := props[key].(string); ok && v != "" {
+			parts = append(parts, fmt.Sprintf("%s=%s", key, v))
+		}
+	}
+	return strings.Join(parts, "  ")
+}
+
+func (m browserModel) renderChannels() string {
+	var sb strings.Builder
+
+	sb.WriteString(fieldNameStyle.Render(fmt.Sprintf("Channels on %s:", m.selectedConnection)))
+	sb.WriteString("\n\n")
+
+	if m.renderListState(&sb) {
+		return messageListStyle.Width(m.width - 4).Height(m.height - 8).Render(sb.String())
+	}
+
+	if len(m.channels) == 0 {
+		sb.WriteString(mutedStyle.Render("  No channels open on this connection"))
+		return messageListStyle.Width(m.width - 4).Height(m.height - 8).Render(sb.String())
+	}
+
+	sb.WriteString(mutedStyle.Render(fmt.Sprintf("    %4s  %-10s %8s %8s %8s %9s  %s", "NUM", "STATE", "PREFETCH", "UNACKED", "UNCONF", "CONSUMERS", "MODE")))
+	sb.WriteString("\n")
+
+	startIdx, endIdx := listWindow(m.selectedIdx, len(m.channels), m.height-13)
+	for i := startIdx; i < endIdx; i++ {
+		ch := m.channels[i]
+		mode := "ack"
+		switch {
+		case ch.Confirm:
+			mode = "confirm"
+		case ch.Transactional:
+			mode = "tx"
+		}
+		prefetch := fmt.Sprintf("%d", ch.PrefetchCount)
+		if ch.GlobalPrefetchCount > 0 {
+			prefetch = fmt.Sprintf("%d/%dg", ch.PrefetchCount, ch.GlobalPrefetchCount)
+		}
+		line := fmt.Sprintf("%4d  %-10s %8s %8d %8d %9d  %s",
+			ch.Number,
+			ch.State,
+			prefetch,
+			ch.MessagesUnacknowledged,
+			ch.MessagesUnconfirmed,
+			ch.ConsumerCount,
+			mode,
+		)
+		m.renderListLine(&sb, i, line)
+	}
+
+	return messageListStyle.Width(m.width - 4).Height(m.height - 8).Render(sb.String())
+}
+
+func (m browserModel) renderConsumers() string {
+	var sb strings.Builder
+
+	sb.WriteString(fieldNameStyle.Render(m.consumerScope.title() + ":"))
+	sb.WriteString("\n\n")
+
+	if m.renderListState(&sb) {
+		return messageListStyle.Width(m.width - 4).Height(m.height - 8).Render(sb.String())
+	}
+
+	if len(m.consumers) == 0 {
+		sb.WriteString(mutedStyle.Render("  No consumers"))
+		return messageListStyle.Width(m.width - 4).Height(m.height - 8).Render(sb.String())
+	}
+
+	sb.WriteString(mutedStyle.Render(fmt.Sprintf("    %-30s %-20s %-30s %8s  %-6s %s", "TAG", "QUEUE", "CHANNEL", "PREFETCH", "ACK", "STATUS")))
+	sb.WriteString("\n")
+
+	startIdx, endIdx := listWindow(m.selectedIdx, len(m.consumers), m.height-13)
+	for i := startIdx; i < endIdx; i++ {
+		c := m.consumers[i]
+		ack := "auto"
+		if c.AckRequired {
+			ack = "manual"
+		}
+		status := c.ActivityStatus
+		if status == "" {
+			status = "up"
+			if !c.Active {
+				status = "inactive"
+			}
+		}
+		if c.Exclusive {
+			status += " exclusive"
+		}
+		line := fmt.Sprintf("%-30s %-20s %-30s %8d  %-6s %s",
+			truncate(c.ConsumerTag, 30),
+			truncate(c.Queue.Name, 20),
+			truncate(c.ChannelDetails.Name, 30),
+			c.PrefetchCount,
+			ack,
+			status,
+		)
+		m.renderListLine(&sb, i, line)
+	}
+
+	return messageListStyle.Width(m.width - 4).Height(m.height - 8).Render(sb.String())
+}
@@ -0,0 +1,105 @@
+package tui
+
+import (
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/epalmerini/rabbithole/internal/rabbitmq"
+)
+
+func TestConsumerScopeMatches(t *testing.T) {
+	c := rabbitmq.ConsumerInfo{
+		Queue:          rabbitmq.ConsumerQueue{Name: "orders"},
+		ChannelDetails: rabbitmq.ConsumerChannel{Name: "conn (1)"},
+	}
+
+	tests := []struct {
+		name  string
+		scope consumerScope
+		want  bool
+	}{
+		{"empty scope", consumerScope{}, true},
+		{"matching queue", consumerScope{queue: "orders"}, true},
+		{"other queue", consumerScope{queue: "users"}, false},
+		{"matching channel", consumerScope{channel: "conn (1)"}, true},
+		{"other channel", consumerScope{channel: "conn (2)"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.scope.matches(c); got != tt.want {
+				t.Errorf("matches() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBrowserConnections_CloseRequiresConfirmation(t *testing.T) {
+	m := newBrowserModel(Config{})
+	m.view = viewConnections
+	m.loading = false
+	m.connections = []rabbitmq.Connection{{Name: "10.0.0.1:5000 -> 10.0.0.2:5672"}}
+
+	result, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'X'}})
+	m = result.(browserModel)
+	if !m.confirmClose {
+		t.Fatal("expected close confirmation prompt")
+	}
+
+	// Typing goes to the reason input, not the browser key bindings
+	result, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'q'}})
+	m = result.(browserModel)
+	if m.closeReasonInput.Value() != "q" {
+		t.Errorf("reason = %q, want %q", m.closeReasonInput.Value(), "q")
+	}
+
+	result, _ = m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	m = result.(browserModel)
+	if m.confirmClose {
+		t.Error("expected Esc to cancel confirmation")
+	}
+	if m.view != viewConnections {
+		t.Errorf("view = %v, want viewConnections", m.view)
+	}
+}
+
+func TestBrowserConsumers_BackReturnsToOrigin(t *testing.T) {
+	m := newBrowserModel(Config{})
+	m.view = viewBindings
+	m.loading = false
+	m.bindings = []rabbitmq.Binding{{Destination: "orders", RoutingKey: "#"}}
+	m.selectedIdx = 1
+
+	result, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'c'}})
+	m = result.(browserModel)
+	if m.view != viewConsumers || cmd == nil {
+		t.Fatalf("expected consumers view with load command, got view %v", m.view)
+	}
+	if m.consumerScope.queue != "orders" {
+		t.Errorf("scope queue = %q, want orders", m.consumerScope.queue)
+	}
+
+	result, _ = m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	m = result.(browserModel)
+	if m.view != viewBindings {
+		t.Errorf("view = %v, want viewBindings", m.view)
+	}
+}
+
+func TestApp_CloseReasonInputKeepsSessionShortcut(t *testing.T) {
+	m := newAppModel(Config{}, &cleanupStore{})
+	m.browser.view = viewConnections
+	m.browser.loading = false
+	m.browser.connections = []rabbitmq.Connection{{Name: "conn"}}
+	m.browser.confirmClose = true
+	m.browser.closeReasonInput.Focus()
+
+	result, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'s'}})
+	m = result.(appModel)
+	if m.view != appViewBrowser {
+		t.Fatalf("view = %v, want appViewBrowser", m.view)
+	}
+	if m.browser.closeReasonInput.Value() != "s" {
+		t.Errorf("reason = %q, want %q", m.browser.closeReasonInput.Value(), "s")
+	}
+}
@@ -0,0 +1,337 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/charmbracelet/bubbles/spinner"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/epalmerini/rabbithole/internal/config"
+	"github.com/epalmerini/rabbithole/internal/rabbitmq"
+)
+
+const (
+	dashboardRefresh = 5 * time.Second
+	alarmPollRefresh = 15 * time.Second
+
+	// dashboardHistory is the number of samples kept for sparklines.
+	dashboardHistory = 60
+)
+
+// pollSeq hands out IDs for timer-driven polls, so ticks from a replaced
+// model are dropped instead of starting a second poll loop.
+var pollSeq atomic.Int64
+
+func nextPollID() int64 {
+	return pollSeq.Add(1)
+}
+
+type dashboardLoadedMsg struct {
+	id       int64
+	overview *rabbitmq.Overview
+	nodes    []rabbitmq.Node
+	checks   []rabbitmq.HealthCheck
+	err      error
+}
+
+type dashboardTickMsg struct {
+	id int64
+}
+
+// dashboardModel shows cluster health: nodes, alarms, resource usage and rates.
+type dashboardModel struct {
+	mgmt          *rabbitmq.ManagementClient
+	keys          *Keymap
+	width, height int
+	pollID        int64
+
+	overview   *rabbitmq.Overview
+	nodes      []rabbitmq.Node
+	checks     []rabbitmq.HealthCheck
+	lastUpdate time.Time
+
+	// Sparkline history
+	publishHist []float64
+	deliverHist []float64
+	queuedHist  []float64
+
+	spinner spinner.Model
+	err     error
+	loading bool
+}
+
+func newDashboardModel(cfg Config) dashboardModel {
+	sp := spinner.New()
+	sp.Spinner = spinner.Dot
+	sp.Style = spinnerStyle
+
+	mgmt, _ := rabbitmq.NewManagementClient(cfg.RabbitMQURL, cfg.ManagementURL)
+
+	return dashboardModel{
+		mgmt:    mgmt,
+		keys:    cfg.Keys,
+		pollID:  nextPollID(),
+		spinner: sp,
+		loading: true,
+	}
+}
+
+func (m dashboardModel) Init() tea.Cmd {
+	return tea.Batch(m.load(), m.spinner.Tick)
+}
+
+func (m dashboardModel) load() tea.Cmd {
+	mgmt := m.mgmt
+	id := m.pollID
+	return func() tea.Msg {
+		if mgmt == nil {
+			return dashboardLoadedMsg{id: id, err: fmt.Errorf("management client not initialized")}
+		}
+
+		ctx := context.Background()
+		overview, err := mgmt.GetOverview(ctx)
+		if err != nil {
+			return dashboardLoadedMsg{id: id, err: fmt.Errorf("failed to load overview: %w", err)}
+		}
+		nodes, err := mgmt.GetNodes(ctx)
+		if err != nil {
+			return dashboardLoadedMsg{id: id, err: fmt.Errorf("failed to load nodes: %w", err)}
+		}
+
+		// Health check endpoints vary across versions; skip those that cannot run
+		var checks []rabbitmq.HealthCheck
+		for _, name := range rabbitmq.HealthChecks {
+			if hc, err := mgmt.CheckHealth(ctx, name); err == nil {
+				checks = append(checks, hc)
+			}
+		}
+
+		return dashboardLoadedMsg{id: id, overview: overview, nodes: nodes, checks: checks}
+	}
+}
+
+func (m dashboardModel) scheduleRefresh() tea.Cmd {
+	id := m.pollID
+	return tea.Tick(dashboardRefresh, func(time.Time) tea.Msg {
+		return dashboardTickMsg{id: id}
+	})
+}
+
+func (m dashboardModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		key := msg.String()
+		switch {
+		case key == "ctrl+c", m.keys.is(config.KeysDashboard, key, "quit"):
+			return m, tea.Quit
+		case m.keys.is(config.KeysDashboard, key, "refresh"):
+			m.loading = true
+			return m, m.load()
+		}
+
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		m.height = msg.Height
+
+	case dashboardLoadedMsg:
+		if msg.id != m.pollID {
+			return m, nil
+		}
+		m.loading = false
+		m.err = msg.err
+		if msg.err == nil {
+			m.overview = msg.overview
+			m.nodes = msg.nodes
+			m.checks = msg.checks
+			m.lastUpdate = time.Now()
+			stats := msg.overview.MessageStats
+			m.publishHist = appendSample(m.publishHist, stats.PublishDetails.Rate, dashboardHistory)
+			m.deliverHist = appendSample(m.deliverHist, stats.DeliverGetDetails.Rate, dashboardHistory)
+			m.queuedHist = appendSample(m.queuedHist, float64(msg.overview.QueueTotals.Messages), dashboardHistory)
+		}
+		return m, m.scheduleRefresh()
+
+	case dashboardTickMsg:
+		if msg.id != m.pollID {
+			return m, nil
+		}
+		return m, m.load()
+
+	case spinner.TickMsg:
+		if m.loading {
+			var cmd tea.Cmd
+			m.spinner, cmd = m.spinner.Update(msg)
+			return m, cmd
+		}
+	}
+
+	return m, nil
+}
+
+func (m dashboardModel) View() string {
+	if m.width == 0 {
+		return m.spinner.View() + " Loading..."
+	}
+
+	title := "rabbithole - Cluster Dashboard"
+	if m.overview != nil && m.overview.ClusterName != "" {
+		title += mutedStyle.Render("  " + m.overview.ClusterName)
+	}
+	header := headerStyle.Width(m.width - 2).Render(title)
+
+	var sb strings.Builder
+	switch {
+	case m.overview == nil && m.err != nil:
+		sb.WriteString(errorStyle.Render(fmt.Sprintf("Error: %v", m.err)))
+	case m.overview == nil:
+		sb.WriteString("  " + m.spinner.View() + " Loading...")
+	default:
+		m.renderOverview(&sb)
+		m.renderAlarms(&sb)
+		m.renderNodes(&sb)
+		if m.err != nil {
+			sb.WriteString("\n" + errorStyle.Render(fmt.Sprintf("Refresh failed: %v", m.err)))
+		}
+	}
+	content := messageListStyle.Width(m.width - 4).Height(m.height - 8).Render(sb.String())
+
+	return lipgloss.JoinVertical(lipgloss.Left, header, content, m.renderHelp())
+}
+
+func (m dashboardModel) renderOverview(sb *strings.Builder) {
+	o := m.overview
+	sb.WriteString(fieldNameStyle.Render("Overview"))
+	sb.WriteString(mutedStyle.Render(fmt.Sprintf("  RabbitMQ %s  Erlang %s", o.RabbitMQVersion, o.ErlangVersion)))
+	sb.WriteString("\n")
+	t := o.ObjectTotals
+	sb.WriteString(mutedStyle.Render(fmt.Sprintf("  %d connections  %d channels  %d exchanges  %d queues  %d consumers",
+		t.Connections, t.Channels, t.Exchanges, t.Queues, t.Consumers)))
+	sb.WriteString("\n\n")
+
+	graphWidth := m.width - 50
+	if graphWidth > dashboardHistory {
+		graphWidth = dashboardHistory
+	}
+	stats := o.MessageStats
+	rows := []struct {
+		label string
+		value string
+		hist  []float64
+	}{
+		{"Publish", formatRate(stats.PublishDetails.Rate), m.publishHist},
+		{"Deliver", formatRate(stats.DeliverGetDetails.Rate), m.deliverHist},
+		{"Queued", fmt.Sprintf("%d msgs (%d unacked)", o.QueueTotals.Messages, o.QueueTotals.MessagesUnacknowledged), m.queuedHist},
+	}
+	for _, r := range rows {
+		sb.WriteString(fmt.Sprintf("  %-8s %-28s %s\n", r.label, r.value, sparklineStyle.Render(sparkline(r.hist, graphWidth))))
+	}
+	sb.WriteString("\n")
+}
+
+func (m dashboardModel) renderAlarms(sb *strings.Builder) {
+	sb.WriteString(fieldNameStyle.Render("Health"))
+	sb.WriteString("\n")
+
+	alarms := rabbitmq.ClusterAlarms(m.nodes)
+	for _, a := range alarms {
+		sb.WriteString(alarmStyle.Render("  ⚠ " + a + " (publishers blocked)"))
+		sb.WriteString("\n")
+	}
+	for _, n := range m.nodes {
+		if len(n.Partitions) > 0 {
+			sb.WriteString(alarmStyle.Render(fmt.Sprintf("  ⚠ network partition: %s cannot see %s", n.Name, strings.Join(n.Partitions, ", "))))
+			sb.WriteString("\n")
+		}
+	}
+
+	var checks []string
+	for _, hc := range m.checks {
+		if hc.OK {
+			checks = append(checks, connectedStyle.Render("✓ ")+hc.Name)
+		} else {
+			checks = append(checks, alarmStyle.Render("✗ "+hc.Name)+mutedStyle.Render(" ("+hc.Reason+")"))
+		}
+	}
+	if len(checks) > 0 {
+		sb.WriteString("  " + strings.Join(checks, "   "))
+		sb.WriteString("\n")
+	} else if len(alarms) == 0 {
+		sb.WriteString(mutedStyle.Render("  No alarms"))
+		sb.WriteString("\n")
+	}
+	sb.WriteString("\n")
+}
+
+func (m dashboardModel) renderNodes(sb *strings.Builder) {
+	sb.WriteString(fieldNameStyle.Render("Nodes"))
+	sb.WriteString("\n")
+	sb.WriteString(mutedStyle.Render(fmt.Sprintf("  %-28s %-8s %-22s %-22s %-14s %-14s %-16s %s",
+		"NAME", "STATUS", "MEMORY", "DISK FREE", "FDS", "SOCKETS", "PROCESSES", "UPTIME")))
+	sb.WriteString("\n")
+
+	for _, n := range m.nodes {
+		status := connectedStyle.Render(fmt.Sprintf("%-8s", "running"))
+		if !n.Running {
+			status = alarmStyle.Render(fmt.Sprintf("%-8s", "down"))
+		}
+
+		mem := fmt.Sprintf("%-22s", fmt.Sprintf("%s / %s", formatBytes(n.MemUsed), formatBytes(n.MemLimit)))
+		if n.MemAlarm {
+			mem = alarmStyle.Render(mem)
+		}
+		disk := fmt.Sprintf("%-22s", fmt.Sprintf("%s (min %s)", formatBytes(n.DiskFree), formatBytes(n.DiskFreeLimit)))
+		if n.DiskFreeAlarm {
+			disk = alarmStyle.Render(disk)
+		}
+
+		sb.WriteString(fmt.Sprintf("  %-28s %s %s %s %-14s %-14s %-16s %s\n",
+			truncate(n.Name, 28),
+			status,
+			mem,
+			disk,
+			usage(n.FDUsed, n.FDTotal),
+			usage(n.SocketsUsed, n.SocketsTotal),
+			usage(n.ProcUsed, n.ProcTotal),
+			formatUptime(time.Duration(n.Uptime)*time.Millisecond),
+		))
+	}
+}
+
+func (m dashboardModel) renderHelp() string {
+	updated := ""
+	if !m.lastUpdate.IsZero() {
+		updated = mutedStyle.Render(fmt.Sprintf("  updated %s, every %s", m.lastUpdate.Format("15:04:05"), dashboardRefresh))
+	}
+	keys := []struct{ key, desc string }{
+		{m.keys.hint(config.KeysDashboard, "refresh"), "refresh"},
+		{m.keys.hint(config.KeysDashboard, "back"), "back"},
+		{m.keys.hint(config.KeysDashboard, "quit"), "quit"},
+	}
+	var parts []string
+	for _, k := range keys {
+		parts = append(parts, fmt.Sprintf("%s %s", helpKeyStyle.Render(k.key), k.desc))
+	}
+	return helpStyle.Render(strings.Join(parts, "  ")) + updated
+}
+
+// usage formats a used/total resource count with its percentage.
+func usage(used, total int) string {
+	if total <= 0 {
+		return fmt.Sprintf("%d", used)
+	}
+	return fmt.Sprintf("%d/%d %d%%", used, total, used*100/total)
+}
+
+// formatUptime formats a duration as days and hours, or hours and minutes.
+func formatUptime(d time.Duration) string {
+	days := int(d.Hours()) / 24
+	hours := int(d.Hours()) % 24
+	if days > 0 {
+		return fmt.Sprintf("%dd %dh", days, hours)
+	}
+	return fmt.Sprintf("%dh %dm", hours, int(d.Minutes())%60)
+}
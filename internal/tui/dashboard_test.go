@@ -0,0 +1,103 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/epalmerini/rabbithole/internal/rabbitmq"
+)
+
+func TestDashboard_LoadRecordsHistory(t *testing.T) {
+	m := newDashboardModel(Config{})
+
+	for i := 1; i <= 3; i++ {
+		overview := &rabbitmq.Overview{}
+		overview.MessageStats.PublishDetails.Rate = float64(i)
+		overview.QueueTotals.Messages = i * 10
+
+		result, cmd := m.Update(dashboardLoadedMsg{id: m.pollID, overview: overview})
+		m = result.(dashboardModel)
+		if cmd == nil {
+			t.Fatal("expected refresh to be scheduled")
+		}
+	}
+
+	if len(m.publishHist) != 3 || m.publishHist[2] != 3 {
+		t.Errorf("publishHist = %v", m.publishHist)
+	}
+	if len(m.queuedHist) != 3 || m.queuedHist[2] != 30 {
+		t.Errorf("queuedHist = %v", m.queuedHist)
+	}
+}
+
+func TestDashboard_IgnoresStalePolls(t *testing.T) {
+	m := newDashboardModel(Config{})
+
+	result, cmd := m.Update(dashboardLoadedMsg{id: m.pollID - 1, overview: &rabbitmq.Overview{}})
+	m = result.(dashboardModel)
+	if cmd != nil || m.overview != nil {
+		t.Error("expected stale load to be dropped")
+	}
+
+	_, cmd = m.Update(dashboardTickMsg{id: m.pollID - 1})
+	if cmd != nil {
+		t.Error("expected stale tick not to start a load")
+	}
+}
+
+func TestDashboard_KeepsDataOnRefreshError(t *testing.T) {
+	m := newDashboardModel(Config{})
+	m.width, m.height = 120, 40
+
+	result, _ := m.Update(dashboardLoadedMsg{id: m.pollID, overview: &rabbitmq.Overview{ClusterName: "prod"}})
+	m = result.(dashboardModel)
+	result, _ = m.Update(dashboardLoadedMsg{id: m.pollID, err: fmt.Errorf("timeout")})
+	m = result.(dashboardModel)
+
+	if m.overview == nil || m.overview.ClusterName != "prod" {
+		t.Error("expected previous overview to be kept")
+	}
+	if !strings.Contains(m.View(), "Refresh failed") {
+		t.Error("expected refresh error in view")
+	}
+}
+
+func TestUsage(t *testing.T) {
+	if got := usage(256, 1024); got != "256/1024 25%" {
+		t.Errorf("usage() = %q", got)
+	}
+	if got := usage(5, 0); got != "5" {
+		t.Errorf("usage() = %q", got)
+	}
+}
+
+func TestFormatUptime(t *testing.T) {
+	if got := formatUptime(50 * time.Hour); got != "2d 2h" {
+		t.Errorf("formatUptime() = %q", got)
+	}
+	if got := formatUptime(90 * time.Minute); got != "1h 30m" {
+		t.Errorf("formatUptime() = %q", got)
+	}
+}
+
+func TestStatusBar_ShowsAlarms(t *testing.T) {
+	m := initialModel(Config{}, nil)
+	m.width, m.height = 160, 40
+
+	result, cmd := m.Update(alarmsPolledMsg{id: m.alarmPollID, alarms: []string{"memory alarm on rabbit@a"}})
+	m = result.(model)
+	if cmd == nil {
+		t.Error("expected next alarm poll to be scheduled")
+	}
+	if !strings.Contains(m.renderStatusBar(), "memory alarm on rabbit@a") {
+		t.Error("expected alarm in status bar")
+	}
+
+	result, _ = m.Update(alarmsPolledMsg{id: m.alarmPollID})
+	m = result.(model)
+	if strings.Contains(m.renderStatusBar(), "alarm") {
+		t.Error("expected alarm to clear")
+	}
+}
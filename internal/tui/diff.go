@@ -0,0 +1,264 @@
+package tui
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/epalmerini/rabbithole/internal/query"
+)
+
+// diffKind classifies a field that differs between two messages.
+type diffKind int
+
+const (
+	diffChanged diffKind = iota
+	diffAdded
+	diffRemoved
+)
+
+// diffEntry is one field that differs, at a path in query syntax
+// (body.country.name, hdr.x-retry), so it can be pasted into a filter.
+type diffEntry struct {
+	path     string
+	kind     diffKind
+	old, new any
+}
+
+// diffView compares two messages in the detail panel, shown while the newer
+// one, b, is selected.
+type diffView struct {
+	a, b    Message
+	entries []diffEntry
+}
+
+func newDiffView(a, b Message) *diffView {
+	return &diffView{a: a, b: b, entries: diffMessages(a, b)}
+}
+
+// diffMessages compares the bodies and headers of a and b. Bodies are
+// compared field by field when both decode, from protobuf or as JSON.
+func diffMessages(a, b Message) []diffEntry {
+	var entries []diffEntry
+	aBody, aOK := diffBody(a)
+	bBody, bOK := diffBody(b)
+	switch {
+	case aOK && bOK:
+		entries = diffValues("body", aBody, bBody, entries)
+	case !bytes.Equal(a.RawBody, b.RawBody):
+		entries = append(entries, diffEntry{
+			path: "body",
+			kind: diffChanged,
+			old:  fmt.Sprintf("%d bytes", len(a.RawBody)),
+			new:  fmt.Sprintf("%d bytes", len(b.RawBody)),
+		})
+	}
+	return diffValues("hdr", diffDoc(a.Headers), diffDoc(b.Headers), entries)
+}
+
+func diffBody(msg Message) (any, bool) {
+	if msg.Decoded != nil {
+		return diffDoc(msg.Decoded), true
+	}
+	var body any
+	if err := json.Unmarshal(msg.RawBody, &body); err != nil {
+		return nil, false
+	}
+	return body, true
+}
+
+// diffDoc converts a decoded body or header table to plain JSON values, so
+// numbers and nested tables of either message compare alike.
+func diffDoc(doc map[string]any) any {
+	out := map[string]any{}
+	if data, err := json.Marshal(doc); err == nil {
+		_ = json.Unmarshal(data, &out)
+	}
+	if out == nil {
+		out = map[string]any{}
+	}
+	return out
+}
+
+// diffValues appends the differences between a and b at path to entries,
+// descending into objects and arrays.
+func diffValues(path string, a, b any, entries []diffEntry) []diffEntry {
+	switch av := a.(type) {
+	case map[string]any:
+		bv, ok := b.(map[string]any)
+		if !ok {
+			break
+		}
+		keys := make([]string, 0, len(av)+len(bv))
+		for k := range av {
+			keys = append(keys, k)
+		}
+		for k := range bv {
+			if _, ok := av[k]; !ok {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			entries = diffField(path+"."+k, av, bv, k, entries)
+		}
+		return entries
+	case []any:
+		bv, ok := b.([]any)
+		if !ok {
+			break
+		}
+		for i := range max(len(av), len(bv)) {
+			child := path + "." + strconv.Itoa(i)
+			switch {
+			case i >= len(bv):
+				entries = append(entries, diffEntry{path: child, kind: diffRemoved, old: av[i]})
+			case i >= len(av):
+				entries = append(entries, diffEntry{path: child, kind: diffAdded, new: bv[i]})
+			default:
+				entries = diffValues(child, av[i], bv[i], entries)
+			}
+		}
+		return entries
+	}
+	if !reflect.DeepEqual(a, b) {
+		entries = append(entries, diffEntry{path: path, kind: diffChanged, old: a, new: b})
+	}
+	return entries
+}
+
+func diffField(path string, a, b map[string]any, key string, entries []diffEntry) []diffEntry {
+	av, inA := a[key]
+	bv, inB := b[key]
+	switch {
+	case !inB:
+		return append(entries, diffEntry{path: path, kind: diffRemoved, old: av})
+	case !inA:
+		return append(entries, diffEntry{path: path, kind: diffAdded, new: bv})
+	}
+	return diffValues(path, av, bv, entries)
+}
+
+// sameMessage reports whether a and b are the same message of the list.
+func sameMessage(a, b Message) bool {
+	return a.ID == b.ID && a.Timestamp.Equal(b.Timestamp) && a.RoutingKey == b.RoutingKey
+}
+
+// markDiff marks the selected message as the base of a diff or, once one is
+// marked, diffs it against the selected message.
+func (m *model) markDiff() tea.Cmd {
+	if m.messages.Len() == 0 || m.selectedIdx >= m.messages.Len() {
+		return nil
+	}
+	m.decodeSelected()
+	msg := *m.messages.At(m.selectedIdx)
+	switch {
+	case m.diffBase == nil:
+		m.diffBase = &msg
+		m.diff = nil
+		return m.setStatusMsg(fmt.Sprintf("Marked #%d: press d on another message to diff", msg.ID))
+	case sameMessage(*m.diffBase, msg):
+		m.diffBase = nil
+		return m.setStatusMsg("Diff mark cleared")
+	}
+	m.diff = newDiffView(*m.diffBase, msg)
+	m.diffBase = nil
+	m.detailViewport.YOffset = 0
+	return nil
+}
+
+// diffPrevious diffs the selected message against the closest earlier one
+// about the same entity: with the same value of the configured diff key when
+// the message has one, otherwise with the same routing key.
+func (m *model) diffPrevious() tea.Cmd {
+	if m.messages.Len() == 0 || m.selectedIdx >= m.messages.Len() {
+		return nil
+	}
+	m.decodeSelected()
+	msg := *m.messages.At(m.selectedIdx)
+
+	key, byEntity := m.entityKey(msg)
+	for i := m.selectedIdx - 1; i >= 0; i-- {
+		prev := m.messages.At(i)
+		if byEntity {
+			decodeLazy(prev, m.config.Decoder)
+			if k, ok := m.entityKey(*prev); !ok || k != key {
+				continue
+			}
+		} else if prev.RoutingKey != msg.RoutingKey {
+			continue
+		}
+		m.diff = newDiffView(*prev, msg)
+		m.diffBase = nil
+		m.detailViewport.YOffset = 0
+		return nil
+	}
+
+	if byEntity {
+		return m.setStatusMsg(fmt.Sprintf("No earlier message with %s = %s", m.config.DiffKey, key))
+	}
+	return m.setStatusMsg("No earlier message with routing key " + msg.RoutingKey)
+}
+
+// entityKey returns the value of the configured diff key in msg.
+func (m model) entityKey(msg Message) (string, bool) {
+	if m.config.DiffKey == "" {
+		return "", false
+	}
+	v, ok := query.Lookup(m.config.DiffKey, msg.queryMessage())
+	if !ok || v == nil {
+		return "", false
+	}
+	return fmt.Sprint(v), true
+}
+
+// renderTitle replaces the detail tab bar while the diff is shown.
+func (d *diffView) renderTitle(width int) string {
+	describe := func(msg Message) string {
+		return fmt.Sprintf("#%d %s %s", msg.ID, msg.RoutingKey, msg.Timestamp.Format("15:04:05.000"))
+	}
+	return fieldNameStyle.Render("Diff ") + mutedStyle.Render(truncate(describe(d.a)+" → "+describe(d.b), width-5))
+}
+
+// renderLines lists the differences, values coloured as in the body tab.
+func (d *diffView) renderLines() []string {
+	if len(d.entries) == 0 {
+		return []string{mutedStyle.Render("No differences in body or headers")}
+	}
+
+	var counts [3]int
+	for _, e := range d.entries {
+		counts[e.kind]++
+	}
+	var summary []string
+	for kind, label := range []string{"changed", "added", "removed"} {
+		if counts[kind] > 0 {
+			summary = append(summary, fmt.Sprintf("%d %s", counts[kind], label))
+		}
+	}
+	lines := []string{mutedStyle.Render(strings.Join(summary, ", ")), ""}
+
+	for _, e := range d.entries {
+		path := jsonKeyStyle.Render(e.path) + ": "
+		switch e.kind {
+		case diffChanged:
+			lines = append(lines, diffChangedStyle.Render("~ ")+path+diffValue(e.old)+mutedStyle.Render(" → ")+diffValue(e.new))
+		case diffAdded:
+			lines = append(lines, diffAddedStyle.Render("+ ")+path+diffValue(e.new))
+		case diffRemoved:
+			lines = append(lines, diffRemovedStyle.Render("- ")+path+diffValue(e.old))
+		}
+	}
+	return lines
+}
+
+func diffValue(v any) string {
+	var sb strings.Builder
+	formatValueSyntax(&sb, v, 1)
+	return sb.String()
+}
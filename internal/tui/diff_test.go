@@ -0,0 +1,133 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+func TestDiffMessages(t *testing.T) {
+	a := Message{
+		Decoded: map[string]any{"country": map[string]any{"name": "Italy", "tags": []any{"eu"}}, "version": 1},
+		Headers: map[string]any{"x-retry": int32(0), "x-source": "web"},
+	}
+	b := Message{
+		Decoded: map[string]any{"country": map[string]any{"name": "Italia", "code": "IT", "tags": []any{"eu", "g7"}}, "version": 1},
+		Headers: map[string]any{"x-retry": int32(1), "x-nested": amqp.Table{"k": "v"}},
+	}
+
+	var got []string
+	for _, e := range diffMessages(a, b) {
+		got = append(got, fmt.Sprintf("%d %s", e.kind, e.path))
+	}
+	want := []string{
+		"1 body.country.code",
+		"0 body.country.name",
+		"1 body.country.tags.1",
+		"1 hdr.x-nested",
+		"0 hdr.x-retry",
+		"2 hdr.x-source",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("diff =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	if entries := diffMessages(a, a); len(entries) != 0 {
+		t.Errorf("a message differs from itself: %v", entries)
+	}
+
+	// Bodies that decode neither way are compared as bytes
+	raw := diffMessages(Message{RawBody: []byte{1}}, Message{RawBody: []byte{1, 2}})
+	if len(raw) != 1 || raw[0].path != "body" || raw[0].new != "2 bytes" {
+		t.Errorf("raw diff = %+v", raw)
+	}
+}
+
+func TestDiffView(t *testing.T) {
+	base := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	msgs := []Message{
+		{ID: 1, RoutingKey: "country.updated", Timestamp: base, RawBody: []byte(`{"id":1,"name":"Italy"}`)},
+		{ID: 2, RoutingKey: "country.updated", Timestamp: base.Add(time.Second), RawBody: []byte(`{"id":2,"name":"France"}`)},
+		{ID: 3, RoutingKey: "user.created", Timestamp: base.Add(2 * time.Second), RawBody: []byte(`{"uid":1}`)},
+		{ID: 4, RoutingKey: "country.updated", Timestamp: base.Add(3 * time.Second), RawBody: []byte(`{"id":1,"name":"Italia"}`)},
+	}
+	newModel := func() model {
+		return model{
+			messages:       newMessageRing(0, msgs...),
+			selectedIdx:    3,
+			width:          120,
+			height:         30,
+			detailViewport: viewport.New(80, 20),
+			vimKeys:        NewVimKeyState(),
+			splitRatio:     0.4,
+		}
+	}
+	key := func(m model, k string) model {
+		t.Helper()
+		var msg tea.KeyMsg
+		if k == "esc" {
+			msg = tea.KeyMsg{Type: tea.KeyEsc}
+		} else {
+			msg = tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(k)}
+		}
+		updated, _ := m.Update(msg)
+		return updated.(model)
+	}
+
+	// D: the previous message with the same routing key
+	m := key(newModel(), "D")
+	if m.diff == nil || m.diff.a.ID != 2 {
+		t.Fatalf("D diffed against %+v, want message 2", m.diff)
+	}
+	view := m.View()
+	for _, s := range []string{"#2 country.updated", "body.id", "body.name", "France", "Italia"} {
+		if !strings.Contains(view, s) {
+			t.Errorf("diff view missing %q", s)
+		}
+	}
+
+	// With a diff key, the previous message about the same entity
+	m = newModel()
+	m.config.DiffKey = "body.id"
+	m = key(m, "D")
+	if m.diff == nil || m.diff.a.ID != 1 {
+		t.Fatalf("D by body.id diffed against %+v, want message 1", m.diff)
+	}
+	if len(m.diff.entries) != 1 || m.diff.entries[0].path != "body.name" {
+		t.Errorf("entries = %+v, want only body.name", m.diff.entries)
+	}
+
+	// d marks A, d on B compares them; the diff shows only while B is selected
+	m = newModel()
+	m.selectedIdx = 2
+	m = key(m, "d")
+	if m.diffBase == nil || m.diffBase.ID != 3 {
+		t.Fatalf("d should mark message 3, got %+v", m.diffBase)
+	}
+	m = key(m, "j")
+	m = key(m, "d")
+	if m.diffBase != nil || m.diff == nil || m.diff.a.ID != 3 || m.diff.b.ID != 4 {
+		t.Fatalf("second d: base = %v, diff = %+v", m.diffBase, m.diff)
+	}
+	m = key(m, "k")
+	if strings.Contains(m.View(), "Diff #3") {
+		t.Error("the diff should hide when another message is selected")
+	}
+	m = key(m, "esc")
+	if m.diff != nil {
+		t.Error("esc should close the diff")
+	}
+
+	// Nothing earlier to compare with
+	m = newModel()
+	m.selectedIdx = 0
+	m = key(m, "D")
+	if m.diff != nil || !strings.Contains(m.statusMsg, "No earlier message") {
+		t.Errorf("diff = %+v, status = %q", m.diff, m.statusMsg)
+	}
+}
@@ -1,35 +1,46 @@
 package tui
 
 import (
-	"regexp"
+	"iter"
 	"sort"
-	"strings"
+
+	"github.com/epalmerini/rabbithole/internal/filter"
+	"github.com/epalmerini/rabbithole/internal/query"
 )
 
-// computeFilteredIndices returns indices into msgs that match the filter expression.
-// Uses the same field prefix syntax as search (rk:, body:, ex:, hdr:, type:, re:).
-// Returns nil for empty expressions or invalid regex.
-func computeFilteredIndices(msgs []Message, expr string) []int {
+func (msg Message) queryMessage() *query.Message {
+	return &query.Message{
+		Exchange:      msg.Exchange,
+		RoutingKey:    msg.RoutingKey,
+		ProtoType:     msg.ProtoType,
+		ContentType:   msg.ContentType,
+		CorrelationID: msg.CorrelationID,
+		ReplyTo:       msg.ReplyTo,
+		MessageID:     msg.MessageID,
+		AppID:         msg.AppID,
+		Timestamp:     msg.Timestamp,
+		Headers:       msg.Headers,
+		Body:          msg.Decoded,
+		RawBody:       msg.RawBody,
+	}
+}
+
+// computeFilteredIndices returns the positions in msgs that match the filter expression.
+// Accepts the same syntax as search: a structured query or the field prefixes
+// (rk:, body:, ex:, hdr:, type:, re:). Returns nil for empty or invalid expressions.
+func computeFilteredIndices(msgs iter.Seq2[int, Message], expr string) []int {
 	if expr == "" {
 		return nil
 	}
 
-	field, query := parseSearchQuery(expr)
-
-	var re *regexp.Regexp
-	if field == "re" {
-		var err error
-		re, err = compileSearchRegex(query)
-		if err != nil {
-			return nil
-		}
-	} else {
-		query = strings.ToLower(query)
+	f, err := filter.New(expr)
+	if err != nil {
+		return nil
 	}
 
 	var indices []int
 	for i, msg := range msgs {
-		if matchesSearch(msg, field, query, re) {
+		if f.Match(*msg.queryMessage()) {
 			indices = append(indices, i)
 		}
 	}
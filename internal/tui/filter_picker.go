@@ -0,0 +1,150 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/epalmerini/rabbithole/internal/config"
+	"github.com/epalmerini/rabbithole/internal/filter"
+)
+
+// checkSavedFilters reports the first saved filter that does not compile.
+func checkSavedFilters(filters []config.SavedFilter) error {
+	for i, f := range filters {
+		name := f.Name
+		if name == "" {
+			name = fmt.Sprintf("#%d", i+1)
+		}
+		if f.Filter == "" {
+			return fmt.Errorf("filter %s: filter is required", name)
+		}
+		if _, err := filter.New(f.Filter); err != nil {
+			return fmt.Errorf("filter %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// filterPicker lists the saved filters of config.toml.
+type filterPicker struct {
+	cursor int
+}
+
+// openFilterPicker opens the saved filters, with the active one selected.
+func (m *model) openFilterPicker() tea.Cmd {
+	if len(m.config.Filters) == 0 {
+		return m.setStatusMsg("No saved filters: add [[filters]] to config.toml")
+	}
+	m.filterPicker = &filterPicker{}
+	for i, f := range m.config.Filters {
+		if m.filterActive && f.Filter == m.filterExpr {
+			m.filterPicker.cursor = i
+		}
+	}
+	return nil
+}
+
+// updateFilterPicker handles keys while the saved filters are listed.
+func (m model) updateFilterPicker(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	p := m.filterPicker
+	filters := m.config.Filters
+	keys, key := m.config.Keys, msg.String()
+	is := func(action string) bool { return keys.is(config.KeysFilters, key, action) }
+	switch {
+	case key == "ctrl+c":
+		return m, tea.Quit
+	case is("close"), keys.is(config.KeysConsumer, key, "filter_pick"):
+		// The key that opened the list also closes it
+		m.filterPicker = nil
+	case is("down"):
+		if p.cursor < len(filters)-1 {
+			p.cursor++
+		}
+	case is("up"):
+		if p.cursor > 0 {
+			p.cursor--
+		}
+	case is("top"):
+		p.cursor = 0
+	case is("bottom"):
+		p.cursor = len(filters) - 1
+	case is("filter"):
+		m.filterPicker = nil
+		return m, m.applyFilter(filters[p.cursor].Filter)
+	case is("search"):
+		m.filterPicker = nil
+		m.searchQuery = filters[p.cursor].Filter
+		return m, m.performSearch()
+	case len(key) == 1 && key >= "1" && key <= "9":
+		if i := int(key[0] - '1'); i < len(filters) {
+			m.filterPicker = nil
+			return m, m.applyFilter(filters[i].Filter)
+		}
+	}
+	return m, nil
+}
+
+func (m model) renderFilterPicker() string {
+	p := m.filterPicker
+	header := headerStyle.Width(m.width - 2).Render("rabbithole — saved filters")
+	status := m.renderStatusBar()
+
+	height := m.height - 5
+	if height < 3 {
+		height = 3
+	}
+	innerWidth := m.width - 4
+
+	lines := []string{
+		fieldNameStyle.Render("Saved filters"),
+		mutedStyle.Render("From config.toml; the active filter is marked"),
+		"",
+	}
+	rows := max(height-2-len(lines), 1)
+	start := 0
+	if p.cursor >= rows {
+		start = p.cursor - rows + 1
+	}
+	end := min(start+rows, len(m.config.Filters))
+
+	nameWidth := 0
+	for _, f := range m.config.Filters {
+		nameWidth = max(nameWidth, lipgloss.Width(f.Name))
+	}
+	nameWidth = min(nameWidth, innerWidth/3)
+	for i := start; i < end; i++ {
+		f := m.config.Filters[i]
+		num := "  "
+		if i < 9 {
+			num = fmt.Sprintf("%d ", i+1)
+		}
+		mark := " "
+		if m.filterActive && f.Filter == m.filterExpr {
+			mark = "●"
+		}
+		line := truncate(fmt.Sprintf("%s%s %-*s  %s", num, mark, nameWidth, truncate(f.Name, nameWidth), f.Filter), innerWidth)
+		if i == p.cursor {
+			line = selectedMessageStyle.Render(line)
+		}
+		lines = append(lines, line)
+	}
+
+	content := messageListStyle.Width(m.width - 2).Height(height).Render(strings.Join(lines, "\n"))
+
+	var parts []string
+	km := m.config.Keys
+	for _, k := range []struct{ key, desc string }{
+		{km.hint(config.KeysFilters, "down", "up"), "nav"},
+		{km.hint(config.KeysFilters, "filter"), "filter"},
+		{km.hint(config.KeysFilters, "search"), "search"},
+		{"1-9", "filter by number"},
+		{km.hint(config.KeysFilters, "close"), "close"},
+	} {
+		parts = append(parts, helpKeyStyle.Render(k.key)+" "+k.desc)
+	}
+	bottomBar := helpStyle.Render(strings.Join(parts, "  "))
+
+	return lipgloss.JoinVertical(lipgloss.Left, header, status, content, bottomBar)
+}
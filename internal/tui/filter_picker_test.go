@@ -0,0 +1,134 @@
+package tui
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/epalmerini/rabbithole/internal/config"
+	"github.com/epalmerini/rabbithole/internal/db"
+)
+
+func newFilterTestModel(t *testing.T, store db.Store) model {
+	t.Helper()
+	cfg := Config{Filters: []config.SavedFilter{
+		{Name: "tenant x", Filter: "hdr:tenant-x"},
+		{Name: "failed", Filter: "rk:failed"},
+	}}
+	m := initialModel(cfg, store)
+	m.messages = newMessageRing(0,
+		Message{ID: 1, RoutingKey: "order.created", Headers: map[string]any{"tenant": "tenant-x"}},
+		Message{ID: 2, RoutingKey: "order.failed", Headers: map[string]any{"tenant": "tenant-y"}},
+		Message{ID: 3, RoutingKey: "payment.failed", Headers: map[string]any{"tenant": "tenant-x"}},
+		Message{ID: 4, RoutingKey: "payment.created", Headers: map[string]any{"tenant": "tenant-x"}},
+	)
+	m.width, m.height = 120, 30
+	m.detailViewport = viewport.New(80, 20)
+	return m
+}
+
+func TestCheckSavedFilters(t *testing.T) {
+	if err := checkSavedFilters([]config.SavedFilter{{Name: "a", Filter: "rk:a"}, {Filter: "body.total > 10"}}); err != nil {
+		t.Errorf("valid filters rejected: %v", err)
+	}
+	if err := checkSavedFilters([]config.SavedFilter{{Name: "empty"}}); err == nil || !strings.Contains(err.Error(), "filter empty: filter is required") {
+		t.Errorf("empty filter error = %v", err)
+	}
+	if err := checkSavedFilters([]config.SavedFilter{{Filter: "re:("}}); err == nil || !strings.Contains(err.Error(), "filter #1:") {
+		t.Errorf("bad regexp error = %v", err)
+	}
+}
+
+func TestFilterPicker(t *testing.T) {
+	m := newFilterTestModel(t, nil)
+
+	m = press(t, m, `"`)
+	if m.filterPicker == nil || !strings.Contains(m.View(), "tenant x") {
+		t.Fatal(`" should list the saved filters`)
+	}
+	m = press(t, m, "2")
+	if m.filterPicker != nil || m.filterExpr != "rk:failed" || len(m.filteredIdx) != 2 {
+		t.Fatalf("2 applied %q with %d matches, want rk:failed with 2", m.filterExpr, len(m.filteredIdx))
+	}
+
+	// Reopening selects the active filter; enter applies the selected one
+	m = press(t, m, `"`)
+	if m.filterPicker.cursor != 1 {
+		t.Errorf("cursor = %d, want the active filter", m.filterPicker.cursor)
+	}
+	m = press(t, m, "k", "enter")
+	if m.filterExpr != "hdr:tenant-x" || len(m.filteredIdx) != 3 {
+		t.Errorf("enter applied %q with %d matches", m.filterExpr, len(m.filteredIdx))
+	}
+
+	// A saved filter can also be searched for, within the active filter
+	m = press(t, m, `"`, "j", "/")
+	if m.filterExpr != "hdr:tenant-x" || len(m.searchResults) != 1 || m.messages.At(m.selectedIdx).RoutingKey != "payment.failed" {
+		t.Errorf("search results = %v, selected %s", m.searchResults, m.messages.At(m.selectedIdx).RoutingKey)
+	}
+}
+
+func TestSearchWithinFilter(t *testing.T) {
+	m := newFilterTestModel(t, nil)
+	m.searchQuery = "rk:created"
+	m.performSearch()
+	if len(m.searchResults) != 2 {
+		t.Fatalf("unfiltered search found %d, want 2", len(m.searchResults))
+	}
+
+	// Changing the filter narrows or widens the results
+	m.applyFilter("rk:payment")
+	if len(m.searchResults) != 1 || m.searchResults[0] != 3 {
+		t.Errorf("search within rk:payment = %v, want [3]", m.searchResults)
+	}
+	m = press(t, m, "F")
+	if len(m.searchResults) != 2 {
+		t.Errorf("with the filter off the search found %d, want 2", len(m.searchResults))
+	}
+}
+
+func TestInputHistory(t *testing.T) {
+	store, err := db.NewStore(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = store.Close() })
+
+	m := newFilterTestModel(t, store)
+	for _, expr := range []string{"rk:order", "hdr:tenant-x", "rk:order"} {
+		m = press(t, m, "f")
+		m.filterInput.SetValue(expr)
+		updated, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+		m = run(t, updated.(model), cmd)
+	}
+
+	// A new view recalls the history of the earlier one, latest first
+	m = newFilterTestModel(t, store)
+	m = press(t, m, "f")
+	m.filterInput.SetValue("draft")
+	key := func(k tea.KeyType) string {
+		updated, _ := m.Update(tea.KeyMsg{Type: k})
+		m = updated.(model)
+		return m.filterInput.Value()
+	}
+	if got := key(tea.KeyUp); got != "rk:order" {
+		t.Errorf("up = %q, want rk:order", got)
+	}
+	if got := key(tea.KeyUp); got != "hdr:tenant-x" {
+		t.Errorf("up = %q, want hdr:tenant-x", got)
+	}
+	if got := key(tea.KeyUp); got != "hdr:tenant-x" {
+		t.Errorf("up past the oldest = %q", got)
+	}
+	key(tea.KeyDown)
+	if got := key(tea.KeyDown); got != "draft" {
+		t.Errorf("down past the latest = %q, want the draft back", got)
+	}
+
+	// Searches have a history of their own
+	if m.searchHistory.entries != nil {
+		t.Errorf("search history = %q, want none", m.searchHistory.entries)
+	}
+}
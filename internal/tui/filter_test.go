@@ -1,6 +1,7 @@
 package tui
 
 import (
+	"slices"
 	"testing"
 )
 
@@ -11,7 +12,7 @@ func TestApplyFilter_RoutingKey(t *testing.T) {
 		{ID: 3, RoutingKey: "events.user.deleted", Exchange: "main"},
 	}
 
-	indices := computeFilteredIndices(msgs, "user")
+	indices := computeFilteredIndices(slices.All(msgs), "user")
 	if len(indices) != 2 {
 		t.Fatalf("expected 2 matches, got %d", len(indices))
 	}
@@ -26,7 +27,7 @@ func TestApplyFilter_FieldPrefix(t *testing.T) {
 		{ID: 2, RoutingKey: "events.order.placed", Exchange: "orders"},
 	}
 
-	indices := computeFilteredIndices(msgs, "ex:orders")
+	indices := computeFilteredIndices(slices.All(msgs), "ex:orders")
 	if len(indices) != 1 || indices[0] != 1 {
 		t.Errorf("expected [1], got %v", indices)
 	}
@@ -39,7 +40,7 @@ func TestApplyFilter_Regex(t *testing.T) {
 		{ID: 3, RoutingKey: "logs.error.timeout"},
 	}
 
-	indices := computeFilteredIndices(msgs, `re:^events\.`)
+	indices := computeFilteredIndices(slices.All(msgs), `re:^events\.`)
 	if len(indices) != 2 {
 		t.Fatalf("expected 2 matches, got %d", len(indices))
 	}
@@ -53,7 +54,7 @@ func TestApplyFilter_Empty(t *testing.T) {
 		{ID: 1, RoutingKey: "events.user.created"},
 	}
 
-	indices := computeFilteredIndices(msgs, "")
+	indices := computeFilteredIndices(slices.All(msgs), "")
 	if indices != nil {
 		t.Errorf("expected nil for empty filter, got %v", indices)
 	}
@@ -64,7 +65,7 @@ func TestApplyFilter_NoMatches(t *testing.T) {
 		{ID: 1, RoutingKey: "events.user.created"},
 	}
 
-	indices := computeFilteredIndices(msgs, "zzz_nonexistent")
+	indices := computeFilteredIndices(slices.All(msgs), "zzz_nonexistent")
 	if len(indices) != 0 {
 		t.Errorf("expected 0 matches, got %d", len(indices))
 	}
@@ -76,7 +77,7 @@ func TestApplyFilter_InvalidRegex(t *testing.T) {
 	}
 
 	// Invalid regex should return empty (not panic)
-	indices := computeFilteredIndices(msgs, "re:[invalid")
+	indices := computeFilteredIndices(slices.All(msgs), "re:[invalid")
 	if indices != nil {
 		t.Errorf("expected nil for invalid regex, got %v", indices)
 	}
@@ -112,3 +113,27 @@ func TestFilteredNavigation(t *testing.T) {
 		t.Errorf("prevVisible(0) = %d, want 0", prev)
 	}
 }
+
+func TestApplyFilter_StructuredQuery(t *testing.T) {
+	msgs := []Message{
+		{ID: 1, RoutingKey: "order.created", Decoded: map[string]any{"total": 150.0}, Headers: map[string]any{"x-tenant": "acme"}},
+		{ID: 2, RoutingKey: "order.created", Decoded: map[string]any{"total": 20.0}, Headers: map[string]any{"x-tenant": "acme"}},
+		{ID: 3, RoutingKey: "order.paid", RawBody: []byte(`{"total": 300}`)},
+	}
+
+	indices := computeFilteredIndices(slices.All(msgs), `body.total > 100 and hdr.x-tenant = "acme"`)
+	if len(indices) != 1 || indices[0] != 0 {
+		t.Errorf("expected [0], got %v", indices)
+	}
+
+	// JSON bodies are queried too
+	indices = computeFilteredIndices(slices.All(msgs), "body.total >= 300")
+	if len(indices) != 1 || indices[0] != 2 {
+		t.Errorf("expected [2], got %v", indices)
+	}
+
+	// A malformed query is an error, not a substring search
+	if indices := computeFilteredIndices(slices.All(msgs), "body.total >"); indices != nil {
+		t.Errorf("expected nil for invalid query, got %v", indices)
+	}
+}
@@ -0,0 +1,548 @@
+package tui
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"iter"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/epalmerini/rabbithole/internal/config"
+	"github.com/epalmerini/rabbithole/internal/query"
+)
+
+// groupLimit caps the groups a stored session is aggregated into.
+const groupLimit = 500
+
+// groupFields are the fields tab cycles through; any other one can be typed.
+var groupFields = []string{"rk", "ex", "type", "app_id"}
+
+// groupStats aggregates the messages sharing one value of the grouped field.
+type groupStats struct {
+	value   any // as query.Lookup returns it; unset when absent
+	present bool
+	count   int64
+	first   time.Time
+	last    time.Time
+	avgSize float64
+	maxSize int64
+	errors  int64 // decode errors; -1 when unknown, as for stored messages
+}
+
+// groupView is the aggregate view of the message list, grouped by one field.
+type groupView struct {
+	field   string
+	groups  []groupStats
+	total   int64
+	cursor  int
+	loading bool
+	err     error
+	seq     int // latest store request; responses to older ones are dropped
+
+	// live follows the groups of the live list as messages come and go;
+	// nil for a replay, grouped in the store
+	live *liveGroups
+
+	editing bool
+	input   textinput.Model
+}
+
+type groupResultMsg struct {
+	seq    int
+	groups []groupStats
+	err    error
+}
+
+// openGroups opens the aggregate view, grouped by routing key.
+func (m *model) openGroups() tea.Cmd {
+	input := textinput.New()
+	input.Placeholder = "body.customer.country, hdr.x-tenant, ..."
+	input.CharLimit = 128
+	input.Width = 40
+	m.groups = &groupView{field: groupFields[0], input: input}
+	return m.loadGroups()
+}
+
+// loadGroups aggregates the list: the loaded messages of the live list in
+// place, a replayed session in the store, as it may not all be loaded.
+func (m *model) loadGroups() tea.Cmd {
+	g := m.groups
+	g.seq++
+	g.err = nil
+	g.loading = false
+	expr, args, isJSON, err := query.GroupSQL(g.field)
+	if err != nil {
+		g.err = err
+		g.groups, g.total = nil, 0
+		return nil
+	}
+
+	if m.pager == nil {
+		g.live = newLiveGroups(g.field, isJSON)
+		for _, msg := range m.visibleMessages() {
+			g.live.add(msg)
+		}
+		g.groups = g.live.stats()
+		g.setTotal()
+		return nil
+	}
+
+	g.live = nil
+	g.loading = true
+	store, p, seq := m.store, *m.pager, g.seq
+	return func() tea.Msg {
+		rows, err := store.GroupMessages(context.Background(), p.where, p.args, p.sessionID, expr, args, groupLimit)
+		if err != nil {
+			return groupResultMsg{seq: seq, err: fmt.Errorf("grouping failed: %w", err)}
+		}
+		groups := make([]groupStats, 0, len(rows))
+		for _, r := range rows {
+			s := groupStats{
+				count:   r.Count,
+				first:   r.FirstSeen,
+				last:    r.LastSeen,
+				avgSize: r.AvgSize,
+				maxSize: r.MaxSize,
+				errors:  -1,
+			}
+			switch {
+			case !r.Key.Valid:
+			case isJSON:
+				s.present = json.Unmarshal([]byte(r.Key.String), &s.value) == nil
+			case r.Key.String != "":
+				s.value, s.present = r.Key.String, true
+			}
+			groups = append(groups, s)
+		}
+		return groupResultMsg{seq: seq, groups: groups}
+	}
+}
+
+// visibleMessages yields the messages the list shows: the filter's matches
+// while one is applied.
+func (m model) visibleMessages() iter.Seq2[int, Message] {
+	if len(m.filteredIdx) == 0 {
+		return m.messages.All()
+	}
+	return func(yield func(int, Message) bool) {
+		for _, i := range m.filteredIdx {
+			if !yield(i, *m.messages.At(i)) {
+				return
+			}
+		}
+	}
+}
+
+// groupMessages aggregates msgs by field, largest groups first.
+func groupMessages(msgs iter.Seq2[int, Message], field string, isJSON bool) []groupStats {
+	lg := newLiveGroups(field, isJSON)
+	for _, msg := range msgs {
+		lg.add(msg)
+	}
+	return lg.stats()
+}
+
+// liveGroups aggregates the live list by one field as messages are listed
+// and evicted, so the group view follows the stream without grouping the
+// whole list again. Messages are evicted oldest first, which lets each group
+// keep its extremes in windows rather than rescanning its messages.
+type liveGroups struct {
+	field  string
+	isJSON bool
+	groups map[string]*liveGroup
+}
+
+type liveGroup struct {
+	key     string
+	stats   groupStats // count, errors, value and presence
+	sizes   int64
+	maxSize windowMax
+	first   windowMax // negated arrival times
+	last    windowMax // arrival times
+}
+
+func newLiveGroups(field string, isJSON bool) *liveGroups {
+	return &liveGroups{field: field, isJSON: isJSON, groups: map[string]*liveGroup{}}
+}
+
+// key returns the group of msg. An empty text field counts as absent, as it
+// is stored.
+func (lg *liveGroups) key(msg Message) (key string, v any, ok bool) {
+	v, ok = query.Lookup(lg.field, msg.queryMessage())
+	if !lg.isJSON && v == "" {
+		ok = false
+	}
+	if !ok {
+		return "\x00", v, false
+	}
+	data, _ := json.Marshal(v)
+	return string(data), v, true
+}
+
+// add counts a message appended to the list.
+func (lg *liveGroups) add(msg Message) {
+	key, v, ok := lg.key(msg)
+	g := lg.groups[key]
+	if g == nil {
+		g = &liveGroup{key: key, stats: groupStats{value: v, present: ok}}
+		lg.groups[key] = g
+	}
+	g.stats.count++
+	size := int64(len(msg.RawBody))
+	g.sizes += size
+	g.maxSize.push(size)
+	if msg.DecodeErr != nil {
+		g.stats.errors++
+	}
+	// Arrival times, as for stored sessions
+	if ts := msg.arrival(); !ts.IsZero() {
+		g.first.push(-ts.UnixNano())
+		g.last.push(ts.UnixNano())
+	}
+}
+
+// remove uncounts the oldest message of the list, when it is evicted.
+func (lg *liveGroups) remove(msg Message) {
+	key, _, _ := lg.key(msg)
+	g := lg.groups[key]
+	if g == nil {
+		return
+	}
+	if g.stats.count--; g.stats.count == 0 {
+		delete(lg.groups, key)
+		return
+	}
+	size := int64(len(msg.RawBody))
+	g.sizes -= size
+	g.maxSize.pop(size)
+	if msg.DecodeErr != nil {
+		g.stats.errors--
+	}
+	if ts := msg.arrival(); !ts.IsZero() {
+		g.first.pop(-ts.UnixNano())
+		g.last.pop(ts.UnixNano())
+	}
+}
+
+// stats returns the groups, largest first.
+func (lg *liveGroups) stats() []groupStats {
+	type entry struct {
+		key   string
+		stats groupStats
+	}
+	entries := make([]entry, 0, len(lg.groups))
+	for _, g := range lg.groups {
+		s := g.stats
+		s.avgSize = float64(g.sizes) / float64(s.count)
+		s.maxSize, _ = g.maxSize.max()
+		if first, ok := g.first.max(); ok {
+			s.first = time.Unix(0, -first)
+		}
+		if last, ok := g.last.max(); ok {
+			s.last = time.Unix(0, last)
+		}
+		entries = append(entries, entry{g.key, s})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		a, b := entries[i].stats, entries[j].stats
+		if a.count != b.count {
+			return a.count > b.count
+		}
+		if a.label() != b.label() {
+			return a.label() < b.label()
+		}
+		return entries[i].key < entries[j].key
+	})
+	groups := make([]groupStats, len(entries))
+	for i, e := range entries {
+		groups[i] = e.stats
+	}
+	return groups
+}
+
+// windowMax is the largest value of a window that values enter newest and
+// leave oldest first. It keeps the values that may still become the largest,
+// in decreasing order.
+type windowMax struct {
+	vals []int64
+}
+
+func (w *windowMax) push(v int64) {
+	n := len(w.vals)
+	for n > 0 && w.vals[n-1] < v {
+		n--
+	}
+	w.vals = append(w.vals[:n], v)
+}
+
+// pop removes v, the oldest value of the window.
+func (w *windowMax) pop(v int64) {
+	if len(w.vals) > 0 && w.vals[0] == v {
+		w.vals = w.vals[1:]
+	}
+}
+
+func (w windowMax) max() (int64, bool) {
+	if len(w.vals) == 0 {
+		return 0, false
+	}
+	return w.vals[0], true
+}
+
+func (g *groupView) setTotal() {
+	g.total = 0
+	for _, s := range g.groups {
+		g.total += s.count
+	}
+	g.cursor = min(g.cursor, max(len(g.groups)-1, 0))
+}
+
+// label renders the group's value as the list shows it.
+func (s groupStats) label() string {
+	if !s.present {
+		return "(none)"
+	}
+	if str, ok := s.value.(string); ok {
+		return str
+	}
+	data, _ := json.Marshal(s.value)
+	return string(data)
+}
+
+// rate returns the group's messages per second between its first and last
+// one, over at least a second.
+func (s groupStats) rate() float64 {
+	span := max(s.last.Sub(s.first).Seconds(), 1)
+	return float64(s.count) / span
+}
+
+// filterExpr returns the filter selecting the group's messages, if its value
+// can be expressed as one.
+func (s groupStats) filterExpr(field string, isJSON bool) (string, bool) {
+	switch v := s.value.(type) {
+	case nil:
+		switch {
+		case s.present:
+			return "", false
+		case isJSON:
+			return "not " + field, true
+		}
+		return field + ` = ""`, true
+	case string:
+		return field + " = " + query.Quote(v), true
+	case float64:
+		return field + " = " + strconv.FormatFloat(v, 'f', -1, 64), true
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		// Decoded protobuf integers and enums
+		return field + " = " + fmt.Sprint(v), true
+	case bool:
+		return field + " = " + strconv.FormatBool(v), true
+	}
+	return "", false
+}
+
+// applyFilter applies a filter expression to the list, "" for none.
+func (m *model) applyFilter(expr string) tea.Cmd {
+	m.leaveHistory(0)
+	if m.pager != nil {
+		m.filterExpr = expr
+		m.filterActive = expr != ""
+		return m.replayFilter(expr)
+	}
+	if expr == "" {
+		// Clear filter
+		m.filterExpr = ""
+		m.filterActive = false
+		m.filteredIdx = nil
+		_ = m.matchSearch()
+		return nil
+	}
+	m.filterExpr = expr
+	m.filterActive = true
+	m.filteredIdx = computeFilteredIndices(m.messages.All(), expr)
+	if len(m.filteredIdx) > 0 && !isVisible(m.filteredIdx, m.selectedIdx) {
+		m.selectedIdx = m.filteredIdx[0]
+		m.detailViewport.YOffset = 0
+	}
+	// A search runs within the filter
+	_ = m.matchSearch()
+	return nil
+}
+
+// updateGroups handles keys while the aggregate view is open.
+func (m model) updateGroups(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	g := m.groups
+	if g.editing {
+		switch msg.String() {
+		case "esc":
+			g.editing = false
+			g.input.Blur()
+		case "enter":
+			field := strings.TrimSpace(g.input.Value())
+			if _, _, _, err := query.GroupSQL(field); err != nil {
+				return m, m.setStatusMsg(err.Error())
+			}
+			g.editing = false
+			g.input.Blur()
+			g.field = field
+			g.cursor = 0
+			return m, m.loadGroups()
+		default:
+			var cmd tea.Cmd
+			g.input, cmd = g.input.Update(msg)
+			return m, cmd
+		}
+		return m, nil
+	}
+
+	keys, key := m.config.Keys, msg.String()
+	is := func(action string) bool { return keys.is(config.KeysGroups, key, action) }
+	switch {
+	case key == "ctrl+c":
+		return m, tea.Quit
+	case is("down"):
+		if g.cursor < len(g.groups)-1 {
+			g.cursor++
+		}
+	case is("up"):
+		if g.cursor > 0 {
+			g.cursor--
+		}
+	case is("top"):
+		g.cursor = 0
+	case is("bottom"):
+		g.cursor = max(len(g.groups)-1, 0)
+	case is("next_field"), is("prev_field"):
+		step := 1
+		if is("prev_field") {
+			step = len(groupFields) - 1
+		}
+		next := 0
+		for i, f := range groupFields {
+			if f == g.field {
+				next = (i + step) % len(groupFields)
+			}
+		}
+		g.field = groupFields[next]
+		g.cursor = 0
+		return m, m.loadGroups()
+	case is("group_by"):
+		g.editing = true
+		g.input.SetValue(g.field)
+		g.input.CursorEnd()
+		return m, g.input.Focus()
+	case is("refresh"):
+		return m, m.loadGroups()
+	case is("filter"):
+		if g.cursor >= len(g.groups) {
+			return m, nil
+		}
+		_, _, isJSON, _ := query.GroupSQL(g.field)
+		expr, ok := g.groups[g.cursor].filterExpr(g.field, isJSON)
+		if !ok {
+			return m, m.setStatusMsg("Cannot filter by " + g.groups[g.cursor].label())
+		}
+		m.groups = nil
+		m.filterInput.SetValue(expr)
+		return m, m.applyFilter(expr)
+	case is("close"), keys.is(config.KeysConsumer, key, "group"):
+		// The key that opened the view also closes it
+		m.groups = nil
+	}
+	return m, nil
+}
+
+// renderGroups renders the aggregate view full screen.
+func (m model) renderGroups() string {
+	g := m.groups
+	header := headerStyle.Width(m.width - 2).Render("rabbithole — groups")
+	status := m.renderStatusBar()
+
+	height := m.height - 5
+	if height < 3 {
+		height = 3
+	}
+	innerHeight := height - 2
+	innerWidth := m.width - 4
+
+	title := fieldNameStyle.Render("Group by ") + g.field
+	if g.editing {
+		title = fieldNameStyle.Render("Group by ") + g.input.View()
+	}
+	lines := []string{title}
+	switch {
+	case g.err != nil:
+		lines = append(lines, errorStyle.Render(g.err.Error()))
+	case g.loading:
+		lines = append(lines, mutedStyle.Render("Aggregating stored messages..."))
+	default:
+		lines = append(lines, mutedStyle.Render(fmt.Sprintf("%d groups over %d messages", len(g.groups), g.total)))
+	}
+	lines = append(lines, "")
+
+	const cols = "%8s %9s %-8s %-8s %8s %8s %6s"
+	keyWidth := max(innerWidth-len(fmt.Sprintf(cols, "", "", "", "", "", "", ""))-1, 10)
+	lines = append(lines, mutedStyle.Render(fmt.Sprintf("%-*s "+cols, keyWidth, "VALUE", "COUNT", "RATE/s", "FIRST", "LAST", "AVG", "MAX", "ERRORS")))
+
+	rows := innerHeight - len(lines)
+	if rows < 1 {
+		rows = 1
+	}
+	start := 0
+	if g.cursor >= rows {
+		start = g.cursor - rows + 1
+	}
+	end := min(start+rows, len(g.groups))
+
+	for i := start; i < end; i++ {
+		s := g.groups[i]
+		errors := "—"
+		if s.errors >= 0 {
+			errors = strconv.FormatInt(s.errors, 10)
+		}
+		line := fmt.Sprintf("%-*s "+cols, keyWidth, truncate(s.label(), keyWidth),
+			strconv.FormatInt(s.count, 10), fmt.Sprintf("%.2f", s.rate()),
+			groupTime(s.first), groupTime(s.last),
+			formatBytes(int64(s.avgSize)), formatBytes(s.maxSize), errors)
+		line = truncate(line, innerWidth)
+		switch {
+		case i == g.cursor:
+			line = selectedMessageStyle.Render(line)
+		case !s.present:
+			line = mutedStyle.Render(line)
+		}
+		lines = append(lines, line)
+	}
+
+	content := messageListStyle.Width(m.width - 2).Height(height).Render(strings.Join(lines, "\n"))
+
+	var parts []string
+	km := m.config.Keys
+	for _, k := range []struct{ key, desc string }{
+		{km.hint(config.KeysGroups, "down", "up"), "nav"},
+		{km.hint(config.KeysGroups, "filter"), "filter to group"},
+		{km.hint(config.KeysGroups, "next_field"), "next field"},
+		{km.hint(config.KeysGroups, "group_by"), "group by path"},
+		{km.hint(config.KeysGroups, "refresh"), "refresh"},
+		{km.hint(config.KeysGroups, "close"), "close"},
+	} {
+		parts = append(parts, helpKeyStyle.Render(k.key)+" "+k.desc)
+	}
+	bottomBar := helpStyle.Render(strings.Join(parts, "  "))
+
+	return lipgloss.JoinVertical(lipgloss.Left, header, status, content, bottomBar)
+}
+
+func groupTime(t time.Time) string {
+	if t.IsZero() {
+		return "—"
+	}
+	// Stored sessions are aggregated in UTC
+	return t.Local().Format("15:04:05")
+}
@@ -0,0 +1,240 @@
+package tui
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/epalmerini/rabbithole/internal/db"
+)
+
+func TestGroupMessages(t *testing.T) {
+	base := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	ring := newMessageRing(0,
+		Message{RoutingKey: "order.created", RawBody: []byte("ab"), Timestamp: base.Add(2 * time.Second), Decoded: map[string]any{"country": "IT"}},
+		Message{RoutingKey: "order.paid", RawBody: []byte("a"), Timestamp: base, DecodeErr: errors.New("bad")},
+		Message{RoutingKey: "order.created", RawBody: []byte("abcdef"), Timestamp: base, Decoded: map[string]any{"country": "FR"}},
+		Message{RoutingKey: "", RawBody: []byte("a"), Timestamp: base},
+	)
+
+	groups := groupMessages(ring.All(), "rk", false)
+	if len(groups) != 3 {
+		t.Fatalf("got %d groups, want 3", len(groups))
+	}
+	g := groups[0]
+	if g.label() != "order.created" || g.count != 2 || g.avgSize != 4 || g.maxSize != 6 {
+		t.Errorf("largest group = %+v, want order.created: 2 messages, avg 4, max 6", g)
+	}
+	if !g.first.Equal(base) || !g.last.Equal(base.Add(2*time.Second)) || g.rate() != 1 {
+		t.Errorf("first/last/rate = %v/%v/%v", g.first, g.last, g.rate())
+	}
+	for _, g := range groups[1:] {
+		switch g.label() {
+		case "order.paid":
+			if g.errors != 1 {
+				t.Errorf("order.paid decode errors = %d, want 1", g.errors)
+			}
+		case "(none)":
+			if g.present {
+				t.Error("an empty routing key should group as absent")
+			}
+		default:
+			t.Errorf("unexpected group %q", g.label())
+		}
+	}
+
+	groups = groupMessages(ring.All(), "body.country", true)
+	var labels []string
+	for _, g := range groups {
+		labels = append(labels, g.label())
+	}
+	if got := strings.Join(labels, " "); got != "(none) FR IT" {
+		t.Errorf("groups by body.country = %q, want %q", got, "(none) FR IT")
+	}
+}
+
+func TestGroupStats_FilterExpr(t *testing.T) {
+	tests := []struct {
+		stats  groupStats
+		field  string
+		isJSON bool
+		want   string
+		ok     bool
+	}{
+		{groupStats{value: `a "b"`, present: true}, "rk", false, `rk = "a \"b\""`, true},
+		{groupStats{value: 42.5, present: true}, "body.total", true, "body.total = 42.5", true},
+		{groupStats{value: true, present: true}, "body.flag", true, "body.flag = true", true},
+		{groupStats{value: int64(9007199254740993), present: true}, "body.id", true, "body.id = 9007199254740993", true},
+		{groupStats{value: uint64(7), present: true}, "body.seq", true, "body.seq = 7", true},
+		{groupStats{value: int32(2), present: true}, "body.status", true, "body.status = 2", true},
+		{groupStats{}, "hdr.x-tenant", true, "not hdr.x-tenant", true},
+		{groupStats{}, "app_id", false, `app_id = ""`, true},
+		{groupStats{value: nil, present: true}, "body.x", true, "", false},
+		{groupStats{value: map[string]any{"a": 1.0}, present: true}, "body.x", true, "", false},
+	}
+	for _, tt := range tests {
+		got, ok := tt.stats.filterExpr(tt.field, tt.isJSON)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("filterExpr(%s, %v) = %q, %v; want %q, %v", tt.field, tt.stats.value, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestGroups_SelectFilters(t *testing.T) {
+	m := model{
+		messages: newMessageRing(0,
+			Message{ID: 1, RoutingKey: "order.created"},
+			Message{ID: 2, RoutingKey: "order.paid"},
+			Message{ID: 3, RoutingKey: "order.paid"},
+		),
+		filterInput:    textinput.New(),
+		detailViewport: viewport.New(80, 20),
+		vimKeys:        NewVimKeyState(),
+		width:          120,
+		height:         30,
+	}
+
+	m = press(t, m, "a")
+	if m.groups == nil || len(m.groups.groups) != 2 {
+		t.Fatalf("a should open the view grouped by routing key, got %+v", m.groups)
+	}
+	if view := m.View(); !strings.Contains(view, "2 groups over 3 messages") {
+		t.Errorf("view missing the summary:\n%s", view)
+	}
+
+	// Tab moves to the exchange, where every message falls in one group
+	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyTab})
+	m = updated.(model)
+	if m.groups.field != "ex" || len(m.groups.groups) != 1 {
+		t.Errorf("tab: field %q with %d groups, want ex with 1", m.groups.field, len(m.groups.groups))
+	}
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyShiftTab})
+	m = updated.(model)
+
+	m = press(t, m, "enter")
+	if m.groups != nil {
+		t.Fatal("enter should close the view")
+	}
+	if m.filterExpr != `rk = "order.paid"` || len(m.filteredIdx) != 2 {
+		t.Errorf("filter = %q over %v, want the order.paid messages", m.filterExpr, m.filteredIdx)
+	}
+}
+
+func TestGroups_ProtobufIntegers(t *testing.T) {
+	// The protobuf decoder yields int64, uint64 and int32 (enum) values
+	m := model{
+		messages: newMessageRing(0,
+			Message{ID: 1, Decoded: map[string]any{"seq": int64(7), "status": int32(1)}},
+			Message{ID: 2, Decoded: map[string]any{"seq": int64(8), "status": int32(2)}},
+			Message{ID: 3, Decoded: map[string]any{"seq": int64(7), "status": int32(2)}},
+		),
+		detailViewport: viewport.New(80, 20),
+	}
+
+	for _, tt := range []struct {
+		field string
+		want  []int
+	}{
+		{"body.seq", []int{0, 2}},
+		{"body.status", []int{0}},
+	} {
+		groups := groupMessages(m.visibleMessages(), tt.field, true)
+		expr, ok := groups[0].filterExpr(tt.field, true)
+		if !ok {
+			t.Fatalf("%s: no filter for %#v", tt.field, groups[0].value)
+		}
+		_ = m.applyFilter(expr)
+		if !slices.Equal(m.filteredIdx, tt.want) {
+			t.Errorf("%s: filter %q selected %v, want %v", tt.field, expr, m.filteredIdx, tt.want)
+		}
+	}
+}
+
+func TestGroups_Replay(t *testing.T) {
+	m := newReplayTestModel(t, 30)
+	m.filterMode = true
+	m.filterInput.SetValue("body.flagged = true")
+	m = press(t, m, "enter")
+
+	m.groups = &groupView{field: "body.seq"}
+	cmd := m.loadGroups()
+	if !m.groups.loading || cmd == nil {
+		t.Fatal("a replay should be grouped in the store")
+	}
+	updated, _ := m.Update(cmd())
+	m = updated.(model)
+
+	g := m.groups
+	if g.err != nil || g.loading {
+		t.Fatalf("groups not loaded: %v", g.err)
+	}
+	if len(g.groups) != 3 || g.total != 3 {
+		t.Fatalf("got %d groups over %d messages, want 3 over the 3 filtered ones", len(g.groups), g.total)
+	}
+	if g.groups[0].errors != -1 {
+		t.Error("decode errors of stored messages should be unknown")
+	}
+	if expr, _ := g.groups[0].filterExpr("body.seq", true); expr != "body.seq = 0" {
+		t.Errorf("filter = %q, want body.seq = 0", expr)
+	}
+}
+
+func TestGroups_LiveAndStoredAgree(t *testing.T) {
+	store, err := db.NewStore(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	ctx := context.Background()
+	sid, err := store.CreateSession(ctx, "orders", "#", "q", "amqp://localhost/")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Publishers' clocks are off, and one message has no timestamp
+	base := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	var live []Message
+	for i, rk := range []string{"order.created", "order.paid", "order.created", "order.created"} {
+		msg := Message{
+			Exchange:   "orders",
+			RoutingKey: rk,
+			RawBody:    []byte(strings.Repeat("x", i+1)),
+			Timestamp:  base.Add(-time.Duration(i) * time.Hour),
+			ConsumedAt: base.Add(time.Duration(i) * time.Second),
+		}
+		rec := db.MessageRecord{SessionID: sid, Exchange: msg.Exchange, RoutingKey: rk, Body: msg.RawBody, Timestamp: msg.Timestamp, ConsumedAt: msg.ConsumedAt}
+		if i == 3 {
+			rec.Timestamp = time.Time{}
+		}
+		if _, err := store.InsertMessage(ctx, &rec); err != nil {
+			t.Fatal(err)
+		}
+		live = append(live, msg)
+	}
+
+	m := initialReplayModel(Config{}, db.Session{ID: sid, Exchange: "orders"}, store)
+	m.groups = &groupView{field: "rk"}
+	updated, _ := m.Update(m.loadGroups()())
+	stored := updated.(model).groups.groups
+
+	want := groupMessages(newMessageRing(0, live...).All(), "rk", false)
+	if len(stored) != len(want) {
+		t.Fatalf("stored groups = %+v, live = %+v", stored, want)
+	}
+	for i, g := range want {
+		s := stored[i]
+		if s.label() != g.label() || s.count != g.count || !s.first.Equal(g.first) || !s.last.Equal(g.last) || s.rate() != g.rate() {
+			t.Errorf("group %s: stored %d over %v-%v, live %d over %v-%v", g.label(), s.count, s.first, s.last, g.count, g.first, g.last)
+		}
+	}
+	if !want[0].first.Equal(base) || !want[0].last.Equal(base.Add(3*time.Second)) {
+		t.Errorf("order.created seen %v-%v, want its arrival times", want[0].first, want[0].last)
+	}
+}
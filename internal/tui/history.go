@@ -0,0 +1,131 @@
+package tui
+
+import (
+	"fmt"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// historyBound returns the stored ID of the oldest live delivery the writer
+// saved: the history is the session's stored messages before it. It is 0
+// when no earlier delivery was evicted or dropped, or while that delivery is
+// still being written.
+func (m model) historyBound() int64 {
+	live := m.messages
+	if m.liveRing != nil {
+		live = *m.liveRing
+	}
+	if live.Len() == 0 || live.At(0).seq <= 1 {
+		return 0
+	}
+	for i := range live.Len() {
+		saved := live.At(i).saved
+		if saved == nil {
+			return 0
+		}
+		select {
+		case <-saved.done:
+			if saved.err == nil {
+				return saved.id
+			}
+			// Dropped by the writer: the next saved delivery bounds the history
+		default:
+			return 0
+		}
+	}
+	return 0
+}
+
+// historyLen returns how many stored messages precede the live list, once
+// the history has been counted.
+func (m model) historyLen() int64 {
+	if m.liveRing == nil || m.pager.total < 0 {
+		return 0
+	}
+	return m.pager.total
+}
+
+// canPageHistory reports whether scrolling above the oldest live message
+// should load the earlier messages of the session from the store.
+func (m model) canPageHistory() bool {
+	return m.store != nil && m.sessionID != 0 && m.pager == nil && !m.replayMode &&
+		!m.filterActive && m.historyBound() != 0
+}
+
+// enterHistory sets the live list aside and pages the stored messages of the
+// session before it, selecting the one back positions from the end.
+// Deliveries keep going to the live list meanwhile.
+func (m *model) enterHistory(back int64) tea.Cmd {
+	bound := m.historyBound()
+	live := m.messages
+	m.liveRing = &live
+	m.messages = messageRing{}
+	m.selectedIdx = 0
+	m.searchResults = nil
+	m.pager = newReplayPager(m.sessionID)
+	m.pager.where, m.pager.args = "m.id < ?", []any{bound}
+	return m.fetchReplay(replayTarget{back: max(back, 1)})
+}
+
+// extendHistory moves the end of the history past a delivery the set-aside
+// live list evicted, so that it stays reachable.
+func (m *model) extendHistory(evicted Message) {
+	saved := evicted.saved
+	if saved == nil {
+		return
+	}
+	select {
+	case <-saved.done:
+	default:
+		// Not written yet; it is found again after returning to the list
+		return
+	}
+	if saved.err != nil {
+		return
+	}
+	p := m.pager
+	p.args = []any{saved.id + 1}
+	if p.total < 0 {
+		p.extended++
+	} else {
+		p.total++
+	}
+}
+
+// historyMoveTo selects the message at session position pos, returning to
+// the live list once pos reaches it.
+func (m *model) historyMoveTo(pos int64) tea.Cmd {
+	if m.pager.total < 0 {
+		// Still counting the history
+		return nil
+	}
+	if n := m.historyLen(); pos >= n {
+		m.leaveHistory(int(pos - n))
+		return nil
+	}
+	return m.replayMoveTo(pos)
+}
+
+// leaveHistory restores the live list with message idx selected.
+func (m *model) leaveHistory(idx int) {
+	if m.liveRing == nil {
+		return
+	}
+	m.messages = *m.liveRing
+	m.liveRing = nil
+	m.pager = nil
+	m.selectedIdx = min(max(idx, 0), max(m.messages.Len()-1, 0))
+	m.detailViewport.YOffset = 0
+	if m.groups != nil {
+		// The group view aggregated the history in the store
+		m.loadGroups()
+	}
+}
+
+// historyStatus describes the selected position among the earlier messages.
+func (m model) historyStatus() string {
+	if m.pager.loading && m.messages.Len() == 0 {
+		return "history loading..."
+	}
+	return fmt.Sprintf("history %d/%d", m.position()+1, m.historyLen())
+}
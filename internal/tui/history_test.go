@@ -0,0 +1,196 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/charmbracelet/bubbles/viewport"
+	"github.com/epalmerini/rabbithole/internal/db"
+)
+
+func TestHistory_ScrollPastTop(t *testing.T) {
+	store, err := db.NewStore(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	ctx := context.Background()
+	sid, err := store.CreateSession(ctx, "orders", "#", "q", "amqp://localhost/")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m := model{
+		config:         Config{MaxMessages: 10},
+		store:          store,
+		sessionID:      sid,
+		messages:       newMessageRing(10),
+		detailViewport: viewport.New(80, 20),
+		vimKeys:        NewVimKeyState(),
+	}
+	// Deliveries are persisted and their IDs reported as the consumer's
+	// writer does; it drops order.5, so the history no longer lines up with
+	// the delivery count
+	deliver := func(i int) {
+		key := fmt.Sprintf("order.%d", i)
+		msg := Message{Exchange: "orders", RoutingKey: key, saved: newSavedRow()}
+		if i == 5 {
+			msg.saved.set(0, errNotSaved)
+		} else {
+			msg.saved.set(store.InsertMessage(ctx, &db.MessageRecord{SessionID: sid, Exchange: "orders", RoutingKey: key, Body: []byte(key)}))
+		}
+		updated, _ := m.Update(msgReceived{msg: msg})
+		m = updated.(model)
+	}
+	for i := range 30 {
+		deliver(i)
+	}
+	// A later session's messages are not part of the history
+	other, err := store.CreateSession(ctx, "orders", "#", "q2", "amqp://localhost/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.InsertMessage(ctx, &db.MessageRecord{SessionID: other, Exchange: "orders", RoutingKey: "other", Body: []byte("x")}); err != nil {
+		t.Fatal(err)
+	}
+	if m.messages.Len() != 10 || m.messages.At(0).RoutingKey != "order.20" {
+		t.Fatalf("live list holds %d messages from %s, want the newest 10", m.messages.Len(), m.messages.At(0).RoutingKey)
+	}
+
+	m = press(t, m, "gg", "k")
+	if m.liveRing == nil || m.selectedKey() != "order.19" {
+		t.Fatalf("k above the oldest live message selected %s (history %v), want order.19", m.selectedKey(), m.liveRing != nil)
+	}
+	if got := m.historyStatus(); got != "history 19/19" {
+		t.Errorf("historyStatus = %q", got)
+	}
+	m = press(t, m, "5", "k")
+	if m.selectedKey() != "order.14" {
+		t.Errorf("5k selected %s, want order.14", m.selectedKey())
+	}
+
+	// Deliveries keep going to the live list while history is shown
+	deliver(30)
+	if m.liveRing.Len() != 10 || m.liveRing.At(0).RoutingKey != "order.21" {
+		t.Errorf("set-aside live list starts at %s, want order.21", m.liveRing.At(0).RoutingKey)
+	}
+
+	m = press(t, m, "7", "j")
+	if m.liveRing != nil || m.pager != nil || m.selectedKey() != "order.21" {
+		t.Errorf("7j selected %s (history %v), want the live order.21", m.selectedKey(), m.liveRing != nil)
+	}
+}
+
+func TestHistory_NeedsEvictedMessages(t *testing.T) {
+	m := model{
+		store:          &cleanupStore{},
+		sessionID:      1,
+		messages:       newMessageRing(10, Message{RoutingKey: "a", seq: 1}),
+		detailViewport: viewport.New(80, 20),
+	}
+	if cmd := m.moveBy(-1); cmd != nil || m.liveRing != nil {
+		t.Error("nothing precedes the first delivery of the session")
+	}
+}
+
+func TestPauseBuffer_Overflow(t *testing.T) {
+	tests := []struct {
+		policy    string
+		wantList  string
+		wantPause bool
+	}{
+		{"", "order.2 order.3 order.4", false},
+		{PauseDropOldest, "order.2 order.3 order.4", false},
+		{PauseDropNewest, "order.0 order.1 order.2", false},
+		{PauseResume, "order.0 order.1 order.2 order.3 order.4", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.policy, func(t *testing.T) {
+			m := model{
+				config:         Config{PauseBuffer: 3, PauseOverflow: tt.policy},
+				messages:       newMessageRing(10),
+				paused:         true,
+				detailViewport: viewport.New(80, 20),
+				vimKeys:        NewVimKeyState(),
+			}
+			for i := range 5 {
+				updated, _ := m.Update(msgReceived{msg: Message{RoutingKey: fmt.Sprintf("order.%d", i)}})
+				m = updated.(model)
+			}
+
+			if tt.wantPause {
+				if m.paused || !strings.Contains(m.statusMsg, "resumed") {
+					t.Errorf("paused = %v, status = %q; want resumed by the overflow", m.paused, m.statusMsg)
+				}
+			} else {
+				if len(m.pauseBuffer) != 3 || m.pauseDropped != 2 {
+					t.Errorf("buffered %d, dropped %d; want 3 and 2", len(m.pauseBuffer), m.pauseDropped)
+				}
+				m = press(t, m, "p")
+			}
+			if got := ringKeys(m.messages); got != tt.wantList {
+				t.Errorf("list = %q, want %q", got, tt.wantList)
+			}
+		})
+	}
+}
+
+func TestAppendLive_FollowsEviction(t *testing.T) {
+	base := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	received := 0
+	receive := func(m model, keys ...string) model {
+		t.Helper()
+		for _, key := range keys {
+			received++
+			msg := Message{RoutingKey: key, ConsumedAt: base.Add(time.Duration(received) * time.Second), RawBody: make([]byte, received*7%10)}
+			updated, _ := m.Update(msgReceived{msg: msg})
+			m = updated.(model)
+		}
+		return m
+	}
+	m := model{
+		messages:       newMessageRing(4),
+		detailViewport: viewport.New(80, 20),
+		vimKeys:        NewVimKeyState(),
+	}
+	m = receive(m, "a.1", "b.1", "a.2", "b.2")
+
+	// Search hits move with the list
+	m.searchQuery = "rk:b"
+	_ = m.performSearch()
+	m = receive(m, "a.3")
+	_ = m.nextSearchResult()
+	if key := m.messages.At(m.selectedIdx).RoutingKey; key != "b.2" {
+		t.Errorf("next search hit = %s, want b.2", key)
+	}
+	m = receive(m, "c.1")
+	if len(m.searchResults) != 1 || m.messages.At(m.searchResults[m.searchResultIdx]).RoutingKey != "b.2" {
+		t.Errorf("search hits after b.1 was evicted = %v at %d", m.searchResults, m.searchResultIdx)
+	}
+
+	// The filter and the group view match going over the whole list again
+	_ = m.applyFilter(`rk ~ "^[ab]"`)
+	m.groups = &groupView{field: "rk"}
+	m.loadGroups()
+	m = receive(m, "a.4", "c.2", "b.3", "a.5", "a.6", "b.4", "c.3")
+	if want := computeFilteredIndices(m.messages.All(), m.filterExpr); !slices.Equal(m.filteredIdx, want) {
+		t.Errorf("filtered = %v, want %v", m.filteredIdx, want)
+	}
+	want := groupMessages(m.visibleMessages(), "rk", false)
+	got := m.groups.groups
+	if len(got) != len(want) {
+		t.Fatalf("groups = %+v, want %+v", got, want)
+	}
+	for i := range want {
+		g, w := got[i], want[i]
+		if g.label() != w.label() || g.count != w.count || g.maxSize != w.maxSize || g.avgSize != w.avgSize || !g.first.Equal(w.first) || !g.last.Equal(w.last) {
+			t.Errorf("group %d = %+v, want %+v", i, g, w)
+		}
+	}
+}
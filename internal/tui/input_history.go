@@ -0,0 +1,86 @@
+package tui
+
+import (
+	"context"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/epalmerini/rabbithole/internal/db"
+)
+
+// historyLimit is how many entries each input remembers across runs.
+const historyLimit = 100
+
+// inputHistory recalls the expressions entered earlier in the search or
+// filter input, with up and down as in a shell.
+type inputHistory struct {
+	kind    string   // db.HistorySearch or db.HistoryFilter
+	entries []string // oldest first
+	pos     int      // entry shown; len(entries) for the text being typed
+	draft   string   // the text being typed, kept while recalling
+}
+
+// loadInputHistory reads the search and filter history from the store.
+func loadInputHistory(store db.Store) (search, filter inputHistory) {
+	search.kind, filter.kind = db.HistorySearch, db.HistoryFilter
+	if store != nil {
+		ctx := context.Background()
+		search.entries, _ = store.ListHistory(ctx, db.HistorySearch, historyLimit)
+		filter.entries, _ = store.ListHistory(ctx, db.HistoryFilter, historyLimit)
+	}
+	search.reset()
+	filter.reset()
+	return search, filter
+}
+
+// reset starts recalling from the latest entry.
+func (h *inputHistory) reset() {
+	h.pos = len(h.entries)
+	h.draft = ""
+}
+
+// recall shows the entry step positions away in the input, -1 being the
+// previous one. Moving past the latest entry brings back the draft.
+func (h *inputHistory) recall(in *textinput.Model, step int) {
+	pos := min(max(h.pos+step, 0), len(h.entries))
+	if pos == h.pos {
+		return
+	}
+	if h.pos == len(h.entries) {
+		h.draft = in.Value()
+	}
+	h.pos = pos
+	if pos == len(h.entries) {
+		in.SetValue(h.draft)
+	} else {
+		in.SetValue(h.entries[pos])
+	}
+	in.CursorEnd()
+}
+
+// add records entry as the latest, saving it to the store in the
+// background.
+func (h *inputHistory) add(store db.Store, entry string) tea.Cmd {
+	if entry == "" {
+		return nil
+	}
+	for i, e := range h.entries {
+		if e == entry {
+			h.entries = append(h.entries[:i], h.entries[i+1:]...)
+			break
+		}
+	}
+	h.entries = append(h.entries, entry)
+	if len(h.entries) > historyLimit {
+		h.entries = h.entries[len(h.entries)-historyLimit:]
+	}
+	h.reset()
+	if store == nil {
+		return nil
+	}
+	kind := h.kind
+	return func() tea.Msg {
+		_ = store.AddHistory(context.Background(), kind, entry, historyLimit)
+		return nil
+	}
+}
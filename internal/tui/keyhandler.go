@@ -3,15 +3,19 @@ package tui
 import (
 	"time"
 	"unicode"
+	"unicode/utf8"
+
+	"github.com/epalmerini/rabbithole/internal/config"
 )
 
 const keyTimeout = 500 * time.Millisecond
 
 // VimKeyState tracks vim-style key sequences and numeric prefixes
 type VimKeyState struct {
-	pendingKeys   string
+	pendingKeys   string // keys pressed so far, separated by spaces
 	numericPrefix int
 	lastKeyTime   time.Time
+	keymap        *Keymap // nil for the default bindings
 }
 
 // VimKeyResult represents the result of processing a key
@@ -26,6 +30,11 @@ func NewVimKeyState() VimKeyState {
 	return VimKeyState{}
 }
 
+// newVimKeyStateWith creates a vim key state tracker with the bindings of km.
+func newVimKeyStateWith(km *Keymap) VimKeyState {
+	return VimKeyState{keymap: km}
+}
+
 // ProcessKey processes a key press and returns the action to take
 func (v *VimKeyState) ProcessKey(key string) VimKeyResult {
 	now := time.Now()
@@ -37,6 +46,15 @@ func (v *VimKeyState) ProcessKey(key string) VimKeyResult {
 	}
 	v.lastKeyTime = now
 
+	// Several characters at once (a paste) are pressed one by one
+	if utf8.RuneCountInString(key) > 1 && !isNamedKey(key) {
+		var result VimKeyResult
+		for _, r := range key {
+			result = v.ProcessKey(string(r))
+		}
+		return result
+	}
+
 	// Handle numeric prefix
 	if len(key) == 1 {
 		r := rune(key[0])
@@ -48,23 +66,21 @@ func (v *VimKeyState) ProcessKey(key string) VimKeyResult {
 	}
 
 	// Build pending key sequence
-	v.pendingKeys += key
+	key = normalizeKey(key)
+	if v.pendingKeys == "" {
+		v.pendingKeys = key
+	} else {
+		v.pendingKeys += " " + key
+	}
 
-	// Check for multi-key sequences
-	result := v.matchSequence()
-	if result.Action != "" {
-		if result.Count == 0 && v.numericPrefix > 0 {
-			result.Count = v.numericPrefix
-		}
-		if result.Count == 0 {
-			result.Count = 1
-		}
+	// Check for complete sequences, or the start of one
+	action, pending := v.keymap.lookup(config.KeysConsumer, v.pendingKeys)
+	if action != "" {
+		result := VimKeyResult{Action: action, Count: max(v.numericPrefix, 1), Clear: true}
 		v.Reset()
 		return result
 	}
-
-	// Check if this could be the start of a valid sequence
-	if v.isPotentialSequence() {
+	if pending {
 		return VimKeyResult{Action: "pending", Clear: false}
 	}
 
@@ -73,88 +89,6 @@ func (v *VimKeyState) ProcessKey(key string) VimKeyResult {
 	return VimKeyResult{Action: "", Clear: true}
 }
 
-// matchSequence checks for complete key sequences
-func (v *VimKeyState) matchSequence() VimKeyResult {
-	switch v.pendingKeys {
-	// Navigation
-	case "j":
-		return VimKeyResult{Action: "move_down", Clear: true}
-	case "k":
-		return VimKeyResult{Action: "move_up", Clear: true}
-	case "gg":
-		return VimKeyResult{Action: "go_top", Clear: true}
-	case "G":
-		return VimKeyResult{Action: "go_bottom", Clear: true}
-	case "zz":
-		return VimKeyResult{Action: "center_line", Clear: true}
-
-	// Search
-	case "/":
-		return VimKeyResult{Action: "search_start", Clear: true}
-	case "n":
-		return VimKeyResult{Action: "search_next", Clear: true}
-	case "N":
-		return VimKeyResult{Action: "search_prev", Clear: true}
-
-	// Actions
-	case "y":
-		return VimKeyResult{Action: "yank_tab", Clear: true}
-	case "Y":
-		return VimKeyResult{Action: "yank", Clear: true}
-	case "f":
-		return VimKeyResult{Action: "filter_start", Clear: true}
-	case "F":
-		return VimKeyResult{Action: "filter_toggle", Clear: true}
-	case "e":
-		return VimKeyResult{Action: "export", Clear: true}
-	case "E":
-		return VimKeyResult{Action: "export_csv", Clear: true}
-	case "m":
-		return VimKeyResult{Action: "bookmark_toggle", Clear: true}
-	case "'":
-		return VimKeyResult{Action: "bookmark_next", Clear: true}
-
-	// View toggles
-	case "t":
-		return VimKeyResult{Action: "toggle_compact", Clear: true}
-	case "T":
-		return VimKeyResult{Action: "toggle_timestamp", Clear: true}
-	case "r":
-		return VimKeyResult{Action: "toggle_raw", Clear: true}
-	case "?":
-		return VimKeyResult{Action: "toggle_help", Clear: true}
-
-	// Pane resizing
-	case "H":
-		return VimKeyResult{Action: "resize_left", Clear: true}
-	case "L":
-		return VimKeyResult{Action: "resize_right", Clear: true}
-
-	// Control
-	case "p", " ":
-		return VimKeyResult{Action: "pause_toggle", Clear: true}
-	case "c":
-		return VimKeyResult{Action: "clear", Clear: true}
-	case "b":
-		return VimKeyResult{Action: "back", Clear: true}
-	case "q":
-		return VimKeyResult{Action: "quit", Clear: true}
-	}
-
-	return VimKeyResult{}
-}
-
-// isPotentialSequence checks if current keys could lead to a valid sequence
-func (v *VimKeyState) isPotentialSequence() bool {
-	potential := []string{"g", "z"}
-	for _, p := range potential {
-		if v.pendingKeys == p {
-			return true
-		}
-	}
-	return false
-}
-
 // Reset clears the key state
 func (v *VimKeyState) Reset() {
 	v.pendingKeys = ""
@@ -34,6 +34,8 @@ func TestProcessKey_SingleKeys(t *testing.T) {
 		{"L resizes right", "L", "resize_right", 1},
 		{"m toggles bookmark", "m", "bookmark_toggle", 1},
 		{"' next bookmark", "'", "bookmark_next", 1},
+		{"d marks for diff", "d", "diff_mark", 1},
+		{"D diffs previous", "D", "diff_previous", 1},
 	}
 
 	for _, tt := range tests {
@@ -0,0 +1,423 @@
+package tui
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/epalmerini/rabbithole/internal/config"
+)
+
+// binding is an action of a view and its default key sequences. Sequences
+// are keys as Bubble Tea names them, separated by spaces.
+type binding struct {
+	action string
+	keys   []string
+}
+
+// defaultBindings are the keys of each view that [keys] can rebind, in the
+// order conflicts are reported. The consumer's actions are those VimKeyState
+// returns; the browsers' match the cases of their key switches, which are
+// written against the first default key; the consumer's overlays and the
+// dashboard test keys with Keymap.is.
+var defaultBindings = map[string][]binding{
+	config.KeysConsumer: {
+		{"move_down", []string{"j", "down"}},
+		{"move_up", []string{"k", "up"}},
+		{"go_top", []string{"g g"}},
+		{"go_bottom", []string{"G"}},
+		{"center_line", []string{"z z"}},
+		{"half_page_down", []string{"ctrl+d"}},
+		{"half_page_up", []string{"ctrl+u"}},
+		{"page_down", []string{"ctrl+f"}},
+		{"page_up", []string{"ctrl+b"}},
+		{"detail_down", []string{"ctrl+j"}},
+		{"detail_up", []string{"ctrl+k"}},
+		{"search_start", []string{"/"}},
+		{"search_next", []string{"n"}},
+		{"search_prev", []string{"N"}},
+		{"filter_start", []string{"f"}},
+		{"filter_toggle", []string{"F"}},
+		{"filter_pick", []string{`"`}},
+		{"yank_tab", []string{"y"}},
+		{"yank", []string{"Y"}},
+		{"export", []string{"e"}},
+		{"export_csv", []string{"E"}},
+		{"bookmark_toggle", []string{"m"}},
+		{"bookmark_next", []string{"'"}},
+		{"annotate_note", []string{"i"}},
+		{"annotate_tags", []string{"#"}},
+		{"trace", []string{"x"}},
+		{"diff_mark", []string{"d"}},
+		{"diff_previous", []string{"D"}},
+		{"group", []string{"a"}},
+		{"next_tab", []string{"tab"}},
+		{"prev_tab", []string{"shift+tab"}},
+		{"toggle_compact", []string{"t"}},
+		{"toggle_timestamp", []string{"T"}},
+		{"toggle_raw", []string{"r"}},
+		{"toggle_help", []string{"?"}},
+		{"toggle_charts", []string{"s"}},
+		{"resize_left", []string{"H"}},
+		{"resize_right", []string{"L"}},
+		{"pause_toggle", []string{"p", "space"}},
+		{"clear", []string{"c"}},
+		{"back", []string{"b"}},
+		{"quit", []string{"q"}},
+	},
+	config.KeysGroups: {
+		{"down", []string{"j", "down"}},
+		{"up", []string{"k", "up"}},
+		{"top", []string{"g", "home"}},
+		{"bottom", []string{"G", "end"}},
+		{"next_field", []string{"tab"}},
+		{"prev_field", []string{"shift+tab"}},
+		{"group_by", []string{":"}},
+		{"filter", []string{"enter"}},
+		{"refresh", []string{"r"}},
+		{"close", []string{"esc", "q", "b"}},
+	},
+	config.KeysTrace: {
+		{"down", []string{"j", "down"}},
+		{"up", []string{"k", "up"}},
+		{"top", []string{"g", "home"}},
+		{"bottom", []string{"G", "end"}},
+		{"jump", []string{"enter"}},
+		{"close", []string{"esc", "q", "b"}},
+	},
+	config.KeysFilters: {
+		{"down", []string{"j", "down"}},
+		{"up", []string{"k", "up"}},
+		{"top", []string{"g", "home"}},
+		{"bottom", []string{"G", "end"}},
+		{"filter", []string{"enter"}},
+		{"search", []string{"/"}},
+		{"close", []string{"esc", "q", "b"}},
+	},
+	config.KeysBrowser: {
+		{"up", []string{"k", "up"}},
+		{"down", []string{"j", "down"}},
+		{"top", []string{"g"}},
+		{"bottom", []string{"G"}},
+		{"filter", []string{"/"}},
+		{"open", []string{"enter"}},
+		{"select", []string{"space"}},
+		{"consumers", []string{"c"}},
+		{"purge", []string{"p"}},
+		{"delete", []string{"d"}},
+		{"select_pattern", []string{"*"}},
+		{"select_all", []string{"a"}},
+		{"select_none", []string{"u"}},
+		{"new", []string{"n"}},
+		{"edit", []string{"e"}},
+		{"close_connection", []string{"X"}},
+		{"back", []string{"esc", "backspace"}},
+		{"policies", []string{"P"}},
+		{"vhosts", []string{"V"}},
+		{"queues", []string{"Q"}},
+		{"connections", []string{"C"}},
+		{"dashboard", []string{"D"}},
+		{"sessions", []string{"s"}},
+		{"refresh", []string{"r"}},
+		{"quit", []string{"q"}},
+	},
+	config.KeysSessions: {
+		{"up", []string{"k", "up"}},
+		{"down", []string{"j", "down"}},
+		{"top", []string{"g"}},
+		{"bottom", []string{"G"}},
+		{"filter", []string{"/"}},
+		{"search", []string{"S"}},
+		{"open", []string{"enter"}},
+		{"delete", []string{"d"}},
+		{"export", []string{"e"}},
+		{"bookmarks", []string{"B"}},
+		{"clear_filter", []string{"esc"}},
+		{"back", []string{"b"}},
+		{"refresh", []string{"r"}},
+		{"quit", []string{"q"}},
+	},
+	config.KeysDashboard: {
+		{"refresh", []string{"r"}},
+		{"back", []string{"b", "esc"}},
+		{"quit", []string{"q"}},
+	},
+}
+
+// namedKeys are the keys written by name rather than as the character they
+// type; a sequence of other letters is one key per letter.
+var namedKeys = map[string]bool{
+	"space": true, "enter": true, "tab": true, "esc": true, "backspace": true,
+	"delete": true, "insert": true, "up": true, "down": true, "left": true,
+	"right": true, "home": true, "end": true, "pgup": true, "pgdown": true,
+}
+
+// Keymap holds the active key bindings of the views [keys] configures. The
+// zero value is not usable; a nil *Keymap has the default bindings.
+type Keymap struct {
+	scopes map[string]*scopeKeys
+}
+
+type scopeKeys struct {
+	keys     map[string][]string // action → sequences
+	actions  map[string]string   // sequence → action
+	prefixes map[string]bool     // proper prefixes of sequences
+	// For the browsers: pressed key → the first default key of its action,
+	// "" for a default key whose action was bound elsewhere
+	translated map[string]string
+}
+
+var defaultKeymap = func() *Keymap {
+	km, err := NewKeymap(config.Keys{})
+	if err != nil {
+		panic(err)
+	}
+	return km
+}()
+
+// NewKeymap applies configured bindings over the defaults, rejecting unknown
+// actions, keys bound to two actions, and sequences that are a prefix of
+// another, which could never complete.
+func NewKeymap(keys config.Keys) (*Keymap, error) {
+	km := &Keymap{scopes: make(map[string]*scopeKeys)}
+	for _, scope := range []string{config.KeysConsumer, config.KeysGroups, config.KeysTrace, config.KeysFilters, config.KeysBrowser, config.KeysSessions, config.KeysDashboard} {
+		section := "keys"
+		if scope != config.KeysConsumer {
+			section += "." + scope
+		}
+		s, err := newScopeKeys(scope, keys.Scope(scope))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", section, err)
+		}
+		km.scopes[scope] = s
+	}
+	return km, nil
+}
+
+func newScopeKeys(scope string, configured map[string]config.KeySequences) (*scopeKeys, error) {
+	defaults := defaultBindings[scope]
+	s := &scopeKeys{
+		keys:       make(map[string][]string),
+		actions:    make(map[string]string),
+		prefixes:   make(map[string]bool),
+		translated: make(map[string]string),
+	}
+	for _, b := range defaults {
+		s.keys[b.action] = b.keys
+	}
+	for action, seqs := range configured {
+		if _, ok := s.keys[action]; !ok {
+			return nil, fmt.Errorf("unknown action %q", action)
+		}
+		parsed := make([]string, 0, len(seqs))
+		for _, seq := range seqs {
+			keys, err := parseKeySequence(seq)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", action, err)
+			}
+			parsed = append(parsed, strings.Join(keys, " "))
+		}
+		s.keys[action] = parsed
+	}
+
+	for _, b := range defaults {
+		for _, seq := range s.keys[b.action] {
+			keys := strings.Split(seq, " ")
+			switch {
+			case keys[0] == "ctrl+c" || (scope == config.KeysConsumer && keys[0] == "esc"):
+				return nil, fmt.Errorf("%s: %q is reserved", b.action, keyLabel(seq))
+			case scope != config.KeysConsumer && len(keys) > 1:
+				return nil, fmt.Errorf("%s: %q: only single keys can be bound here", b.action, keyLabel(seq))
+			case scope == config.KeysConsumer && b.action == "back" && len(keys) > 1:
+				return nil, fmt.Errorf("back: %q: only single keys can be bound to back", keyLabel(seq))
+			case scope == config.KeysConsumer && strings.ContainsAny(keys[0], "123456789") && len(keys[0]) == 1:
+				return nil, fmt.Errorf("%s: %q starts with a count", b.action, keyLabel(seq))
+			case scope == config.KeysFilters && strings.ContainsAny(keys[0], "123456789") && len(keys[0]) == 1:
+				return nil, fmt.Errorf("%s: %q applies a saved filter by number", b.action, keyLabel(seq))
+			}
+			if other, ok := s.actions[seq]; ok && other != b.action {
+				return nil, fmt.Errorf("%q is bound to both %s and %s", keyLabel(seq), other, b.action)
+			}
+			s.actions[seq] = b.action
+			for i := 1; i < len(keys); i++ {
+				s.prefixes[strings.Join(keys[:i], " ")] = true
+			}
+		}
+	}
+	for _, b := range defaults {
+		for _, seq := range s.keys[b.action] {
+			if s.prefixes[seq] {
+				for _, longer := range slices.Sorted(maps.Keys(s.actions)) {
+					if strings.HasPrefix(longer, seq+" ") {
+						return nil, fmt.Errorf("%q (%s) is a prefix of %q (%s)", keyLabel(seq), b.action, keyLabel(longer), s.actions[longer])
+					}
+				}
+			}
+		}
+	}
+
+	if scope != config.KeysConsumer {
+		for _, b := range defaults {
+			for _, key := range b.keys {
+				s.translated[key] = ""
+			}
+		}
+		for _, b := range defaults {
+			for _, key := range s.keys[b.action] {
+				s.translated[key] = b.keys[0]
+			}
+		}
+	}
+	return s, nil
+}
+
+// parseKeySequence splits a configured sequence into keys: on spaces if it
+// has any ("ctrl+w l"), otherwise a named key ("ctrl+d", "enter") or one key
+// per character ("gg").
+func parseKeySequence(seq string) ([]string, error) {
+	seq = strings.TrimSpace(seq)
+	if seq == "" {
+		return nil, fmt.Errorf("empty key sequence")
+	}
+	if strings.Contains(seq, " ") {
+		keys := strings.Fields(seq)
+		for _, k := range keys {
+			if utf8.RuneCountInString(k) > 1 && !isNamedKey(k) {
+				return nil, fmt.Errorf("unknown key %q", k)
+			}
+		}
+		return keys, nil
+	}
+	if isNamedKey(seq) {
+		return []string{seq}, nil
+	}
+	var keys []string
+	for _, r := range seq {
+		keys = append(keys, string(r))
+	}
+	return keys, nil
+}
+
+func isNamedKey(k string) bool {
+	if namedKeys[k] {
+		return true
+	}
+	if mod, key, ok := strings.Cut(k, "+"); ok && key != "" {
+		return mod == "ctrl" || mod == "alt" || mod == "shift"
+	}
+	var n int
+	_, err := fmt.Sscanf(k, "f%d", &n)
+	return err == nil && k == fmt.Sprintf("f%d", n)
+}
+
+// normalizeKey names a pressed key as sequences do.
+func normalizeKey(key string) string {
+	if key == " " {
+		return "space"
+	}
+	return key
+}
+
+func (km *Keymap) scope(name string) *scopeKeys {
+	if km == nil {
+		km = defaultKeymap
+	}
+	return km.scopes[name]
+}
+
+// lookup returns the action of a sequence, or whether it is the start of
+// a longer one.
+func (km *Keymap) lookup(scope, seq string) (action string, pending bool) {
+	s := km.scope(scope)
+	return s.actions[seq], s.prefixes[seq]
+}
+
+// is reports whether key, pressed on its own, triggers action.
+func (km *Keymap) is(scope, key, action string) bool {
+	return km.scope(scope).actions[normalizeKey(key)] == action
+}
+
+// translate maps a key pressed in a browser to the key its switch matches
+// for the bound action. Keys bound to nothing pass through, except default
+// keys whose action was bound elsewhere, which map to "".
+func (km *Keymap) translate(scope, key string) string {
+	t, ok := km.scope(scope).translated[normalizeKey(key)]
+	if !ok {
+		return key
+	}
+	if t == "space" {
+		return " "
+	}
+	return t
+}
+
+// hint returns the first key of each action, for help bars: "j/k".
+func (km *Keymap) hint(scope string, actions ...string) string {
+	s := km.scope(scope)
+	var labels []string
+	for _, a := range actions {
+		if keys := s.keys[a]; len(keys) > 0 {
+			labels = append(labels, keyLabel(keys[0]))
+		}
+	}
+	return strings.Join(labels, "/")
+}
+
+// helpKeys labels the keys of actions for the help overlay: every key of a
+// single action ("p / Space"), or the first key of each ("j / k").
+func (km *Keymap) helpKeys(scope string, actions ...string) string {
+	s := km.scope(scope)
+	var seqs []string
+	if len(actions) == 1 {
+		seqs = s.keys[actions[0]]
+	} else {
+		for _, a := range actions {
+			if keys := s.keys[a]; len(keys) > 0 {
+				seqs = append(seqs, keys[0])
+			}
+		}
+	}
+	labels := make([]string, len(seqs))
+	for i, seq := range seqs {
+		labels[i] = helpKeyLabel(seq)
+	}
+	return strings.Join(labels, " / ")
+}
+
+// keyLabel writes a sequence as configured: "gg", "ctrl+w l".
+func keyLabel(seq string) string {
+	keys := strings.Split(seq, " ")
+	if slices.ContainsFunc(keys, func(k string) bool { return utf8.RuneCountInString(k) > 1 }) {
+		return seq
+	}
+	return strings.Join(keys, "")
+}
+
+// helpKeyLabel writes a sequence for the help overlay: "Ctrl+U", "S-Tab".
+func helpKeyLabel(seq string) string {
+	keys := strings.Split(seq, " ")
+	for i, k := range keys {
+		switch {
+		case utf8.RuneCountInString(k) == 1:
+		case k == "shift+tab":
+			keys[i] = "S-Tab"
+		case k == "up":
+			keys[i] = "↑"
+		case k == "down":
+			keys[i] = "↓"
+		case k == "left":
+			keys[i] = "←"
+		case k == "right":
+			keys[i] = "→"
+		default:
+			parts := strings.Split(k, "+")
+			for j, p := range parts {
+				parts[j] = strings.ToUpper(p[:1]) + p[1:]
+			}
+			keys[i] = strings.Join(parts, "+")
+		}
+	}
+	return keyLabel(strings.Join(keys, " "))
+}
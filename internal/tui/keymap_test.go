@@ -0,0 +1,207 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/epalmerini/rabbithole/internal/config"
+)
+
+func mustKeymap(t *testing.T, keys config.Keys) *Keymap {
+	t.Helper()
+	km, err := NewKeymap(keys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return km
+}
+
+func TestNewKeymap_Invalid(t *testing.T) {
+	tests := []struct {
+		keys config.Keys
+		want string
+	}{
+		{config.Keys{Consumer: map[string]config.KeySequences{"jump": {"x"}}}, `keys: unknown action "jump"`},
+		{config.Keys{Consumer: map[string]config.KeySequences{"quit": {"x"}}}, `"x" is bound to both trace and quit`},
+		{config.Keys{Consumer: map[string]config.KeySequences{"clear": {"g"}}}, `"g" (clear) is a prefix of "gg" (go_top)`},
+		{config.Keys{Consumer: map[string]config.KeySequences{"go_top": {"1g"}}}, `go_top: "1g" starts with a count`},
+		{config.Keys{Consumer: map[string]config.KeySequences{"quit": {"esc"}}}, `quit: "esc" is reserved`},
+		{config.Keys{Consumer: map[string]config.KeySequences{"back": {"ctrl+w b"}}}, "only single keys can be bound to back"},
+		{config.Keys{Consumer: map[string]config.KeySequences{"center_line": {"ctrl+w foo"}}}, `center_line: unknown key "foo"`},
+		{config.Keys{Browser: map[string]config.KeySequences{"queues": {"gq"}}}, `keys.browser: queues: "gq": only single keys`},
+		{config.Keys{Sessions: map[string]config.KeySequences{"replay": {"o"}}}, `keys.sessions: unknown action "replay"`},
+		{config.Keys{Groups: map[string]config.KeySequences{"close": {"gq"}}}, `keys.groups: close: "gq": only single keys`},
+		{config.Keys{Browser: map[string]config.KeySequences{"queues": {"u"}}}, `keys.browser: "u" is bound to both select_none and queues`},
+		{config.Keys{Filters: map[string]config.KeySequences{"down": {"2"}}}, `keys.filters: down: "2" applies a saved filter by number`},
+		{config.Keys{Dashboard: map[string]config.KeySequences{"open": {"o"}}}, `keys.dashboard: unknown action "open"`},
+	}
+	for _, tt := range tests {
+		_, err := NewKeymap(tt.keys)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("NewKeymap(%+v) error = %v, want %q", tt.keys, err, tt.want)
+		}
+	}
+
+	if _, err := NewKeymap(config.Keys{}); err != nil {
+		t.Errorf("the defaults were rejected: %v", err)
+	}
+}
+
+func TestProcessKey_CustomBindings(t *testing.T) {
+	v := newVimKeyStateWith(mustKeymap(t, config.Keys{Consumer: map[string]config.KeySequences{
+		"go_top":      {"gg", "home"},
+		"clear":       {"ctrl+l"},
+		"yank_tab":    {"c"},
+		"center_line": {"ctrl+w z"},
+	}}))
+
+	for _, tt := range []struct {
+		keys       []string
+		wantAction string
+		wantCount  int
+	}{
+		{[]string{"home"}, "go_top", 1},
+		{[]string{"g", "g"}, "go_top", 1},
+		{[]string{"ctrl+l"}, "clear", 1},
+		{[]string{"c"}, "yank_tab", 1},
+		{[]string{"y"}, "", 0},
+		{[]string{"ctrl+w", "z"}, "center_line", 1},
+		{[]string{"1", "2", "j"}, "move_down", 12},
+	} {
+		var result VimKeyResult
+		for _, k := range tt.keys {
+			result = v.ProcessKey(k)
+		}
+		if result.Action != tt.wantAction || result.Count != tt.wantCount {
+			t.Errorf("%q = %s x%d, want %s x%d", tt.keys, result.Action, result.Count, tt.wantAction, tt.wantCount)
+		}
+	}
+}
+
+func TestKeymap_Browser(t *testing.T) {
+	km := mustKeymap(t, config.Keys{Browser: map[string]config.KeySequences{
+		"queues":      {"U"},
+		"down":        {"N"},
+		"select_none": {"x"},
+	}})
+	for key, want := range map[string]string{"U": "Q", "Q": "", "N": "j", "j": "", "down": "", "k": "k", "x": "u", "u": "", "z": "z"} {
+		if got := km.translate(config.KeysBrowser, key); got != want {
+			t.Errorf("translate(%q) = %q, want %q", key, got, want)
+		}
+	}
+
+	m := newBrowserModel(Config{Keys: km})
+	m.loading = false
+	m.width, m.height = 120, 30
+	if help := m.renderHelp(); !strings.Contains(help, "N/k") || !strings.Contains(help, "U") {
+		t.Errorf("help bar = %q, want the bound keys", help)
+	}
+	m, _ = pressKey(m, "U")
+	if m.view != viewQueues {
+		t.Errorf("U opened view %v, want the queues", m.view)
+	}
+	if help := m.renderHelp(); !strings.Contains(help, "a/x") {
+		t.Errorf("queue help bar = %q, want a/x for all/none", help)
+	}
+}
+
+func TestKeymap_GroupsAndDashboard(t *testing.T) {
+	cfg := Config{Keys: mustKeymap(t, config.Keys{
+		Groups:    map[string]config.KeySequences{"close": {"x"}, "down": {"n"}},
+		Dashboard: map[string]config.KeySequences{"back": {"h"}},
+	})}
+
+	m := initialModel(cfg, nil)
+	m.messages = newMessageRing(0, Message{ID: 1, RoutingKey: "a"}, Message{ID: 2, RoutingKey: "b"})
+	m.width, m.height = 120, 30
+	m.detailViewport = viewport.New(80, 20)
+	m = press(t, m, "a", "n")
+	if m.groups == nil || m.groups.cursor != 1 {
+		t.Fatalf("n should move down the groups, got %+v", m.groups)
+	}
+	if help := m.View(); !strings.Contains(help, "n/k") || !strings.Contains(help, "x close") {
+		t.Errorf("help bar does not show the bound keys:\n%s", help)
+	}
+	if m = press(t, m, "q"); m.groups == nil {
+		t.Error("q is no longer bound to close")
+	}
+	if m = press(t, m, "x"); m.groups != nil {
+		t.Error("x should close the groups")
+	}
+
+	app := newAppModel(cfg, nil)
+	app.view = appViewDashboard
+	app.dashboard = newDashboardModel(cfg)
+	updated, _ := app.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("b")})
+	if updated.(appModel).view != appViewDashboard {
+		t.Error("b is no longer bound to back")
+	}
+	updated, _ = app.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("h")})
+	if updated.(appModel).view != appViewBrowser {
+		t.Error("h should go back to the browser")
+	}
+}
+
+func TestKeymap_TraceAndFilters(t *testing.T) {
+	km := mustKeymap(t, config.Keys{
+		Trace:   map[string]config.KeySequences{"down": {"n"}, "jump": {"o"}},
+		Filters: map[string]config.KeySequences{"down": {"n"}, "close": {"c"}},
+	})
+
+	m := newFilterTestModel(t, nil)
+	m.config.Keys = km
+	m = press(t, m, `"`, "n")
+	if m.filterPicker == nil || m.filterPicker.cursor != 1 {
+		t.Fatalf("n should move down the saved filters, got %+v", m.filterPicker)
+	}
+	if help := m.View(); !strings.Contains(help, "n/k") || !strings.Contains(help, "c close") {
+		t.Errorf("help bar does not show the bound keys:\n%s", help)
+	}
+	if m = press(t, m, "q"); m.filterPicker == nil {
+		t.Error("q is no longer bound to close")
+	}
+	if m = press(t, m, "c"); m.filterPicker != nil {
+		t.Error("c should close the saved filters")
+	}
+
+	m.trace = &traceView{entries: []traceEntry{{msg: Message{RoutingKey: "a"}}, {msg: Message{RoutingKey: "b"}}}}
+	m = press(t, m, "n")
+	if m.trace.cursor != 1 {
+		t.Errorf("n should move down the trace, cursor = %d", m.trace.cursor)
+	}
+	if help := m.View(); !strings.Contains(help, "n/k") || !strings.Contains(help, "o jump") {
+		t.Errorf("help bar does not show the bound keys:\n%s", help)
+	}
+	if m = press(t, m, "x"); m.trace != nil {
+		t.Error("the trace key should close the timeline")
+	}
+}
+
+func TestHelpOverlay_ActiveBindings(t *testing.T) {
+	cfg := Config{Keys: mustKeymap(t, config.Keys{Consumer: map[string]config.KeySequences{
+		"toggle_help": {"h"},
+		"clear":       {"ctrl+l"},
+	}})}
+	m := initialModel(cfg, nil)
+	m.width, m.height = 120, 60
+	m.detailViewport = viewport.New(80, 20)
+	if !strings.Contains(m.View(), "Press h for help") {
+		t.Error("the empty view should name the help key")
+	}
+
+	m = press(t, m, "h")
+	view := m.View()
+	if !m.showHelp || !strings.Contains(view, "Ctrl+L") || !strings.Contains(view, "Press h or Esc to close") {
+		t.Fatalf("help overlay does not list the active bindings:\n%s", view)
+	}
+	if strings.Contains(view, "?") {
+		t.Error("the help overlay still shows the unbound ? key")
+	}
+
+	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("h")})
+	if updated.(model).showHelp {
+		t.Error("h should close the help overlay")
+	}
+}
@@ -1,6 +1,12 @@
 package tui
 
-import "time"
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+)
 
 // Message represents a consumed RabbitMQ message
 type Message struct {
@@ -8,6 +14,7 @@ type Message struct {
 	RoutingKey    string
 	Exchange      string
 	Timestamp     time.Time
+	ConsumedAt    time.Time // when rabbithole received it
 	RawBody       []byte
 	Decoded       map[string]any
 	DecodeErr     error
@@ -19,4 +26,72 @@ type Message struct {
 	AppID         string
 	ProtoType     string
 	Historical    bool // true if loaded from database (previous session)
+
+	// Annotations, saved with the stored message (see saveAnnotation)
+	Bookmarked bool
+	Note       string
+	Tags       []string
+
+	// Paged replay rows are decoded when first viewed (see decodeLazy)
+	lazy          bool
+	storedDecoded string // decoded_json from the store
+
+	// Row colour of the alert rule the message matched
+	highlight *lipgloss.Style
+
+	// storeID is the stored message's ID when it was loaded from the store;
+	// live deliveries learn theirs from saved once the writer persists them
+	storeID int64
+	saved   *savedRow // nil unless the delivery was handed to the writer
+
+	// published is set when Timestamp is the publisher's, not the arrival time
+	published bool
+
+	// seq numbers the deliveries of the current session from 1; 0 for
+	// messages of other sessions
+	seq int
+}
+
+// errNotSaved reports a live delivery the writer dropped.
+var errNotSaved = errors.New("the message was not saved")
+
+// savedRow receives the stored ID of a live delivery from the async writer.
+// Copies of the message share it.
+type savedRow struct {
+	done chan struct{} // closed once the insert finished
+	id   int64
+	err  error
+}
+
+func newSavedRow() *savedRow {
+	return &savedRow{done: make(chan struct{})}
+}
+
+// set records the outcome of the insert; the writer calls it once.
+func (r *savedRow) set(id int64, err error) {
+	r.id, r.err = id, err
+	close(r.done)
+}
+
+// storedID returns the ID of the stored message, waiting for the writer to
+// insert a live delivery. It is 0 for messages that are not being saved.
+func (m Message) storedID(ctx context.Context) (int64, error) {
+	if m.storeID != 0 || m.saved == nil {
+		return m.storeID, nil
+	}
+	select {
+	case <-m.saved.done:
+		return m.saved.id, m.saved.err
+	case <-ctx.Done():
+		return 0, ctx.Err()
+	}
+}
+
+// arrival returns when the message was received, or its timestamp for
+// messages stored before arrival times were kept.
+func (m Message) arrival() time.Time {
+	if m.ConsumedAt.IsZero() {
+		return m.Timestamp
+	}
+	return m.ConsumedAt
 }
@@ -6,6 +6,7 @@ import (
 	"encoding/csv"
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"regexp"
@@ -19,8 +20,10 @@ import (
 	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/epalmerini/rabbithole/internal/alert"
 	"github.com/epalmerini/rabbithole/internal/config"
 	"github.com/epalmerini/rabbithole/internal/db"
+	"github.com/epalmerini/rabbithole/internal/filter"
 	"github.com/epalmerini/rabbithole/internal/rabbitmq"
 )
 
@@ -34,7 +37,7 @@ const (
 
 type model struct {
 	config        Config
-	messages      []Message
+	messages      messageRing
 	selectedIdx   int
 	messageCount  int
 	connState     connectionState
@@ -56,6 +59,12 @@ type model struct {
 
 	// Replay mode (read-only, no AMQP connection)
 	replayMode bool
+	pager      *replayPager // pages the replayed session from the store
+
+	// Evicted history: scrolling above the oldest live message pages the
+	// earlier messages of the current session back in from the store
+	received int          // deliveries this session, numbering Message.seq
+	liveRing *messageRing // the live list, set aside while history is shown
 
 	// Vim command state
 	vimKeys VimKeyState
@@ -66,27 +75,35 @@ type model struct {
 	searchInput     textinput.Model
 	searchResults   []int
 	searchResultIdx int
+	searchHistory   inputHistory
 
 	// Filter
-	filterMode   bool
-	filterExpr   string
-	filterActive bool
-	filterInput  textinput.Model
-	filteredIdx  []int // sorted indices into m.messages; nil when filter is off
-
-	// Bookmarks
-	bookmarks map[int]bool
+	filterMode    bool
+	filterExpr    string
+	filterActive  bool
+	filterInput   textinput.Model
+	filteredIdx   []int // sorted indices into m.messages; nil when filter is off
+	filterHistory inputHistory
+	filterPicker  *filterPicker // saved filters; nil when closed
+	liveFilter    compiledFilter
+
+	// Note or tags being edited on the selected message
+	annotating    annotateField
+	annotateInput textinput.Model
+	annotateID    int // the message being annotated
 
 	// UI state
 	splitRatio   float64
 	compactMode  bool
+	columns      []listColumn // of the message list
 	showHelp     bool
 	timestampRel bool
 	detailTab    int // 0=body, 1=headers, 2=metadata
 
 	// Pause buffer (messages received while paused)
-	pauseBuffer []Message
-	newMsgCount int
+	pauseBuffer  []Message
+	newMsgCount  int
+	pauseDropped int // messages the pause buffer overflowed
 
 	// Components
 	spinner        spinner.Model
@@ -95,9 +112,34 @@ type model struct {
 	// Live stats
 	stats stats
 
+	// Alert rules applied to consumed messages; bells and notifications are
+	// written to alertOut, the terminal
+	alerts   *alert.Rules
+	alertOut io.Writer
+
+	// Rate, latency and size charts, shown in place of the detail panel
+	charts      *charts
+	showCharts  bool
+	chartTickID int64
+
 	// Status messages (brief confirmations)
 	statusMsg     string
 	statusMsgTime time.Time
+
+	// Resource alarms, polled from the management API
+	mgmt        *rabbitmq.ManagementClient
+	alarms      []string
+	alarmPollID int64
+
+	// Conversation timeline of the message a trace started from; nil when closed
+	trace *traceView
+
+	// Aggregate view of the list grouped by one field; nil when closed
+	groups *groupView
+
+	// Message diff: the message marked with d, and the open comparison
+	diffBase *Message
+	diff     *diffView
 }
 
 // Tea messages
@@ -138,19 +180,32 @@ func initialModel(cfg Config, store db.Store) model {
 
 	splitRatio := loadSplitRatio(cfg)
 
+	mgmt, _ := rabbitmq.NewManagementClient(cfg.RabbitMQURL, cfg.ManagementURL)
+
+	// Rules are validated at startup
+	alerts, _ := alert.NewRules(cfg.Alerts)
+
+	searchHistory, filterHistory := loadInputHistory(store)
+
 	return model{
 		config:         cfg,
 		store:          store,
-		messages:       make([]Message, 0, cfg.MessageLimit()),
+		mgmt:           mgmt,
+		alerts:         alerts,
+		alertOut:       os.Stderr,
+		alarmPollID:    nextPollID(),
+		messages:       newMessageRing(cfg.MessageLimit()),
 		connState:      stateConnecting,
 		viewport:       viewport.New(80, 20),
 		detailViewport: viewport.New(80, 20),
-		vimKeys:        NewVimKeyState(),
-		bookmarks:      make(map[int]bool),
+		vimKeys:        newVimKeyStateWith(cfg.Keys),
 		splitRatio:     splitRatio,
 		compactMode:    cfg.CompactMode,
+		columns:        listColumns(cfg.Columns),
 		searchInput:    si,
 		filterInput:    fi,
+		searchHistory:  searchHistory,
+		filterHistory:  filterHistory,
 		spinner:        sp,
 	}
 }
@@ -160,6 +215,7 @@ func (m model) Init() tea.Cmd {
 		tea.EnterAltScreen,
 		m.connectCmd(),
 		m.spinner.Tick,
+		m.pollAlarms(),
 	)
 }
 
@@ -181,7 +237,12 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.searchMode = false
 				m.searchQuery = m.searchInput.Value()
 				m.searchInput.Blur()
-				m.performSearch()
+				return m, tea.Batch(m.performSearch(), m.searchHistory.add(m.store, m.searchQuery))
+			case "up", "ctrl+p":
+				m.searchHistory.recall(&m.searchInput, -1)
+				return m, nil
+			case "down", "ctrl+n":
+				m.searchHistory.recall(&m.searchInput, 1)
 				return m, nil
 			default:
 				var cmd tea.Cmd
@@ -201,20 +262,12 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.filterMode = false
 				m.filterInput.Blur()
 				expr := m.filterInput.Value()
-				if expr == "" {
-					// Clear filter
-					m.filterExpr = ""
-					m.filterActive = false
-					m.filteredIdx = nil
-				} else {
-					m.filterExpr = expr
-					m.filterActive = true
-					m.filteredIdx = computeFilteredIndices(m.messages, expr)
-					if len(m.filteredIdx) > 0 && !isVisible(m.filteredIdx, m.selectedIdx) {
-						m.selectedIdx = m.filteredIdx[0]
-						m.detailViewport.YOffset = 0
-					}
-				}
+				return m, tea.Batch(m.applyFilter(expr), m.filterHistory.add(m.store, expr))
+			case "up", "ctrl+p":
+				m.filterHistory.recall(&m.filterInput, -1)
+				return m, nil
+			case "down", "ctrl+n":
+				m.filterHistory.recall(&m.filterInput, 1)
 				return m, nil
 			default:
 				var cmd tea.Cmd
@@ -223,9 +276,29 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 		}
 
+		// Handle note and tag input
+		if m.annotating != annotateNone {
+			return m.updateAnnotate(msg)
+		}
+
+		// Handle the saved filter picker
+		if m.filterPicker != nil {
+			return m.updateFilterPicker(msg)
+		}
+
+		// Handle trace timeline
+		if m.trace != nil {
+			return m.updateTrace(msg)
+		}
+
+		// Handle aggregate view
+		if m.groups != nil {
+			return m.updateGroups(msg)
+		}
+
 		// Handle help overlay
 		if m.showHelp {
-			if msg.String() == "?" || msg.String() == "esc" || msg.String() == "q" {
+			if m.config.Keys.is(config.KeysConsumer, msg.String(), "toggle_help") || msg.String() == "esc" || msg.String() == "q" {
 				m.showHelp = false
 				return m, nil
 			}
@@ -236,41 +309,9 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		switch msg.String() {
 		case "ctrl+c":
 			return m, tea.Quit
-		case "ctrl+u":
-			m.moveBy(-m.visibleItems() / 2)
-			return m, nil
-		case "ctrl+d":
-			m.moveBy(m.visibleItems() / 2)
-			return m, nil
-		case "ctrl+f":
-			m.moveBy(m.visibleItems())
-			return m, nil
-		case "ctrl+b":
-			m.moveBy(-m.visibleItems())
-			return m, nil
-		case "ctrl+j":
-			// Scroll detail viewport down
-			m.detailViewport.YOffset++
-			return m, nil
-		case "ctrl+k":
-			// Scroll detail viewport up
-			if m.detailViewport.YOffset > 0 {
-				m.detailViewport.YOffset--
-			}
-			return m, nil
-		case "tab":
-			m.detailTab = (m.detailTab + 1) % m.tabCount()
-			m.detailViewport.YOffset = 0
-			return m, nil
-		case "shift+tab":
-			m.detailTab = (m.detailTab + m.tabCount() - 1) % m.tabCount()
-			m.detailViewport.YOffset = 0
-			return m, nil
-		case "up":
-			m.moveBy(-1)
-			return m, nil
-		case "down":
-			m.moveBy(1)
+		case "esc":
+			m.diff = nil
+			m.diffBase = nil
 			return m, nil
 		}
 
@@ -282,22 +323,49 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 		switch result.Action {
 		case "move_down":
-			m.moveBy(result.Count)
+			cmds = append(cmds, m.moveBy(result.Count))
 		case "move_up":
-			m.moveBy(-result.Count)
+			cmds = append(cmds, m.moveBy(-result.Count))
 		case "go_top":
-			if len(m.filteredIdx) > 0 {
+			if m.liveRing != nil {
+				cmds = append(cmds, m.historyMoveTo(0))
+			} else if m.pager != nil {
+				cmds = append(cmds, m.replayMoveTo(0))
+			} else if len(m.filteredIdx) > 0 {
 				m.selectedIdx = m.filteredIdx[0]
 			} else {
 				m.selectedIdx = 0
 			}
 			m.detailViewport.YOffset = 0
 		case "go_bottom":
-			if len(m.filteredIdx) > 0 {
+			m.leaveHistory(0)
+			if m.pager != nil {
+				cmds = append(cmds, m.replayMoveTo(m.pager.total-1))
+			} else if len(m.filteredIdx) > 0 {
 				m.selectedIdx = m.filteredIdx[len(m.filteredIdx)-1]
-			} else if len(m.messages) > 0 {
-				m.selectedIdx = len(m.messages) - 1
+			} else if m.messages.Len() > 0 {
+				m.selectedIdx = m.messages.Len() - 1
+			}
+			m.detailViewport.YOffset = 0
+		case "half_page_down":
+			cmds = append(cmds, m.moveBy(m.visibleItems()/2))
+		case "half_page_up":
+			cmds = append(cmds, m.moveBy(-m.visibleItems()/2))
+		case "page_down":
+			cmds = append(cmds, m.moveBy(m.visibleItems()))
+		case "page_up":
+			cmds = append(cmds, m.moveBy(-m.visibleItems()))
+		case "detail_down":
+			m.detailViewport.YOffset++
+		case "detail_up":
+			if m.detailViewport.YOffset > 0 {
+				m.detailViewport.YOffset--
 			}
+		case "next_tab":
+			m.detailTab = (m.detailTab + 1) % m.tabCount()
+			m.detailViewport.YOffset = 0
+		case "prev_tab":
+			m.detailTab = (m.detailTab + m.tabCount() - 1) % m.tabCount()
 			m.detailViewport.YOffset = 0
 		case "center_line":
 			// Centering is handled in renderMessageList
@@ -305,21 +373,31 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.searchMode = true
 			m.searchInput.SetValue("")
 			m.searchInput.Focus()
+			m.searchHistory.reset()
 			return m, textinput.Blink
 		case "search_next":
-			m.nextSearchResult()
+			cmds = append(cmds, m.nextSearchResult())
 		case "search_prev":
-			m.prevSearchResult()
+			cmds = append(cmds, m.prevSearchResult())
 		case "filter_start":
 			m.filterMode = true
 			m.filterInput.SetValue(m.filterExpr)
 			m.filterInput.Focus()
+			m.filterHistory.reset()
 			return m, textinput.Blink
 		case "filter_toggle":
-			if m.filterExpr != "" {
+			m.leaveHistory(0)
+			if m.pager != nil && m.filterExpr != "" {
+				m.filterActive = !m.filterActive
+				expr := ""
+				if m.filterActive {
+					expr = m.filterExpr
+				}
+				cmds = append(cmds, m.replayFilter(expr))
+			} else if m.filterExpr != "" {
 				m.filterActive = !m.filterActive
 				if m.filterActive {
-					m.filteredIdx = computeFilteredIndices(m.messages, m.filterExpr)
+					m.filteredIdx = computeFilteredIndices(m.messages.All(), m.filterExpr)
 					if len(m.filteredIdx) > 0 && !isVisible(m.filteredIdx, m.selectedIdx) {
 						m.selectedIdx = m.filteredIdx[0]
 						m.detailViewport.YOffset = 0
@@ -327,7 +405,10 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				} else {
 					m.filteredIdx = nil
 				}
+				_ = m.matchSearch()
 			}
+		case "filter_pick":
+			return m, m.openFilterPicker()
 		case "yank":
 			return m, m.yankMessage()
 		case "yank_tab":
@@ -337,9 +418,21 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		case "export_csv":
 			return m, m.exportCSV()
 		case "bookmark_toggle":
-			m.toggleBookmark()
+			cmds = append(cmds, m.toggleBookmark())
 		case "bookmark_next":
 			m.nextBookmark()
+		case "annotate_note":
+			return m, m.startAnnotate(annotateNote)
+		case "annotate_tags":
+			return m, m.startAnnotate(annotateTags)
+		case "trace":
+			return m, m.startTrace()
+		case "group":
+			return m, m.openGroups()
+		case "diff_mark":
+			return m, m.markDiff()
+		case "diff_previous":
+			return m, m.diffPrevious()
 		case "toggle_compact":
 			m.compactMode = !m.compactMode
 		case "toggle_timestamp":
@@ -348,6 +441,8 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.showRaw = !m.showRaw
 		case "toggle_help":
 			m.showHelp = !m.showHelp
+		case "toggle_charts":
+			return m, m.toggleCharts()
 		case "resize_left":
 			if m.splitRatio > 0.2 {
 				m.splitRatio -= 0.05
@@ -366,40 +461,22 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			if m.replayMode {
 				return m, nil
 			}
-			m.paused = !m.paused
-			if !m.paused {
-				// Merge buffered messages into the main list
-				for _, buffered := range m.pauseBuffer {
-					m.messageCount++
-					buffered.ID = m.messageCount
-					m.messages = append(m.messages, buffered)
-					if len(m.messages) > m.config.MessageLimit() {
-						newBookmarks := make(map[int]bool)
-						for id := range m.bookmarks {
-							if id > 1 {
-								newBookmarks[id-1] = true
-							}
-						}
-						m.bookmarks = newBookmarks
-						m.messages = m.messages[1:]
-						if m.selectedIdx > 0 {
-							m.selectedIdx--
-						}
-					}
-				}
-				m.pauseBuffer = m.pauseBuffer[:0]
-				m.newMsgCount = 0
-				// Re-apply filter after merging
-				if m.filterActive && m.filterExpr != "" {
-					m.filteredIdx = computeFilteredIndices(m.messages, m.filterExpr)
-				}
+			if m.paused {
+				m.resume()
+			} else {
+				m.paused = true
 			}
 		case "clear":
-			m.messages = m.messages[:0]
+			m.leaveHistory(0)
+			if m.pager != nil {
+				// The replayed session stays in the store
+				return m, nil
+			}
+			m.messages.Clear()
 			m.pauseBuffer = m.pauseBuffer[:0]
+			m.pauseDropped = 0
 			m.selectedIdx = 0
 			m.messageCount = 0
-			m.bookmarks = make(map[int]bool)
 			m.filteredIdx = nil
 			m.newMsgCount = 0
 		case "back":
@@ -412,23 +489,10 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.width = msg.Width
 		m.height = msg.Height
 
-		// Update viewport dimensions
-		contentHeight := m.height - 5 // header(3) + status(1) + help(1)
-		if contentHeight < 3 {
-			contentHeight = 3
-		}
-		listWidth := int(float64(m.width) * m.splitRatio)
-		if listWidth < 20 {
-			listWidth = 20
-		}
-		detailWidth := m.width - listWidth - 1
-		if detailWidth < 20 {
-			detailWidth = 20
-		}
-
 		// Resize detail viewport (account for border and padding)
+		_, _, detailWidth, detailHeight := m.panes()
 		m.detailViewport.Width = detailWidth - 4
-		m.detailViewport.Height = contentHeight - 2
+		m.detailViewport.Height = detailHeight - 2
 
 	case connectedMsg:
 		m.connState = stateConnected
@@ -437,9 +501,15 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.cancelConsume = msg.cancelConsume
 		m.asyncWriter = msg.asyncWriter
 		m.sessionID = msg.sessionID
+		// Sequence numbers only place messages within their own session
+		m.received = 0
+		m.leaveHistory(0)
+		for i := range m.messages.Len() {
+			m.messages.At(i).seq = 0
+		}
 		// Load historical messages first
 		if len(msg.historicalMsgs) > 0 {
-			m.messages = msg.historicalMsgs
+			m.messages = newMessageRing(m.config.MessageLimit(), msg.historicalMsgs...)
 			m.messageCount = msg.historicalCount
 		}
 		cmds = append(cmds, m.waitForMessage())
@@ -463,42 +533,26 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		cmds = append(cmds, m.connectWithRetry(msg.attempt))
 
 	case msgReceived:
-		m.stats.record(time.Now(), len(msg.msg.RawBody))
+		now := time.Now()
+		m.stats.record(now, len(msg.msg.RawBody))
+		m.recordCharts(msg.msg, now)
+		m.received++
+		msg.msg.seq = m.received
+		pause, alertCmd := m.fireAlerts(&msg.msg)
+		cmds = append(cmds, alertCmd)
 		if m.paused {
-			m.newMsgCount++
-			msg.msg.ID = m.messageCount + m.newMsgCount
-			m.pauseBuffer = append(m.pauseBuffer, msg.msg)
-			// Cap pause buffer
-			if len(m.pauseBuffer) > m.config.MessageLimit() {
-				m.pauseBuffer = m.pauseBuffer[1:]
-			}
+			cmds = append(cmds, m.bufferPaused(msg.msg))
 		} else {
-			m.messageCount++
-			msg.msg.ID = m.messageCount
-			m.messages = append(m.messages, msg.msg)
-			// Keep max messages
-			if len(m.messages) > m.config.MessageLimit() {
-				// Update bookmarks when removing old messages
-				newBookmarks := make(map[int]bool)
-				for id := range m.bookmarks {
-					if id > 1 {
-						newBookmarks[id-1] = true
-					}
-				}
-				m.bookmarks = newBookmarks
-				m.messages = m.messages[1:]
-				if m.selectedIdx > 0 {
-					m.selectedIdx--
-				}
-			}
+			m.appendLive(msg.msg)
 			// Auto-scroll to latest if at bottom
-			if m.selectedIdx == len(m.messages)-2 {
-				m.selectedIdx = len(m.messages) - 1
+			if m.liveRing == nil && m.selectedIdx == m.messages.Len()-2 {
+				m.selectedIdx = m.messages.Len() - 1
+			}
+			if pause {
+				m.paused = true
+				_, rule := m.alerts.Fired()
+				cmds = append(cmds, m.setStatusMsg("Paused by alert "+rule))
 			}
-		}
-		// Re-apply filter for new message
-		if m.filterActive && m.filterExpr != "" {
-			m.filteredIdx = computeFilteredIndices(m.messages, m.filterExpr)
 		}
 		cmds = append(cmds, m.waitForMessage())
 
@@ -512,12 +566,72 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	case clearStatusMsg:
 		m.statusMsg = ""
+
+	case replayPageMsg:
+		if m.pager != nil && msg.seq == m.pager.seq {
+			cmds = append(cmds, m.applyReplayPage(msg))
+		}
+
+	case alertCommandMsg:
+		if msg.err != nil {
+			cmds = append(cmds, m.setStatusMsg(msg.err.Error()))
+		}
+
+	case annotationSavedMsg:
+		if msg.err != nil {
+			cmds = append(cmds, m.setStatusMsg("Annotation not saved: "+msg.err.Error()))
+		}
+
+	case groupResultMsg:
+		if m.groups != nil && m.groups.seq == msg.seq {
+			m.groups.loading = false
+			m.groups.err = msg.err
+			m.groups.groups = msg.groups
+			m.groups.setTotal()
+		}
+
+	case traceResultMsg:
+		if m.trace != nil && m.trace.keys == msg.keys {
+			m.trace.loading = false
+			m.trace.err = msg.err
+			if msg.err == nil && m.messages.Len() > m.selectedIdx {
+				m.trace.setEntries(msg.entries, m.messages.At(m.selectedIdx).ID)
+			}
+		}
+
+	case alarmsPolledMsg:
+		if msg.id == m.alarmPollID {
+			if msg.err == nil {
+				m.alarms = msg.alarms
+			}
+			cmds = append(cmds, m.scheduleAlarmPoll())
+		}
+
+	case chartTickMsg:
+		if m.showCharts && msg.id == m.chartTickID {
+			cmds = append(cmds, m.scheduleChartTick())
+		}
+
+	case alarmPollTickMsg:
+		if msg.id == m.alarmPollID {
+			cmds = append(cmds, m.pollAlarms())
+		}
 	}
 
 	return m, tea.Batch(cmds...)
 }
 
-func (m *model) moveBy(delta int) {
+func (m *model) moveBy(delta int) tea.Cmd {
+	if m.liveRing != nil {
+		return m.historyMoveTo(m.position() + int64(delta))
+	}
+	if m.pager != nil {
+		return m.replayMoveTo(m.position() + int64(delta))
+	}
+	if m.selectedIdx+delta < 0 && m.canPageHistory() {
+		return m.enterHistory(int64(-(m.selectedIdx + delta)))
+	}
+
 	var newIdx int
 
 	if len(m.filteredIdx) > 0 {
@@ -538,8 +652,8 @@ func (m *model) moveBy(delta int) {
 		if newIdx < 0 {
 			newIdx = 0
 		}
-		if newIdx >= len(m.messages) {
-			newIdx = len(m.messages) - 1
+		if newIdx >= m.messages.Len() {
+			newIdx = m.messages.Len() - 1
 		}
 		if newIdx < 0 {
 			newIdx = 0
@@ -557,62 +671,207 @@ func (m *model) moveBy(delta int) {
 	if m.config.AutoPauseOnSelect && delta != 0 {
 		m.paused = true
 	}
+	return nil
 }
 
-func (m model) visibleItems() int {
-	// Account for borders (2) in message list
-	items := m.height - 6
-	if items < 1 {
-		return 1
+// appendLive adds a delivery to the live list. Once the list is full each one
+// evicts the oldest message, which stays reachable from the store (see
+// enterHistory). The filter, search and group view follow without going
+// over the list again.
+func (m *model) appendLive(msg Message) {
+	m.messageCount++
+	msg.ID = m.messageCount
+	if m.liveRing != nil {
+		// History is shown: the live list is set aside with its selection
+		if evicted, ok := m.liveRing.Push(msg); ok {
+			m.extendHistory(evicted)
+		}
+		return
 	}
-	return items
+	if evicted, ok := m.messages.Push(msg); ok {
+		if m.selectedIdx > 0 {
+			m.selectedIdx--
+		}
+		m.evicted(evicted)
+	}
+	m.listed(msg, m.messages.Len()-1)
 }
 
-func (m *model) performSearch() {
-	m.searchResults = nil
-	m.searchResultIdx = 0
-	if m.searchQuery == "" {
-		return
+// evicted moves the positions kept into the list up by one after its oldest
+// message was evicted.
+func (m *model) evicted(msg Message) {
+	visible := true
+	if m.filterActive && m.filterExpr != "" {
+		m.filteredIdx, visible = shiftOut(m.filteredIdx)
+	}
+	var hit bool
+	if m.searchResults, hit = shiftOut(m.searchResults); hit && m.searchResultIdx > 0 {
+		m.searchResultIdx--
 	}
+	if g := m.groups; g != nil && g.live != nil && visible {
+		g.live.remove(msg)
+	}
+}
 
-	// Parse field prefix (e.g. "rk:country", "body:alice", "re:pattern")
-	field, query := parseSearchQuery(m.searchQuery)
+// listed adds the message appended at position i to the filter's matches
+// and the group view.
+func (m *model) listed(msg Message, i int) {
+	visible := true
+	if m.filterActive && m.filterExpr != "" {
+		// An invalid filter matches nothing, and so hides nothing
+		if f := m.liveFilter.compile(m.filterExpr); f != nil && f.Match(*msg.queryMessage()) {
+			m.filteredIdx = append(m.filteredIdx, i)
+		} else {
+			visible = f == nil
+		}
+	}
+	if g := m.groups; g != nil && g.live != nil {
+		if visible {
+			g.live.add(msg)
+		}
+		g.groups = g.live.stats()
+		g.setTotal()
+	}
+}
 
-	var re *regexp.Regexp
-	if field == "re" {
-		var err error
-		re, err = compileSearchRegex(query)
-		if err != nil {
-			m.setStatusMsg("Invalid regex: " + err.Error())
-			return
+// compiledFilter caches the filter expression compiled, to match deliveries
+// against as they arrive.
+type compiledFilter struct {
+	expr   string
+	filter *filter.Filter // nil if expr is invalid
+}
+
+// compile returns expr compiled, compiling it only when it changed.
+func (c *compiledFilter) compile(expr string) *filter.Filter {
+	if c.expr != expr {
+		c.expr, c.filter = expr, nil
+		if f, err := filter.New(expr); err == nil {
+			c.filter = f
 		}
-	} else {
-		query = strings.ToLower(query)
 	}
+	return c.filter
+}
 
-	for i, msg := range m.messages {
-		if matchesSearch(msg, field, query, re) {
-			m.searchResults = append(m.searchResults, i)
+// shiftOut drops position 0 from sorted positions into the list and moves the
+// rest up by one, reporting whether 0 was among them.
+func shiftOut(positions []int) ([]int, bool) {
+	dropped := len(positions) > 0 && positions[0] == 0
+	if dropped {
+		positions = positions[1:]
+	}
+	for i := range positions {
+		positions[i]--
+	}
+	return positions, dropped
+}
+
+// bufferPaused holds a delivery until the consumer resumes. A full buffer
+// applies the configured overflow policy.
+func (m *model) bufferPaused(msg Message) tea.Cmd {
+	m.newMsgCount++
+	if len(m.pauseBuffer) >= m.config.PauseLimit() {
+		switch m.config.PauseOverflow {
+		case PauseDropNewest:
+			m.pauseDropped++
+			return nil
+		case PauseResume:
+			m.pauseBuffer = append(m.pauseBuffer, msg)
+			n := len(m.pauseBuffer)
+			m.resume()
+			return m.setStatusMsg(fmt.Sprintf("Pause buffer full: resumed with %d messages", n))
+		default:
+			m.pauseBuffer = m.pauseBuffer[1:]
+			m.pauseDropped++
 		}
 	}
+	m.pauseBuffer = append(m.pauseBuffer, msg)
+	return nil
+}
+
+// resume unpauses, merging the buffered deliveries into the list.
+func (m *model) resume() {
+	m.paused = false
+	for _, buffered := range m.pauseBuffer {
+		m.appendLive(buffered)
+	}
+	m.pauseBuffer = m.pauseBuffer[:0]
+	m.newMsgCount = 0
+	m.pauseDropped = 0
+}
+
+func (m model) visibleItems() int {
+	// Account for borders (2) in message list
+	_, listHeight, _, _ := m.panes()
+	return max(listHeight-1, 1)
+}
+
+// panes returns the sizes of the message list and the detail panel: side by
+// side, or one above the other with a horizontal split.
+func (m model) panes() (listWidth, listHeight, detailWidth, detailHeight int) {
+	// Content height: total - header(3) - status(1) - help(1)
+	contentHeight := max(m.height-5, 3)
+	if m.config.Split == config.SplitHorizontal {
+		// Both span the width, as the header does; the second pair of
+		// borders comes out of the height
+		width := max(m.width-2, 20)
+		listHeight = max(int(float64(contentHeight-2)*m.splitRatio), 3)
+		detailHeight = max(contentHeight-2-listHeight, 5)
+		return width, listHeight, width, detailHeight
+	}
+	listWidth = max(int(float64(m.width)*m.splitRatio), 20)
+	detailWidth = max(m.width-listWidth-1, 20)
+	return listWidth, contentHeight, detailWidth, contentHeight
+}
+
+func (m *model) performSearch() tea.Cmd {
+	m.searchResults = nil
+	m.searchResultIdx = 0
+	m.leaveHistory(0)
+	if m.pager != nil {
+		return m.replaySearch()
+	}
+	if m.searchQuery == "" {
+		return nil
+	}
+
+	if err := m.matchSearch(); err != nil {
+		return m.setStatusMsg("Invalid search: " + err.Error())
+	}
 
 	// Jump to first result
 	if len(m.searchResults) > 0 {
 		m.selectedIdx = m.searchResults[0]
 		m.detailViewport.YOffset = 0
 	}
+	return nil
+}
+
+// matchSearch finds the live messages matching the search query among those
+// the filter shows, leaving the selection alone.
+func (m *model) matchSearch() error {
+	m.searchResults = nil
+	m.searchResultIdx = 0
+	if m.searchQuery == "" || m.pager != nil {
+		return nil
+	}
+	// Field prefixes (e.g. "rk:country", "re:pattern") or a structured query
+	f, err := filter.New(m.searchQuery)
+	if err != nil {
+		return err
+	}
+	for i, msg := range m.messages.All() {
+		if (len(m.filteredIdx) == 0 || isVisible(m.filteredIdx, i)) && f.Match(*msg.queryMessage()) {
+			m.searchResults = append(m.searchResults, i)
+		}
+	}
+	return nil
 }
 
 // parseSearchQuery extracts an optional field prefix from a search query.
 // Supported prefixes: rk:, body:, ex:, hdr:, type:, re:
 // Returns ("", query) for unprefixed queries.
 func parseSearchQuery(q string) (field, query string) {
-	for _, prefix := range []string{"rk:", "body:", "ex:", "hdr:", "type:", "re:"} {
-		if strings.HasPrefix(q, prefix) {
-			return prefix[:len(prefix)-1], q[len(prefix):]
-		}
-	}
-	return "", q
+	return filter.ParseSearch(q)
 }
 
 // compileSearchRegex compiles a regex pattern for search.
@@ -620,63 +879,37 @@ func compileSearchRegex(pattern string) (*regexp.Regexp, error) {
 	return regexp.Compile(pattern)
 }
 
+// matchesSearch reports whether msg matches a text search, as the filter
+// package matches it; query must be lower case.
 func matchesSearch(msg Message, field, query string, re *regexp.Regexp) bool {
-	switch field {
-	case "re":
-		if re == nil {
-			return false
-		}
-		if re.MatchString(msg.RoutingKey) {
-			return true
-		}
-		if msg.Decoded != nil {
-			bodyJSON, _ := json.Marshal(msg.Decoded)
-			return re.Match(bodyJSON)
-		}
-		return false
-	case "rk":
-		return strings.Contains(strings.ToLower(msg.RoutingKey), query)
-	case "body":
-		if msg.Decoded != nil {
-			bodyJSON, _ := json.Marshal(msg.Decoded)
-			return strings.Contains(strings.ToLower(string(bodyJSON)), query)
-		}
-		return false
-	case "ex":
-		return strings.Contains(strings.ToLower(msg.Exchange), query)
-	case "hdr":
-		if len(msg.Headers) > 0 {
-			hdrJSON, _ := json.Marshal(msg.Headers)
-			return strings.Contains(strings.ToLower(string(hdrJSON)), query)
-		}
-		return false
-	case "type":
-		return strings.Contains(strings.ToLower(msg.ProtoType), query)
-	default:
-		// Unprefixed: search routing key + body (original behavior)
-		if strings.Contains(strings.ToLower(msg.RoutingKey), query) {
-			return true
-		}
-		if msg.Decoded != nil {
-			bodyJSON, _ := json.Marshal(msg.Decoded)
-			return strings.Contains(strings.ToLower(string(bodyJSON)), query)
-		}
-		return false
-	}
+	return filter.MatchText(*msg.queryMessage(), field, query, re)
 }
 
-func (m *model) nextSearchResult() {
+func (m *model) nextSearchResult() tea.Cmd {
+	if m.pager != nil {
+		if m.pager.search == "" || m.pager.matches == 0 {
+			return nil
+		}
+		return m.replaySearchStep(true, false)
+	}
 	if len(m.searchResults) == 0 {
-		return
+		return nil
 	}
 	m.searchResultIdx = (m.searchResultIdx + 1) % len(m.searchResults)
 	m.selectedIdx = m.searchResults[m.searchResultIdx]
 	m.detailViewport.YOffset = 0
+	return nil
 }
 
-func (m *model) prevSearchResult() {
+func (m *model) prevSearchResult() tea.Cmd {
+	if m.pager != nil {
+		if m.pager.search == "" || m.pager.matches == 0 {
+			return nil
+		}
+		return m.replaySearchStep(false, false)
+	}
 	if len(m.searchResults) == 0 {
-		return
+		return nil
 	}
 	m.searchResultIdx--
 	if m.searchResultIdx < 0 {
@@ -684,36 +917,30 @@ func (m *model) prevSearchResult() {
 	}
 	m.selectedIdx = m.searchResults[m.searchResultIdx]
 	m.detailViewport.YOffset = 0
+	return nil
 }
 
-func (m *model) toggleBookmark() {
-	if len(m.messages) == 0 {
-		return
-	}
-	msgID := m.messages[m.selectedIdx].ID
-	if m.bookmarks[msgID] {
-		delete(m.bookmarks, msgID)
-	} else {
-		m.bookmarks[msgID] = true
+func (m *model) toggleBookmark() tea.Cmd {
+	if m.messages.Len() == 0 || m.selectedIdx >= m.messages.Len() {
+		return nil
 	}
+	msg := m.messages.At(m.selectedIdx)
+	msg.Bookmarked = !msg.Bookmarked
+	return m.saveAnnotation(*msg)
 }
 
 func (m *model) nextBookmark() {
-	if len(m.bookmarks) == 0 {
-		return
-	}
-
 	// Find next bookmarked message after current position
-	for i := m.selectedIdx + 1; i < len(m.messages); i++ {
-		if m.bookmarks[m.messages[i].ID] {
+	for i := m.selectedIdx + 1; i < m.messages.Len(); i++ {
+		if m.messages.At(i).Bookmarked {
 			m.selectedIdx = i
 			m.detailViewport.YOffset = 0
 			return
 		}
 	}
 	// Wrap around
-	for i := 0; i <= m.selectedIdx; i++ {
-		if m.bookmarks[m.messages[i].ID] {
+	for i := 0; i <= m.selectedIdx && i < m.messages.Len(); i++ {
+		if m.messages.At(i).Bookmarked {
 			m.selectedIdx = i
 			m.detailViewport.YOffset = 0
 			return
@@ -722,11 +949,12 @@ func (m *model) nextBookmark() {
 }
 
 func (m *model) yankMessage() tea.Cmd {
-	if len(m.messages) == 0 || m.selectedIdx >= len(m.messages) {
+	if m.messages.Len() == 0 || m.selectedIdx >= m.messages.Len() {
 		return nil
 	}
 
-	msg := m.messages[m.selectedIdx]
+	m.decodeSelected()
+	msg := *m.messages.At(m.selectedIdx)
 
 	type yankMessage struct {
 		RoutingKey string         `json:"routing_key"`
@@ -758,10 +986,11 @@ func (m *model) yankMessage() tea.Cmd {
 }
 
 func (m *model) yankTab() tea.Cmd {
-	if len(m.messages) == 0 || m.selectedIdx >= len(m.messages) {
+	if m.messages.Len() == 0 || m.selectedIdx >= m.messages.Len() {
 		return nil
 	}
-	msg := m.messages[m.selectedIdx]
+	m.decodeSelected()
+	msg := *m.messages.At(m.selectedIdx)
 
 	switch m.detailTab {
 	case 0: // Body
@@ -803,7 +1032,7 @@ func (m *model) yankTab() tea.Cmd {
 }
 
 func (m *model) exportMessages() tea.Cmd {
-	if len(m.messages) == 0 {
+	if m.messages.Len() == 0 {
 		return m.setStatusMsg("No messages to export")
 	}
 
@@ -814,11 +1043,16 @@ func (m *model) exportMessages() tea.Cmd {
 		Timestamp  time.Time      `json:"timestamp"`
 		Headers    map[string]any `json:"headers,omitempty"`
 		Body       any            `json:"body,omitempty"`
+		Bookmarked bool           `json:"bookmarked,omitempty"`
+		Note       string         `json:"note,omitempty"`
+		Tags       []string       `json:"tags,omitempty"`
 		RawBody    string         `json:"raw_body"`
 	}
 
-	exports := make([]exportMessage, len(m.messages))
-	for i, msg := range m.messages {
+	exports := make([]exportMessage, m.messages.Len())
+	for i := range m.messages.Len() {
+		decodeLazy(m.messages.At(i), m.config.Decoder)
+		msg := *m.messages.At(i)
 		exports[i] = exportMessage{
 			ID:         msg.ID,
 			RoutingKey: msg.RoutingKey,
@@ -826,6 +1060,9 @@ func (m *model) exportMessages() tea.Cmd {
 			Timestamp:  msg.Timestamp,
 			Headers:    msg.Headers,
 			Body:       msg.Decoded,
+			Bookmarked: msg.Bookmarked,
+			Note:       msg.Note,
+			Tags:       msg.Tags,
 			RawBody:    base64.StdEncoding.EncodeToString(msg.RawBody),
 		}
 	}
@@ -854,7 +1091,7 @@ func (m *model) exportMessages() tea.Cmd {
 }
 
 func (m *model) exportCSV() tea.Cmd {
-	if len(m.messages) == 0 {
+	if m.messages.Len() == 0 {
 		return m.setStatusMsg("No messages to export")
 	}
 
@@ -863,7 +1100,10 @@ func (m *model) exportCSV() tea.Cmd {
 		return m.setStatusMsg("Export failed: " + err.Error())
 	}
 
-	exportPath, err := writeCSVExport(m.messages, filepath.Join(dataDir, "exports"))
+	for i := range m.messages.Len() {
+		decodeLazy(m.messages.At(i), m.config.Decoder)
+	}
+	exportPath, err := writeCSVExport(m.messages.Messages(), filepath.Join(dataDir, "exports"))
 	if err != nil {
 		return m.setStatusMsg("Export failed: " + err.Error())
 	}
@@ -945,22 +1185,20 @@ func (m model) View() string {
 		return m.renderHelpOverlay()
 	}
 
-	// Calculate content height: total - header(3) - status(1) - help(1)
-	contentHeight := m.height - 5
-	if contentHeight < 3 {
-		contentHeight = 3
+	if m.trace != nil {
+		return m.renderTrace()
 	}
 
-	// Calculate widths
-	listWidth := int(float64(m.width) * m.splitRatio)
-	if listWidth < 20 {
-		listWidth = 20
+	if m.groups != nil {
+		return m.renderGroups()
 	}
-	detailWidth := m.width - listWidth - 1
-	if detailWidth < 20 {
-		detailWidth = 20
+
+	if m.filterPicker != nil {
+		return m.renderFilterPicker()
 	}
 
+	listWidth, listHeight, detailWidth, detailHeight := m.panes()
+
 	// Header
 	header := headerStyle.Width(m.width - 2).Render("rabbithole")
 
@@ -968,10 +1206,20 @@ func (m model) View() string {
 	status := m.renderStatusBar()
 
 	// Main content
-	messageList := m.renderMessageList(listWidth, contentHeight)
-	detailPanel := m.renderDetailPanel(detailWidth, contentHeight)
+	messageList := m.renderMessageList(listWidth, listHeight)
+	var detailPanel string
+	if m.showCharts {
+		detailPanel = m.renderCharts(detailWidth, detailHeight)
+	} else {
+		detailPanel = m.renderDetailPanel(detailWidth, detailHeight)
+	}
 
-	content := lipgloss.JoinHorizontal(lipgloss.Top, messageList, detailPanel)
+	var content string
+	if m.config.Split == config.SplitHorizontal {
+		content = lipgloss.JoinVertical(lipgloss.Left, messageList, detailPanel)
+	} else {
+		content = lipgloss.JoinHorizontal(lipgloss.Top, messageList, detailPanel)
+	}
 
 	// Help bar, search bar, or filter bar
 	var bottomBar string
@@ -979,6 +1227,8 @@ func (m model) View() string {
 		bottomBar = m.renderSearchBar()
 	} else if m.filterMode {
 		bottomBar = m.renderFilterBar()
+	} else if m.annotating != annotateNone {
+		bottomBar = m.renderAnnotateBar()
 	} else {
 		bottomBar = m.renderHelpBar()
 	}
@@ -1011,24 +1261,52 @@ func (m model) renderStatusBar() string {
 		}
 	}
 
+	// Resource alarms block publishers, which often explains a quiet consumer
+	if len(m.alarms) > 0 {
+		left = append(left, alarmStyle.Render("⚠ "+strings.Join(m.alarms, ", ")))
+	}
+
 	// Paused indicator (only when paused)
 	if m.paused {
 		pause := disconnectedStyle.Render("PAUSED")
 		if m.newMsgCount > 0 {
 			pause += " " + newMsgStyle.Render(fmt.Sprintf("+%d new", m.newMsgCount))
 		}
+		if m.pauseDropped > 0 {
+			pause += " " + mutedStyle.Render(fmt.Sprintf("(%d dropped)", m.pauseDropped))
+		}
 		left = append(left, pause)
 	}
 
+	// Messages that matched an alert rule
+	if fired, rule := m.alerts.Fired(); fired > 0 {
+		left = append(left, alarmStyle.Render(fmt.Sprintf("⚑ %d alerts (%s)", fired, rule)))
+	}
+
+	// Diff base, waiting for the message to compare it with
+	if m.diffBase != nil {
+		left = append(left, bookmarkStyle.Render(fmt.Sprintf("DIFF #%d", m.diffBase.ID)))
+	}
+
 	// Filter indicator (only when a filter expression exists)
 	if m.filterActive && m.filterExpr != "" {
-		left = append(left, disconnectedStyle.Render(fmt.Sprintf("FILTER: %s (%d)", m.filterExpr, len(m.filteredIdx))))
+		count := int64(len(m.filteredIdx))
+		if m.pager != nil {
+			count = m.pager.total
+		}
+		left = append(left, disconnectedStyle.Render(fmt.Sprintf("FILTER: %s (%d)", m.filterExpr, count)))
 	} else if m.filterExpr != "" {
 		left = append(left, mutedStyle.Render(fmt.Sprintf("filter off: %s", m.filterExpr)))
 	}
 
 	// Search results indicator (only during active search)
-	if m.searchQuery != "" {
+	if m.searchQuery != "" && m.pager != nil {
+		if m.pager.matches > 0 {
+			left = append(left, statusBarStyle.Render(fmt.Sprintf("%d matches", m.pager.matches)))
+		} else if !m.pager.loading {
+			left = append(left, mutedStyle.Render("no matches"))
+		}
+	} else if m.searchQuery != "" {
 		if len(m.searchResults) > 0 {
 			left = append(left, statusBarStyle.Render(fmt.Sprintf("%d/%d", m.searchResultIdx+1, len(m.searchResults))))
 		} else {
@@ -1051,16 +1329,20 @@ func (m model) renderStatusBar() string {
 
 	// Message count
 	historicalCount := 0
-	for _, msg := range m.messages {
+	for _, msg := range m.messages.All() {
 		if msg.Historical {
 			historicalCount++
 		}
 	}
-	liveCount := len(m.messages) - historicalCount
-	if historicalCount > 0 {
+	liveCount := m.messages.Len() - historicalCount
+	if m.liveRing != nil {
+		right = append(right, statusBarStyle.Render(m.historyStatus()))
+	} else if m.pager != nil {
+		right = append(right, statusBarStyle.Render(m.replayStatus()))
+	} else if historicalCount > 0 {
 		right = append(right, statusBarStyle.Render(fmt.Sprintf("%dH+%dL msgs", historicalCount, liveCount)))
 	} else {
-		right = append(right, statusBarStyle.Render(fmt.Sprintf("%d msgs", len(m.messages))))
+		right = append(right, statusBarStyle.Render(fmt.Sprintf("%d msgs", m.messages.Len())))
 	}
 
 	// Live stats (only when connected and have messages)
@@ -1099,7 +1381,17 @@ func (m model) renderMessageList(width, height int) string {
 	}
 
 	// Empty state
-	if len(m.messages) == 0 {
+	if m.messages.Len() == 0 && m.pager != nil {
+		text := "Loading session..."
+		if !m.pager.loading {
+			text = "No messages"
+			if m.filterActive {
+				text = "No matches for filter"
+			}
+		}
+		return messageListStyle.Width(width).Height(height).Render(emptyStateStyle.Render(text))
+	}
+	if m.messages.Len() == 0 {
 		emptyContent := strings.Join([]string{
 			"",
 			emptyStateStyle.Render("No messages yet"),
@@ -1107,7 +1399,7 @@ func (m model) renderMessageList(width, height int) string {
 			mutedStyle.Render(fmt.Sprintf("Watching: %s", m.config.Exchange)),
 			mutedStyle.Render(fmt.Sprintf("Routing: %s", m.config.RoutingKey)),
 			"",
-			mutedStyle.Render("Press ? for help"),
+			mutedStyle.Render("Press " + m.config.Keys.hint(config.KeysConsumer, "toggle_help") + " for help"),
 		}, "\n")
 		return messageListStyle.Width(width).Height(height).Render(emptyContent)
 	}
@@ -1117,7 +1409,7 @@ func (m model) renderMessageList(width, height int) string {
 	if len(m.filteredIdx) > 0 {
 		visible = m.filteredIdx
 	} else {
-		visible = make([]int, len(m.messages))
+		visible = make([]int, m.messages.Len())
 		for i := range visible {
 			visible[i] = i
 		}
@@ -1148,7 +1440,7 @@ func (m model) renderMessageList(width, height int) string {
 	innerWidth := width - 4 // Account for border and padding
 
 	for _, i := range visible[startPos:endPos] {
-		msg := m.messages[i]
+		msg := *m.messages.At(i)
 
 		// Source indicator: H=historical (from DB), L=live (from queue)
 		sourceIndicator := "L"
@@ -1164,7 +1456,7 @@ func (m model) renderMessageList(width, height int) string {
 
 		// Bookmark indicator
 		prefix := sourceIndicator + dlxIndicator
-		if m.bookmarks[msg.ID] {
+		if msg.Bookmarked {
 			prefix += "*"
 		} else if i == m.selectedIdx {
 			prefix += ">"
@@ -1177,19 +1469,14 @@ func (m model) renderMessageList(width, height int) string {
 			rk := truncate(msg.RoutingKey, innerWidth-3)
 			line = prefix + rk
 		} else {
-			var ts string
-			if m.timestampRel {
-				ts = formatRelativeTime(msg.Timestamp)
-			} else {
-				ts = msg.Timestamp.Format("15:04:05")
-			}
-			rk := truncate(msg.RoutingKey, innerWidth-12)
-			line = fmt.Sprintf("%s%s %s", prefix, ts, rk)
+			line = prefix + m.renderColumns(m.messages.At(i), innerWidth-3)
 		}
 
 		if i == m.selectedIdx {
 			line = selectedMessageStyle.Render(line)
-		} else if m.bookmarks[msg.ID] {
+		} else if msg.highlight != nil {
+			line = msg.highlight.Render(line)
+		} else if msg.Bookmarked {
 			line = bookmarkStyle.Render(line)
 		} else if isDLXMessage(msg) {
 			line = dlxStyle.Render(line)
@@ -1211,13 +1498,14 @@ func (m model) renderDetailPanel(width, height int) string {
 		innerHeight = 1
 	}
 
-	if len(m.messages) == 0 || m.selectedIdx >= len(m.messages) {
+	if m.messages.Len() == 0 || m.selectedIdx >= m.messages.Len() {
 		return detailPanelStyle.Width(width).Height(height).Render(
 			mutedStyle.Render("Select a message to view details"),
 		)
 	}
 
-	msg := m.messages[m.selectedIdx]
+	m.decodeSelected()
+	msg := *m.messages.At(m.selectedIdx)
 	innerWidth := width - 4
 
 	// Clamp tab index if DLX tab disappeared (switched to non-DLX message)
@@ -1240,6 +1528,12 @@ func (m model) renderDetailPanel(width, height int) string {
 	case 3:
 		lines = renderDLXTab(msg)
 	}
+	if d := m.diff; d != nil && sameMessage(d.b, msg) {
+		tabBar = d.renderTitle(innerWidth)
+		lines = d.renderLines()
+	} else if note := renderAnnotation(msg, innerWidth); note != "" {
+		lines = append([]string{note}, lines...)
+	}
 
 	// Split into individual lines for scrolling
 	allLines := strings.Split(strings.Join(lines, "\n"), "\n")
@@ -1274,7 +1568,7 @@ func (m model) renderDetailPanel(width, height int) string {
 }
 
 func (m model) tabCount() int {
-	if len(m.messages) > 0 && m.selectedIdx < len(m.messages) && isDLXMessage(m.messages[m.selectedIdx]) {
+	if m.messages.Len() > 0 && m.selectedIdx < m.messages.Len() && isDLXMessage(*m.messages.At(m.selectedIdx)) {
 		return 4
 	}
 	return 3
@@ -1321,6 +1615,15 @@ func metadataMap(msg Message) map[string]any {
 	if msg.ReplyTo != "" {
 		meta["reply_to"] = msg.ReplyTo
 	}
+	if msg.Bookmarked {
+		meta["bookmarked"] = true
+	}
+	if msg.Note != "" {
+		meta["note"] = msg.Note
+	}
+	if len(msg.Tags) > 0 {
+		meta["tags"] = msg.Tags
+	}
 	return meta
 }
 
@@ -1352,21 +1655,26 @@ func (m model) renderBodyTab(msg Message) []string {
 }
 
 func (m model) renderSearchBar() string {
-	return helpStyle.Render("Search: ") + m.searchInput.View() + helpStyle.Render("  (Enter to search, Esc to cancel)")
+	hint := "  (Enter to search, ↑/↓ history, Esc to cancel)"
+	if m.filterActive && m.filterExpr != "" {
+		hint = "  (within the filter; Enter to search, ↑/↓ history, Esc to cancel)"
+	}
+	return helpStyle.Render("Search: ") + m.searchInput.View() + helpStyle.Render(hint)
 }
 
 func (m model) renderFilterBar() string {
-	return helpStyle.Render("Filter: ") + m.filterInput.View() + helpStyle.Render("  (Enter to apply, Esc to cancel)")
+	return helpStyle.Render("Filter: ") + m.filterInput.View() + helpStyle.Render("  (Enter to apply, ↑/↓ history, Esc to cancel)")
 }
 
 func (m model) renderHelpBar() string {
+	km := m.config.Keys
 	keys := []struct{ key, desc string }{
-		{"j/k", "nav"},
-		{"/", "search"},
-		{"f", "filter"},
-		{"y", "copy"},
-		{"tab", "section"},
-		{"?", "help"},
+		{km.hint(config.KeysConsumer, "move_down", "move_up"), "nav"},
+		{km.hint(config.KeysConsumer, "search_start"), "search"},
+		{km.hint(config.KeysConsumer, "filter_start"), "filter"},
+		{km.hint(config.KeysConsumer, "yank_tab"), "copy"},
+		{km.hint(config.KeysConsumer, "next_tab"), "section"},
+		{km.hint(config.KeysConsumer, "toggle_help"), "help"},
 	}
 
 	var parts []string
@@ -1383,6 +1691,10 @@ func (m model) renderHelpOverlay() string {
 	lines = append(lines, fieldNameStyle.Render("Keybindings"))
 	lines = append(lines, "")
 
+	km := m.config.Keys
+	k := func(actions ...string) string { return km.helpKeys(config.KeysConsumer, actions...) }
+	count := "5" + km.hint(config.KeysConsumer, "move_down") + " / 10" + km.hint(config.KeysConsumer, "move_up")
+
 	sections := []struct {
 		name string
 		keys []struct{ key, desc string }
@@ -1390,53 +1702,64 @@ func (m model) renderHelpOverlay() string {
 		{
 			name: "Navigation",
 			keys: []struct{ key, desc string }{
-				{"j / k", "Move down / up"},
-				{"5j / 10k", "Move 5 down / 10 up"},
-				{"gg", "Go to top"},
-				{"G", "Go to bottom"},
-				{"Ctrl+U / Ctrl+D", "Half page up / down"},
-				{"Ctrl+F / Ctrl+B", "Full page up / down"},
+				{k("move_down", "move_up"), "Move down / up"},
+				{count, "Move 5 down / 10 up"},
+				{k("go_top"), "Go to top"},
+				{k("go_bottom"), "Go to bottom"},
+				{k("center_line"), "Center selected line"},
+				{k("half_page_up", "half_page_down"), "Half page up / down"},
+				{k("page_down", "page_up"), "Full page down / up"},
+				{k("detail_down", "detail_up"), "Scroll detail down / up"},
 			},
 		},
 		{
 			name: "Search & Filter",
 			keys: []struct{ key, desc string }{
-				{"/", "Start search (prefix: rk: body: ex: hdr: type: re:)"},
-				{"n / N", "Next / previous result"},
-				{"f", "Set filter (same prefixes as search)"},
-				{"F", "Toggle filter on/off"},
+				{k("search_start"), "Start search (prefix: rk: body: ex: hdr: type: re:)"},
+				{k("search_next", "search_prev"), "Next / previous result"},
+				{k("filter_start"), "Set filter (same prefixes as search)"},
+				{k("filter_toggle"), "Toggle filter on/off"},
+				{k("filter_pick"), "Pick a saved filter (1-9 applies directly)"},
+				{"↑ / ↓", "Recall earlier searches and filters while typing"},
 				{"Esc", "Clear search / cancel filter"},
 			},
 		},
 		{
 			name: "Actions",
 			keys: []struct{ key, desc string }{
-				{"y", "Copy active tab content (body / headers / routing key)"},
-				{"Y", "Copy full message to clipboard"},
-				{"e", "Export all messages to JSON"},
-				{"E", "Export all messages to CSV"},
-				{"m", "Toggle bookmark"},
-				{"'", "Jump to next bookmark"},
-				{"c", "Clear all messages"},
+				{k("yank_tab"), "Copy active tab content (body / headers / routing key)"},
+				{k("yank"), "Copy full message to clipboard"},
+				{k("export"), "Export all messages to JSON"},
+				{k("export_csv"), "Export all messages to CSV"},
+				{k("bookmark_toggle"), "Toggle bookmark"},
+				{k("bookmark_next"), "Jump to next bookmark"},
+				{k("annotate_note"), "Edit note on message"},
+				{k("annotate_tags"), "Edit tags on message"},
+				{k("trace"), "Trace conversation across sessions"},
+				{k("diff_mark"), "Mark message / diff against marked"},
+				{k("diff_previous"), "Diff against previous same entity"},
+				{k("group"), "Group messages by field"},
+				{k("clear"), "Clear all messages"},
 			},
 		},
 		{
 			name: "View",
 			keys: []struct{ key, desc string }{
-				{"Tab / S-Tab", "Switch detail section"},
-				{"r", "Toggle raw/decoded view"},
-				{"t", "Toggle compact mode"},
-				{"T", "Toggle timestamp format"},
-				{"H / L", "Resize panes left / right"},
-				{"?", "Toggle this help"},
+				{k("next_tab", "prev_tab"), "Switch detail section"},
+				{k("toggle_raw"), "Toggle raw/decoded view"},
+				{k("toggle_compact"), "Toggle compact mode"},
+				{k("toggle_timestamp"), "Toggle timestamp format"},
+				{k("toggle_charts"), "Toggle rate/latency charts"},
+				{k("resize_left", "resize_right"), "Shrink / grow the message list"},
+				{k("toggle_help"), "Toggle this help"},
 			},
 		},
 		{
 			name: "Control",
 			keys: []struct{ key, desc string }{
-				{"p / Space", "Pause / resume"},
-				{"b", "Back to browser"},
-				{"q / Ctrl+C", "Quit"},
+				{k("pause_toggle"), "Pause / resume"},
+				{k("back"), "Back to browser"},
+				{k("quit") + " / Ctrl+C", "Quit"},
 			},
 		},
 	}
@@ -1449,7 +1772,7 @@ func (m model) renderHelpOverlay() string {
 		lines = append(lines, "")
 	}
 
-	lines = append(lines, mutedStyle.Render("Press ? or Esc to close"))
+	lines = append(lines, mutedStyle.Render("Press "+k("toggle_help")+" or Esc to close"))
 
 	content := strings.Join(lines, "\n")
 
@@ -106,7 +106,7 @@ func TestMoveBy(t *testing.T) {
 			msgs[i] = Message{ID: i + 1, RoutingKey: "test"}
 		}
 		return model{
-			messages:       msgs,
+			messages:       newMessageRing(0, msgs...),
 			selectedIdx:    selectedIdx,
 			detailViewport: viewport.New(80, 20),
 		}
@@ -166,11 +166,11 @@ func TestMoveBy(t *testing.T) {
 func TestPerformSearch(t *testing.T) {
 	makeModel := func() model {
 		return model{
-			messages: []Message{
+			messages: newMessageRing(0, []Message{
 				{ID: 1, RoutingKey: "order.created", Decoded: map[string]any{"id": 1, "status": "new"}},
 				{ID: 2, RoutingKey: "user.updated", Decoded: map[string]any{"name": "alice"}},
 				{ID: 3, RoutingKey: "order.shipped", Decoded: map[string]any{"id": 2, "status": "shipped"}},
-			},
+			}...),
 			detailViewport: viewport.New(80, 20),
 		}
 	}
@@ -266,10 +266,10 @@ func TestPerformSearch(t *testing.T) {
 
 	t.Run("field prefix ex: searches exchange only", func(t *testing.T) {
 		m := model{
-			messages: []Message{
+			messages: newMessageRing(0, []Message{
 				{ID: 1, Exchange: "events", RoutingKey: "order.created"},
 				{ID: 2, Exchange: "commands", RoutingKey: "user.updated"},
-			},
+			}...),
 			detailViewport: viewport.New(80, 20),
 		}
 		m.searchQuery = "ex:events"
@@ -284,10 +284,10 @@ func TestPerformSearch(t *testing.T) {
 
 	t.Run("field prefix hdr: searches headers", func(t *testing.T) {
 		m := model{
-			messages: []Message{
+			messages: newMessageRing(0, []Message{
 				{ID: 1, RoutingKey: "a", Headers: map[string]any{"x-trace-id": "abc123"}},
 				{ID: 2, RoutingKey: "b", Headers: map[string]any{"x-source": "web"}},
-			},
+			}...),
 			detailViewport: viewport.New(80, 20),
 		}
 		m.searchQuery = "hdr:abc123"
@@ -302,10 +302,10 @@ func TestPerformSearch(t *testing.T) {
 
 	t.Run("field prefix type: searches proto type", func(t *testing.T) {
 		m := model{
-			messages: []Message{
+			messages: newMessageRing(0, []Message{
 				{ID: 1, RoutingKey: "a", ProtoType: "CountryUpdated"},
 				{ID: 2, RoutingKey: "b", ProtoType: "UserCreated"},
-			},
+			}...),
 			detailViewport: viewport.New(80, 20),
 		}
 		m.searchQuery = "type:country"
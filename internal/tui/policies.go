@@ -0,0 +1,506 @@
+package tui
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/epalmerini/rabbithole/internal/rabbitmq"
+)
+
+// Policy form fields, in tab order.
+const (
+	policyFieldName = iota
+	policyFieldPattern
+	policyFieldApplyTo
+	policyFieldPriority
+	policyFieldDefinition
+	policyFieldKind
+	policyFieldCount
+)
+
+// maxPolicyPreview caps the number of matching resources listed in the form preview.
+const maxPolicyPreview = 8
+
+type policiesLoadedMsg struct {
+	policies []rabbitmq.Policy
+}
+
+type policySavedMsg struct {
+	name string
+}
+
+type policyDeletedMsg struct {
+	name string
+}
+
+// policyForm holds the create/edit policy dialog state.
+type policyForm struct {
+	editing         bool // editing an existing policy (name and kind are fixed)
+	kind            string
+	applyToIdx      int
+	focused         int
+	nameInput       textinput.Model
+	patternInput    textinput.Model
+	priorityInput   textinput.Model
+	definitionInput textinput.Model
+	err             error
+}
+
+func newPolicyForm(p *rabbitmq.Policy) policyForm {
+	name := textinput.New()
+	name.Placeholder = "my-policy"
+	name.CharLimit = 100
+	name.Width = 40
+
+	pattern := textinput.New()
+	pattern.Placeholder = "^orders\\."
+	pattern.CharLimit = 200
+	pattern.Width = 40
+
+	priority := textinput.New()
+	priority.Placeholder = "0"
+	priority.CharLimit = 6
+	priority.Width = 10
+
+	definition := textinput.New()
+	definition.Placeholder = `{"message-ttl": 60000}`
+	definition.CharLimit = 1000
+	definition.Width = 60
+
+	f := policyForm{
+		kind:            rabbitmq.PolicyKindUser,
+		nameInput:       name,
+		patternInput:    pattern,
+		priorityInput:   priority,
+		definitionInput: definition,
+	}
+
+	if p != nil {
+		f.editing = true
+		f.kind = p.Kind
+		f.nameInput.SetValue(p.Name)
+		f.patternInput.SetValue(p.Pattern)
+		f.priorityInput.SetValue(strconv.Itoa(p.Priority))
+		if def, err := json.Marshal(p.Definition); err == nil {
+			f.definitionInput.SetValue(string(def))
+		}
+		for i, v := range rabbitmq.PolicyApplyTo {
+			if v == p.ApplyTo {
+				f.applyToIdx = i
+			}
+		}
+		f.focused = policyFieldPattern
+	}
+
+	f.focusField()
+	return f
+}
+
+func (f *policyForm) focusField() {
+	f.nameInput.Blur()
+	f.patternInput.Blur()
+	f.priorityInput.Blur()
+	f.definitionInput.Blur()
+	switch f.focused {
+	case policyFieldName:
+		f.nameInput.Focus()
+	case policyFieldPattern:
+		f.patternInput.Focus()
+	case policyFieldPriority:
+		f.priorityInput.Focus()
+	case policyFieldDefinition:
+		f.definitionInput.Focus()
+	}
+}
+
+// nextField moves focus by delta, skipping fields that cannot change while editing.
+func (f *policyForm) nextField(delta int) {
+	for {
+		f.focused = (f.focused + delta + policyFieldCount) % policyFieldCount
+		if !f.editing || (f.focused != policyFieldName && f.focused != policyFieldKind) {
+			break
+		}
+	}
+	f.focusField()
+}
+
+// policy validates the form and builds the policy to save.
+func (f policyForm) policy() (rabbitmq.Policy, error) {
+	p := rabbitmq.Policy{
+		Name:    strings.TrimSpace(f.nameInput.Value()),
+		Pattern: f.patternInput.Value(),
+		ApplyTo: rabbitmq.PolicyApplyTo[f.applyToIdx],
+		Kind:    f.kind,
+	}
+	if p.Name == "" {
+		return p, fmt.Errorf("name is required")
+	}
+	if _, err := regexp.Compile(p.Pattern); err != nil {
+		return p, fmt.Errorf("invalid pattern: %w", err)
+	}
+	if v := strings.TrimSpace(f.priorityInput.Value()); v != "" {
+		prio, err := strconv.Atoi(v)
+		if err != nil {
+			return p, fmt.Errorf("priority must be an integer")
+		}
+		p.Priority = prio
+	}
+	if err := json.Unmarshal([]byte(f.definitionInput.Value()), &p.Definition); err != nil {
+		return p, fmt.Errorf("definition must be a JSON object: %w", err)
+	}
+	if len(p.Definition) == 0 {
+		return p, fmt.Errorf("definition must not be empty")
+	}
+	return p, nil
+}
+
+func (m browserModel) loadPolicies() tea.Cmd {
+	mgmt := m.mgmt
+	return func() tea.Msg {
+		if mgmt == nil {
+			return errorMsg{err: fmt.Errorf("management client not initialized")}
+		}
+
+		ctx := context.Background()
+		policies, err := mgmt.GetPolicies(ctx, mgmt.VHost())
+		if err != nil {
+			return errorMsg{err: fmt.Errorf("failed to load policies: %w", err)}
+		}
+
+		// Operator policies require elevated permissions; show user policies regardless
+		if ops, err := mgmt.GetOperatorPolicies(ctx, mgmt.VHost()); err == nil {
+			policies = append(policies, ops...)
+		}
+
+		rabbitmq.SortPolicies(policies)
+		return policiesLoadedMsg{policies: policies}
+	}
+}
+
+func (m browserModel) savePolicy(p rabbitmq.Policy) tea.Cmd {
+	mgmt := m.mgmt
+	return func() tea.Msg {
+		if mgmt == nil {
+			return errorMsg{err: fmt.Errorf("management client not initialized")}
+		}
+		if err := mgmt.PutPolicy(context.Background(), mgmt.VHost(), p); err != nil {
+			return errorMsg{err: fmt.Errorf("failed to save policy: %w", err)}
+		}
+		return policySavedMsg{name: p.Name}
+	}
+}
+
+func (m browserModel) deletePolicy(p rabbitmq.Policy) tea.Cmd {
+	mgmt := m.mgmt
+	return func() tea.Msg {
+		if mgmt == nil {
+			return errorMsg{err: fmt.Errorf("management client not initialized")}
+		}
+		if err := mgmt.DeletePolicy(context.Background(), mgmt.VHost(), p.Name, p.Kind); err != nil {
+			return errorMsg{err: fmt.Errorf("failed to delete policy: %w", err)}
+		}
+		return policyDeletedMsg{name: p.Name}
+	}
+}
+
+// updatePolicies handles keys in the policies list. handled is false for keys
+// that fall through to the shared browser navigation.
+func (m browserModel) updatePolicies(msg tea.KeyMsg) (_ browserModel, _ tea.Cmd, handled bool) {
+	if m.confirmPolicyDelete {
+		switch msg.String() {
+		case "enter", "y":
+			m.confirmPolicyDelete = false
+			if m.selectedIdx < len(m.policies) {
+				m.loading = true
+				return m, m.deletePolicy(m.policies[m.selectedIdx]), true
+			}
+		case "esc", "n":
+			m.confirmPolicyDelete = false
+		}
+		return m, nil, true
+	}
+
+	switch m.key(msg) {
+	case "n":
+		m.policyForm = newPolicyForm(nil)
+		m.view = viewPolicyEdit
+		return m, textinput.Blink, true
+	case "e", "enter":
+		if m.selectedIdx < len(m.policies) {
+			p := m.policies[m.selectedIdx]
+			m.policyForm = newPolicyForm(&p)
+			m.view = viewPolicyEdit
+			return m, textinput.Blink, true
+		}
+		return m, nil, true
+	case "d":
+		if m.selectedIdx < len(m.policies) {
+			m.confirmPolicyDelete = true
+		}
+		return m, nil, true
+	case "r":
+		m.loading = true
+		return m, m.loadPolicies(), true
+	case "esc", "backspace":
+		m.view = viewExchanges
+		m.selectedIdx = 0
+		m.scrollOff = 0
+		m.err = nil
+		return m, nil, true
+	}
+	return m, nil, false
+}
+
+// updatePolicyForm handles keys in the create/edit policy dialog.
+func (m browserModel) updatePolicyForm(msg tea.KeyMsg) (browserModel, tea.Cmd) {
+	f := &m.policyForm
+	switch msg.String() {
+	case "ctrl+c":
+		return m, tea.Quit
+	case "esc":
+		m.view = viewPolicies
+		return m, nil
+	case "tab", "down":
+		f.nextField(1)
+		return m, nil
+	case "shift+tab", "up":
+		f.nextField(-1)
+		return m, nil
+	case "enter":
+		p, err := f.policy()
+		if err != nil {
+			f.err = err
+			return m, nil
+		}
+		f.err = nil
+		m.view = viewPolicies
+		m.loading = true
+		return m, m.savePolicy(p)
+	}
+
+	switch f.focused {
+	case policyFieldApplyTo:
+		switch msg.String() {
+		case " ", "right", "l":
+			f.applyToIdx = (f.applyToIdx + 1) % len(rabbitmq.PolicyApplyTo)
+		case "left", "h":
+			f.applyToIdx = (f.applyToIdx + len(rabbitmq.PolicyApplyTo) - 1) % len(rabbitmq.PolicyApplyTo)
+		}
+		return m, nil
+	case policyFieldKind:
+		if msg.String() == " " {
+			if f.kind == rabbitmq.PolicyKindOperator {
+				f.kind = rabbitmq.PolicyKindUser
+			} else {
+				f.kind = rabbitmq.PolicyKindOperator
+			}
+		}
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	switch f.focused {
+	case policyFieldName:
+		f.nameInput, cmd = f.nameInput.Update(msg)
+	case policyFieldPattern:
+		f.patternInput, cmd = f.patternInput.Update(msg)
+	case policyFieldPriority:
+		f.priorityInput, cmd = f.priorityInput.Update(msg)
+	case policyFieldDefinition:
+		f.definitionInput, cmd = f.definitionInput.Update(msg)
+	}
+	return m, cmd
+}
+
+// policyPreview returns the names of queues and exchanges the form's pattern
+// and apply-to would match, formatted for display.
+func (m browserModel) policyPreview() ([]string, error) {
+	f := m.policyForm
+	if _, err := regexp.Compile(f.patternInput.Value()); err != nil {
+		return nil, err
+	}
+	p := rabbitmq.Policy{Pattern: f.patternInput.Value(), ApplyTo: rabbitmq.PolicyApplyTo[f.applyToIdx]}
+
+	var matches []string
+	for _, q := range m.queues {
+		if p.Matches(q.Name, false, q.Type) {
+			matches = append(matches, "queue    "+q.Name)
+		}
+	}
+	for _, ex := range m.exchanges {
+		if p.Matches(ex.Name, true, "") {
+			matches = append(matches, "exchange "+ex.Name)
+		}
+	}
+	sort.Strings(matches)
+	return matches, nil
+}
+
+func (m browserModel) renderPolicies() string {
+	var sb strings.Builder
+
+	sb.WriteString(fieldNameStyle.Render(fmt.Sprintf("Policies (vhost %s):", m.vhost())))
+	sb.WriteString("\n\n")
+
+	if m.loading {
+		sb.WriteString("  " + m.spinner.View() + " Loading...")
+		return messageListStyle.Width(m.width - 4).Height(m.height - 8).Render(sb.String())
+	}
+
+	if m.err != nil {
+		sb.WriteString(errorStyle.Render(fmt.Sprintf("  Error: %v", m.err)))
+		sb.WriteString("\n\n")
+	}
+
+	if len(m.policies) == 0 {
+		sb.WriteString(mutedStyle.Render("  No policies defined (press n to create one)"))
+		return messageListStyle.Width(m.width - 4).Height(m.height - 8).Render(sb.String())
+	}
+
+	sb.WriteString(mutedStyle.Render(fmt.Sprintf("    %-8s %-24s %-24s %-14s %4s  %s", "KIND", "NAME", "PATTERN", "APPLY-TO", "PRIO", "DEFINITION")))
+	sb.WriteString("\n")
+
+	visibleItems := m.height - 13
+	if visibleItems < 1 {
+		visibleItems = 1
+	}
+	startIdx := 0
+	if m.selectedIdx >= visibleItems {
+		startIdx = m.selectedIdx - visibleItems + 1
+	}
+	endIdx := startIdx + visibleItems
+	if endIdx > len(m.policies) {
+		endIdx = len(m.policies)
+	}
+
+	for i := startIdx; i < endIdx; i++ {
+		p := m.policies[i]
+		def, _ := json.Marshal(p.Definition)
+		line := fmt.Sprintf("%-8s %-24s %-24s %-14s %4d  %s",
+			p.Kind,
+			truncate(p.Name, 24),
+			truncate(p.Pattern, 24),
+			p.ApplyTo,
+			p.Priority,
+			truncate(string(def), max(m.width-96, 10)),
+		)
+
+		if i == m.selectedIdx {
+			hint := ""
+			if m.confirmPolicyDelete {
+				hint = errorStyle.Render("  [Enter to confirm delete, Esc to cancel]")
+			}
+			sb.WriteString(selectedMessageStyle.Width(m.width - 8).Render("▶ " + line + hint))
+		} else {
+			sb.WriteString(normalMessageStyle.Width(m.width - 8).Render("  " + line))
+		}
+		sb.WriteString("\n")
+	}
+
+	return messageListStyle.Width(m.width - 4).Height(m.height - 8).Render(sb.String())
+}
+
+func (m browserModel) renderPolicyForm() string {
+	f := m.policyForm
+	var sb strings.Builder
+
+	title := "New policy"
+	if f.editing {
+		title = fmt.Sprintf("Edit %s: %s", f.kind, f.nameInput.Value())
+	}
+	sb.WriteString(fieldNameStyle.Render(title))
+	sb.WriteString("\n\n")
+
+	label := func(field int, text string) string {
+		if f.focused == field {
+			return selectedMessageStyle.Render("▶ " + text)
+		}
+		return normalMessageStyle.Render("  " + text)
+	}
+
+	if !f.editing {
+		sb.WriteString(label(policyFieldName, "Name:       ") + f.nameInput.View() + "\n")
+	}
+	sb.WriteString(label(policyFieldPattern, "Pattern:    ") + f.patternInput.View() + "\n")
+	sb.WriteString(label(policyFieldApplyTo, "Apply to:   ") + "◀ " + rabbitmq.PolicyApplyTo[f.applyToIdx] + " ▶\n")
+	sb.WriteString(label(policyFieldPriority, "Priority:   ") + f.priorityInput.View() + "\n")
+	sb.WriteString(label(policyFieldDefinition, "Definition: ") + f.definitionInput.View() + "\n")
+	if !f.editing {
+		checkbox := "[ ]"
+		if f.kind == rabbitmq.PolicyKindOperator {
+			checkbox = "[x]"
+		}
+		sb.WriteString(label(policyFieldKind, "Operator:   ") + checkbox + "\n")
+	}
+	sb.WriteString("\n")
+
+	if f.err != nil {
+		sb.WriteString(errorStyle.Render("  " + f.err.Error()))
+		sb.WriteString("\n\n")
+	}
+
+	// Pattern preview
+	matches, err := m.policyPreview()
+	switch {
+	case err != nil:
+		sb.WriteString(errorStyle.Render(fmt.Sprintf("  Invalid pattern: %v", err)))
+	case len(matches) == 0:
+		sb.WriteString(mutedStyle.Render("  Matches no queues or exchanges"))
+	default:
+		sb.WriteString(fieldNameStyle.Render(fmt.Sprintf("  Matches %d:", len(matches))))
+		sb.WriteString("\n")
+		for i, name := range matches {
+			if i == maxPolicyPreview {
+				sb.WriteString(mutedStyle.Render(fmt.Sprintf("    ... and %d more", len(matches)-maxPolicyPreview)))
+				break
+			}
+			sb.WriteString(mutedStyle.Render("    " + name))
+			sb.WriteString("\n")
+		}
+	}
+	sb.WriteString("\n\n")
+
+	sb.WriteString(helpStyle.Render("Tab to switch fields, Space/←/→ to change apply-to, Enter to save, Esc to cancel"))
+
+	return detailPanelStyle.Width(m.width - 4).Height(m.height - 8).Render(sb.String())
+}
+
+// queueByName returns the loaded queue with the given name, or nil.
+func (m browserModel) queueByName(name string) *rabbitmq.Queue {
+	for i := range m.queues {
+		if m.queues[i].Name == name {
+			return &m.queues[i]
+		}
+	}
+	return nil
+}
+
+// effectivePolicyLine summarises the policies the broker applied to a queue.
+func effectivePolicyLine(q *rabbitmq.Queue) string {
+	if q == nil {
+		return ""
+	}
+	var parts []string
+	if q.Policy != "" {
+		parts = append(parts, "policy "+q.Policy)
+	}
+	if q.OperatorPolicy != "" {
+		parts = append(parts, "operator "+q.OperatorPolicy)
+	}
+	if len(parts) == 0 {
+		return "no policy"
+	}
+	line := strings.Join(parts, ", ")
+	if len(q.EffectivePolicyDefinition) > 0 {
+		def, _ := json.Marshal(q.EffectivePolicyDefinition)
+		line += " " + string(def)
+	}
+	return line
+}
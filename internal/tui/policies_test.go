@@ -0,0 +1,144 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/epalmerini/rabbithole/internal/rabbitmq"
+)
+
+func TestPolicyForm_Validation(t *testing.T) {
+	tests := []struct {
+		name       string
+		policyName string
+		pattern    string
+		priority   string
+		definition string
+		wantErr    string
+	}{
+		{"valid", "ttl", "^orders", "5", `{"message-ttl":1000}`, ""},
+		{"missing name", "", ".*", "", `{"a":1}`, "name is required"},
+		{"invalid regex", "p", "(", "", `{"a":1}`, "invalid pattern"},
+		{"non-numeric priority", "p", ".*", "high", `{"a":1}`, "priority must be an integer"},
+		{"invalid definition", "p", ".*", "", `not json`, "definition must be a JSON object"},
+		{"empty definition", "p", ".*", "", `{}`, "definition must not be empty"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newPolicyForm(nil)
+			f.nameInput.SetValue(tt.policyName)
+			f.patternInput.SetValue(tt.pattern)
+			f.priorityInput.SetValue(tt.priority)
+			f.definitionInput.SetValue(tt.definition)
+
+			p, err := f.policy()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if p.Name != tt.policyName || p.Priority != 5 || p.Definition["message-ttl"] != float64(1000) {
+					t.Errorf("unexpected policy: %+v", p)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("error = %v, want containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestPolicyForm_EditSkipsFixedFields(t *testing.T) {
+	f := newPolicyForm(&rabbitmq.Policy{Name: "ttl", Pattern: ".*", ApplyTo: "exchanges", Kind: rabbitmq.PolicyKindOperator})
+
+	if rabbitmq.PolicyApplyTo[f.applyToIdx] != "exchanges" {
+		t.Errorf("apply-to = %q, want exchanges", rabbitmq.PolicyApplyTo[f.applyToIdx])
+	}
+	for i := 0; i < policyFieldCount*2; i++ {
+		f.nextField(1)
+		if f.focused == policyFieldName || f.focused == policyFieldKind {
+			t.Fatalf("focused fixed field %d while editing", f.focused)
+		}
+	}
+}
+
+func TestBrowserPolicyPreview(t *testing.T) {
+	m := newBrowserModel(Config{})
+	m.queues = []rabbitmq.Queue{
+		{Name: "orders.created", Type: "classic"},
+		{Name: "orders.quorum", Type: "quorum"},
+		{Name: "users", Type: "classic"},
+	}
+	m.exchanges = []rabbitmq.Exchange{{Name: "orders"}}
+	m.policyForm = newPolicyForm(nil)
+	m.policyForm.patternInput.SetValue("^orders")
+
+	matches, err := m.policyPreview()
+	if err != nil {
+		t.Fatalf("policyPreview: %v", err)
+	}
+	// apply-to "all" matches both queues and the exchange
+	if len(matches) != 3 {
+		t.Errorf("expected 3 matches, got %v", matches)
+	}
+
+	// quorum_queues only matches the quorum queue
+	for i, v := range rabbitmq.PolicyApplyTo {
+		if v == "quorum_queues" {
+			m.policyForm.applyToIdx = i
+		}
+	}
+	matches, _ = m.policyPreview()
+	if len(matches) != 1 || !strings.Contains(matches[0], "orders.quorum") {
+		t.Errorf("expected only orders.quorum, got %v", matches)
+	}
+
+	m.policyForm.patternInput.SetValue("(")
+	if _, err := m.policyPreview(); err == nil {
+		t.Error("expected error for invalid pattern")
+	}
+}
+
+func TestBrowserPolicies_DeleteRequiresConfirmation(t *testing.T) {
+	m := newBrowserModel(Config{})
+	m.view = viewPolicies
+	m.loading = false
+	m.policies = []rabbitmq.Policy{{Name: "ttl", Kind: rabbitmq.PolicyKindUser}}
+
+	result, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'d'}})
+	m = result.(browserModel)
+	if cmd != nil {
+		t.Error("expected no command before confirmation")
+	}
+	if !m.confirmPolicyDelete {
+		t.Fatal("expected delete confirmation prompt")
+	}
+
+	result, _ = m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	m = result.(browserModel)
+	if m.confirmPolicyDelete {
+		t.Error("expected Esc to cancel confirmation")
+	}
+	if m.view != viewPolicies {
+		t.Errorf("view = %v, want viewPolicies", m.view)
+	}
+}
+
+func TestEffectivePolicyLine(t *testing.T) {
+	if got := effectivePolicyLine(&rabbitmq.Queue{}); got != "no policy" {
+		t.Errorf("got %q, want %q", got, "no policy")
+	}
+	q := &rabbitmq.Queue{
+		Policy:                    "ttl",
+		OperatorPolicy:            "limits",
+		EffectivePolicyDefinition: map[string]any{"max-length": float64(10)},
+	}
+	got := effectivePolicyLine(q)
+	for _, want := range []string{"policy ttl", "operator limits", `"max-length":10`} {
+		if !strings.Contains(got, want) {
+			t.Errorf("effectivePolicyLine() = %q, missing %q", got, want)
+		}
+	}
+}
@@ -0,0 +1,355 @@
+package tui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/epalmerini/rabbithole/internal/rabbitmq"
+)
+
+// Bulk queue actions.
+const (
+	bulkPurge  = "purge"
+	bulkDelete = "delete"
+)
+
+// bulkDoneMsg reports the outcome of a bulk purge or delete.
+type bulkDoneMsg struct {
+	action string
+	done   []string
+	err    error
+}
+
+// bulkOp is a pending bulk action awaiting confirmation.
+type bulkOp struct {
+	action  string
+	queues  []string
+	back    browserView // view to return to afterwards
+	confirm textinput.Model
+}
+
+func newPatternInput() textinput.Model {
+	ti := textinput.New()
+	ti.Placeholder = "rabbithole-*"
+	ti.CharLimit = 200
+	ti.Width = 40
+	return ti
+}
+
+// toggleQueueSelection adds or removes a queue from the multi-selection.
+func (m *browserModel) toggleQueueSelection(name string) {
+	if m.selectedQueues[name] {
+		delete(m.selectedQueues, name)
+	} else {
+		m.selectedQueues[name] = true
+	}
+}
+
+// selectQueuesMatching adds every queue whose name matches the glob pattern
+// to the selection and returns how many matched.
+func (m *browserModel) selectQueuesMatching(pattern string) (int, error) {
+	if _, err := path.Match(pattern, ""); err != nil {
+		return 0, fmt.Errorf("invalid pattern %q: %w", pattern, err)
+	}
+	n := 0
+	for _, q := range m.queues {
+		if ok, _ := path.Match(pattern, q.Name); ok {
+			m.selectedQueues[q.Name] = true
+			n++
+		}
+	}
+	return n, nil
+}
+
+// selection returns the selected queue names shown in the current view, in
+// order, or fallback if none of them is. Queues selected in another view are
+// left out, so a bulk action never touches queues the user cannot see.
+func (m browserModel) selection(fallback string) []string {
+	var names []string
+	for _, name := range m.shownQueues() {
+		if m.selectedQueues[name] && !slices.Contains(names, name) {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		if fallback == "" {
+			return nil
+		}
+		return []string{fallback}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// shownQueues returns the queues listed in the current view: the queue list,
+// or the destinations of the bindings.
+func (m browserModel) shownQueues() []string {
+	var names []string
+	switch m.view {
+	case viewQueues:
+		for _, q := range m.queues {
+			names = append(names, q.Name)
+		}
+	case viewBindings:
+		for _, b := range m.bindings {
+			names = append(names, b.Destination)
+		}
+	}
+	return names
+}
+
+// startBulk opens the preview and confirmation for action on queues.
+func (m browserModel) startBulk(action string, queues []string) (browserModel, tea.Cmd) {
+	if len(queues) == 0 {
+		return m, nil
+	}
+	m.bulk = bulkOp{
+		action:  action,
+		queues:  queues,
+		back:    m.view,
+		confirm: textinput.New(),
+	}
+	m.bulk.confirm.Placeholder = action
+	m.bulk.confirm.CharLimit = 20
+	m.bulk.confirm.Width = 20
+	m.view = viewBulkConfirm
+	if m.config.Production {
+		m.bulk.confirm.Focus()
+		return m, textinput.Blink
+	}
+	return m, nil
+}
+
+func (m browserModel) runBulk(action string, queues []string) tea.Cmd {
+	mgmt := m.mgmt
+	return func() tea.Msg {
+		if mgmt == nil {
+			return errorMsg{err: fmt.Errorf("management client not initialized")}
+		}
+
+		ctx := context.Background()
+		var done []string
+		var errs []error
+		for _, q := range queues {
+			var err error
+			if action == bulkDelete {
+				err = mgmt.DeleteQueue(ctx, mgmt.VHost(), q)
+			} else {
+				err = mgmt.PurgeQueue(ctx, mgmt.VHost(), q)
+			}
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", q, err))
+				continue
+			}
+			done = append(done, q)
+		}
+		return bulkDoneMsg{action: action, done: done, err: errors.Join(errs...)}
+	}
+}
+
+// updateBulkConfirm handles keys on the bulk preview. Production profiles
+// must type the action name; others confirm with Enter.
+func (m browserModel) updateBulkConfirm(msg tea.KeyMsg) (browserModel, tea.Cmd) {
+	switch msg.String() {
+	case "ctrl+c":
+		return m, tea.Quit
+	case "esc":
+		m.view = m.bulk.back
+		m.bulk = bulkOp{}
+		return m, nil
+	case "enter":
+		if m.config.Production && strings.TrimSpace(m.bulk.confirm.Value()) != m.bulk.action {
+			m.notice = fmt.Sprintf("Type %q to confirm", m.bulk.action)
+			return m, nil
+		}
+		action, queues := m.bulk.action, m.bulk.queues
+		m.view = m.bulk.back
+		m.bulk = bulkOp{}
+		m.notice = ""
+		m.loading = true
+		return m, m.runBulk(action, queues)
+	}
+
+	if m.config.Production {
+		var cmd tea.Cmd
+		m.bulk.confirm, cmd = m.bulk.confirm.Update(msg)
+		return m, cmd
+	}
+	return m, nil
+}
+
+// updateQueues handles keys in the queue list. handled is false for keys
+// that fall through to the shared browser navigation.
+func (m browserModel) updateQueues(msg tea.KeyMsg) (_ browserModel, _ tea.Cmd, handled bool) {
+	if m.patternMode {
+		switch msg.String() {
+		case "esc":
+			m.patternMode = false
+			m.patternInput.Blur()
+		case "enter":
+			m.patternMode = false
+			m.patternInput.Blur()
+			n, err := m.selectQueuesMatching(m.patternInput.Value())
+			if err != nil {
+				m.notice = err.Error()
+			} else {
+				m.notice = fmt.Sprintf("%d queues match %q", n, m.patternInput.Value())
+			}
+		default:
+			var cmd tea.Cmd
+			m.patternInput, cmd = m.patternInput.Update(msg)
+			return m, cmd, true
+		}
+		return m, nil, true
+	}
+
+	current := ""
+	if m.selectedIdx < len(m.queues) {
+		current = m.queues[m.selectedIdx].Name
+	}
+
+	switch m.key(msg) {
+	case " ":
+		if current != "" {
+			m.toggleQueueSelection(current)
+		}
+		return m, nil, true
+	case "*":
+		m.patternMode = true
+		m.patternInput.SetValue("")
+		m.patternInput.Focus()
+		return m, textinput.Blink, true
+	case "a":
+		for _, q := range m.queues {
+			m.selectedQueues[q.Name] = true
+		}
+		return m, nil, true
+	case "u":
+		m.selectedQueues = make(map[string]bool)
+		return m, nil, true
+	case "p":
+		m, cmd := m.startBulk(bulkPurge, m.selection(current))
+		return m, cmd, true
+	case "d":
+		m, cmd := m.startBulk(bulkDelete, m.selection(current))
+		return m, cmd, true
+	case "c":
+		if current != "" {
+			m, cmd := m.showConsumers(consumerScope{queue: current}, viewQueues)
+			return m, cmd, true
+		}
+		return m, nil, true
+	case "esc", "backspace":
+		m.view = viewExchanges
+		m.selectedIdx = 0
+		m.scrollOff = 0
+		m.err = nil
+		return m, nil, true
+	}
+	return m, nil, false
+}
+
+func (m browserModel) renderQueues() string {
+	var sb strings.Builder
+
+	title := "Queues:"
+	if n := len(m.selectedQueues); n > 0 {
+		title = fmt.Sprintf("Queues (%d selected):", n)
+	}
+	sb.WriteString(fieldNameStyle.Render(title))
+	sb.WriteString("\n\n")
+
+	if m.renderListState(&sb) {
+		return messageListStyle.Width(m.width - 4).Height(m.height - 8).Render(sb.String())
+	}
+
+	if len(m.queues) == 0 {
+		sb.WriteString(mutedStyle.Render("  No queues found"))
+		return messageListStyle.Width(m.width - 4).Height(m.height - 8).Render(sb.String())
+	}
+
+	sb.WriteString(mutedStyle.Render(fmt.Sprintf("        %-40s %-8s %10s %9s  %s", "NAME", "TYPE", "MESSAGES", "CONSUMERS", "POLICY")))
+	sb.WriteString("\n")
+
+	// Two lines are kept for the selected queue's effective policy
+	startIdx, endIdx := listWindow(m.selectedIdx, len(m.queues), m.height-15)
+	for i := startIdx; i < endIdx; i++ {
+		q := m.queues[i]
+		line := fmt.Sprintf("%s %-40s %-8s %10d %9d  %s", checkbox(m.selectedQueues[q.Name]), truncate(q.Name, 40), q.Type, q.Messages, q.Consumers, queuePolicies(&q))
+		m.renderListLine(&sb, i, line)
+	}
+
+	if m.selectedIdx < len(m.queues) {
+		sb.WriteString("\n")
+		sb.WriteString(fieldNameStyle.Render("  Effective policy: "))
+		sb.WriteString(mutedStyle.Render(effectivePolicyLine(&m.queues[m.selectedIdx])))
+	}
+
+	return messageListStyle.Width(m.width - 4).Height(m.height - 8).Render(sb.String())
+}
+
+func (m browserModel) renderBulkConfirm() string {
+	var sb strings.Builder
+
+	b := m.bulk
+	sb.WriteString(errorStyle.Render(fmt.Sprintf("%s %d queue(s)", strings.ToUpper(b.action[:1])+b.action[1:], len(b.queues))))
+	sb.WriteString(mutedStyle.Render(fmt.Sprintf("  vhost %s", m.vhost())))
+	sb.WriteString("\n\n")
+
+	total := 0
+	visible := m.height - 16
+	for i, name := range b.queues {
+		msgs := 0
+		if q := m.queueByName(name); q != nil {
+			msgs = q.Messages
+		}
+		total += msgs
+		if i < visible {
+			sb.WriteString(fmt.Sprintf("  %-50s %s\n", truncate(name, 50), mutedStyle.Render(fmt.Sprintf("%d msgs", msgs))))
+		}
+	}
+	if len(b.queues) > visible && visible >= 0 {
+		sb.WriteString(mutedStyle.Render(fmt.Sprintf("  ... and %d more\n", len(b.queues)-visible)))
+	}
+	sb.WriteString("\n")
+	sb.WriteString(mutedStyle.Render(fmt.Sprintf("  %d messages will be lost", total)))
+	sb.WriteString("\n\n")
+
+	if m.config.Production {
+		sb.WriteString(errorStyle.Render(fmt.Sprintf("  Production profile: type %q to confirm: ", b.action)))
+		sb.WriteString(b.confirm.View())
+		sb.WriteString("\n")
+	}
+	sb.WriteString(helpStyle.Render("  Press Enter to confirm, Esc to cancel"))
+
+	return detailPanelStyle.Width(m.width - 4).Height(m.height - 8).Render(sb.String())
+}
+
+// queuePolicies names the policies applied to q for the queue list.
+func queuePolicies(q *rabbitmq.Queue) string {
+	var names []string
+	if q.Policy != "" {
+		names = append(names, q.Policy)
+	}
+	if q.OperatorPolicy != "" {
+		names = append(names, "op:"+q.OperatorPolicy)
+	}
+	if len(names) == 0 {
+		return "-"
+	}
+	return strings.Join(names, ", ")
+}
+
+func checkbox(checked bool) string {
+	if checked {
+		return "[x]"
+	}
+	return "[ ]"
+}
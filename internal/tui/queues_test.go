@@ -0,0 +1,187 @@
+package tui
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/epalmerini/rabbithole/internal/rabbitmq"
+)
+
+func newQueuesBrowser(production bool) browserModel {
+	m := newBrowserModel(Config{Production: production})
+	m.view = viewQueues
+	m.loading = false
+	m.queues = []rabbitmq.Queue{
+		{Name: "orders"},
+		{Name: "rabbithole-abc123"},
+		{Name: "rabbithole-def456"},
+	}
+	return m
+}
+
+func pressKey(m browserModel, key string) (browserModel, tea.Cmd) {
+	var msg tea.KeyMsg
+	switch key {
+	case "enter":
+		msg = tea.KeyMsg{Type: tea.KeyEnter}
+	case "esc":
+		msg = tea.KeyMsg{Type: tea.KeyEsc}
+	case " ":
+		msg = tea.KeyMsg{Type: tea.KeySpace, Runes: []rune{' '}}
+	default:
+		msg = tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(key)}
+	}
+	result, cmd := m.Update(msg)
+	return result.(browserModel), cmd
+}
+
+func TestSelectQueuesMatching(t *testing.T) {
+	m := newQueuesBrowser(false)
+
+	n, err := m.selectQueuesMatching("rabbithole-*")
+	if err != nil {
+		t.Fatalf("selectQueuesMatching: %v", err)
+	}
+	if n != 2 {
+		t.Errorf("matched %d, want 2", n)
+	}
+	want := []string{"rabbithole-abc123", "rabbithole-def456"}
+	if got := m.selection(""); !reflect.DeepEqual(got, want) {
+		t.Errorf("selection = %v, want %v", got, want)
+	}
+
+	if _, err := m.selectQueuesMatching("["); err == nil {
+		t.Error("expected error for malformed pattern")
+	}
+}
+
+func TestBrowserQueues_ToggleSelection(t *testing.T) {
+	m := newQueuesBrowser(false)
+
+	m, _ = pressKey(m, " ")
+	if !m.selectedQueues["orders"] {
+		t.Fatal("expected orders to be selected")
+	}
+	m, _ = pressKey(m, " ")
+	if m.selectedQueues["orders"] {
+		t.Error("expected second space to deselect")
+	}
+
+	// Without a selection, actions target the queue under the cursor
+	if got := m.selection("orders"); !reflect.DeepEqual(got, []string{"orders"}) {
+		t.Errorf("selection = %v, want [orders]", got)
+	}
+}
+
+func TestBrowserBulk_ConfirmWithEnter(t *testing.T) {
+	m := newQueuesBrowser(false)
+	m.selectedQueues["rabbithole-abc123"] = true
+
+	m, _ = pressKey(m, "p")
+	if m.view != viewBulkConfirm || m.bulk.action != bulkPurge {
+		t.Fatalf("expected purge preview, got view %v action %q", m.view, m.bulk.action)
+	}
+
+	m, cmd := pressKey(m, "enter")
+	if cmd == nil {
+		t.Fatal("expected purge command after confirmation")
+	}
+	if m.view != viewQueues {
+		t.Errorf("view = %v, want viewQueues", m.view)
+	}
+}
+
+func TestBrowserBulk_ProductionRequiresTypedConfirmation(t *testing.T) {
+	m := newQueuesBrowser(true)
+	m.selectedQueues["orders"] = true
+
+	m, _ = pressKey(m, "d")
+	if m.view != viewBulkConfirm {
+		t.Fatalf("view = %v, want viewBulkConfirm", m.view)
+	}
+
+	m, cmd := pressKey(m, "enter")
+	if cmd != nil || m.view != viewBulkConfirm {
+		t.Fatal("expected Enter without typed confirmation to be refused")
+	}
+
+	for _, r := range "delete" {
+		m, _ = pressKey(m, string(r))
+	}
+	m, cmd = pressKey(m, "enter")
+	if cmd == nil {
+		t.Fatal("expected delete command after typed confirmation")
+	}
+	if m.view != viewQueues {
+		t.Errorf("view = %v, want viewQueues", m.view)
+	}
+}
+
+func TestBrowserBulk_DoneUpdatesSelection(t *testing.T) {
+	m := newQueuesBrowser(false)
+	m.selectedQueues["rabbithole-abc123"] = true
+	m.selectedQueues["orders"] = true
+	m.createdQueues[m.queueRef("rabbithole-abc123")] = true
+
+	result, _ := m.Update(bulkDoneMsg{action: bulkDelete, done: []string{"rabbithole-abc123"}})
+	m = result.(browserModel)
+
+	if m.selectedQueues["rabbithole-abc123"] || m.createdQueues[m.queueRef("rabbithole-abc123")] {
+		t.Error("expected deleted queue to be dropped from selection and created queues")
+	}
+	if !m.selectedQueues["orders"] {
+		t.Error("expected queues not processed to stay selected")
+	}
+	if m.notice != "Deleted 1 queue(s)" {
+		t.Errorf("notice = %q", m.notice)
+	}
+}
+
+func TestBrowserBulk_OnlyShownQueues(t *testing.T) {
+	m := newQueuesBrowser(false)
+	m.selectedQueues["orders"] = true
+	m.selectedQueues["rabbithole-abc123"] = true
+
+	// Only one of the selected queues is bound to this exchange
+	m.view = viewBindings
+	m.bindings = []rabbitmq.Binding{{Destination: "rabbithole-abc123"}, {Destination: "audit"}}
+	m.selectedIdx = 2
+	m, _ = pressKey(m, "d")
+	if m.view != viewBulkConfirm || !reflect.DeepEqual(m.bulk.queues, []string{"rabbithole-abc123"}) {
+		t.Fatalf("bulk queues = %v, want only the shown selection", m.bulk.queues)
+	}
+
+	// With nothing shown selected, purge acts on the current binding
+	m = newQueuesBrowser(false)
+	m.selectedQueues["orders"] = true
+	m.view = viewBindings
+	m.bindings = []rabbitmq.Binding{{Destination: "audit"}}
+	m.selectedIdx = 1
+	m, _ = pressKey(m, "p")
+	if !reflect.DeepEqual(m.bulk.queues, []string{"audit"}) {
+		t.Errorf("bulk queues = %v, want [audit]", m.bulk.queues)
+	}
+}
+
+func TestRenderQueues_EffectivePolicy(t *testing.T) {
+	m := newQueuesBrowser(false)
+	m.width, m.height = 160, 30
+	m.queues[0].Policy = "ttl"
+	m.queues[0].EffectivePolicyDefinition = map[string]any{"message-ttl": float64(60000)}
+	m.queues[1].OperatorPolicy = "limits"
+
+	view := m.renderQueues()
+	for _, want := range []string{"POLICY", "ttl", "op:limits", `Effective policy: policy ttl {"message-ttl":60000}`} {
+		if !strings.Contains(view, want) {
+			t.Errorf("queue list missing %q:\n%s", want, view)
+		}
+	}
+
+	m, _ = pressKey(m, "j")
+	m, _ = pressKey(m, "j")
+	if view := m.renderQueues(); !strings.Contains(view, "Effective policy: no policy") {
+		t.Errorf("selected queue without a policy:\n%s", view)
+	}
+}
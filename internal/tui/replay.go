@@ -0,0 +1,335 @@
+package tui
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/epalmerini/rabbithole/internal/db"
+	"github.com/epalmerini/rabbithole/internal/filter"
+	"github.com/epalmerini/rabbithole/internal/proto"
+)
+
+// replayPageSize is how many stored messages a replay holds in memory; the
+// rest of the session is read from the store as the selection moves.
+const replayPageSize = 500
+
+// replayPager pages a stored session through the consumer's message list,
+// which holds one window of it. Filters and searches run in SQL, so they
+// cover the whole session without loading it.
+type replayPager struct {
+	sessionID int64
+	where     string // active filter as a condition over messages m
+	args      []any
+	total     int64 // messages matching where; -1 until counted
+	offset    int64 // position of messages[0] among them
+	seq       int   // latest request; responses to older ones are dropped
+	loading   bool
+	extended  int64 // messages the history gained while being counted
+
+	search     string // active search condition, "" when none
+	searchArgs []any
+	matches    int64 // messages matching both the filter and the search
+}
+
+// replayTarget is the message a page request should select: a stored message
+// ID when id is set, a position counted back from the end when back is set,
+// otherwise a position among the filtered messages.
+type replayTarget struct {
+	pos  int64
+	id   int64
+	back int64
+}
+
+type replayPageMsg struct {
+	seq      int
+	total    int64
+	matches  int64 // -1 when the search was not counted
+	offset   int64
+	selected int64
+	messages []db.Message // nil keeps the loaded window
+	notes    map[int64]db.Annotation
+	noMatch  bool // a search step found nothing; nothing moves
+	err      error
+}
+
+func newReplayPager(sessionID int64) *replayPager {
+	return &replayPager{sessionID: sessionID, where: "1", total: -1}
+}
+
+// and joins two SQL conditions with their arguments.
+func and(a string, aArgs []any, b string, bArgs []any) (string, []any) {
+	return "(" + a + ") AND (" + b + ")", append(append([]any{}, aArgs...), bArgs...)
+}
+
+// position returns the session position of the selected message.
+func (m model) position() int64 {
+	return m.pager.offset + int64(m.selectedIdx)
+}
+
+// replayMoveTo selects the message at pos, loading the page around it when
+// it is outside the window.
+func (m *model) replayMoveTo(pos int64) tea.Cmd {
+	p := m.pager
+	if pos >= p.total {
+		pos = p.total - 1
+	}
+	if pos < 0 {
+		pos = 0
+	}
+	if pos >= p.offset && pos < p.offset+int64(m.messages.Len()) {
+		if idx := int(pos - p.offset); idx != m.selectedIdx {
+			m.selectedIdx = idx
+			m.detailViewport.YOffset = 0
+		}
+		return nil
+	}
+	return m.fetchReplay(replayTarget{pos: pos})
+}
+
+// fetchReplay loads the page holding target in the background.
+func (m *model) fetchReplay(target replayTarget) tea.Cmd {
+	p := m.pager
+	p.seq++
+	p.loading = true
+	req, store := *p, m.store
+	loaded := int64(m.messages.Len())
+	return func() tea.Msg {
+		msg := replayPageMsg{seq: req.seq, matches: -1}
+		msg.err = loadReplayPage(context.Background(), store, req, loaded, target, &msg)
+		return msg
+	}
+}
+
+// replaySearchStep moves to the next (or previous) search match after the
+// selected message, wrapping around the session.
+func (m *model) replaySearchStep(forward, countMatches bool) tea.Cmd {
+	p := m.pager
+	p.seq++
+	p.loading = true
+	req, store := *p, m.store
+	loaded := int64(m.messages.Len())
+	var from int64
+	if !countMatches && m.selectedIdx < m.messages.Len() {
+		from = int64(m.messages.At(m.selectedIdx).ID)
+	}
+	return func() tea.Msg {
+		ctx := context.Background()
+		msg := replayPageMsg{seq: req.seq, matches: -1}
+		where, args := and(req.where, req.args, req.search, req.searchArgs)
+		if countMatches {
+			n, err := store.CountQueryMessages(ctx, where, args, req.sessionID)
+			if err != nil {
+				msg.err = err
+				return msg
+			}
+			msg.matches = n
+		}
+
+		next, err := store.NextQueryMessage(ctx, where, args, req.sessionID, from, forward)
+		if err == nil && next == 0 && from != 0 {
+			wrap := int64(0)
+			if !forward {
+				wrap = math.MaxInt64
+			}
+			next, err = store.NextQueryMessage(ctx, where, args, req.sessionID, wrap, forward)
+		}
+		if err != nil {
+			msg.err = err
+			return msg
+		}
+		if next == 0 {
+			msg.noMatch = true
+			return msg
+		}
+		msg.err = loadReplayPage(ctx, store, req, loaded, replayTarget{id: next}, &msg)
+		return msg
+	}
+}
+
+// loadReplayPage fills msg with the page around target. The window is kept
+// when target is already loaded and the filter has not changed.
+func loadReplayPage(ctx context.Context, store db.Store, p replayPager, loaded int64, target replayTarget, msg *replayPageMsg) error {
+	total := p.total
+	if total < 0 {
+		n, err := store.CountQueryMessages(ctx, p.where, p.args, p.sessionID)
+		if err != nil {
+			return err
+		}
+		total = n
+	}
+
+	pos := target.pos
+	if target.back != 0 {
+		pos = total - target.back
+	}
+	if target.id != 0 {
+		where, args := and(p.where, p.args, "m.id < ?", []any{target.id})
+		n, err := store.CountQueryMessages(ctx, where, args, p.sessionID)
+		if err != nil {
+			return err
+		}
+		pos = n
+	}
+	pos = min(max(pos, 0), max(total-1, 0))
+
+	msg.total, msg.selected = total, pos
+	if p.total >= 0 && pos >= p.offset && pos < p.offset+loaded {
+		msg.offset = p.offset
+		return nil
+	}
+
+	offset := min(max(pos-replayPageSize/2, 0), max(total-replayPageSize, 0))
+	rows, err := store.QueryMessages(ctx, p.where, p.args, p.sessionID, replayPageSize, offset)
+	if err != nil {
+		return err
+	}
+	msg.offset = offset
+	msg.messages = rows
+	if msg.messages == nil {
+		msg.messages = []db.Message{}
+	}
+	msg.notes, err = store.GetAnnotations(ctx, storeIDs(rows))
+	return err
+}
+
+// applyReplayPage installs a loaded page and its selection.
+func (m *model) applyReplayPage(msg replayPageMsg) tea.Cmd {
+	p := m.pager
+	p.loading = false
+	if msg.err != nil {
+		return m.setStatusMsg("Replay failed: " + msg.err.Error())
+	}
+	if msg.matches >= 0 {
+		p.matches = msg.matches
+	}
+	if msg.noMatch {
+		return nil
+	}
+	if p.total < 0 {
+		msg.total += p.extended
+		p.extended = 0
+	}
+	p.total = msg.total
+	if m.liveRing != nil && msg.total == 0 {
+		// The writer dropped every earlier delivery
+		m.leaveHistory(0)
+		return m.setStatusMsg("No earlier messages stored")
+	}
+	if msg.messages != nil {
+		p.offset = msg.offset
+		m.messages = newMessageRing(0, replayMessages(msg.messages, msg.notes)...)
+		if m.liveRing == nil {
+			// History pages leave the live message count alone
+			m.messageCount = m.messages.Len()
+		}
+	}
+	idx := int(msg.selected - p.offset)
+	if idx >= m.messages.Len() {
+		idx = m.messages.Len() - 1
+	}
+	if idx < 0 {
+		idx = 0
+	}
+	if idx != m.selectedIdx {
+		m.detailViewport.YOffset = 0
+	}
+	m.selectedIdx = idx
+	return nil
+}
+
+// replayFilter pushes a filter expression (empty for none) down to the store
+// and reloads the window around the selected message.
+func (m *model) replayFilter(expr string) tea.Cmd {
+	where, args := "1", []any(nil)
+	if expr != "" {
+		f, err := filter.New(expr)
+		if err != nil {
+			return m.setStatusMsg("Invalid filter: " + err.Error())
+		}
+		where, args = f.SQL()
+	}
+	p := m.pager
+	p.where, p.args, p.total = where, args, -1
+
+	var target replayTarget
+	if m.selectedIdx < m.messages.Len() {
+		target.id = int64(m.messages.At(m.selectedIdx).ID)
+	}
+	return m.fetchReplay(target)
+}
+
+// replaySearch runs the search query over the whole session and jumps to the
+// first match.
+func (m *model) replaySearch() tea.Cmd {
+	p := m.pager
+	p.search, p.searchArgs, p.matches = "", nil, 0
+	if m.searchQuery == "" {
+		return nil
+	}
+	f, err := filter.New(m.searchQuery)
+	if err != nil {
+		return m.setStatusMsg("Invalid search: " + err.Error())
+	}
+	p.search, p.searchArgs = f.SQL()
+	return m.replaySearchStep(true, true)
+}
+
+// replayMessages converts a stored page and its annotations without
+// decoding it: bodies are decoded as messages are viewed (see decodeLazy).
+func replayMessages(rows []db.Message, notes map[int64]db.Annotation) []Message {
+	msgs := convertDBMessages(rows, nil)
+	annotate(msgs, notes)
+	for i := range msgs {
+		msgs[i].ID = int(rows[i].ID)
+		msgs[i].lazy = true
+		if rows[i].DecodedJson.Valid {
+			msgs[i].storedDecoded = rows[i].DecodedJson.String
+		}
+	}
+	return msgs
+}
+
+// decodeLazy decodes a paged replay message on first use: with the proto
+// decoder when configured, otherwise from the body decoded at capture.
+func decodeLazy(msg *Message, dec *proto.Decoder) {
+	if !msg.lazy {
+		return
+	}
+	msg.lazy = false
+	if dec != nil {
+		if decoded, protoType, err := dec.DecodeWithHintAndType(msg.RawBody, msg.RoutingKey); err == nil {
+			msg.Decoded = decoded
+			msg.ProtoType = protoType
+			return
+		}
+	}
+	if msg.storedDecoded != "" {
+		var decoded map[string]any
+		if err := json.Unmarshal([]byte(msg.storedDecoded), &decoded); err == nil {
+			msg.Decoded = decoded
+		}
+	}
+}
+
+// decodeSelected decodes the selected message in place. The list shares its
+// backing array with every copy of the model, so this also works from View.
+func (m model) decodeSelected() {
+	if m.selectedIdx < m.messages.Len() {
+		decodeLazy(m.messages.At(m.selectedIdx), m.config.Decoder)
+	}
+}
+
+// replayStatus describes the selected position in a paged replay.
+func (m model) replayStatus() string {
+	p := m.pager
+	if p.loading && m.messages.Len() == 0 {
+		return "loading..."
+	}
+	if p.total <= 0 {
+		return "0 msgs"
+	}
+	return fmt.Sprintf("%d/%d msgs", m.position()+1, p.total)
+}
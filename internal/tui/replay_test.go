@@ -0,0 +1,193 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/epalmerini/rabbithole/internal/db"
+)
+
+// newReplayTestModel stores a session of n messages, order.0 … order.n-1,
+// where every tenth one has a decoded body, and opens it for replay.
+func newReplayTestModel(t *testing.T, n int) model {
+	t.Helper()
+	store, err := db.NewStore(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = store.Close() })
+	ctx := context.Background()
+
+	sid, err := store.CreateSession(ctx, "orders", "#", "q", "amqp://localhost/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	base := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	for i := range n {
+		rec := db.MessageRecord{
+			SessionID:  sid,
+			Exchange:   "orders",
+			RoutingKey: fmt.Sprintf("order.%d", i),
+			Body:       []byte{0x08, byte(i)},
+			Timestamp:  base.Add(time.Duration(i) * time.Second),
+		}
+		if i%10 == 0 {
+			rec.Decoded = map[string]any{"seq": i, "flagged": true}
+		}
+		if _, err := store.InsertMessage(ctx, &rec); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	m := initialReplayModel(Config{}, db.Session{ID: sid, Exchange: "orders"}, store)
+	m.width, m.height = 120, 40
+	m.detailViewport = viewport.New(80, 20)
+	return run(t, m, m.fetchReplay(replayTarget{}))
+}
+
+// run executes cmd and feeds the messages it produces back into the model.
+func run(t *testing.T, m model, cmd tea.Cmd) model {
+	t.Helper()
+	if cmd == nil {
+		return m
+	}
+	switch msg := cmd().(type) {
+	case tea.BatchMsg:
+		for _, c := range msg {
+			m = run(t, m, c)
+		}
+	case replayPageMsg:
+		updated, next := m.Update(msg)
+		m = run(t, updated.(model), next)
+	}
+	return m
+}
+
+func press(t *testing.T, m model, keys ...string) model {
+	t.Helper()
+	for _, key := range keys {
+		var msg tea.KeyMsg
+		switch key {
+		case "enter":
+			msg = tea.KeyMsg{Type: tea.KeyEnter}
+		default:
+			msg = tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(key)}
+		}
+		updated, cmd := m.Update(msg)
+		m = run(t, updated.(model), cmd)
+	}
+	return m
+}
+
+func (m model) selectedKey() string {
+	return m.messages.At(m.selectedIdx).RoutingKey
+}
+
+func TestReplay_Pages(t *testing.T) {
+	m := newReplayTestModel(t, 1200)
+
+	if !m.replayMode || m.pager.total != 1200 {
+		t.Fatalf("replayMode = %v, total = %d; want a 1200 message replay", m.replayMode, m.pager.total)
+	}
+	if m.messages.Len() != replayPageSize {
+		t.Fatalf("loaded %d messages, want one page of %d", m.messages.Len(), replayPageSize)
+	}
+	if m.messages.At(0).Decoded != nil {
+		t.Error("rows should not be decoded before they are viewed")
+	}
+
+	m = press(t, m, "G")
+	if m.selectedKey() != "order.1199" || m.position() != 1199 {
+		t.Errorf("G selected %s at %d, want order.1199", m.selectedKey(), m.position())
+	}
+	if got := m.replayStatus(); got != "1200/1200 msgs" {
+		t.Errorf("replayStatus = %q", got)
+	}
+
+	// Moving past the window loads the page around the target
+	m = press(t, m, "gg")
+	m = press(t, m, "7", "0", "0", "j")
+	if m.selectedKey() != "order.700" {
+		t.Errorf("700j selected %s, want order.700", m.selectedKey())
+	}
+	m = press(t, m, "k")
+	if m.selectedKey() != "order.699" {
+		t.Errorf("k selected %s, want order.699", m.selectedKey())
+	}
+
+	m = press(t, m, "gg")
+	if m.selectedKey() != "order.0" || m.pager.offset != 0 {
+		t.Errorf("gg selected %s with offset %d", m.selectedKey(), m.pager.offset)
+	}
+}
+
+func TestReplay_FilterInSQL(t *testing.T) {
+	m := newReplayTestModel(t, 1200)
+	m = press(t, m, "G")
+
+	m.filterMode = true
+	m.filterInput.SetValue("body.flagged = true")
+	m = press(t, m, "enter")
+	if m.pager.total != 120 {
+		t.Fatalf("filtered total = %d, want 120", m.pager.total)
+	}
+	if m.selectedKey() != "order.1190" {
+		t.Errorf("filter kept %s selected, want the nearest match order.1190", m.selectedKey())
+	}
+	for _, msg := range m.messages.All() {
+		if msg.storedDecoded == "" {
+			t.Fatalf("%s does not match the filter", msg.RoutingKey)
+		}
+	}
+
+	// F toggles the pushed-down filter off and on
+	m = press(t, m, "F")
+	if m.filterActive || m.pager.total != 1200 {
+		t.Errorf("F off: active = %v, total = %d", m.filterActive, m.pager.total)
+	}
+	m = press(t, m, "F")
+	if !m.filterActive || m.pager.total != 120 {
+		t.Errorf("F on: active = %v, total = %d", m.filterActive, m.pager.total)
+	}
+}
+
+func TestReplay_SearchInSQL(t *testing.T) {
+	m := newReplayTestModel(t, 1200)
+
+	m.searchMode = true
+	m.searchInput.SetValue("rk:order.11")
+	m = press(t, m, "enter")
+	if m.pager.matches != 111 { // order.11, order.110-119 and order.1100-1199
+		t.Errorf("matches = %d, want 111", m.pager.matches)
+	}
+	if m.selectedKey() != "order.11" {
+		t.Fatalf("search selected %s, want order.11", m.selectedKey())
+	}
+
+	m = press(t, m, "n")
+	if m.selectedKey() != "order.110" {
+		t.Errorf("n selected %s, want order.110", m.selectedKey())
+	}
+	m = press(t, m, "N", "N")
+	if m.selectedKey() != "order.1199" {
+		t.Errorf("N wrapped to %s, want order.1199", m.selectedKey())
+	}
+}
+
+func TestReplay_DecodesSelected(t *testing.T) {
+	m := newReplayTestModel(t, 20)
+	m = press(t, m, "1", "0", "j")
+	_ = m.View()
+	msg := m.messages.At(m.selectedIdx)
+	if msg.Decoded["seq"] != float64(10) {
+		t.Errorf("selected message decoded to %v, want the stored body", msg.Decoded)
+	}
+	if m.messages.At(11).Decoded != nil {
+		t.Error("only the viewed message should be decoded")
+	}
+}
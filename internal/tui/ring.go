@@ -0,0 +1,76 @@
+package tui
+
+import "iter"
+
+// messageRing is the consumer's message list. Once it holds limit messages,
+// each push overwrites the oldest one instead of shifting the rest; a zero
+// limit leaves it unbounded, as for replay pages.
+//
+// Copies of a ring share its backing array, like copies of a slice, so
+// messages decoded in place through At are seen by every copy of the model.
+type messageRing struct {
+	buf   []Message
+	start int // index in buf of the oldest message
+	limit int
+}
+
+func newMessageRing(limit int, msgs ...Message) messageRing {
+	r := messageRing{limit: limit}
+	if limit > 0 {
+		r.buf = make([]Message, 0, max(limit, len(msgs)))
+	}
+	r.buf = append(r.buf, msgs...)
+	if limit > 0 && len(msgs) > limit {
+		r.buf = r.buf[len(msgs)-limit:]
+	}
+	return r
+}
+
+// Len returns the number of messages held.
+func (r messageRing) Len() int {
+	return len(r.buf)
+}
+
+// At returns the i-th oldest message.
+func (r messageRing) At(i int) *Message {
+	return &r.buf[(r.start+i)%len(r.buf)]
+}
+
+// Push appends msg, returning the message it evicted when the ring was full.
+func (r *messageRing) Push(msg Message) (evicted Message, ok bool) {
+	if r.limit <= 0 || len(r.buf) < r.limit {
+		r.buf = append(r.buf, msg)
+		return Message{}, false
+	}
+	evicted = r.buf[r.start]
+	r.buf[r.start] = msg
+	r.start = (r.start + 1) % len(r.buf)
+	return evicted, true
+}
+
+// Clear empties the ring, keeping its limit.
+func (r *messageRing) Clear() {
+	clear(r.buf)
+	r.buf = r.buf[:0]
+	r.start = 0
+}
+
+// All yields the messages from oldest to newest with their positions.
+func (r messageRing) All() iter.Seq2[int, Message] {
+	return func(yield func(int, Message) bool) {
+		for i := range len(r.buf) {
+			if !yield(i, *r.At(i)) {
+				return
+			}
+		}
+	}
+}
+
+// Messages returns the messages from oldest to newest in a new slice.
+func (r messageRing) Messages() []Message {
+	msgs := make([]Message, 0, len(r.buf))
+	for _, msg := range r.All() {
+		msgs = append(msgs, msg)
+	}
+	return msgs
+}
@@ -0,0 +1,65 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+)
+
+func ringKeys(r messageRing) string {
+	var keys []string
+	for _, msg := range r.All() {
+		keys = append(keys, msg.RoutingKey)
+	}
+	return strings.Join(keys, " ")
+}
+
+func TestMessageRing(t *testing.T) {
+	r := newMessageRing(3)
+	for _, key := range []string{"a", "b", "c"} {
+		if _, ok := r.Push(Message{RoutingKey: key}); ok {
+			t.Fatalf("push %s evicted from a ring with room", key)
+		}
+	}
+
+	evicted, ok := r.Push(Message{RoutingKey: "d"})
+	if !ok || evicted.RoutingKey != "a" {
+		t.Errorf("push into a full ring evicted %q, %v; want a", evicted.RoutingKey, ok)
+	}
+	r.Push(Message{RoutingKey: "e"})
+	if got := ringKeys(r); got != "c d e" {
+		t.Errorf("ring = %q, want c d e", got)
+	}
+	if r.Len() != 3 || r.At(0).RoutingKey != "c" || r.At(2).RoutingKey != "e" {
+		t.Errorf("Len = %d, At(0) = %s, At(2) = %s", r.Len(), r.At(0).RoutingKey, r.At(2).RoutingKey)
+	}
+
+	// Copies share messages decoded in place
+	cp := r
+	cp.At(1).Decoded = map[string]any{"ok": true}
+	if r.At(1).Decoded == nil {
+		t.Error("a copy of the ring should share its messages")
+	}
+
+	r.Clear()
+	r.Push(Message{RoutingKey: "f"})
+	if got := ringKeys(r); got != "f" {
+		t.Errorf("ring after Clear = %q, want f", got)
+	}
+}
+
+func TestNewMessageRing(t *testing.T) {
+	msgs := []Message{{RoutingKey: "a"}, {RoutingKey: "b"}, {RoutingKey: "c"}}
+	if got := ringKeys(newMessageRing(2, msgs...)); got != "b c" {
+		t.Errorf("limited ring = %q, want the newest b c", got)
+	}
+
+	unbounded := newMessageRing(0, msgs...)
+	for range 10 {
+		if _, ok := unbounded.Push(Message{}); ok {
+			t.Fatal("a ring without a limit should not evict")
+		}
+	}
+	if unbounded.Len() != 13 || len(unbounded.Messages()) != 13 {
+		t.Errorf("unbounded ring holds %d messages, want 13", unbounded.Len())
+	}
+}
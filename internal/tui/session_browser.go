@@ -2,7 +2,10 @@ package tui
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"os"
+	"path/filepath"
 	"strings"
 	"time"
 
@@ -10,7 +13,11 @@ import (
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/epalmerini/rabbithole/internal/archive"
+	"github.com/epalmerini/rabbithole/internal/config"
 	"github.com/epalmerini/rabbithole/internal/db"
+	"github.com/epalmerini/rabbithole/internal/proto"
+	"github.com/epalmerini/rabbithole/internal/query"
 )
 
 // sessionEntry holds a session with its message count for display.
@@ -29,14 +36,27 @@ type sessionDeletedMsg struct {
 }
 
 type replaySessionMsg struct {
-	session  db.Session
-	messages []db.Message
+	session   db.Session
+	messageID int64 // stored message to start at; 0 for the first
 }
 
 type sessionFTSResultMsg struct {
 	sessionIDs []int64
 }
 
+type sessionExportedMsg struct {
+	path  string
+	count int
+}
+
+// exportFormatKeys maps the keys of the export prompt to formats.
+var exportFormatKeys = map[string]string{
+	"j": archive.FormatJSON,
+	"n": archive.FormatNDJSON,
+	"c": archive.FormatCSV,
+	"p": archive.FormatParquet,
+}
+
 type sessionBrowserModel struct {
 	store  db.Store
 	config Config
@@ -62,6 +82,15 @@ type sessionBrowserModel struct {
 	// Delete confirmation
 	confirmDelete bool
 
+	// Export format prompt (e key)
+	exportMode bool
+
+	// Annotated messages of every session (B key)
+	showAnnotated bool
+	annotated     []db.AnnotatedMessage
+	annotatedIdx  int
+	annotatedOff  int
+
 	// Spinner / loading
 	spinner spinner.Model
 	loading bool
@@ -79,8 +108,8 @@ func newSessionBrowserModel(cfg Config, store db.Store) sessionBrowserModel {
 	si.Width = 40
 
 	ftsInput := textinput.New()
-	ftsInput.Placeholder = "Search message content..."
-	ftsInput.CharLimit = 100
+	ftsInput.Placeholder = "Search message content or query (body.total > 100)..."
+	ftsInput.CharLimit = 256
 	ftsInput.Width = 40
 
 	sp := spinner.New()
@@ -128,15 +157,57 @@ func (m sessionBrowserModel) loadSessions() tea.Cmd {
 	}
 }
 
-func (m sessionBrowserModel) loadSessionMessages(session db.Session) tea.Cmd {
+// replaySession opens a session in the consumer view, which pages it from
+// the store.
+func (m sessionBrowserModel) replaySession(session db.Session) tea.Cmd {
+	return func() tea.Msg {
+		return replaySessionMsg{session: session}
+	}
+}
+
+// exportSession writes a whole session to the exports directory, reading it
+// from the store a page at a time.
+func (m sessionBrowserModel) exportSession(session db.Session, format string) tea.Cmd {
 	store := m.store
+	protoPath := m.config.ProtoPath
 	return func() tea.Msg {
-		ctx := context.Background()
-		msgs, err := store.ListMessagesBySessionAsc(ctx, session.ID, 10000, 0)
+		var dec *proto.Decoder
+		if protoPath != "" {
+			d, err := proto.NewDecoder(protoPath)
+			if err != nil {
+				return errorMsg{err: fmt.Errorf("failed to load proto files: %w", err)}
+			}
+			dec = d
+		}
+
+		dataDir, err := db.DefaultDataDir()
+		if err != nil {
+			return errorMsg{err: fmt.Errorf("export failed: %w", err)}
+		}
+		exportDir := filepath.Join(dataDir, "exports")
+		if err := os.MkdirAll(exportDir, 0755); err != nil {
+			return errorMsg{err: fmt.Errorf("export failed: %w", err)}
+		}
+		filename := fmt.Sprintf("rabbithole-session-%d-%s.%s", session.ID, time.Now().Format("20060102-150405"), format)
+		exportPath := filepath.Join(exportDir, filename)
+
+		f, err := os.Create(exportPath)
 		if err != nil {
-			return errorMsg{err: fmt.Errorf("failed to load messages: %w", err)}
+			return errorMsg{err: fmt.Errorf("export failed: %w", err)}
 		}
-		return replaySessionMsg{session: session, messages: msgs}
+
+		w, err := archive.NewWriter(f, format, nil)
+		if err != nil {
+			_ = f.Close()
+			_ = os.Remove(exportPath)
+			return errorMsg{err: fmt.Errorf("export failed: %w", err)}
+		}
+		n, err := archive.ExportSession(context.Background(), store, session.ID, dec, nil, w)
+		if err = errors.Join(err, w.Close(), f.Close()); err != nil {
+			_ = os.Remove(exportPath)
+			return errorMsg{err: fmt.Errorf("export failed: %w", err)}
+		}
+		return sessionExportedMsg{path: exportPath, count: n}
 	}
 }
 
@@ -150,10 +221,25 @@ func (m sessionBrowserModel) deleteSession(sessionID int64) tea.Cmd {
 	}
 }
 
-func (m sessionBrowserModel) searchFTS(query string) tea.Cmd {
+// searchFTS finds sessions with matching messages: full-text search, or a
+// structured query (body.total > 100) evaluated in SQL.
+func (m sessionBrowserModel) searchFTS(text string) tea.Cmd {
 	store := m.store
 	return func() tea.Msg {
-		ids, err := store.SearchSessionsByContent(context.Background(), query, 100)
+		if query.LooksLikeQuery(text) {
+			q, err := query.Parse(text)
+			if err != nil {
+				return errorMsg{err: fmt.Errorf("invalid query: %w", err)}
+			}
+			where, args := q.SQL(time.Now())
+			ids, err := store.QuerySessions(context.Background(), where, args, 100)
+			if err != nil {
+				return errorMsg{err: fmt.Errorf("query failed: %w", err)}
+			}
+			return sessionFTSResultMsg{sessionIDs: ids}
+		}
+
+		ids, err := store.SearchSessionsByContent(context.Background(), text, 100)
 		if err != nil {
 			return errorMsg{err: fmt.Errorf("FTS search failed: %w", err)}
 		}
@@ -235,7 +321,24 @@ func (m sessionBrowserModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 		}
 
-		switch msg.String() {
+		// Handle the list of annotated messages
+		if m.showAnnotated {
+			return m.updateAnnotated(msg)
+		}
+
+		// Handle export format prompt
+		if m.exportMode {
+			m.exportMode = false
+			format, ok := exportFormatKeys[msg.String()]
+			idx := m.getActualIndex(m.selectedIdx)
+			if ok && idx >= 0 && idx < len(m.sessions) {
+				m.loading = true
+				return m, m.exportSession(m.sessions[idx].session, format)
+			}
+			return m, nil
+		}
+
+		switch m.config.Keys.translate(config.KeysSessions, msg.String()) {
 		case "q", "ctrl+c":
 			return m, tea.Quit
 		case "/":
@@ -279,13 +382,18 @@ func (m sessionBrowserModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			idx := m.getActualIndex(m.selectedIdx)
 			if idx >= 0 && idx < len(m.sessions) {
 				m.loading = true
-				return m, m.loadSessionMessages(m.sessions[idx].session)
+				return m, m.replaySession(m.sessions[idx].session)
 			}
 		case "d":
 			idx := m.getActualIndex(m.selectedIdx)
 			if idx >= 0 && idx < len(m.sessions) {
 				m.confirmDelete = true
 			}
+		case "e":
+			idx := m.getActualIndex(m.selectedIdx)
+			if idx >= 0 && idx < len(m.sessions) {
+				m.exportMode = true
+			}
 		case "esc":
 			// Clear FTS filter if active, otherwise clear metadata filter
 			if m.ftsQuery != "" {
@@ -306,6 +414,11 @@ func (m sessionBrowserModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		case "r":
 			m.loading = true
 			return m, m.loadSessions()
+		case "B":
+			m.showAnnotated = true
+			m.annotatedIdx, m.annotatedOff = 0, 0
+			m.loading = true
+			return m, m.loadAnnotated()
 		}
 
 	case tea.WindowSizeMsg:
@@ -357,6 +470,20 @@ func (m sessionBrowserModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return clearStatusMsg{}
 		}))
 
+	case annotatedLoadedMsg:
+		m.loading = false
+		m.err = msg.err
+		m.annotated = msg.entries
+		m.annotatedIdx = min(m.annotatedIdx, max(len(m.annotated)-1, 0))
+
+	case sessionExportedMsg:
+		m.loading = false
+		m.statusMsg = fmt.Sprintf("Exported %d messages to %s", msg.count, msg.path)
+		m.statusMsgTime = time.Now()
+		cmds = append(cmds, tea.Tick(3*time.Second, func(_ time.Time) tea.Msg {
+			return clearStatusMsg{}
+		}))
+
 	case replaySessionMsg:
 		// Handled by parent appModel
 		m.loading = false
@@ -458,12 +585,20 @@ func (m sessionBrowserModel) View() string {
 	)
 
 	content := m.renderSessions()
+	if m.showAnnotated {
+		content = m.renderAnnotated()
+	}
 
 	var bottomBar string
-	if m.searchMode {
+	if m.showAnnotated {
+		bottomBar = m.renderAnnotatedHelp()
+	} else if m.searchMode {
 		bottomBar = helpStyle.Render("Filter: ") + m.searchInput.View() + helpStyle.Render("  (Enter to apply, Esc to cancel)")
 	} else if m.ftsMode {
 		bottomBar = helpStyle.Render("Search content: ") + m.ftsInput.View() + helpStyle.Render("  (Enter to search, Esc to cancel)")
+	} else if m.exportMode {
+		bottomBar = helpStyle.Render(fmt.Sprintf("Export as: %s json  %s ndjson  %s csv  %s parquet  (any other key to cancel)",
+			helpKeyStyle.Render("j"), helpKeyStyle.Render("n"), helpKeyStyle.Render("c"), helpKeyStyle.Render("p")))
 	} else {
 		bottomBar = m.renderHelp()
 	}
@@ -546,7 +681,8 @@ func (m sessionBrowserModel) renderSessions() string {
 			deleteHint = errorStyle.Render("  [Enter to confirm delete, Esc to cancel]")
 		}
 
-		line := fmt.Sprintf("%-20s  %s  │  %d msgs  │  %s → %s",
+		line := fmt.Sprintf("%s  %-20s  %s  │  %d msgs  │  %s → %s",
+			mutedStyle.Render(fmt.Sprintf("#%-4d", s.ID)),
 			truncate(s.Exchange, 20),
 			routingKeyStyle.Render(truncate(s.RoutingKey, 15)),
 			entry.msgCount,
@@ -566,15 +702,18 @@ func (m sessionBrowserModel) renderSessions() string {
 }
 
 func (m sessionBrowserModel) renderHelp() string {
+	h := func(actions ...string) string { return m.config.Keys.hint(config.KeysSessions, actions...) }
 	keys := []struct{ key, desc string }{
-		{"j/k", "navigate"},
-		{"/", "filter"},
-		{"S", "search content"},
-		{"enter", "replay"},
-		{"d", "delete"},
-		{"r", "refresh"},
-		{"b", "back"},
-		{"q", "quit"},
+		{h("down", "up"), "navigate"},
+		{h("filter"), "filter"},
+		{h("search"), "search content"},
+		{h("open"), "replay"},
+		{h("delete"), "delete"},
+		{h("export"), "export"},
+		{h("bookmarks"), "bookmarks"},
+		{h("refresh"), "refresh"},
+		{h("back"), "back"},
+		{h("quit"), "quit"},
 	}
 
 	var parts []string
@@ -5,6 +5,7 @@ import (
 	"testing"
 	"time"
 
+	tea "github.com/charmbracelet/bubbletea"
 	"github.com/epalmerini/rabbithole/internal/db"
 )
 
@@ -191,41 +192,39 @@ func TestSessionBrowserFTSFilter(t *testing.T) {
 	}
 }
 
-func TestInitialReplayModel(t *testing.T) {
-	cfg := Config{
-		Exchange:   "test-exchange",
-		RoutingKey: "#",
-	}
-	session := db.Session{
-		ID:       1,
-		Exchange: "test-exchange",
-	}
-	dbMsgs := []db.Message{
-		{ID: 1, Exchange: "test-exchange", RoutingKey: "order.created", Body: []byte("msg1")},
-		{ID: 2, Exchange: "test-exchange", RoutingKey: "order.shipped", Body: []byte("msg2")},
-	}
+func TestSessionBrowserExportPrompt(t *testing.T) {
+	t.Run("format key starts export", func(t *testing.T) {
+		m := makeSessionBrowser(sampleEntries())
+		m = pressSessionKey(m, "e")
+		if !m.exportMode {
+			t.Fatal("expected export prompt after e")
+		}
+		m2, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("c")})
+		m = m2.(sessionBrowserModel)
+		if m.exportMode || !m.loading || cmd == nil {
+			t.Errorf("expected export to start: exportMode=%v loading=%v cmd=%v", m.exportMode, m.loading, cmd != nil)
+		}
+	})
 
-	m := initialReplayModel(cfg, session, dbMsgs)
+	t.Run("other key cancels", func(t *testing.T) {
+		m := makeSessionBrowser(sampleEntries())
+		m = pressSessionKey(m, "e")
+		m = pressSessionKey(m, "x")
+		if m.exportMode || m.loading {
+			t.Error("expected prompt to close without exporting")
+		}
+	})
 
-	if !m.replayMode {
-		t.Error("expected replayMode=true")
-	}
-	if m.connState != stateConnected {
-		t.Errorf("connState = %d, want %d (stateConnected)", m.connState, stateConnected)
-	}
-	if len(m.messages) != 2 {
-		t.Errorf("messages len = %d, want 2", len(m.messages))
-	}
-	if m.messageCount != 2 {
-		t.Errorf("messageCount = %d, want 2", m.messageCount)
-	}
-	if m.store != nil {
-		t.Error("expected store=nil in replay mode")
-	}
-	// All messages should be marked as historical
-	for i, msg := range m.messages {
-		if !msg.Historical {
-			t.Errorf("message %d: expected Historical=true", i)
+	t.Run("no sessions", func(t *testing.T) {
+		m := makeSessionBrowser(nil)
+		m = pressSessionKey(m, "e")
+		if m.exportMode {
+			t.Error("export prompt should not open without a session")
 		}
-	}
+	})
+}
+
+func pressSessionKey(m sessionBrowserModel, key string) sessionBrowserModel {
+	m2, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(key)})
+	return m2.(sessionBrowserModel)
 }
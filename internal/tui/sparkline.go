@@ -0,0 +1,42 @@
+package tui
+
+import "strings"
+
+var sparkBlocks = []rune("▁▂▃▄▅▆▇█")
+
+// sparkline renders the last width values as a block-character graph scaled
+// between zero and the largest value shown.
+func sparkline(values []float64, width int) string {
+	if width <= 0 || len(values) == 0 {
+		return ""
+	}
+	if len(values) > width {
+		values = values[len(values)-width:]
+	}
+
+	maxVal := 0.0
+	for _, v := range values {
+		if v > maxVal {
+			maxVal = v
+		}
+	}
+
+	var sb strings.Builder
+	for _, v := range values {
+		idx := 0
+		if maxVal > 0 && v > 0 {
+			idx = int(v / maxVal * float64(len(sparkBlocks)-1))
+		}
+		sb.WriteRune(sparkBlocks[idx])
+	}
+	return sb.String()
+}
+
+// appendSample adds v to a history, dropping the oldest samples beyond limit.
+func appendSample(history []float64, v float64, limit int) []float64 {
+	history = append(history, v)
+	if len(history) > limit {
+		history = history[len(history)-limit:]
+	}
+	return history
+}
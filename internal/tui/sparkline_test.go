@@ -0,0 +1,36 @@
+package tui
+
+import "testing"
+
+func TestSparkline(t *testing.T) {
+	tests := []struct {
+		name   string
+		values []float64
+		width  int
+		want   string
+	}{
+		{"empty", nil, 10, ""},
+		{"zero width", []float64{1}, 0, ""},
+		{"all zero", []float64{0, 0, 0}, 10, "▁▁▁"},
+		{"scaled to max", []float64{0, 7, 14}, 10, "▁▄█"},
+		{"keeps latest", []float64{100, 1, 2}, 2, "▄█"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := sparkline(tt.values, tt.width); got != tt.want {
+				t.Errorf("sparkline() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAppendSample(t *testing.T) {
+	var h []float64
+	for i := 0; i < 5; i++ {
+		h = appendSample(h, float64(i), 3)
+	}
+	if len(h) != 3 || h[0] != 2 || h[2] != 4 {
+		t.Errorf("history = %v, want [2 3 4]", h)
+	}
+}
@@ -1,153 +1,253 @@
 package tui
 
-import "github.com/charmbracelet/lipgloss"
+import (
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
+)
 
+// Colours of the active theme, set by applyTheme
 var (
-	// Color palette - minimalist theme
-	orangeColor   = lipgloss.Color("#FF9500") // Headers, accents, selected items, field names
-	offWhiteColor = lipgloss.Color("#E8E8E8") // Primary text
-	greyColor     = lipgloss.Color("#6B7280") // Borders, muted text, secondary elements
-	darkBgColor   = lipgloss.Color("#1A1A1A") // Background
-	greenColor    = lipgloss.Color("#22C55E") // Connected status
-	redColor      = lipgloss.Color("#EF4444") // Errors, disconnected
+	accentColor     lipgloss.Color // Headers, accents, selected items, field names
+	textColor       lipgloss.Color // Primary text
+	mutedColor      lipgloss.Color // Borders, muted text, secondary elements
+	backgroundColor lipgloss.Color // Background of overlays
+	selectionColor  lipgloss.Color // Background of the selected row
+	successColor    lipgloss.Color // Connected status
+	errorColor      lipgloss.Color // Errors, disconnected
 
 	// JSON syntax highlighting
-	jsonKeyColor    = lipgloss.Color("#FF9500") // Orange bold
-	jsonStringColor = lipgloss.Color("#22C55E") // Green
-	jsonNumberColor = lipgloss.Color("#60A5FA") // Blue
-	jsonBoolColor   = lipgloss.Color("#F472B6") // Pink
-	jsonNullColor   = lipgloss.Color("#6B7280") // Grey italic
+	jsonKeyColor    lipgloss.Color
+	jsonStringColor lipgloss.Color
+	jsonNumberColor lipgloss.Color
+	jsonBoolColor   lipgloss.Color
+	jsonNullColor   lipgloss.Color
+
+	// plainTheme is set when colours are off: the selection and alert
+	// highlights are then shown with text attributes instead
+	plainTheme bool
+)
+
+// Styles, built from the theme's colours by applyTheme
+var (
+	headerStyle          lipgloss.Style
+	statusBarStyle       lipgloss.Style
+	connectedStyle       lipgloss.Style
+	disconnectedStyle    lipgloss.Style
+	messageListStyle     lipgloss.Style
+	selectedMessageStyle lipgloss.Style
+	normalMessageStyle   lipgloss.Style
+	routingKeyStyle      lipgloss.Style
+	detailPanelStyle     lipgloss.Style
+	fieldNameStyle       lipgloss.Style
+	helpStyle            lipgloss.Style
+	helpKeyStyle         lipgloss.Style
+	mutedStyle           lipgloss.Style
+	errorStyle           lipgloss.Style
+	dividerStyle         lipgloss.Style
+	jsonKeyStyle         lipgloss.Style
+	jsonStringStyle      lipgloss.Style
+	jsonNumberStyle      lipgloss.Style
+	jsonBoolStyle        lipgloss.Style
+	jsonNullStyle        lipgloss.Style
+	bookmarkStyle        lipgloss.Style
+	helpOverlayStyle     lipgloss.Style
+	helpCategoryStyle    lipgloss.Style
+	spinnerStyle         lipgloss.Style
+	newMsgStyle          lipgloss.Style
+	emptyStateStyle      lipgloss.Style
+	confirmationStyle    lipgloss.Style
+	dlxStyle             lipgloss.Style
+	sparklineStyle       lipgloss.Style
+	alarmStyle           lipgloss.Style
+	diffAddedStyle       lipgloss.Style
+	diffRemovedStyle     lipgloss.Style
+	diffChangedStyle     lipgloss.Style
+	statusSepStyle       lipgloss.Style
+)
+
+func init() {
+	applyTheme(darkTheme)
+}
+
+// applyTheme sets the colours and styles the views are drawn with. Views
+// read the styles when they render, so it is applied before the program
+// starts.
+func applyTheme(t Theme) {
+	accentColor = t.Accent
+	textColor = t.Text
+	mutedColor = t.Muted
+	backgroundColor = t.Background
+	selectionColor = t.Selection
+	successColor = t.Success
+	errorColor = t.Error
+	jsonKeyColor = t.JSONKey
+	jsonStringColor = t.JSONString
+	jsonNumberColor = t.JSONNumber
+	jsonBoolColor = t.JSONBool
+	jsonNullColor = t.JSONNull
+	plainTheme = t.plain
+	if t.plain {
+		// lipgloss drops bold and reverse too when NO_COLOR is set; keep
+		// them, as the colours are all unset
+		lipgloss.SetColorProfile(termenv.ANSI)
+	}
 
 	// Header
 	headerStyle = lipgloss.NewStyle().
-			Bold(true).
-			Foreground(orangeColor).
-			Border(lipgloss.RoundedBorder()).
-			BorderForeground(greyColor).
-			Padding(0, 1).
-			MarginBottom(1)
+		Bold(true).
+		Foreground(accentColor).
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(mutedColor).
+		Padding(0, 1).
+		MarginBottom(1)
 
 	// Status bar
 	statusBarStyle = lipgloss.NewStyle().
-			Foreground(greyColor).
-			Padding(0, 1)
+		Foreground(mutedColor).
+		Padding(0, 1)
 
 	connectedStyle = lipgloss.NewStyle().
-			Foreground(greenColor).
-			Bold(true)
+		Foreground(successColor).
+		Bold(true)
 
 	disconnectedStyle = lipgloss.NewStyle().
-				Foreground(redColor).
-				Bold(true)
+		Foreground(errorColor).
+		Bold(true)
 
 	// Message list
 	messageListStyle = lipgloss.NewStyle().
-				Border(lipgloss.RoundedBorder()).
-				BorderForeground(greyColor).
-				Padding(0, 1)
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(mutedColor).
+		Padding(0, 1)
 
 	selectedMessageStyle = lipgloss.NewStyle().
-				Background(lipgloss.Color("#2D2D2D")).
-				Foreground(orangeColor).
-				Bold(true)
+		Background(selectionColor).
+		Foreground(accentColor).
+		Bold(true)
 
 	normalMessageStyle = lipgloss.NewStyle().
-				Foreground(offWhiteColor)
+		Foreground(textColor)
 
 	// Routing key styles
 	routingKeyStyle = lipgloss.NewStyle().
-			Foreground(orangeColor).
-			Italic(true)
+		Foreground(accentColor).
+		Italic(true)
 
 	// Detail panel
 	detailPanelStyle = lipgloss.NewStyle().
-				Border(lipgloss.RoundedBorder()).
-				BorderForeground(greyColor).
-				Padding(1)
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(mutedColor).
+		Padding(1)
 
 	fieldNameStyle = lipgloss.NewStyle().
-			Foreground(orangeColor).
-			Bold(true)
+		Foreground(accentColor).
+		Bold(true)
 
 	// Help bar
 	helpStyle = lipgloss.NewStyle().
-			Foreground(greyColor).
-			Padding(0, 1)
+		Foreground(mutedColor).
+		Padding(0, 1)
 
 	helpKeyStyle = lipgloss.NewStyle().
-			Foreground(orangeColor).
-			Bold(true)
+		Foreground(accentColor).
+		Bold(true)
 
 	// Utility styles
 	mutedStyle = lipgloss.NewStyle().
-			Foreground(greyColor)
+		Foreground(mutedColor)
 
 	errorStyle = lipgloss.NewStyle().
-			Foreground(redColor)
+		Foreground(errorColor)
 
 	// Section divider
 	dividerStyle = lipgloss.NewStyle().
-			Foreground(greyColor)
+		Foreground(mutedColor)
 
 	// JSON syntax highlighting styles
 	jsonKeyStyle = lipgloss.NewStyle().
-			Foreground(jsonKeyColor).
-			Bold(true)
+		Foreground(jsonKeyColor).
+		Bold(true)
 
 	jsonStringStyle = lipgloss.NewStyle().
-			Foreground(jsonStringColor)
+		Foreground(jsonStringColor)
 
 	jsonNumberStyle = lipgloss.NewStyle().
-			Foreground(jsonNumberColor)
+		Foreground(jsonNumberColor)
 
 	jsonBoolStyle = lipgloss.NewStyle().
-			Foreground(jsonBoolColor)
+		Foreground(jsonBoolColor)
 
 	jsonNullStyle = lipgloss.NewStyle().
-			Foreground(jsonNullColor).
-			Italic(true)
+		Foreground(jsonNullColor).
+		Italic(true)
 
 	// Bookmark indicator
 	bookmarkStyle = lipgloss.NewStyle().
-			Foreground(orangeColor).
-			Bold(true)
+		Foreground(accentColor).
+		Bold(true)
 
 	// Help overlay
 	helpOverlayStyle = lipgloss.NewStyle().
-				Border(lipgloss.RoundedBorder()).
-				BorderForeground(orangeColor).
-				Padding(1, 2).
-				Background(darkBgColor)
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(accentColor).
+		Padding(1, 2).
+		Background(backgroundColor)
 
 	helpCategoryStyle = lipgloss.NewStyle().
-				Foreground(orangeColor).
-				Bold(true).
-				MarginTop(1)
+		Foreground(accentColor).
+		Bold(true).
+		MarginTop(1)
 
 	// Spinner style
 	spinnerStyle = lipgloss.NewStyle().
-			Foreground(orangeColor)
+		Foreground(accentColor)
 
 	// New message indicator
 	newMsgStyle = lipgloss.NewStyle().
-			Foreground(greenColor).
-			Bold(true)
+		Foreground(successColor).
+		Bold(true)
 
 	// Empty state
 	emptyStateStyle = lipgloss.NewStyle().
-			Foreground(greyColor).
-			Italic(true).
-			Align(lipgloss.Center)
+		Foreground(mutedColor).
+		Italic(true).
+		Align(lipgloss.Center)
 
 	// Confirmation message (for clipboard copy, export, etc.)
 	confirmationStyle = lipgloss.NewStyle().
-				Foreground(greenColor)
+		Foreground(successColor)
 
 	// Dead-letter indicator
 	dlxStyle = lipgloss.NewStyle().
-			Foreground(redColor)
+		Foreground(errorColor)
+
+	// Sparkline history graphs
+	sparklineStyle = lipgloss.NewStyle().
+		Foreground(jsonNumberColor)
+
+	// Resource alarms and failing health checks
+	alarmStyle = lipgloss.NewStyle().
+		Foreground(errorColor).
+		Bold(true)
+
+	// Message diff markers
+	diffAddedStyle = lipgloss.NewStyle().
+		Foreground(successColor).
+		Bold(true)
+
+	diffRemovedStyle = lipgloss.NewStyle().
+		Foreground(errorColor).
+		Bold(true)
+
+	diffChangedStyle = lipgloss.NewStyle().
+		Foreground(accentColor).
+		Bold(true)
 
 	// Status bar middle-dot separator (right group)
 	statusSepStyle = lipgloss.NewStyle().
-			Foreground(greyColor)
-)
+		Foreground(mutedColor)
+
+	if t.plain {
+		selectedMessageStyle = selectedMessageStyle.Reverse(true)
+	}
+}
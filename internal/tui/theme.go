@@ -0,0 +1,191 @@
+package tui
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/epalmerini/rabbithole/internal/config"
+)
+
+// Theme is the palette the views are drawn with.
+type Theme struct {
+	Accent     lipgloss.Color // headers, keys, selection, field names
+	Text       lipgloss.Color
+	Muted      lipgloss.Color // borders and secondary text
+	Background lipgloss.Color // overlays
+	Selection  lipgloss.Color // background of the selected row
+	Success    lipgloss.Color
+	Error      lipgloss.Color
+	JSONKey    lipgloss.Color
+	JSONString lipgloss.Color
+	JSONNumber lipgloss.Color
+	JSONBool   lipgloss.Color
+	JSONNull   lipgloss.Color
+
+	// plain themes have no colours: the selection is reversed instead
+	plain bool
+}
+
+// darkTheme is the default, for dark terminals.
+var darkTheme = Theme{
+	Accent:     "#FF9500",
+	Text:       "#E8E8E8",
+	Muted:      "#6B7280",
+	Background: "#1A1A1A",
+	Selection:  "#2D2D2D",
+	Success:    "#22C55E",
+	Error:      "#EF4444",
+	JSONKey:    "#FF9500",
+	JSONString: "#22C55E",
+	JSONNumber: "#60A5FA",
+	JSONBool:   "#F472B6",
+	JSONNull:   "#6B7280",
+}
+
+// builtinThemes can be picked by name with [ui] theme.
+var builtinThemes = map[string]Theme{
+	"dark": darkTheme,
+	"light": {
+		Accent:     "#C2410C",
+		Text:       "#1F2937",
+		Muted:      "#6B7280",
+		Background: "#F9FAFB",
+		Selection:  "#E5E7EB",
+		Success:    "#15803D",
+		Error:      "#B91C1C",
+		JSONKey:    "#C2410C",
+		JSONString: "#15803D",
+		JSONNumber: "#1D4ED8",
+		JSONBool:   "#BE185D",
+		JSONNull:   "#6B7280",
+	},
+	// high-contrast uses the Okabe-Ito palette, which stays distinct with
+	// the common forms of colour blindness: success is blue, not green
+	"high-contrast": {
+		Accent:     "#F0E442",
+		Text:       "#FFFFFF",
+		Muted:      "#BBBBBB",
+		Background: "#000000",
+		Selection:  "#0072B2",
+		Success:    "#56B4E9",
+		Error:      "#D55E00",
+		JSONKey:    "#F0E442",
+		JSONString: "#56B4E9",
+		JSONNumber: "#E69F00",
+		JSONBool:   "#CC79A7",
+		JSONNull:   "#BBBBBB",
+	},
+}
+
+// colorNames are the colour names themes and alert highlights may use.
+var colorNames = map[string]lipgloss.Color{
+	"red":     "#EF4444",
+	"green":   "#22C55E",
+	"orange":  "#FF9500",
+	"yellow":  "#FACC15",
+	"blue":    "#60A5FA",
+	"pink":    "#F472B6",
+	"magenta": "#D946EF",
+	"cyan":    "#22D3EE",
+	"white":   "#E8E8E8",
+	"black":   "#000000",
+	"grey":    "#6B7280",
+}
+
+// parseColor reads a colour name, #rgb, #rrggbb or an ANSI 256 colour.
+func parseColor(s string) (lipgloss.Color, bool) {
+	if c, ok := colorNames[strings.ToLower(s)]; ok {
+		return c, true
+	}
+	if strings.HasPrefix(s, "#") && (len(s) == 4 || len(s) == 7) {
+		if _, err := strconv.ParseUint(s[1:], 16, 32); err == nil {
+			return lipgloss.Color(s), true
+		}
+	}
+	if n, err := strconv.Atoi(s); err == nil && n >= 0 && n <= 255 {
+		return lipgloss.Color(s), true
+	}
+	return "", false
+}
+
+// NewTheme returns the named theme: a built-in one, or one of custom on top
+// of its base. An empty name is the dark theme. With noColor, as NO_COLOR
+// asks, the theme is still checked but no colours are used.
+func NewTheme(name string, custom map[string]config.ThemeColors, noColor bool) (Theme, error) {
+	if name == "" {
+		name = "dark"
+	}
+	t, ok := builtinThemes[name]
+	if c, isCustom := custom[name]; isCustom {
+		var err error
+		if t, err = customTheme(c); err != nil {
+			return Theme{}, fmt.Errorf("themes.%s: %w", name, err)
+		}
+	} else if !ok {
+		return Theme{}, fmt.Errorf("unknown theme %q (use %s, or define it in [themes])", name, strings.Join(themeNames(), ", "))
+	}
+	if noColor {
+		return Theme{plain: true}, nil
+	}
+	return t, nil
+}
+
+func customTheme(c config.ThemeColors) (Theme, error) {
+	base := c.Base
+	if base == "" {
+		base = "dark"
+	}
+	t, ok := builtinThemes[base]
+	if !ok {
+		return Theme{}, fmt.Errorf("unknown base %q (use %s)", base, strings.Join(themeNames(), ", "))
+	}
+	for _, f := range []struct {
+		key   string
+		value string
+		color *lipgloss.Color
+	}{
+		{"accent", c.Accent, &t.Accent},
+		{"text", c.Text, &t.Text},
+		{"muted", c.Muted, &t.Muted},
+		{"background", c.Background, &t.Background},
+		{"selection", c.Selection, &t.Selection},
+		{"success", c.Success, &t.Success},
+		{"error", c.Error, &t.Error},
+		{"json_key", c.JSONKey, &t.JSONKey},
+		{"json_string", c.JSONString, &t.JSONString},
+		{"json_number", c.JSONNumber, &t.JSONNumber},
+		{"json_bool", c.JSONBool, &t.JSONBool},
+		{"json_null", c.JSONNull, &t.JSONNull},
+	} {
+		if f.value == "" {
+			continue
+		}
+		color, ok := parseColor(f.value)
+		if !ok {
+			return Theme{}, fmt.Errorf("invalid %s %q (use a colour name, #rrggbb or 0-255)", f.key, f.value)
+		}
+		*f.color = color
+	}
+	return t, nil
+}
+
+func themeNames() []string {
+	names := make([]string, 0, len(builtinThemes))
+	for name := range builtinThemes {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// highlightStyle is the row style of an alert highlight: the colour, or an
+// underline when colours are off.
+func highlightStyle(c lipgloss.Color) lipgloss.Style {
+	if plainTheme {
+		return lipgloss.NewStyle().Underline(true).Bold(true)
+	}
+	return lipgloss.NewStyle().Foreground(c).Bold(true)
+}
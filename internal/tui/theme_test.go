@@ -0,0 +1,81 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/epalmerini/rabbithole/internal/alert"
+	"github.com/epalmerini/rabbithole/internal/config"
+)
+
+func TestNewTheme(t *testing.T) {
+	custom := map[string]config.ThemeColors{
+		"paper": {Base: "light", Accent: "#b58900", JSONKey: "33", Error: "magenta"},
+		"dusk":  {Accent: "orange"},
+	}
+
+	if th, err := NewTheme("", nil, false); err != nil || th != darkTheme {
+		t.Errorf("default theme = %+v, %v; want dark", th, err)
+	}
+	th, err := NewTheme("paper", custom, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	light := builtinThemes["light"]
+	if th.Accent != "#b58900" || th.JSONKey != "33" || th.Error != colorNames["magenta"] || th.Text != light.Text {
+		t.Errorf("paper = %+v, want light with the custom colours", th)
+	}
+	if th, _ := NewTheme("dusk", custom, false); th.Background != darkTheme.Background {
+		t.Errorf("a theme without base should start from dark, got %+v", th)
+	}
+
+	// NO_COLOR drops the colours, but a bad theme is still reported
+	if th, err := NewTheme("high-contrast", nil, true); err != nil || !th.plain || th.Accent != "" {
+		t.Errorf("NO_COLOR theme = %+v, %v", th, err)
+	}
+	for _, tt := range []struct {
+		name   string
+		custom map[string]config.ThemeColors
+		want   string
+	}{
+		{"solarized", nil, `unknown theme "solarized" (use dark, high-contrast, light`},
+		{"x", map[string]config.ThemeColors{"x": {Base: "sepia"}}, `themes.x: unknown base "sepia"`},
+		{"x", map[string]config.ThemeColors{"x": {Muted: "#12345"}}, `themes.x: invalid muted "#12345"`},
+		{"x", map[string]config.ThemeColors{"x": {Selection: "#gggggg"}}, `invalid selection "#gggggg"`},
+	} {
+		if _, err := NewTheme(tt.name, tt.custom, true); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("NewTheme(%q) error = %v, want %q", tt.name, err, tt.want)
+		}
+	}
+}
+
+func TestApplyTheme_Plain(t *testing.T) {
+	profile := lipgloss.ColorProfile()
+	t.Cleanup(func() {
+		applyTheme(darkTheme)
+		lipgloss.SetColorProfile(profile)
+	})
+
+	th, _ := NewTheme("dark", nil, true)
+	applyTheme(th)
+	if !selectedMessageStyle.GetReverse() || selectedMessageStyle.GetForeground() != lipgloss.Color("") {
+		t.Error("without colours the selection should be reversed")
+	}
+	if got := selectedMessageStyle.Render("x"); got == "x" {
+		t.Error("the reversed selection was rendered without attributes")
+	}
+	if strings.Contains(headerStyle.Render("rabbithole"), "38;") {
+		t.Error("the header was rendered with a colour")
+	}
+
+	alerts, err := alert.NewRules([]config.AlertRule{{Filter: "rk:failed", Highlight: "red"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := model{alerts: alerts}
+	msg := Message{RoutingKey: "failed"}
+	if _, _ = m.fireAlerts(&msg); msg.highlight == nil || !msg.highlight.GetUnderline() {
+		t.Error("without colours an alert highlight should be underlined")
+	}
+}
@@ -0,0 +1,298 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/epalmerini/rabbithole/internal/config"
+	"github.com/epalmerini/rabbithole/internal/db"
+)
+
+// traceLimit caps the stored messages a single trace reads.
+const traceLimit = 500
+
+// traceEntry is one hop of a traced conversation.
+type traceEntry struct {
+	msg       Message
+	sessionID int64  // stored session the hop was read from; 0 for the live list
+	liveID    int    // Message.ID in the live list; 0 for stored hops
+	via       string // property linking the hop to the traced message
+}
+
+// traceView is the timeline of messages related to the one it started from.
+type traceView struct {
+	keys    db.TraceKeys
+	entries []traceEntry
+	cursor  int
+	loading bool
+	err     error
+}
+
+type traceResultMsg struct {
+	keys    db.TraceKeys
+	entries []traceEntry
+	err     error
+}
+
+// traceKeysFor returns the properties of msg a trace follows.
+func traceKeysFor(msg Message, header string) db.TraceKeys {
+	keys := db.TraceKeys{
+		CorrelationID: msg.CorrelationID,
+		MessageID:     msg.MessageID,
+		ReplyTo:       msg.ReplyTo, // marks the replies found by message id
+	}
+	if header != "" {
+		if v, ok := msg.Headers[header]; ok && v != nil {
+			keys.Header = header
+			keys.HeaderValue = fmt.Sprint(v)
+		}
+	}
+	return keys
+}
+
+// startTrace opens the timeline for the selected message. Live matches are
+// collected here, as the message list may change under a running command;
+// the store is searched in the background.
+func (m *model) startTrace() tea.Cmd {
+	if m.messages.Len() == 0 || m.selectedIdx >= m.messages.Len() {
+		return nil
+	}
+	keys := traceKeysFor(*m.messages.At(m.selectedIdx), m.config.TraceHeader)
+	if keys.IsZero() {
+		what := "correlation id or message id"
+		if m.config.TraceHeader != "" {
+			what += " or " + m.config.TraceHeader + " header"
+		}
+		return m.setStatusMsg("Nothing to trace: message has no " + what)
+	}
+
+	var live []traceEntry
+	for _, msg := range m.messages.All() {
+		if via := keys.Match(msg.CorrelationID, msg.MessageID, msg.Headers); via != "" {
+			live = append(live, traceEntry{msg: msg, liveID: msg.ID, via: via})
+		}
+	}
+	m.trace = &traceView{keys: keys, loading: m.store != nil}
+	m.trace.setEntries(sortTrace(live), m.messages.At(m.selectedIdx).ID)
+	if m.store == nil {
+		return nil
+	}
+
+	store := m.store
+	return func() tea.Msg {
+		stored, err := store.TraceMessages(context.Background(), keys, traceLimit)
+		if err != nil {
+			return traceResultMsg{keys: keys, err: fmt.Errorf("trace failed: %w", err)}
+		}
+		return traceResultMsg{keys: keys, entries: mergeTrace(live, stored, keys)}
+	}
+}
+
+// mergeTrace adds the stored hops to the live ones, skipping stored copies of
+// messages already in the live list.
+func mergeTrace(live []traceEntry, stored []db.Message, keys db.TraceKeys) []traceEntry {
+	seen := make(map[string]bool, len(live))
+	for _, e := range live {
+		seen[traceFingerprint(e.msg)] = true
+	}
+
+	entries := append([]traceEntry{}, live...)
+	for i, msg := range convertDBMessages(stored, nil) {
+		if seen[traceFingerprint(msg)] {
+			continue
+		}
+		entries = append(entries, traceEntry{
+			msg:       msg,
+			sessionID: stored[i].SessionID,
+			via:       keys.Match(msg.CorrelationID, msg.MessageID, msg.Headers),
+		})
+	}
+	return sortTrace(entries)
+}
+
+// traceFingerprint identifies a message across the live list and the store,
+// which share no ID.
+func traceFingerprint(msg Message) string {
+	return fmt.Sprintf("%s\x00%s\x00%d\x00%s\x00%s", msg.Exchange, msg.RoutingKey, msg.Timestamp.UnixNano(), msg.MessageID, msg.RawBody)
+}
+
+// setEntries replaces the timeline, keeping the cursor on the hop with the
+// given live message ID.
+func (t *traceView) setEntries(entries []traceEntry, selectedID int) {
+	t.entries = entries
+	t.cursor = 0
+	for i, e := range entries {
+		if e.liveID != 0 && e.liveID == selectedID {
+			t.cursor = i
+		}
+	}
+}
+
+func sortTrace(entries []traceEntry) []traceEntry {
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].msg.arrival().Before(entries[j].msg.arrival())
+	})
+	return entries
+}
+
+// updateTrace handles keys while the timeline is open.
+func (m model) updateTrace(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	t := m.trace
+	keys, key := m.config.Keys, msg.String()
+	is := func(action string) bool { return keys.is(config.KeysTrace, key, action) }
+	switch {
+	case key == "ctrl+c":
+		return m, tea.Quit
+	case is("close"), keys.is(config.KeysConsumer, key, "trace"):
+		// The key that opened the timeline also closes it
+		m.trace = nil
+	case is("down"):
+		if t.cursor < len(t.entries)-1 {
+			t.cursor++
+		}
+	case is("up"):
+		if t.cursor > 0 {
+			t.cursor--
+		}
+	case is("top"):
+		t.cursor = 0
+	case is("bottom"):
+		if len(t.entries) > 0 {
+			t.cursor = len(t.entries) - 1
+		}
+	case is("jump"):
+		if t.cursor >= len(t.entries) {
+			return m, nil
+		}
+		e := t.entries[t.cursor]
+		idx := -1
+		for i, msg := range m.messages.All() {
+			if e.liveID != 0 && msg.ID == e.liveID {
+				idx = i
+				break
+			}
+		}
+		if idx < 0 {
+			if e.sessionID != 0 {
+				return m, m.setStatusMsg(fmt.Sprintf("Stored in session #%d, not in the live list", e.sessionID))
+			}
+			return m, m.setStatusMsg("No longer in the live list")
+		}
+		m.trace = nil
+		m.selectedIdx = idx
+		m.detailViewport.YOffset = 0
+		if len(m.filteredIdx) > 0 && !isVisible(m.filteredIdx, m.selectedIdx) {
+			m.filterActive = false
+			m.filteredIdx = nil
+		}
+	}
+	return m, nil
+}
+
+func (m model) renderTrace() string {
+	t := m.trace
+	header := headerStyle.Width(m.width - 2).Render("rabbithole — trace")
+	status := m.renderStatusBar()
+
+	height := m.height - 5
+	if height < 3 {
+		height = 3
+	}
+	innerHeight := height - 2
+	innerWidth := m.width - 4
+
+	lines := []string{fieldNameStyle.Render(truncate(describeTraceKeys(t.keys), innerWidth))}
+	switch {
+	case t.err != nil:
+		lines = append(lines, errorStyle.Render(t.err.Error()))
+	case t.loading:
+		lines = append(lines, mutedStyle.Render("Searching stored sessions..."))
+	case len(t.entries) > 1:
+		span := t.entries[len(t.entries)-1].msg.arrival().Sub(t.entries[0].msg.arrival())
+		lines = append(lines, mutedStyle.Render(fmt.Sprintf("%d messages over %s", len(t.entries), formatLatency(span))))
+	default:
+		lines = append(lines, mutedStyle.Render(fmt.Sprintf("%d messages", len(t.entries))))
+	}
+	lines = append(lines, "")
+
+	rows := innerHeight - len(lines)
+	if rows < 1 {
+		rows = 1
+	}
+	start := 0
+	if t.cursor >= rows {
+		start = t.cursor - rows + 1
+	}
+	end := start + rows
+	if end > len(t.entries) {
+		end = len(t.entries)
+	}
+
+	for i := start; i < end; i++ {
+		e := t.entries[i]
+		hop := "—"
+		if i > 0 {
+			hop = "+" + formatLatency(e.msg.arrival().Sub(t.entries[i-1].msg.arrival()))
+		}
+		source := "live"
+		if e.sessionID != 0 {
+			source = fmt.Sprintf("session #%d", e.sessionID)
+		}
+		line := fmt.Sprintf("%s %-12s %-12s %-14s %s → %s", e.msg.arrival().Format("15:04:05.000"), hop, source, "via "+e.via, e.msg.Exchange, e.msg.RoutingKey)
+		line = truncate(line, innerWidth)
+		switch {
+		case i == t.cursor:
+			line = selectedMessageStyle.Render(line)
+		case e.sessionID != 0:
+			line = mutedStyle.Render(line)
+		}
+		lines = append(lines, line)
+	}
+
+	content := messageListStyle.Width(m.width - 2).Height(height).Render(strings.Join(lines, "\n"))
+
+	var parts []string
+	km := m.config.Keys
+	for _, k := range []struct{ key, desc string }{
+		{km.hint(config.KeysTrace, "down", "up"), "nav"},
+		{km.hint(config.KeysTrace, "jump"), "jump to message"},
+		{km.hint(config.KeysTrace, "close"), "close"},
+	} {
+		parts = append(parts, helpKeyStyle.Render(k.key)+" "+k.desc)
+	}
+	bottomBar := helpStyle.Render(strings.Join(parts, "  "))
+
+	return lipgloss.JoinVertical(lipgloss.Left, header, status, content, bottomBar)
+}
+
+// describeTraceKeys lists the properties a trace follows.
+func describeTraceKeys(k db.TraceKeys) string {
+	var parts []string
+	if k.CorrelationID != "" {
+		parts = append(parts, "correlation_id="+k.CorrelationID)
+	}
+	if k.MessageID != "" {
+		parts = append(parts, "message_id="+k.MessageID)
+	}
+	if k.Header != "" && k.HeaderValue != "" {
+		parts = append(parts, k.Header+"="+k.HeaderValue)
+	}
+	return "Trace: " + strings.Join(parts, "  ")
+}
+
+// formatLatency renders the time between two hops at a useful precision.
+func formatLatency(d time.Duration) string {
+	switch {
+	case d >= time.Minute:
+		return d.Round(time.Second).String()
+	case d >= time.Second:
+		return d.Round(time.Millisecond).String()
+	default:
+		return d.Round(time.Microsecond).String()
+	}
+}
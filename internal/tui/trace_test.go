@@ -0,0 +1,141 @@
+package tui
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/epalmerini/rabbithole/internal/db"
+)
+
+func TestTrace(t *testing.T) {
+	store, err := db.NewStore(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	ctx := context.Background()
+
+	base := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	// Latencies come from arrival times; the publishers' clocks are off
+	skew := -time.Hour
+	request := Message{ID: 1, Exchange: "orders", RoutingKey: "order.request", MessageID: "msg-1", Timestamp: base.Add(skew), ConsumedAt: base, RawBody: []byte("req")}
+	unrelated := Message{ID: 2, Exchange: "orders", RoutingKey: "order.other", MessageID: "msg-2", Timestamp: base.Add(skew), ConsumedAt: base.Add(time.Millisecond)}
+	reply := Message{ID: 3, Exchange: "orders", RoutingKey: "order.reply", CorrelationID: "msg-1", Timestamp: base.Add(-skew), ConsumedAt: base.Add(300 * time.Millisecond)}
+
+	// The current session already persisted the request; an earlier one
+	// holds a downstream hop
+	current, err := store.CreateSession(ctx, "orders", "#", "q", "amqp://localhost/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	earlier, err := store.CreateSession(ctx, "billing", "#", "q", "amqp://localhost/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, rec := range []db.MessageRecord{
+		{SessionID: current, Exchange: "orders", RoutingKey: "order.request", MessageID: "msg-1", Timestamp: base.Add(skew), ConsumedAt: base, Body: []byte("req")},
+		{SessionID: earlier, Exchange: "billing", RoutingKey: "invoice.created", CorrelationID: "msg-1", Timestamp: base.Add(2 * skew), ConsumedAt: base.Add(100 * time.Millisecond), Body: []byte("inv")},
+	} {
+		if _, err := store.InsertMessage(ctx, &rec); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	m := model{
+		store:          store,
+		messages:       newMessageRing(0, request, unrelated, reply),
+		detailViewport: viewport.New(80, 20),
+		vimKeys:        NewVimKeyState(),
+	}
+
+	updated, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("x")})
+	m = updated.(model)
+	if m.trace == nil || !m.trace.loading {
+		t.Fatal("x should open a loading trace")
+	}
+	if len(m.trace.entries) != 2 {
+		t.Errorf("live hops before the store answers = %d, want 2", len(m.trace.entries))
+	}
+
+	updated, _ = m.Update(cmd())
+	m = updated.(model)
+	if m.trace.loading || m.trace.err != nil {
+		t.Fatalf("trace not loaded: %v", m.trace.err)
+	}
+
+	var got []string
+	for _, e := range m.trace.entries {
+		got = append(got, e.msg.RoutingKey+"/"+e.via)
+	}
+	want := []string{"order.request/message_id", "invoice.created/correlation_id", "order.reply/correlation_id"}
+	if strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Fatalf("timeline = %v, want %v", got, want)
+	}
+	if m.trace.entries[0].sessionID != 0 {
+		t.Error("the stored copy of a live message should be dropped")
+	}
+	if m.trace.entries[1].sessionID != earlier {
+		t.Errorf("stored hop session = %d, want %d", m.trace.entries[1].sessionID, earlier)
+	}
+
+	m.width, m.height = 120, 30
+	view := m.View()
+	for _, s := range []string{"+100ms", "+200ms", "session #", "3 messages over 300ms"} {
+		if !strings.Contains(view, s) {
+			t.Errorf("timeline view missing %q", s)
+		}
+	}
+
+	// Jumping to a live hop closes the trace and selects it
+	m.trace.cursor = 2
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = updated.(model)
+	if m.trace != nil || m.selectedIdx != 2 {
+		t.Errorf("enter: trace open = %v, selectedIdx = %d, want closed at 2", m.trace != nil, m.selectedIdx)
+	}
+}
+
+func TestTrace_NothingToTrace(t *testing.T) {
+	m := model{
+		messages: newMessageRing(0, Message{ID: 1, RoutingKey: "a", Headers: map[string]any{"x-trace-id": "t-1"}}),
+		vimKeys:  NewVimKeyState(),
+	}
+	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("x")})
+	if got := updated.(model); got.trace != nil || !strings.Contains(got.statusMsg, "Nothing to trace") {
+		t.Errorf("trace = %v, status = %q; want a status message", got.trace, got.statusMsg)
+	}
+
+	// The configured header is enough to trace by
+	m.config.TraceHeader = "x-trace-id"
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("x")})
+	if got := updated.(model); got.trace == nil || len(got.trace.entries) != 1 || got.trace.entries[0].via != "x-trace-id" {
+		t.Errorf("expected a trace by header, got %+v", got.trace)
+	}
+}
+
+func TestTrace_MarksReplies(t *testing.T) {
+	request := Message{ID: 1, RoutingKey: "rpc.request", MessageID: "msg-1", ReplyTo: "amq.rabbitmq.reply-to"}
+	reply := Message{ID: 2, RoutingKey: "rpc.reply", CorrelationID: "msg-1"}
+	sameQueue := Message{ID: 3, RoutingKey: "rpc.other", MessageID: "msg-9", ReplyTo: "amq.rabbitmq.reply-to"}
+	m := model{
+		messages: newMessageRing(0, request, reply, sameQueue),
+		vimKeys:  NewVimKeyState(),
+	}
+
+	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("x")})
+	m = updated.(model)
+	var got []string
+	for _, e := range m.trace.entries {
+		got = append(got, e.msg.RoutingKey+"/"+e.via)
+	}
+	// Sharing a reply queue does not make requests related
+	want := []string{"rpc.request/message_id", "rpc.reply/reply"}
+	if strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Errorf("timeline = %v, want %v", got, want)
+	}
+}
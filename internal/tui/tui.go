@@ -24,6 +24,14 @@ const (
 	initialBackoff = 1 * time.Second
 )
 
+// pruneInterval is how often the retention policy is applied while running.
+const pruneInterval = time.Hour
+
+// pruneFailedMsg reports a background prune that failed.
+type pruneFailedMsg struct {
+	err error
+}
+
 // Run starts the TUI application with the given file config.
 func Run(fileCfg *config.FileConfig, configDir string) error {
 	// Always init persistence
@@ -34,6 +42,46 @@ func Run(fileCfg *config.FileConfig, configDir string) error {
 	}
 	defer func() { _ = persistStore.Close() }()
 
+	// Old sessions are pruned in the background according to [retention]
+	// once the program runs, so failures can be shown
+	retention, err := db.RetentionFromConfig(fileCfg)
+	if err != nil {
+		return fmt.Errorf("invalid retention config: %w", err)
+	}
+	// Report bad alert rules now rather than when a profile is picked
+	rules := append([]config.AlertRule{}, fileCfg.Alerts...)
+	for _, p := range fileCfg.Profiles {
+		rules = append(rules, p.Alerts...)
+	}
+	if err := checkAlertRules(rules); err != nil {
+		return fmt.Errorf("invalid alert rules: %w", err)
+	}
+	filters := append([]config.SavedFilter{}, fileCfg.Filters...)
+	for _, p := range fileCfg.Profiles {
+		filters = append(filters, p.Filters...)
+	}
+	if err := checkSavedFilters(filters); err != nil {
+		return fmt.Errorf("invalid saved filters: %w", err)
+	}
+	if _, err := NewKeymap(fileCfg.Keys); err != nil {
+		return fmt.Errorf("invalid key bindings: %w", err)
+	}
+	if _, err := newListColumns(fileCfg.UI.Columns); err != nil {
+		return fmt.Errorf("invalid ui.columns: %w", err)
+	}
+	switch fileCfg.UI.Split {
+	case "", config.SplitVertical, config.SplitHorizontal:
+	default:
+		return fmt.Errorf("invalid ui.split %q (use %s or %s)", fileCfg.UI.Split, config.SplitVertical, config.SplitHorizontal)
+	}
+
+	// Colours are set before any view is built; NO_COLOR turns them off
+	theme, err := NewTheme(fileCfg.UI.Theme, fileCfg.Themes, os.Getenv("NO_COLOR") != "")
+	if err != nil {
+		return fmt.Errorf("invalid theme: %w", err)
+	}
+	applyTheme(theme)
+
 	// Migrate prefs.json if needed
 	if dataDir, err := db.DefaultDataDir(); err == nil {
 		_ = config.MigratePrefs(dataDir, configDir)
@@ -67,6 +115,14 @@ func Run(fileCfg *config.FileConfig, configDir string) error {
 
 	p := tea.NewProgram(m, tea.WithAltScreen())
 
+	if retention.Enabled() {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go persistStore.RunPruner(ctx, retention, pruneInterval, func(err error) {
+			p.Send(pruneFailedMsg{err: err})
+		})
+	}
+
 	finalModel, err := p.Run()
 	if err != nil {
 		return fmt.Errorf("error running program: %w", err)
@@ -89,10 +145,23 @@ func resolveToTUIConfig(fileCfg *config.FileConfig, profileName, configDir strin
 		ProtoPath:         resolved.ProtoPath,
 		DBPath:            resolved.DBPath,
 		MaxMessages:       resolved.MaxMessages,
+		PauseBuffer:       resolved.PauseBuffer,
+		PauseOverflow:     resolved.PauseOverflow,
+		Production:        resolved.Production,
+		TraceHeader:       resolved.TraceHeader,
+		DiffKey:           resolved.DiffKey,
+		LatencyHeader:     resolved.LatencyHeader,
+		Alerts:            resolved.Alerts,
+		Filters:           resolved.Filters,
 		DefaultSplitRatio: resolved.DefaultSplitRatio,
 		CompactMode:       resolved.CompactMode,
+		ChartMinutes:      resolved.ChartMinutes,
+		Columns:           resolved.Columns,
+		Split:             resolved.Split,
 		ConfigDir:         resolved.ConfigDir,
 	}
+	// Run has reported bad bindings already
+	cfg.Keys, _ = NewKeymap(resolved.Keys)
 
 	if cfg.ProtoPath != "" {
 		dec, err := proto.NewDecoder(cfg.ProtoPath)
@@ -161,6 +230,9 @@ func (m model) connectWithRetry(attempt int) tea.Cmd {
 				dbMsgs, err := m.store.ListMessagesBySessionAsc(ctx, lastSession.ID, int64(m.config.MessageLimit()), 0)
 				if err == nil {
 					historicalMsgs = convertDBMessages(dbMsgs, m.config.Decoder)
+					if notes, err := m.store.GetAnnotations(ctx, storeIDs(dbMsgs)); err == nil {
+						annotate(historicalMsgs, notes)
+					}
 				}
 			}
 
@@ -197,6 +269,7 @@ func (m model) connectWithRetry(attempt int) tea.Cmd {
 					RoutingKey:    del.RoutingKey,
 					Exchange:      del.Exchange,
 					Timestamp:     del.Timestamp,
+					ConsumedAt:    del.ConsumedAt,
 					RawBody:       del.Body,
 					Headers:       headers,
 					ContentType:   del.ContentType,
@@ -204,6 +277,7 @@ func (m model) connectWithRetry(attempt int) tea.Cmd {
 					ReplyTo:       del.ReplyTo,
 					MessageID:     del.MessageID,
 					AppID:         del.AppID,
+					published:     del.Published,
 				}
 
 				// Try to decode protobuf with routing key hint
@@ -219,19 +293,28 @@ func (m model) connectWithRetry(attempt int) tea.Cmd {
 
 				// Persist message
 				if writer != nil {
-					writer.Save(&db.MessageRecord{
+					msg.saved = newSavedRow()
+					queued := writer.SaveThen(&db.MessageRecord{
 						Exchange:      del.Exchange,
 						RoutingKey:    del.RoutingKey,
 						Body:          del.Body,
 						ContentType:   del.ContentType,
 						Headers:       del.Headers,
-						Timestamp:     del.Timestamp,
+						Timestamp:     del.PublisherTimestamp(),
+						ConsumedAt:    del.ConsumedAt,
 						ProtoType:     msg.ProtoType,
+						Decoded:       msg.Decoded,
 						CorrelationID: del.CorrelationID,
 						ReplyTo:       del.ReplyTo,
 						MessageID:     del.MessageID,
 						AppID:         del.AppID,
-					})
+						DeliveryMode:  del.DeliveryMode,
+						Priority:      del.Priority,
+						Expiration:    del.Expiration,
+					}, msg.saved.set)
+					if !queued {
+						msg.saved.set(0, errNotSaved)
+					}
 				}
 
 				msgChan <- msg
@@ -270,6 +353,41 @@ func scheduleRetry(attempt int, delay time.Duration) tea.Cmd {
 	})
 }
 
+// alarmsPolledMsg carries the resource alarms firing in the cluster.
+type alarmsPolledMsg struct {
+	id     int64
+	alarms []string
+	err    error
+}
+
+// alarmPollTickMsg triggers the next alarm poll.
+type alarmPollTickMsg struct {
+	id int64
+}
+
+// pollAlarms fetches node alarms so the status bar can explain blocked publishers.
+func (m model) pollAlarms() tea.Cmd {
+	mgmt := m.mgmt
+	id := m.alarmPollID
+	if mgmt == nil || m.replayMode {
+		return nil
+	}
+	return func() tea.Msg {
+		nodes, err := mgmt.GetNodes(context.Background())
+		if err != nil {
+			return alarmsPolledMsg{id: id, err: err}
+		}
+		return alarmsPolledMsg{id: id, alarms: rabbitmq.ClusterAlarms(nodes)}
+	}
+}
+
+func (m model) scheduleAlarmPoll() tea.Cmd {
+	id := m.alarmPollID
+	return tea.Tick(alarmPollRefresh, func(time.Time) tea.Msg {
+		return alarmPollTickMsg{id: id}
+	})
+}
+
 // cleanup releases consumer and persistence resources.
 // Safe to call on zero-value or already-cleaned-up models.
 func (m *model) cleanup() {
@@ -297,8 +415,9 @@ func (m *model) cleanup() {
 	}
 }
 
-// initialReplayModel creates a consumer model pre-loaded with session messages (no AMQP).
-func initialReplayModel(cfg Config, session db.Session, dbMsgs []db.Message) model {
+// initialReplayModel creates a consumer model that pages a stored session
+// from the store (no AMQP). Its first page is loaded by startReplay.
+func initialReplayModel(cfg Config, session db.Session, store db.Store) model {
 	si := textinput.New()
 	si.Placeholder = "Search..."
 	si.CharLimit = 100
@@ -314,23 +433,24 @@ func initialReplayModel(cfg Config, session db.Session, dbMsgs []db.Message) mod
 	sp.Style = spinnerStyle
 
 	splitRatio := loadSplitRatio(cfg)
-
-	msgs := convertDBMessages(dbMsgs, cfg.Decoder)
+	searchHistory, filterHistory := loadInputHistory(store)
 
 	return model{
 		config:         cfg,
 		replayMode:     true,
-		messages:       msgs,
-		messageCount:   len(msgs),
+		store:          store,
+		pager:          newReplayPager(session.ID),
 		connState:      stateConnected,
 		viewport:       viewport.New(80, 20),
 		detailViewport: viewport.New(80, 20),
-		vimKeys:        NewVimKeyState(),
-		bookmarks:      make(map[int]bool),
+		vimKeys:        newVimKeyStateWith(cfg.Keys),
 		splitRatio:     splitRatio,
 		compactMode:    cfg.CompactMode,
+		columns:        listColumns(cfg.Columns),
 		searchInput:    si,
 		filterInput:    fi,
+		searchHistory:  searchHistory,
+		filterHistory:  filterHistory,
 		spinner:        sp,
 	}
 }
@@ -341,13 +461,18 @@ func convertDBMessages(dbMsgs []db.Message, dec *proto.Decoder) []Message {
 	for i, dbMsg := range dbMsgs {
 		msg := Message{
 			ID:         i + 1,
+			storeID:    dbMsg.ID,
 			Exchange:   dbMsg.Exchange,
 			RoutingKey: dbMsg.RoutingKey,
+			ConsumedAt: dbMsg.ConsumedAt,
 			RawBody:    dbMsg.Body,
 			Historical: true,
 		}
 		if dbMsg.Timestamp.Valid {
 			msg.Timestamp = dbMsg.Timestamp.Time
+			msg.published = true
+		} else {
+			msg.Timestamp = dbMsg.ConsumedAt
 		}
 		if dbMsg.ContentType.Valid {
 			msg.ContentType = dbMsg.ContentType.String
@@ -0,0 +1,169 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/epalmerini/rabbithole/internal/rabbitmq"
+)
+
+// vhostEntry holds a virtual host with its queue count for display.
+type vhostEntry struct {
+	vhost  rabbitmq.VHost
+	queues int
+}
+
+type vhostsLoadedMsg struct {
+	entries []vhostEntry
+}
+
+// vhostSwitchedMsg tells the parent the browser moved to another vhost,
+// so consumers started afterwards connect with the rewritten URL.
+type vhostSwitchedMsg struct {
+	vhost string
+	url   string
+}
+
+func (m browserModel) loadVHosts() tea.Cmd {
+	mgmt := m.mgmt
+	return func() tea.Msg {
+		if mgmt == nil {
+			return errorMsg{err: fmt.Errorf("management client not initialized")}
+		}
+
+		ctx := context.Background()
+		vhosts, err := mgmt.GetVHosts(ctx)
+		if err != nil {
+			return errorMsg{err: fmt.Errorf("failed to load vhosts: %w", err)}
+		}
+
+		// Queue counts are best effort: listing every queue can be denied or slow
+		counts := make(map[string]int)
+		if queues, err := mgmt.GetAllQueues(ctx); err == nil {
+			for _, q := range queues {
+				counts[q.VHost]++
+			}
+		}
+
+		sort.Slice(vhosts, func(i, j int) bool { return vhosts[i].Name < vhosts[j].Name })
+		entries := make([]vhostEntry, len(vhosts))
+		for i, v := range vhosts {
+			entries[i] = vhostEntry{vhost: v, queues: counts[v.Name]}
+		}
+		return vhostsLoadedMsg{entries: entries}
+	}
+}
+
+// switchVHost rescopes the browser to vhost and reloads the topology.
+func (m browserModel) switchVHost(vhost string) (browserModel, tea.Cmd) {
+	newURL, err := rabbitmq.URLWithVHost(m.config.RabbitMQURL, vhost)
+	if err != nil {
+		m.err = err
+		return m, nil
+	}
+
+	m.config.RabbitMQURL = newURL
+	if m.mgmt != nil {
+		m.mgmt = m.mgmt.WithVHost(vhost)
+	}
+	m.view = viewExchanges
+	m.selectedIdx = 0
+	m.scrollOff = 0
+	m.selectedExchange = ""
+	m.filterQuery = ""
+	m.filteredList = nil
+	m.exchanges = nil
+	m.queues = nil
+	m.bindings = nil
+	m.policies = nil
+	m.selectedQueues = make(map[string]bool)
+	m.err = nil
+	m.loading = true
+
+	return m, tea.Batch(
+		m.loadTopology(),
+		func() tea.Msg { return vhostSwitchedMsg{vhost: vhost, url: newURL} },
+	)
+}
+
+// updateVHosts handles keys in the vhost picker. handled is false for keys
+// that fall through to the shared browser navigation.
+func (m browserModel) updateVHosts(msg tea.KeyMsg) (_ browserModel, _ tea.Cmd, handled bool) {
+	switch m.key(msg) {
+	case "enter":
+		if m.selectedIdx < len(m.vhosts) {
+			m, cmd := m.switchVHost(m.vhosts[m.selectedIdx].vhost.Name)
+			return m, cmd, true
+		}
+		return m, nil, true
+	case "r":
+		m.loading = true
+		return m, m.loadVHosts(), true
+	case "esc", "backspace":
+		m.view = viewExchanges
+		m.selectedIdx = 0
+		m.scrollOff = 0
+		m.err = nil
+		return m, nil, true
+	}
+	return m, nil, false
+}
+
+func (m browserModel) renderVHosts() string {
+	var sb strings.Builder
+
+	sb.WriteString(fieldNameStyle.Render("Select a virtual host:"))
+	sb.WriteString("\n\n")
+
+	if m.loading {
+		sb.WriteString("  " + m.spinner.View() + " Loading...")
+		return messageListStyle.Width(m.width - 4).Height(m.height - 8).Render(sb.String())
+	}
+
+	if m.err != nil {
+		sb.WriteString(errorStyle.Render(fmt.Sprintf("  Error: %v", m.err)))
+		return messageListStyle.Width(m.width - 4).Height(m.height - 8).Render(sb.String())
+	}
+
+	if len(m.vhosts) == 0 {
+		sb.WriteString(mutedStyle.Render("  No virtual hosts found"))
+		return messageListStyle.Width(m.width - 4).Height(m.height - 8).Render(sb.String())
+	}
+
+	visibleItems := m.height - 12
+	if visibleItems < 1 {
+		visibleItems = 1
+	}
+	startIdx := 0
+	if m.selectedIdx >= visibleItems {
+		startIdx = m.selectedIdx - visibleItems + 1
+	}
+	endIdx := startIdx + visibleItems
+	if endIdx > len(m.vhosts) {
+		endIdx = len(m.vhosts)
+	}
+
+	current := m.vhost()
+	for i := startIdx; i < endIdx; i++ {
+		e := m.vhosts[i]
+		marker := " "
+		if e.vhost.Name == current {
+			marker = "●"
+		}
+		line := fmt.Sprintf("%s %-30s %s", marker, truncate(e.vhost.Name, 30),
+			mutedStyle.Render(fmt.Sprintf("%d queues  %d msgs (%d ready, %d unacked)",
+				e.queues, e.vhost.Messages, e.vhost.MessagesReady, e.vhost.MessagesUnacknowledged)))
+
+		if i == m.selectedIdx {
+			sb.WriteString(selectedMessageStyle.Width(m.width - 8).Render("▶ " + line))
+		} else {
+			sb.WriteString(normalMessageStyle.Width(m.width - 8).Render("  " + line))
+		}
+		sb.WriteString("\n")
+	}
+
+	return messageListStyle.Width(m.width - 4).Height(m.height - 8).Render(sb.String())
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 
+	"github.com/epalmerini/rabbithole/internal/cli"
 	"github.com/epalmerini/rabbithole/internal/config"
 	"github.com/epalmerini/rabbithole/internal/tui"
 	"github.com/epalmerini/rabbithole/internal/xdg"
@@ -14,6 +15,12 @@ var version = "dev"
 
 func main() {
 	showVersion := flag.Bool("version", false, "Show version")
+	flag.Usage = func() {
+		_, _ = fmt.Fprintf(flag.CommandLine.Output(), "Usage: rabbithole [flags] [command [command flags]]\n\n")
+		flag.PrintDefaults()
+		_, _ = fmt.Fprintln(flag.CommandLine.Output())
+		cli.Usage(flag.CommandLine.Output())
+	}
 	flag.Parse()
 
 	if *showVersion {
@@ -21,6 +28,12 @@ func main() {
 		return
 	}
 
+	if flag.NArg() > 0 && !cli.IsCommand(flag.Arg(0)) {
+		fmt.Fprintf(os.Stderr, "Unknown command %q\n\n", flag.Arg(0))
+		flag.Usage()
+		os.Exit(2)
+	}
+
 	configDir, err := xdg.Dir("XDG_CONFIG_HOME", ".config")
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error resolving config directory: %v\n", err)
@@ -33,6 +46,16 @@ func main() {
 		os.Exit(1)
 	}
 
+	// Subcommands run headless, without the TUI
+	if flag.NArg() > 0 {
+		env := cli.Env{FileConfig: fileCfg, ConfigDir: configDir, Stdout: os.Stdout, Stderr: os.Stderr}
+		if err := cli.Run(flag.Arg(0), flag.Args()[1:], env); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	if err := tui.Run(fileCfg, configDir); err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)